@@ -37,6 +37,7 @@ func main() {
 			&profile.GetAddressStatsCommand,
 			&profile.GetKeyStatsCommand,
 			&profile.GetLocationStatsCommand,
+			&profile.BlockGraphSummaryCommand,
 		},
 	}
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,112 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package profile
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/profile/blockprofile"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// BlockGraphSummaryCommand summarizes exported transaction dependency graphs
+var BlockGraphSummaryCommand = cli.Command{
+	Action:    blockGraphSummaryAction,
+	Name:      "block-graph-summary",
+	Usage:     "computes critical path and speedup distributions of transaction dependency graphs",
+	ArgsUsage: "<graph directory> <blockNumFirst> <blockNumLast>",
+	Flags: []cli.Flag{
+		&utils.ChainIDFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The aida-profile block-graph-summary command requires three arguments:
+<graph directory> <blockNumFirst> <blockNumLast>
+
+<graph directory> contains the JSON dependency graphs exported by
+--profile-blocks-graph and <blockNumFirst> and <blockNumLast> are the first
+and last block of the inclusive range of blocks to be summarized.
+
+Distributions of critical path lengths and theoretical speedups are printed to the console.
+`,
+}
+
+// blockGraphSummaryAction reads the dependency graphs of a block range and prints their statistics.
+func blockGraphSummaryAction(ctx *cli.Context) error {
+	if ctx.Args().Len() != 3 {
+		return fmt.Errorf("block-graph-summary command requires exactly 3 arguments")
+	}
+	log := logger.NewLogger(ctx.String(logger.LogLevelFlag.Name), "Block-Graph-Summary")
+
+	dir := ctx.Args().Get(0)
+	first, last, err := utils.SetBlockRange(ctx.Args().Get(1), ctx.Args().Get(2), utils.ChainID(ctx.Int(utils.ChainIDFlag.Name)))
+	if err != nil {
+		return err
+	}
+
+	summary := new(blockprofile.GraphSummary)
+	missing := 0
+	for block := first; block <= last; block++ {
+		file, err := os.Open(blockprofile.BlockGraphFile(dir, block, "json"))
+		if errors.Is(err, os.ErrNotExist) {
+			missing++
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("cannot open graph of block %v; %w", block, err)
+		}
+		graph, err := blockprofile.ReadBlockGraph(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+		summary.Add(graph)
+	}
+	if summary.Blocks == 0 {
+		return fmt.Errorf("no dependency graphs found in %v for blocks %v-%v", dir, first, last)
+	}
+	if missing > 0 {
+		log.Warningf("Graphs of %v blocks are missing", missing)
+	}
+
+	log.Noticef("Blocks: %v, transactions: %v, dependencies: %v", summary.Blocks, summary.Transactions, summary.Edges)
+	log.Noticef("Speedup of block range: %.2f", summary.TotalSpeedup())
+	log.Noticef("%-18v %13v %13v %13v %13v %13v %13v", "distribution", "min", "mean", "p50", "p90", "p99", "max")
+	printDistribution(log, "critical path time", summary.CriticalTime(), func(v float64) string {
+		return time.Duration(v).Round(time.Microsecond).String()
+	})
+	printDistribution(log, "critical path txs", summary.CriticalLength(), func(v float64) string {
+		return fmt.Sprintf("%.1f", v)
+	})
+	printDistribution(log, "speedup", summary.Speedup(), func(v float64) string {
+		return fmt.Sprintf("%.2f", v)
+	})
+	printDistribution(log, "independent txs", summary.Width(), func(v float64) string {
+		return fmt.Sprintf("%.1f", v)
+	})
+	return nil
+}
+
+// printDistribution prints a single distribution as a table row.
+func printDistribution(log logger.Logger, name string, d blockprofile.Distribution, format func(float64) string) {
+	log.Noticef("%-18v %13v %13v %13v %13v %13v %13v", name, format(d.Min), format(d.Mean), format(d.P50), format(d.P90), format(d.P99), format(d.Max))
+}
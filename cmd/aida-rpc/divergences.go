@@ -0,0 +1,68 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Aida/executor/extension/validator"
+	"github.com/urfave/cli/v2"
+)
+
+// divergenceSummaryTop is the maximal number of groups printed per category.
+const divergenceSummaryTop = 20
+
+// DivergencesCommand summarizes a divergence report written by RPC validation.
+var DivergencesCommand = cli.Command{
+	Action:    SummarizeDivergences,
+	Name:      "divergences",
+	Usage:     "summarizes a divergence report",
+	ArgsUsage: "<report>",
+	Description: `
+The divergences command reads a report written by aida-rpc with --rpc-divergence-report
+and prints the number of mismatches grouped by method, error type and contract.`,
+}
+
+// SummarizeDivergences prints the most frequent methods, error types and contracts of a divergence report.
+func SummarizeDivergences(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return fmt.Errorf("divergences command requires exactly 1 argument")
+	}
+
+	records, err := validator.ReadDivergenceReport(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+
+	summary := validator.SummarizeDivergences(records)
+	fmt.Printf("Divergences: %v\n", summary.Total)
+	printDivergenceGroups("Method", summary.ByMethod)
+	printDivergenceGroups("Error type", summary.ByErrorType)
+	printDivergenceGroups("Contract", summary.ByContract)
+	return nil
+}
+
+func printDivergenceGroups(title string, groups []validator.DivergenceGroup) {
+	fmt.Printf("\n%v:\n", title)
+	for i, group := range groups {
+		if i == divergenceSummaryTop {
+			fmt.Printf("\t... %v more\n", len(groups)-i)
+			break
+		}
+		fmt.Printf("\t%-44v %v\n", group.Key, group.Count)
+	}
+}
@@ -33,8 +33,14 @@ func main() {
 		Usage: "Sends real API requests recorded on rpcapi.fantom.network to StateDB then compares recorded" +
 			"result with result returned by DB.",
 		Copyright: "(c) 2023 Fantom Foundation",
+		Commands: []*cli.Command{
+			&ServeCommand,
+			&RecordCommand,
+			&DivergencesCommand,
+		},
 		Flags: []cli.Flag{
 			&utils.RpcRecordingFileFlag,
+			&utils.RpcSpeedUpFlag,
 			&substate.WorkersFlag,
 
 			// VM
@@ -45,6 +51,7 @@ func main() {
 			&utils.ChainIDFlag,
 			&utils.ContinueOnFailureFlag,
 			&utils.ValidateFlag,
+			&utils.RpcDivergenceReportFlag,
 			&utils.NoHeartbeatLoggingFlag,
 			&utils.ErrorLoggingFlag,
 			&utils.TrackProgressFlag,
@@ -67,6 +74,7 @@ func main() {
 
 			// Performance
 			&utils.CpuProfileFlag,
+			&utils.MetricsServerFlag,
 			&utils.MemoryProfileFlag,
 			&utils.ProfileFlag,
 			&utils.ProfileFileFlag,
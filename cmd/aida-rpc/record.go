@@ -0,0 +1,76 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// RecordCommand data structure for the record app
+var RecordCommand = cli.Command{
+	Action: Record,
+	Name:   "record",
+	Usage:  "records JSON-RPC requests passing through a reverse proxy",
+	Flags: []cli.Flag{
+		&utils.RpcAddressFlag,
+		&utils.RpcUpstreamFlag,
+		&utils.RpcRecordingFileFlag,
+		&utils.ChainIDFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The record command forwards JSON-RPC requests sent by HTTP to --rpc-addr to the
+endpoint --rpc-upstream. Requests of methods replayable by aida-rpc are written
+together with their responses, the latest block of the upstream and its timestamp
+into the recording --rpc-recording. Recordings with the .gz extension are compressed.`,
+}
+
+// Record forwards JSON-RPC requests to an upstream endpoint and records them until interrupted.
+func Record(ctx *cli.Context) (err error) {
+	cfg, err := utils.NewConfig(ctx, utils.NoArgs)
+	if err != nil {
+		return err
+	}
+	if cfg.RpcRecordingPath == "" {
+		return fmt.Errorf("recording is not set; use --%v", utils.RpcRecordingFileFlag.Name)
+	}
+	log := logger.NewLogger(cfg.LogLevel, "Rpc-Record")
+
+	writer, err := rpc.NewFileWriter(cfg.RpcRecordingPath)
+	if err != nil {
+		return fmt.Errorf("cannot create recording %v; %w", cfg.RpcRecordingPath, err)
+	}
+	defer func() {
+		err = errors.Join(err, writer.Close())
+	}()
+
+	proxy := rpc.NewRecordingProxy(cfg.RpcUpstream, writer.Writer, log)
+	log.Noticef("Recording requests to %v into %v", cfg.RpcUpstream, cfg.RpcRecordingPath)
+	if err = serveUntilInterrupted(ctx, proxy, cfg.RpcAddress, log); err != nil {
+		return err
+	}
+
+	recorded, skipped := proxy.Stats()
+	log.Noticef("Recorded %v requests, skipped %v requests", recorded, skipped)
+	return nil
+}
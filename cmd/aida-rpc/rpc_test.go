@@ -311,6 +311,30 @@ func TestRpc_AllTransactionsAreProcessed_Parallel(t *testing.T) {
 	}
 }
 
+func TestRpcProcessor_ExecutionTimeIsRecorded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	archive := state.NewMockNonCommittableStateDB(ctrl)
+	req := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Params:     []interface{}{testingAddress, "0x2"},
+			MethodBase: "getBalance",
+		},
+	}
+
+	archive.EXPECT().GetBalance(common.HexToAddress(testingAddress)).Return(big.NewInt(1))
+
+	state := executor.State[*rpc.RequestAndResults]{Block: 2, Data: req}
+	if err := makeRpcProcessor(&utils.Config{}).Process(state, &executor.Context{Archive: archive}); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	if _, executed := req.ExecutionTime(); !executed {
+		t.Errorf("execution time of request was not recorded")
+	}
+	if req.ExecutionEnd.Before(req.ExecutionStart) {
+		t.Errorf("execution ended before it started; start: %v, end: %v", req.ExecutionStart, req.ExecutionEnd)
+	}
+}
+
 func TestRpc_ValidationDoesNotFailOnValidTransaction_Sequential(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	provider := executor.NewMockProvider[*rpc.RequestAndResults](ctrl)
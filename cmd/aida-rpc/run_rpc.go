@@ -17,15 +17,18 @@
 package main
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension/logger"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
 	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
 	"github.com/Fantom-foundation/Aida/executor/extension/register"
 	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
 	"github.com/Fantom-foundation/Aida/executor/extension/tracker"
 	"github.com/Fantom-foundation/Aida/executor/extension/validator"
+	log "github.com/Fantom-foundation/Aida/logger"
 	"github.com/Fantom-foundation/Aida/rpc"
 	"github.com/Fantom-foundation/Aida/state"
 	"github.com/Fantom-foundation/Aida/utils"
@@ -38,6 +41,11 @@ func RunRpc(ctx *cli.Context) error {
 		return err
 	}
 
+	// divergences are only found by validation
+	if cfg.RpcDivergenceReport != "" && !cfg.Validate {
+		return fmt.Errorf("--%v requires --%v", utils.RpcDivergenceReportFlag.Name, utils.ValidateFlag.Name)
+	}
+
 	cfg.SrcDbReadonly = true
 
 	rpcSource, err := executor.OpenRpcRecording(cfg, ctx)
@@ -47,6 +55,10 @@ func RunRpc(ctx *cli.Context) error {
 
 	defer rpcSource.Close()
 
+	if cfg.RpcSpeedUp > 0 {
+		rpcSource = executor.MakeTimedRpcRequestProvider(rpcSource, cfg.RpcSpeedUp, log.NewLogger(cfg.LogLevel, "timed-rpc-provider"))
+	}
+
 	return run(cfg, rpcSource, nil, makeRpcProcessor(cfg), nil)
 }
 
@@ -61,7 +73,9 @@ type rpcProcessor struct {
 }
 
 func (p rpcProcessor) Process(state executor.State[*rpc.RequestAndResults], ctx *executor.Context) error {
+	state.Data.ExecutionStart = time.Now()
 	ctx.ExecutionResult = rpc.Execute(uint64(state.Block), state.Data, ctx.Archive, p.cfg)
+	state.Data.ExecutionEnd = time.Now()
 	return nil
 }
 
@@ -82,9 +96,11 @@ func run(
 		profiler.MakeCpuProfiler[*rpc.RequestAndResults](cfg),
 		logger.MakeProgressLogger[*rpc.RequestAndResults](cfg, 15*time.Second),
 		logger.MakeErrorLogger[*rpc.RequestAndResults](cfg),
+		monitor.MakeMetricsServer[*rpc.RequestAndResults](cfg),
 		tracker.MakeRequestProgressTracker(cfg, 100_000),
 		statedb.MakeTemporaryArchivePrepper(),
 		validator.MakeRpcComparator(cfg),
+		tracker.MakeRequestLatencyTracker(cfg),
 	}
 
 	// this is for testing purposes so mock statedb and mock extension can be used
@@ -0,0 +1,106 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// ServeCommand data structure for the serve app
+var ServeCommand = cli.Command{
+	Action: Serve,
+	Name:   "serve",
+	Usage:  "serves JSON-RPC requests from the archive of a StateDB",
+	Flags: []cli.Flag{
+		&utils.RpcAddressFlag,
+		&utils.StateDbSrcFlag,
+		&utils.VmImplementation,
+		&utils.ChainIDFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The serve command opens the StateDB given by --db-src read-only and answers
+eth_getBalance, eth_getTransactionCount, eth_call, eth_estimateGas, eth_getCode
+and eth_getStorageAt requests sent by HTTP to --rpc-addr. Block tags are
+resolved against the block height of the archive of the StateDB.`,
+}
+
+// Serve answers JSON-RPC requests using the archive of a StateDB until interrupted.
+func Serve(ctx *cli.Context) (err error) {
+	cfg, err := utils.NewConfig(ctx, utils.NoArgs)
+	if err != nil {
+		return err
+	}
+	if cfg.StateDbSrc == "" {
+		return fmt.Errorf("StateDB is not set; use --%v", utils.StateDbSrcFlag.Name)
+	}
+	log := logger.NewLogger(cfg.LogLevel, "Rpc-Serve")
+
+	cfg.SrcDbReadonly = true
+	db, _, err := utils.PrepareStateDB(cfg)
+	if err != nil {
+		return fmt.Errorf("cannot open StateDB; %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, db.Close())
+	}()
+	if !cfg.ArchiveMode {
+		return fmt.Errorf("StateDB %v has no archive", cfg.StateDbSrc)
+	}
+
+	return serveUntilInterrupted(ctx, rpc.NewServer(db, cfg), cfg.RpcAddress, log)
+}
+
+// serveUntilInterrupted serves HTTP requests at the given address until the process is interrupted.
+func serveUntilInterrupted(ctx *cli.Context, handler http.Handler, addr string, log logger.Logger) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("cannot start JSON-RPC server; %w", err)
+	}
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	stop, cancel := signal.NotifyContext(ctx.Context, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-stop.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdown); err != nil {
+			log.Errorf("cannot shut down JSON-RPC server; %v", err)
+		}
+	}()
+
+	log.Noticef("Serving JSON-RPC requests at http://%v", listener.Addr())
+	if err = server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	log.Notice("JSON-RPC server stopped")
+	return nil
+}
@@ -36,6 +36,8 @@ func initTraceApp() *cli.App {
 			&RecordCommand,
 			&trace.TraceReplayCommand,
 			&trace.TraceReplaySubstateCommand,
+			&trace.TraceConvertCommand,
+			&trace.TraceCommand,
 		},
 	}
 }
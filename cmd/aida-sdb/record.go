@@ -18,6 +18,7 @@ package main
 
 import (
 	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
 	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
 	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
 	"github.com/Fantom-foundation/Aida/executor/extension/tracker"
@@ -38,6 +39,7 @@ var RecordCommand = cli.Command{
 	Flags: []cli.Flag{
 		&utils.UpdateBufferSizeFlag,
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.SyncPeriodLengthFlag,
 		&substate.WorkersFlag,
 		&utils.ChainIDFlag,
@@ -82,6 +84,7 @@ func record(
 	var extensions = []executor.Extension[txcontext.TxContext]{
 		profiler.MakeCpuProfiler[txcontext.TxContext](cfg),
 		tracker.MakeBlockProgressTracker(cfg, 0),
+		monitor.MakeMetricsServer[txcontext.TxContext](cfg),
 		statedb.MakeTemporaryStatePrepper(cfg),
 		statedb.MakeProxyRecorderPrepper[txcontext.TxContext](cfg),
 		validator.MakeLiveDbValidator(cfg, validator.ValidateTxTarget{WorldState: true, Receipt: true}),
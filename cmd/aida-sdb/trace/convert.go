@@ -0,0 +1,57 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package trace
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/tracer"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// ConvertTrace converts a storage trace into the chunked trace format.
+func ConvertTrace(ctx *cli.Context) error {
+	if ctx.Args().Len() != 2 {
+		return fmt.Errorf("convert command requires exactly 2 arguments")
+	}
+
+	cfg, err := utils.NewConfig(ctx, utils.OneToNArgs)
+	if err != nil {
+		return err
+	}
+	log := logger.NewLogger(cfg.LogLevel, "Trace-Convert")
+
+	input, output := ctx.Args().Get(0), ctx.Args().Get(1)
+	log.Noticef("Converting %v into %v", input, output)
+	if err = tracer.ConvertTrace(input, output, cfg.TraceChunkSize*1024*1024); err != nil {
+		return fmt.Errorf("cannot convert trace %v; %w", input, err)
+	}
+
+	inputInfo, err := os.Stat(input)
+	if err != nil {
+		return err
+	}
+	outputInfo, err := os.Stat(output)
+	if err != nil {
+		return err
+	}
+	log.Noticef("Converted trace of %v bytes into %v bytes", inputInfo.Size(), outputInfo.Size())
+	return nil
+}
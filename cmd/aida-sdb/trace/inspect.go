@@ -0,0 +1,135 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package trace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/tracer"
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// inspectedOperation is the JSON representation of a decoded operation.
+type inspectedOperation struct {
+	Operation string              `json:"operation"`
+	Arguments operation.Operation `json:"arguments"`
+}
+
+// inspectedTransaction is the JSON representation of the operations of a transaction.
+type inspectedTransaction struct {
+	Block       int                  `json:"block"`
+	Transaction int                  `json:"transaction"`
+	Operations  []inspectedOperation `json:"operations"`
+}
+
+// InspectTrace lists the operations of storage traces.
+func InspectTrace(ctx *cli.Context) (err error) {
+	cfg, err := utils.NewConfig(ctx, utils.BlockRangeArgs)
+	if err != nil {
+		return err
+	}
+
+	var encoder *json.Encoder
+	if cfg.Output != "" {
+		file, err := os.Create(cfg.Output)
+		if err != nil {
+			return fmt.Errorf("cannot create %v; %w", cfg.Output, err)
+		}
+		out := bufio.NewWriter(file)
+		defer func() {
+			err = errors.Join(err, out.Flush(), file.Close())
+		}()
+		encoder = json.NewEncoder(out)
+	}
+
+	rCtx := context.NewReplay()
+	return forEachTransaction(cfg, func(block int, tx int, ops []operation.Operation) error {
+		if encoder == nil {
+			fmt.Printf("Block %v, transaction %v\n", block, tx)
+		}
+		inspected := inspectedTransaction{Block: block, Transaction: tx}
+		for _, op := range ops {
+			// the recorded operation is listed with its decoded arguments
+			label := operation.GetLabel(op.GetId())
+			decoded := operation.Absolute(op, rCtx)
+			if encoder == nil {
+				fmt.Printf("\t%s: ", label)
+				decoded.Debug(&rCtx.Context)
+				fmt.Println()
+				continue
+			}
+			inspected.Operations = append(inspected.Operations, inspectedOperation{label, decoded})
+		}
+		if encoder == nil {
+			return nil
+		}
+		return encoder.Encode(inspected)
+	})
+}
+
+// TraceStats prints statistics of storage traces.
+func TraceStats(ctx *cli.Context) error {
+	cfg, err := utils.NewConfig(ctx, utils.BlockRangeArgs)
+	if err != nil {
+		return err
+	}
+	log := logger.NewLogger(cfg.LogLevel, "Trace-Stats")
+
+	stats := tracer.NewTraceStats()
+	err = forEachTransaction(cfg, func(_ int, _ int, ops []operation.Operation) error {
+		return stats.Add(ops)
+	})
+	if err != nil {
+		return err
+	}
+	stats.Print(os.Stdout)
+
+	if cfg.Output != "" {
+		if err = stats.WriteJson(cfg.Output); err != nil {
+			return err
+		}
+		log.Noticef("Statistics written to %v", cfg.Output)
+	}
+	return nil
+}
+
+// forEachTransaction calls the visitor for the operations of each transaction in the
+// configured block range. Begin-block and end-block operations are part of the first and
+// the last transaction of their block respectively.
+func forEachTransaction(cfg *utils.Config, visit func(block int, tx int, ops []operation.Operation) error) error {
+	provider, err := executor.OpenOperations(cfg)
+	if err != nil {
+		return err
+	}
+	defer provider.Close()
+
+	return provider.Run(int(cfg.First), int(cfg.Last)+1, func(info executor.TransactionInfo[[]operation.Operation]) error {
+		if info.Block > int(cfg.Last) {
+			return nil
+		}
+		return visit(info.Block, info.Transaction, info.Data)
+	})
+}
@@ -19,6 +19,7 @@ package trace
 import (
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension/logger"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
 	"github.com/Fantom-foundation/Aida/executor/extension/primer"
 	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
 	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
@@ -91,6 +92,7 @@ func replay(
 	var extensionList = []executor.Extension[[]operation.Operation]{
 		profiler.MakeCpuProfiler[[]operation.Operation](cfg),
 		logger.MakeProgressLogger[[]operation.Operation](cfg, 0),
+		monitor.MakeMetricsServer[[]operation.Operation](cfg),
 		profiler.MakeMemoryUsagePrinter[[]operation.Operation](cfg),
 		profiler.MakeMemoryProfiler[[]operation.Operation](cfg),
 		statedb.MakeStateDbManager[[]operation.Operation](cfg, ""),
@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package trace
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/tracer"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+)
+
+// CutTrace writes a block range of storage traces into a new trace.
+func CutTrace(ctx *cli.Context) error {
+	cfg, err := utils.NewConfig(ctx, utils.BlockRangeArgs)
+	if err != nil {
+		return err
+	}
+	return rewriteTrace(cfg, tracer.NewTraceFilter(cfg.First, cfg.Last), "Trace-Cut")
+}
+
+// MergeTrace concatenates storage traces into a new trace.
+func MergeTrace(ctx *cli.Context) error {
+	if ctx.Args().Len() == 0 {
+		return fmt.Errorf("merge command requires at least 1 argument")
+	}
+	cfg, err := utils.NewConfig(ctx, utils.OneToNArgs)
+	if err != nil {
+		return err
+	}
+	if cfg.Output == "" {
+		return fmt.Errorf("output trace is not set; use --%v", utils.OutputFlag.Name)
+	}
+	log := logger.NewLogger(cfg.LogLevel, "Trace-Merge")
+
+	files, err := tracer.SortTraceFiles(ctx.Args().Slice())
+	if err != nil {
+		return err
+	}
+	log.Noticef("Merging %v traces into %v", len(files), cfg.Output)
+	if err = tracer.RewriteTrace(files, cfg.Output, tracer.AllBlocks); err != nil {
+		return fmt.Errorf("cannot merge traces; %w", err)
+	}
+	return nil
+}
+
+// FilterTrace writes selected transactions and operations of storage traces into a new trace.
+func FilterTrace(ctx *cli.Context) error {
+	cfg, err := utils.NewConfig(ctx, utils.BlockRangeArgs)
+	if err != nil {
+		return err
+	}
+
+	filter := tracer.NewTraceFilter(cfg.First, cfg.Last)
+	if len(cfg.TraceContracts) > 0 {
+		filter.Contracts = make(map[common.Address]bool)
+		for _, contract := range cfg.TraceContracts {
+			if !common.IsHexAddress(contract) {
+				return fmt.Errorf("invalid contract address %v", contract)
+			}
+			filter.Contracts[common.HexToAddress(contract)] = true
+		}
+	}
+	if len(cfg.TraceOperations) > 0 {
+		ids := make(map[string]byte)
+		for id, label := range operation.CreateIdLabelMap() {
+			ids[label] = id
+		}
+		filter.Operations = make(map[byte]bool)
+		for _, label := range cfg.TraceOperations {
+			id, ok := ids[label]
+			if !ok {
+				return fmt.Errorf("unknown operation %v", label)
+			}
+			filter.Operations[id] = true
+		}
+	}
+	return rewriteTrace(cfg, filter, "Trace-Filter")
+}
+
+// rewriteTrace writes storage traces of the configured range passing the filter into the output trace.
+func rewriteTrace(cfg *utils.Config, filter tracer.TraceFilter, name string) error {
+	if cfg.Output == "" {
+		return fmt.Errorf("output trace is not set; use --%v", utils.OutputFlag.Name)
+	}
+	log := logger.NewLogger(cfg.LogLevel, name)
+
+	files, err := tracer.GetTraceFiles(cfg)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no trace files cover blocks %v-%v", cfg.First, cfg.Last)
+	}
+	log.Noticef("Writing blocks %v-%v of %v traces into %v", cfg.First, cfg.Last, len(files), cfg.Output)
+	if err = tracer.RewriteTrace(files, cfg.Output, filter); err != nil {
+		return fmt.Errorf("cannot rewrite traces; %w", err)
+	}
+	return nil
+}
@@ -33,6 +33,7 @@ var TraceReplayCommand = cli.Command{
 		&utils.CarmenSchemaFlag,
 		&utils.ChainIDFlag,
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.SyncPeriodLengthFlag,
 		&utils.KeepDbFlag,
 		&utils.MemoryBreakdownFlag,
@@ -81,6 +82,7 @@ var TraceReplaySubstateCommand = cli.Command{
 	Flags: []cli.Flag{
 		&utils.ChainIDFlag,
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.RandomizePrimingFlag,
 		&utils.RandomSeedFlag,
 		&utils.PrimeThresholdFlag,
@@ -108,3 +110,141 @@ The trace replay-substate command requires two arguments:
 <blockNumFirst> and <blockNumLast> are the first and
 last block of the inclusive range of blocks to replay storage traces.`,
 }
+
+// TraceConvertCommand data structure for the convert app
+var TraceConvertCommand = cli.Command{
+	Action:    ConvertTrace,
+	Name:      "convert",
+	Usage:     "converts storage traces into the seekable chunked format",
+	ArgsUsage: "<input trace> <output trace>",
+	Flags: []cli.Flag{
+		&utils.TraceChunkSizeFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace convert command requires two arguments:
+<input trace> <output trace>
+
+The input trace of any format version is rewritten as a chunked,
+zstd-compressed trace with a block index, which allows replays to
+start at any block without decompressing preceding blocks.`,
+}
+
+// TraceCommand groups commands examining and manipulating storage traces.
+var TraceCommand = cli.Command{
+	Name:  "trace",
+	Usage: "examines and manipulates storage traces",
+	Subcommands: []*cli.Command{
+		&TraceInspectCommand,
+		&TraceStatsCommand,
+		&TraceCutCommand,
+		&TraceMergeCommand,
+		&TraceFilterCommand,
+	},
+}
+
+// TraceInspectCommand data structure for the inspect app
+var TraceInspectCommand = cli.Command{
+	Action:    InspectTrace,
+	Name:      "inspect",
+	Usage:     "lists operations of storage traces",
+	ArgsUsage: "<blockNumFirst> <blockNumLast>",
+	Flags: []cli.Flag{
+		&utils.TraceFileFlag,
+		&utils.TraceDirectoryFlag,
+		&utils.OutputFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace inspect command requires two arguments:
+<blockNumFirst> <blockNumLast>
+
+It prints all operations of the inclusive block range grouped by block and
+transaction. Operations referring to the contract and key caches of the
+trace are printed with their decoded contracts and keys. If --output is set,
+the operations are written as JSON lines, one object per transaction.`,
+}
+
+// TraceStatsCommand data structure for the stats app
+var TraceStatsCommand = cli.Command{
+	Action:    TraceStats,
+	Name:      "stats",
+	Usage:     "prints statistics of storage traces",
+	ArgsUsage: "<blockNumFirst> <blockNumLast>",
+	Flags: []cli.Flag{
+		&utils.TraceFileFlag,
+		&utils.TraceDirectoryFlag,
+		&utils.OutputFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace stats command requires two arguments:
+<blockNumFirst> <blockNumLast>
+
+It prints an operation histogram, the serialized size per operation type and
+the distribution of the maximum snapshot depth of transactions in the inclusive
+block range. If --output is set, the statistics are also written as JSON.`,
+}
+
+// TraceCutCommand data structure for the cut app
+var TraceCutCommand = cli.Command{
+	Action:    CutTrace,
+	Name:      "cut",
+	Usage:     "extracts a block range of storage traces into a new trace",
+	ArgsUsage: "<blockNumFirst> <blockNumLast>",
+	Flags: []cli.Flag{
+		&utils.TraceFileFlag,
+		&utils.TraceDirectoryFlag,
+		&utils.OutputFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace cut command requires two arguments:
+<blockNumFirst> <blockNumLast>
+
+It writes all operations of the inclusive block range into the trace file
+given by --output. Operations are re-encoded for the contract and key caches
+of the new trace, so the result can be replayed on its own.`,
+}
+
+// TraceMergeCommand data structure for the merge app
+var TraceMergeCommand = cli.Command{
+	Action:    MergeTrace,
+	Name:      "merge",
+	Usage:     "concatenates storage traces into a single trace",
+	ArgsUsage: "<trace> <trace> ...",
+	Flags: []cli.Flag{
+		&utils.OutputFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace merge command requires at least one trace file as argument.
+
+The traces are ordered by their first block and written into the trace file
+given by --output. The block ranges of merged traces must not overlap.`,
+}
+
+// TraceFilterCommand data structure for the filter app
+var TraceFilterCommand = cli.Command{
+	Action:    FilterTrace,
+	Name:      "filter",
+	Usage:     "extracts selected transactions and operations of storage traces into a new trace",
+	ArgsUsage: "<blockNumFirst> <blockNumLast>",
+	Flags: []cli.Flag{
+		&utils.TraceFileFlag,
+		&utils.TraceDirectoryFlag,
+		&utils.TraceContractsFlag,
+		&utils.TraceOperationsFlag,
+		&utils.OutputFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The trace filter command requires two arguments:
+<blockNumFirst> <blockNumLast>
+
+It writes the inclusive block range into the trace file given by --output.
+If --trace-contracts is set, only transactions touching one of the listed
+contracts are kept. If --trace-operations is set, only the listed operations
+(e.g. GetState, SetState) are kept besides block, transaction, sync-period
+and snapshot operations.`,
+}
@@ -32,7 +32,9 @@ func initStochasticApp() *cli.App {
 		Copyright: "(c) 2022-23 Fantom Foundation",
 		Flags:     []cli.Flag{},
 		Commands: []*cli.Command{
+			&stochastic.StochasticCompareCommand,
 			&stochastic.StochasticEstimateCommand,
+			&stochastic.StochasticFuzzCommand,
 			&stochastic.StochasticGenerateCommand,
 			&stochastic.StochasticRecordCommand,
 			&stochastic.StochasticReplayCommand,
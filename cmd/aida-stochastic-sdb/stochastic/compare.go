@@ -0,0 +1,179 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	log "github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/stochastic"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// StochasticCompareCommand data structure for the compare app.
+var StochasticCompareCommand = cli.Command{
+	Action:    stochasticCompareAction,
+	Name:      "compare",
+	Usage:     "compares the events of a stochastic replay with recorded events",
+	ArgsUsage: "<simulation-length> <simulation-file> <event-file>",
+	Flags: []cli.Flag{
+		&utils.BalanceRangeFlag,
+		&utils.CarmenSchemaFlag,
+		&utils.ContinueOnFailureFlag,
+		&utils.NonceRangeFlag,
+		&utils.OutputFlag,
+		&utils.RandomSeedFlag,
+		&utils.StateDbImplementationFlag,
+		&utils.StateDbVariantFlag,
+		&utils.DbTmpFlag,
+		&log.LogLevelFlag,
+	},
+	Description: `
+The stochastic compare command requires three arguments:
+<simulation-length> <simulation.json> <events.json>
+
+<simulation-length> determines the number of replayed blocks,
+<simulation.json> contains the simulation parameters produced by the stochastic estimator, and
+<events.json> is the event file produced by the stochastic recorder.
+
+The events of the stochastic replay are recorded and compared to the recorded events.
+The comparison is printed as a scorecard and written in JSON format if --output is set.`,
+}
+
+// stochasticCompareAction implements the compare command.
+func stochasticCompareAction(ctx *cli.Context) error {
+	if ctx.Args().Len() != 3 {
+		return fmt.Errorf("missing simulation length, simulation file and events file as parameter")
+	}
+
+	// process configuration; the simulation length is the last block
+	cfg, err := utils.NewConfig(ctx, utils.LastBlockArg)
+	if err != nil {
+		return err
+	}
+	if cfg.DbImpl == "memory" {
+		return fmt.Errorf("db-impl memory is not supported")
+	}
+	// blocks of the simulation start after the priming block
+	cfg.First = 1
+	l := log.NewLogger(cfg.LogLevel, "Stochastic Compare")
+
+	simulation, err := stochastic.ReadSimulation(ctx.Args().Get(1))
+	if err != nil {
+		return fmt.Errorf("failed reading simulation; %v", err)
+	}
+	recorded, err := stochastic.ReadEvents(ctx.Args().Get(2))
+	if err != nil {
+		return err
+	}
+
+	// replay the simulation while recording its events
+	provider, replay := prepareStochasticReplay(cfg, simulation)
+	defer provider.Close()
+
+	registry := stochastic.NewEventRegistry()
+	extra := []executor.Extension[[]stochastic.Operation]{
+		makeEventRecorder(&registry),
+	}
+	if err = runStochasticReplay(cfg, provider, replay, extra); err != nil {
+		return err
+	}
+	replayed := registry.NewEventRegistryJSON()
+
+	scorecard, err := stochastic.CompareEvents(recorded, &replayed)
+	if err != nil {
+		return err
+	}
+	printScorecard(l, scorecard)
+
+	if cfg.Output != "" {
+		l.Noticef("Write scorecard %v", cfg.Output)
+		return writeScorecard(scorecard, cfg.Output)
+	}
+	return nil
+}
+
+// printScorecard prints the distances of the scorecard.
+func printScorecard(l log.Logger, s *stochastic.FidelityScorecard) {
+	l.Notice("Fidelity scorecard (0 = indistinguishable, 1 = maximal distance):")
+	l.Noticef("\tTransition matrix distance: %.6f", s.TransitionDistance)
+	l.Noticef("\tOperation frequency divergence: %.6f", s.OperationDivergence)
+	for _, a := range []struct {
+		name   string
+		access stochastic.AccessFidelity
+	}{
+		{"Contracts", s.Contracts},
+		{"Keys", s.Keys},
+		{"Values", s.Values},
+	} {
+		l.Noticef("\t%v: class distance %.6f, counting KS-distance %.6f, queuing distance %.6f",
+			a.name, a.access.ClassDistance, a.access.CountingKSDistance, a.access.QueuingDistance)
+	}
+	l.Noticef("\tSnapshot depth KS-distance: %.6f", s.SnapshotKSDistance)
+}
+
+// writeScorecard writes the scorecard in JSON format.
+func writeScorecard(s *stochastic.FidelityScorecard, filename string) error {
+	f, fErr := os.Create(filename)
+	if fErr != nil {
+		return fmt.Errorf("cannot open JSON file; %v", fErr)
+	}
+	defer f.Close()
+
+	jOut, jErr := json.MarshalIndent(s, "", "    ")
+	if jErr != nil {
+		return fmt.Errorf("failed to convert JSON file; %v", jErr)
+	}
+
+	_, pErr := fmt.Fprintln(f, string(jOut))
+	if pErr != nil {
+		return fmt.Errorf("failed to convert JSON file; %v", pErr)
+	}
+
+	return nil
+}
+
+func makeEventRecorder(registry *stochastic.EventRegistry) executor.Extension[[]stochastic.Operation] {
+	return &eventRecorder{registry: registry}
+}
+
+// eventRecorder registers the StateDB operations of the simulation in an event registry.
+// It must be placed after the stochastic replay so that the priming is not recorded.
+type eventRecorder struct {
+	extension.NilExtension[[]stochastic.Operation]
+	registry *stochastic.EventRegistry
+	db       state.StateDB // StateDB without the event proxy
+}
+
+// PreRun wraps the StateDB in an event proxy.
+func (r *eventRecorder) PreRun(_ executor.State[[]stochastic.Operation], ctx *executor.Context) error {
+	r.db = ctx.State
+	ctx.State = stochastic.NewEventProxy(ctx.State, r.registry)
+	return nil
+}
+
+// PostRun restores the StateDB.
+func (r *eventRecorder) PostRun(_ executor.State[[]stochastic.Operation], ctx *executor.Context, _ error) error {
+	ctx.State = r.db
+	return nil
+}
@@ -31,11 +31,18 @@ var StochasticEstimateCommand = cli.Command{
 	Name:      "estimate",
 	Usage:     "estimates parameters of access distributions and produces a simulation file",
 	ArgsUsage: "<event-file>",
+	Flags: []cli.Flag{
+		&utils.MarkovOrderFlag,
+		&utils.OutputFlag,
+	},
 	Description: `
 The stochastic estimator command requires one argument:
 <events.json>
 
-<events.json> is the event file produced by the stochastic recorder.`,
+<events.json> is the event file produced by the stochastic recorder.
+
+With --markov-order 2, the next operation of the simulation depends on the
+previous and the current operation instead of the current operation only.`,
 }
 
 // stochasticEstimateAction implements estimator command for computing statistical parameters.
@@ -57,7 +64,17 @@ func stochasticEstimateAction(ctx *cli.Context) error {
 
 	// estimate parameters
 	log.Info("Estimate parameters")
-	estimationModel := stochastic.NewEstimationModelJSON(eventRegistryJSON)
+	var estimationModel stochastic.EstimationModelJSON
+	switch order := ctx.Int(utils.MarkovOrderFlag.Name); order {
+	case 1:
+		estimationModel = stochastic.NewEstimationModelJSON(eventRegistryJSON)
+	case 2:
+		if estimationModel, err = stochastic.NewSecondOrderEstimationModelJSON(eventRegistryJSON); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported markov order %v", order)
+	}
 
 	// write simulation file
 	outputFileName := ctx.String(utils.OutputFlag.Name)
@@ -0,0 +1,262 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	log "github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state/proxy"
+	"github.com/Fantom-foundation/Aida/stochastic"
+	"github.com/Fantom-foundation/Aida/tracer"
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// StochasticFuzzCommand data structure for the fuzz app.
+var StochasticFuzzCommand = cli.Command{
+	Action:    stochasticFuzzAction,
+	Name:      "fuzz",
+	Usage:     "differentially fuzzes a StateDB implementation against a shadow implementation",
+	ArgsUsage: "<simulation-length> [<simulation-file>]",
+	Flags: []cli.Flag{
+		&utils.BalanceRangeFlag,
+		&utils.CarmenSchemaFlag,
+		&utils.NonceRangeFlag,
+		&utils.OutputFlag,
+		&utils.RandomSeedFlag,
+		&utils.StateDbImplementationFlag,
+		&utils.StateDbVariantFlag,
+		&utils.ShadowDbImplementationFlag,
+		&utils.ShadowDbVariantFlag,
+		&utils.DbTmpFlag,
+		&utils.BlockLengthFlag,
+		&utils.SyncPeriodLengthFlag,
+		&utils.TransactionLengthFlag,
+		&utils.ContractNumberFlag,
+		&utils.KeysNumberFlag,
+		&utils.ValuesNumberFlag,
+		&utils.SnapshotDepthFlag,
+		&log.LogLevelFlag,
+	},
+	Description: `
+The stochastic fuzz command requires one or two arguments:
+<simulation-length> [<simulation.json>]
+
+<simulation-length> determines the number of blocks and
+<simulation.json> contains the simulation parameters produced by the stochastic estimator.
+Without a simulation file, a uniform simulation is generated.
+
+The primary and the shadow StateDB are driven by the same stochastic operations until
+they diverge. The operations are then shrunk to a minimal sequence still diverging,
+which is written as a trace file to --output (default: ./fuzz-reproducer.dat).`,
+}
+
+// stochasticFuzzAction implements the fuzz command.
+func stochasticFuzzAction(ctx *cli.Context) error {
+	if ctx.Args().Len() < 1 || ctx.Args().Len() > 2 {
+		return fmt.Errorf("missing simulation length as parameter")
+	}
+
+	// process configuration; the simulation length is the last block
+	cfg, err := utils.NewConfig(ctx, utils.LastBlockArg)
+	if err != nil {
+		return err
+	}
+	if cfg.ShadowImpl == "" {
+		return fmt.Errorf("fuzzing requires a shadow db; set --%v", utils.ShadowDbImplementationFlag.Name)
+	}
+	cfg.ShadowDb = true
+	if cfg.Output == "" {
+		cfg.Output = "./fuzz-reproducer.dat"
+	}
+	l := log.NewLogger(cfg.LogLevel, "Stochastic Fuzz")
+
+	var simulation *stochastic.EstimationModelJSON
+	if ctx.Args().Len() == 2 {
+		simulation, err = stochastic.ReadSimulation(ctx.Args().Get(1))
+		if err != nil {
+			return fmt.Errorf("failed reading simulation; %v", err)
+		}
+	} else {
+		l.Info("Produce uniform simulation")
+		events := stochastic.GenerateUniformRegistry(cfg, l).NewEventRegistryJSON()
+		e := stochastic.NewEstimationModelJSON(&events)
+		simulation = &e
+	}
+
+	f := &fuzzer{cfg: cfg, log: l}
+	d, err := f.run(simulation)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		l.Noticef("No divergence found in %v blocks using random seed %v", cfg.Last, cfg.RandomSeed)
+		return nil
+	}
+	ops := d.ops
+	l.Warningf("Divergence found after %v operations; %v", len(ops), d.err)
+
+	if f.fails(ops) {
+		l.Notice("Minimizing operations...")
+		ops = tracer.MinimizeOperations(ops, f.fails)
+	} else {
+		l.Warning("Divergence cannot be reproduced by replaying the operations; skipping minimization")
+	}
+
+	if err = tracer.WriteOperations(ops, cfg.Output); err != nil {
+		return err
+	}
+	l.Noticef("Wrote reproducer with %v operations to %v", len(ops), cfg.Output)
+	return fmt.Errorf("StateDB diverged from shadow DB; %v", d.err)
+}
+
+// fuzzer drives a StateDB and its shadow with stochastic operations.
+type fuzzer struct {
+	cfg *utils.Config
+	log log.Logger
+}
+
+// divergence is the error of a diverged StateDB and the absolute operations issued to the
+// StateDB up to the divergence.
+type divergence struct {
+	err error
+	ops []operation.Operation
+}
+
+// run replays the simulation until the primary and the shadow StateDB diverge. It returns
+// nil if the StateDBs do not diverge within the simulation length.
+func (f *fuzzer) run(simulation *stochastic.EstimationModelJSON) (*divergence, error) {
+	db, dbPath, err := utils.PrepareStateDB(f.cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dbPath)
+
+	// record the operations issued to the StateDB
+	traceDir, err := os.MkdirTemp(f.cfg.DbTmp, "fuzz_trace_*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a temporary directory; %v", err)
+	}
+	defer os.RemoveAll(traceDir)
+	traceFile := filepath.Join(traceDir, "trace.dat")
+	rCtx, err := context.NewRecord(traceFile, 0)
+	if err != nil {
+		return nil, err
+	}
+	recorder := proxy.NewRecorderProxy(db, rCtx)
+
+	// operations and their arguments are sampled by separate random generators
+	f.log.Noticef("using random seed %d", f.cfg.RandomSeed)
+	rg := rand.New(rand.NewSource(f.cfg.RandomSeed))
+	generator := stochastic.NewOperationGenerator(simulation, rand.New(rand.NewSource(rg.Int63())))
+	replayer := stochastic.NewReplayer(f.cfg, simulation, rand.New(rand.NewSource(rg.Int63())), f.log)
+
+	replayer.Prime(recorder)
+	runErr := recorder.Error()
+	for blocks := uint64(0); runErr == nil && blocks < f.cfg.Last; {
+		op, err := generator.Next()
+		if err != nil {
+			rCtx.Close()
+			return nil, err
+		}
+		runErr = replayer.Execute(recorder, op)
+		if op.ID == stochastic.EndBlockID {
+			blocks++
+		}
+	}
+	rCtx.Close()
+	if err = db.Close(); err != nil {
+		f.log.Warningf("failed to close StateDB; %v", err)
+	}
+	if runErr == nil {
+		return nil, nil
+	}
+
+	var ops []operation.Operation
+	err = tracer.ReadOperations([]string{traceFile}, func(op operation.Operation) error {
+		ops = append(ops, op)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &divergence{err: runErr, ops: closeOperations(ops)}, nil
+}
+
+// fails replays the operations on a new StateDB and its shadow and reports whether they diverge.
+// Operations panicking the StateDB do not reproduce the divergence.
+func (f *fuzzer) fails(ops []operation.Operation) (diverged bool) {
+	db, dbPath, err := utils.PrepareStateDB(f.cfg)
+	if err != nil {
+		f.log.Errorf("failed to create StateDB; %v", err)
+		return false
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+	defer func() {
+		if r := recover(); r != nil {
+			diverged = false
+		}
+	}()
+
+	ctx := context.NewReplay()
+	for _, op := range ops {
+		op.Execute(db, ctx)
+		if db.Error() != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// closeOperations ends the transaction, block and sync-period which are open at the end
+// of the operations, so that the operations can be replayed as a trace.
+func closeOperations(ops []operation.Operation) []operation.Operation {
+	var inSyncPeriod, inBlock, inTransaction bool
+	for _, op := range ops {
+		switch op.GetId() {
+		case operation.BeginSyncPeriodID:
+			inSyncPeriod = true
+		case operation.EndSyncPeriodID:
+			inSyncPeriod = false
+		case operation.BeginBlockID:
+			inBlock = true
+		case operation.EndBlockID:
+			inBlock = false
+		case operation.BeginTransactionID:
+			inTransaction = true
+		case operation.EndTransactionID:
+			inTransaction = false
+		}
+	}
+	if inTransaction {
+		ops = append(ops, operation.NewEndTransaction())
+	}
+	if inBlock {
+		ops = append(ops, operation.NewEndBlock())
+	}
+	if inSyncPeriod {
+		ops = append(ops, operation.NewEndSyncPeriod())
+	}
+	return ops
+}
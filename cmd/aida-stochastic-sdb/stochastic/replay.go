@@ -18,11 +18,17 @@ package stochastic
 
 import (
 	"fmt"
-	"os"
-	"strconv"
-	"time"
-
-	"github.com/Fantom-foundation/Aida/logger"
+	"math/rand"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/executor/extension/logger"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
+	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
+	"github.com/Fantom-foundation/Aida/executor/extension/register"
+	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
+	log "github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
 	"github.com/Fantom-foundation/Aida/state/proxy"
 	"github.com/Fantom-foundation/Aida/stochastic"
 	"github.com/Fantom-foundation/Aida/tracer/context"
@@ -42,9 +48,21 @@ var StochasticReplayCommand = cli.Command{
 		&utils.ContinueOnFailureFlag,
 		&utils.CpuProfileFlag,
 		&utils.DebugFromFlag,
+		&utils.DiagnosticServerFlag,
+		&utils.ErrorLoggingFlag,
+		&utils.KeepDbFlag,
 		&utils.MemoryBreakdownFlag,
+		&utils.MemoryProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.NonceRangeFlag,
+		&utils.OverwriteRunIdFlag,
+		&utils.ProfileFlag,
+		&utils.ProfileDepthFlag,
+		&utils.ProfileFileFlag,
+		&utils.ProfileSqlite3Flag,
+		&utils.ProfileIntervalFlag,
 		&utils.RandomSeedFlag,
+		&utils.RegisterRunFlag,
 		&utils.StateDbImplementationFlag,
 		&utils.StateDbVariantFlag,
 		&utils.DbTmpFlag,
@@ -54,7 +72,7 @@ var StochasticReplayCommand = cli.Command{
 		&utils.TraceFlag,
 		&utils.ShadowDbImplementationFlag,
 		&utils.ShadowDbVariantFlag,
-		&logger.LogLevelFlag,
+		&log.LogLevelFlag,
 	},
 	Description: `
 The stochastic replay command requires two argument:
@@ -71,12 +89,8 @@ func stochasticReplayAction(ctx *cli.Context) error {
 	if ctx.Args().Len() != 2 {
 		return fmt.Errorf("missing simulation file and simulation length as parameter")
 	}
-	simLength, perr := strconv.ParseInt(ctx.Args().Get(0), 10, 64)
-	if perr != nil {
-		return fmt.Errorf("simulation length is not an integer; %v", perr)
-	}
 
-	// process configuration
+	// process configuration; the simulation length is the last block
 	cfg, err := utils.NewConfig(ctx, utils.LastBlockArg)
 	if err != nil {
 		return err
@@ -84,13 +98,8 @@ func stochasticReplayAction(ctx *cli.Context) error {
 	if cfg.DbImpl == "memory" {
 		return fmt.Errorf("db-impl memory is not supported")
 	}
-	log := logger.NewLogger(cfg.LogLevel, "Stochastic Replay")
-
-	// start CPU profiling if requested.
-	if err := utils.StartCPUProfile(cfg); err != nil {
-		return err
-	}
-	defer utils.StopCPUProfile(cfg)
+	// blocks of the simulation start after the priming block
+	cfg.First = 1
 
 	// read simulation file
 	simulation, serr := stochastic.ReadSimulation(ctx.Args().Get(1))
@@ -98,50 +107,118 @@ func stochasticReplayAction(ctx *cli.Context) error {
 		return fmt.Errorf("failed reading simulation; %v", serr)
 	}
 
-	// create a directory for the store to place all its files, and
-	// instantiate the state DB under testing.
-	log.Notice("Create StateDB")
-	db, stateDbDir, err := utils.PrepareStateDB(cfg)
-	if err != nil {
-		return err
+	provider, replay := prepareStochasticReplay(cfg, simulation)
+	defer provider.Close()
+
+	return runStochasticReplay(cfg, provider, replay, nil)
+}
+
+// prepareStochasticReplay creates the provider and the processor of a stochastic replay. Operations
+// and their arguments are sampled by separate random generators since the provider and the processor
+// run concurrently.
+func prepareStochasticReplay(cfg *utils.Config, simulation *stochastic.EstimationModelJSON) (executor.Provider[[]stochastic.Operation], *stochasticReplay) {
+	rg := rand.New(rand.NewSource(cfg.RandomSeed))
+	provider := executor.NewStochasticProvider(simulation, rand.New(rand.NewSource(rg.Int63())))
+	replay := makeStochasticReplay(cfg, simulation, rand.New(rand.NewSource(rg.Int63())))
+	return provider, replay
+}
+
+func runStochasticReplay(
+	cfg *utils.Config,
+	provider executor.Provider[[]stochastic.Operation],
+	replay *stochasticReplay,
+	extra []executor.Extension[[]stochastic.Operation],
+) error {
+	// order of extensionList has to be maintained
+	var extensionList = []executor.Extension[[]stochastic.Operation]{
+		profiler.MakeCpuProfiler[[]stochastic.Operation](cfg),
+		statedb.MakeStateDbManager[[]stochastic.Operation](cfg, ""),
+		register.MakeRegisterProgress[[]stochastic.Operation](cfg, 0),
+		// RegisterProgress should be the as top-most as possible on the list
+		// In this case, after StateDb is created.
+		// Any error that happen in extension above it will not be correctly recorded.
+		logger.MakeProgressLogger[[]stochastic.Operation](cfg, 0),
+		logger.MakeErrorLogger[[]stochastic.Operation](cfg),
+		monitor.MakeMetricsServer[[]stochastic.Operation](cfg),
+		profiler.MakeMemoryUsagePrinter[[]stochastic.Operation](cfg),
+		profiler.MakeMemoryProfiler[[]stochastic.Operation](cfg),
+		replay,
+		logger.MakeDbLogger[[]stochastic.Operation](cfg),
+		profiler.MakeOperationProfiler[[]stochastic.Operation](cfg),
+		profiler.MakeDiagnosticServer[[]stochastic.Operation](cfg),
+	}
+
+	extensionList = append(extensionList, extra...)
+
+	return executor.NewExecutor(provider, cfg.LogLevel).Run(
+		executor.Params{
+			From: int(cfg.First),
+			To:   int(cfg.Last) + 1,
+		},
+		replay,
+		extensionList,
+	)
+}
+
+func makeStochasticReplay(cfg *utils.Config, e *stochastic.EstimationModelJSON, rg *rand.Rand) *stochasticReplay {
+	l := log.NewLogger(cfg.LogLevel, "Stochastic Replay")
+	return &stochasticReplay{
+		cfg:      cfg,
+		replayer: stochastic.NewReplayer(cfg, e, rg, l),
+		log:      l,
 	}
-	defer os.RemoveAll(stateDbDir)
+}
 
-	// Enable tracing if debug flag is set
-	if cfg.Trace {
-		rCtx, err := context.NewRecord(cfg.TraceFile, uint64(0))
+// stochasticReplay is the processor applying sampled operations to the StateDB. As an extension,
+// it primes the StateDB before the simulation and reports the operation statistics afterwards.
+type stochasticReplay struct {
+	extension.NilExtension[[]stochastic.Operation]
+	cfg      *utils.Config
+	replayer *stochastic.Replayer
+	rCtx     *context.Record // trace recording if enabled
+	db       state.StateDB   // StateDB without the recorder proxy
+	log      log.Logger
+}
+
+// PreRun enables tracing if requested and primes the StateDB.
+func (r *stochasticReplay) PreRun(_ executor.State[[]stochastic.Operation], ctx *executor.Context) error {
+	if r.cfg.Trace {
+		rCtx, err := context.NewRecord(r.cfg.TraceFile, uint64(0))
 		if err != nil {
 			return err
 		}
-		defer rCtx.Close()
-		db = proxy.NewRecorderProxy(db, rCtx)
+		r.rCtx = rCtx
+		r.db = ctx.State
+		ctx.State = proxy.NewRecorderProxy(ctx.State, rCtx)
 	}
 
-	// run simulation.
-	log.Info("Run simulation")
-	runErr := stochastic.RunStochasticReplay(db, simulation, int(simLength), cfg, logger.NewLogger(cfg.LogLevel, "Stochastic"))
+	r.log.Noticef("using random seed %d", r.cfg.RandomSeed)
+	r.log.Noticef("Simulation block range: first %v, last %v", r.cfg.First, r.cfg.Last)
+	r.replayer.Prime(ctx.State)
+	return nil
+}
 
-	// print memory usage after simulation
-	if cfg.MemoryBreakdown {
-		if usage := db.GetMemoryUsage(); usage != nil {
-			log.Noticef("State DB memory usage: %d byte\n%s", usage.UsedBytes, usage.Breakdown)
-		} else {
-			log.Info("Utilized storage solution does not support memory breakdowns")
+// Process applies the operations of a transaction to the StateDB.
+func (r *stochasticReplay) Process(state executor.State[[]stochastic.Operation], ctx *executor.Context) error {
+	for _, op := range state.Data {
+		err := r.replayer.Execute(ctx.State, op)
+		if err == nil {
+			continue
 		}
+		if !r.cfg.ContinueOnFailure {
+			return err
+		}
+		ctx.ErrorInput <- err
 	}
+	return nil
+}
 
-	// close the DB and print disk usage
-	start := time.Now()
-	if err := db.Close(); err != nil {
-		log.Criticalf("Failed to close database; %v", err)
-	}
-	log.Infof("Closing DB took %v", time.Since(start))
-
-	size, err := utils.GetDirectorySize(stateDbDir)
-	if err != nil {
-		return fmt.Errorf("cannot size of state-db (%v); %v", stateDbDir, err)
+// PostRun reports the operation statistics and closes the trace recording.
+func (r *stochasticReplay) PostRun(_ executor.State[[]stochastic.Operation], ctx *executor.Context, _ error) error {
+	r.replayer.Report()
+	if r.rCtx != nil {
+		ctx.State = r.db
+		r.rCtx.Close()
 	}
-	log.Noticef("Final disk usage: %v MiB", float32(size)/float32(1024*1024))
-
-	return runErr
+	return nil
 }
@@ -41,6 +41,7 @@ var RunArchiveApp = cli.App{
 
 		// utils
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.ChainIDFlag,
 		&logger.LogLevelFlag,
 		&utils.StateDbLoggingFlag,
@@ -77,6 +77,7 @@ var RunSubstateCmd = cli.Command{
 
 		// Profiling
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.CpuProfilePerIntervalFlag,
 		&utils.DiagnosticServerFlag,
 		&utils.MemoryBreakdownFlag,
@@ -90,6 +91,9 @@ var RunSubstateCmd = cli.Command{
 		&utils.ProfileIntervalFlag,
 		&utils.ProfileDBFlag,
 		&utils.ProfileBlocksFlag,
+		&utils.ProfileBlocksGraphFlag,
+		&utils.ProfileBlocksGraphFmtFlag,
+		&utils.ProfileBlocksSlotsFlag,
 
 		// RegisterRun
 		&utils.RegisterRunFlag,
@@ -107,6 +111,10 @@ var RunSubstateCmd = cli.Command{
 		&utils.SyncPeriodLengthFlag,
 		&utils.KeepDbFlag,
 		&utils.CustomDbNameFlag,
+		&utils.CheckpointIntervalFlag,
+		&utils.ResumeFlag,
+		&utils.OptimisticExecutionFlag,
+		&utils.JournalFlag,
 		//&utils.MaxNumTransactionsFlag,
 		&utils.ValidateTxStateFlag,
 		&utils.ValidateFlag,
@@ -153,6 +161,7 @@ var RunTxGeneratorCmd = cli.Command{
 
 		// Profiling
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.CpuProfilePerIntervalFlag,
 		&utils.DiagnosticServerFlag,
 		&utils.MemoryBreakdownFlag,
@@ -19,6 +19,7 @@ package main
 import (
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension/logger"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
 	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
 	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
 	"github.com/Fantom-foundation/Aida/executor/extension/validator"
@@ -54,6 +55,7 @@ var RunEthTestsCmd = cli.Command{
 
 		// Profiling
 		&utils.CpuProfileFlag,
+		&utils.MetricsServerFlag,
 		&utils.CpuProfilePerIntervalFlag,
 		&utils.DiagnosticServerFlag,
 		&utils.MemoryBreakdownFlag,
@@ -100,6 +102,7 @@ func runEth(
 		profiler.MakeDiagnosticServer[txcontext.TxContext](cfg),
 		logger.MakeProgressLogger[txcontext.TxContext](cfg, 0),
 		logger.MakeErrorLogger[txcontext.TxContext](cfg),
+		monitor.MakeMetricsServer[txcontext.TxContext](cfg),
 	}
 
 	if stateDb == nil {
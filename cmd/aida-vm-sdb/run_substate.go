@@ -17,17 +17,21 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension/aidadb"
 	"github.com/Fantom-foundation/Aida/executor/extension/logger"
+	"github.com/Fantom-foundation/Aida/executor/extension/monitor"
 	"github.com/Fantom-foundation/Aida/executor/extension/primer"
 	"github.com/Fantom-foundation/Aida/executor/extension/profiler"
 	"github.com/Fantom-foundation/Aida/executor/extension/register"
 	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
 	"github.com/Fantom-foundation/Aida/executor/extension/tracker"
 	"github.com/Fantom-foundation/Aida/executor/extension/validator"
+	log "github.com/Fantom-foundation/Aida/logger"
 	"github.com/Fantom-foundation/Aida/state"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
@@ -59,6 +63,27 @@ func runSubstates(
 	processor executor.Processor[txcontext.TxContext],
 	extra []executor.Extension[txcontext.TxContext],
 ) error {
+	if cfg.Resume {
+		if stateDb != nil {
+			return errors.New("cannot resume a run with externally provided state-db")
+		}
+		if err := resumeFromCheckpoint(cfg); err != nil {
+			return err
+		}
+	}
+
+	// vm-sdb runs with one worker unless transactions are executed optimistically
+	numWorkers := 1
+	granularity := executor.BlockLevel
+	if cfg.OptimisticExecution {
+		// memory state-db is re-prepared from the substate of each transaction, hence it cannot be shared
+		if cfg.DbImpl == "memory" {
+			return errors.New("optimistic execution is not supported by memory state-db")
+		}
+		numWorkers = cfg.Workers
+		granularity = executor.OptimisticTransactionLevel
+	}
+
 	// order of extensionList has to be maintained
 	var extensionList = []executor.Extension[txcontext.TxContext]{
 		profiler.MakeCpuProfiler[txcontext.TxContext](cfg),
@@ -69,6 +94,9 @@ func runSubstates(
 		extensionList = append(
 			extensionList,
 			statedb.MakeStateDbManager[txcontext.TxContext](cfg, ""),
+			// Checkpointer has to be after StateDbManager so that its PostRun is called before the db is closed.
+			statedb.MakeCheckpointer[txcontext.TxContext](cfg),
+			statedb.MakeJournal[txcontext.TxContext](cfg),
 			statedb.MakeLiveDbBlockChecker[txcontext.TxContext](cfg),
 			validator.MakeShadowDbValidator(cfg),
 			logger.MakeDbLogger[txcontext.TxContext](cfg),
@@ -78,7 +106,7 @@ func runSubstates(
 	extensionList = append(extensionList, extra...)
 
 	extensionList = append(extensionList, []executor.Extension[txcontext.TxContext]{
-		register.MakeRegisterProgress(cfg, 100_000),
+		register.MakeRegisterProgress[txcontext.TxContext](cfg, 100_000),
 		// RegisterProgress should be the as top-most as possible on the list
 		// In this case, after StateDb is created.
 		// Any error that happen in extension above it will not be correctly recorded.
@@ -87,6 +115,7 @@ func runSubstates(
 		profiler.MakeVirtualMachineStatisticsPrinter[txcontext.TxContext](cfg),
 		logger.MakeProgressLogger[txcontext.TxContext](cfg, 15*time.Second),
 		logger.MakeErrorLogger[txcontext.TxContext](cfg),
+		monitor.MakeMetricsServer[txcontext.TxContext](cfg),
 		tracker.MakeBlockProgressTracker(cfg, 100_000),
 		primer.MakeStateDbPrimer[txcontext.TxContext](cfg),
 		profiler.MakeMemoryUsagePrinter[txcontext.TxContext](cfg),
@@ -111,11 +140,41 @@ func runSubstates(
 		executor.Params{
 			From:                   int(cfg.First),
 			To:                     int(cfg.Last) + 1,
-			NumWorkers:             1, // vm-sdb can run only with one worker
+			NumWorkers:             numWorkers,
 			State:                  stateDb,
-			ParallelismGranularity: executor.BlockLevel,
+			ParallelismGranularity: granularity,
 		},
 		processor,
 		extensionList,
 	)
 }
+
+// resumeFromCheckpoint adjusts the configuration so that the run continues in
+// the state-db given by --db-src right after the block of its last checkpoint.
+func resumeFromCheckpoint(cfg *utils.Config) error {
+	if cfg.StateDbSrc == "" {
+		return errors.New("--resume requires a checkpointed state-db given by --db-src")
+	}
+	if cfg.ShadowDb {
+		return errors.New("--resume is not supported when using shadow db")
+	}
+
+	checkpoint, err := utils.ReadCheckpoint(cfg.StateDbSrc)
+	if err != nil {
+		return fmt.Errorf("cannot read checkpoint; %w", err)
+	}
+	if err = checkpoint.Validate(cfg.StateDbSrc, cfg); err != nil {
+		return fmt.Errorf("cannot resume from checkpoint; %w", err)
+	}
+	if checkpoint.Block >= cfg.Last {
+		return fmt.Errorf("checkpoint at block %v already covers the last block %v", checkpoint.Block, cfg.Last)
+	}
+
+	log.NewLogger(cfg.LogLevel, "Resume").Noticef("Resuming from checkpoint at block %v created %v", checkpoint.Block, checkpoint.CreateTime)
+
+	// the state-db is reused in place, it must not be primed nor deleted
+	cfg.First = checkpoint.Block + 1
+	cfg.SkipPriming = true
+	cfg.KeepDb = true
+	return nil
+}
@@ -49,6 +49,7 @@ func main() {
 			&utils.ValidateTxStateFlag,
 			//&utils.OnlySuccessfulFlag,
 			&utils.CpuProfileFlag,
+			&utils.MetricsServerFlag,
 			&utils.DiagnosticServerFlag,
 			&utils.AidaDbFlag,
 			&logger.LogLevelFlag,
@@ -0,0 +1,100 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Aida/cmd/util-db/flags"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state/proxy"
+	"github.com/urfave/cli/v2"
+)
+
+var JournalCommand = cli.Command{
+	Action:    inspectJournal,
+	Name:      "journal",
+	Usage:     "Prints content of a write-ahead journal of a state-db",
+	ArgsUsage: "<state-db directory or journal file>",
+	Flags: []cli.Flag{
+		&flags.Detailed,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The journal command prints the block recorded in a journal written by --journal
+and whether the block was completed and committed, which determines whether the
+block is replayed or discarded when the state-db is reopened.`,
+}
+
+// inspectJournal prints a summary of a journal and optionally all its records.
+func inspectJournal(ctx *cli.Context) error {
+	if ctx.Args().Len() != 1 {
+		return fmt.Errorf("journal command requires exactly 1 argument")
+	}
+	log := logger.NewLogger(ctx.String(logger.LogLevelFlag.Name), "Journal-Inspect")
+
+	filename := ctx.Args().First()
+	if info, err := os.Stat(filename); err == nil && info.IsDir() {
+		filename = filepath.Join(filename, proxy.JournalFileName)
+	}
+
+	journal, err := proxy.ReadJournal(filename)
+	if err != nil {
+		return err
+	}
+
+	block, root, found := journal.Block()
+	if !found {
+		log.Noticef("Journal %v does not contain any block", filename)
+		return nil
+	}
+
+	status := "incomplete; block will be discarded"
+	if committedRoot, committed := journal.CommittedRoot(); committed {
+		status = fmt.Sprintf("committed with root %v", committedRoot)
+	} else if journal.IsComplete() {
+		status = "complete but not committed; block will be replayed"
+	}
+
+	log.Noticef("Journal: %v", filename)
+	log.Noticef("Block: %v", block)
+	log.Noticef("Root before block: %v", root)
+	log.Noticef("Status: %v", status)
+	log.Noticef("Records: %v", len(journal.Entries))
+	if journal.Torn {
+		log.Warning("Journal ends with a torn record")
+	}
+
+	counts := make(map[proxy.JournalOp]int)
+	for _, entry := range journal.Entries {
+		counts[entry.Op]++
+	}
+	for op := proxy.JournalBeginBlock; op <= proxy.JournalFinalise; op++ {
+		if counts[op] > 0 {
+			log.Infof("\t%v: %v", op, counts[op])
+		}
+	}
+
+	if ctx.Bool(flags.Detailed.Name) {
+		for i, entry := range journal.Entries {
+			fmt.Printf("%d\t%v\n", i, entry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Aida/cmd/util-db/flags"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	substatecontext "github.com/Fantom-foundation/Aida/txcontext/substate"
+	"github.com/Fantom-foundation/Aida/utildb"
+	"github.com/Fantom-foundation/Aida/utils"
+	substate "github.com/Fantom-foundation/Substate"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/urfave/cli/v2"
+)
+
+var StateDiffCommand = cli.Command{
+	Action:    stateDiff,
+	Name:      "state-diff",
+	Usage:     "Compares content of two state-dbs at a block",
+	ArgsUsage: "<state-db A> <state-db B>",
+	Flags: []cli.Flag{
+		&utils.AidaDbFlag,
+		&flags.Block,
+		&flags.MaxDiffs,
+		&utils.OutputFlag,
+		&substate.WorkersFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The state-diff command compares accounts, balances, nonces, code and storage of two
+state-dbs kept by --keep-db. The content of both state-dbs is enumerated account by
+account, so accounts and storage slots present in only one of them are reported.
+State-dbs not supporting iteration (carmen archives and carmen state-dbs created before
+key indexing) are compared on the world-state of the block generated from --aida-db
+instead, in which case the report is marked as partial. State-dbs not positioned at
+the block are read from their archive. If --output is set, the report is written as JSON.`,
+}
+
+// stateDiff compares two state-dbs and reports all differences.
+func stateDiff(ctx *cli.Context) (err error) {
+	if ctx.Args().Len() != 2 {
+		return fmt.Errorf("state-diff command requires exactly 2 arguments")
+	}
+
+	cfg, err := utils.NewConfig(ctx, utils.OneToNArgs)
+	if err != nil {
+		return err
+	}
+	log := logger.NewLogger(cfg.LogLevel, "State-Diff")
+
+	pathA, pathB := ctx.Args().Get(0), ctx.Args().Get(1)
+	block := ctx.Uint64(flags.Block.Name)
+	if !ctx.IsSet(flags.Block.Name) {
+		info, err := utils.ReadStateDbInfo(filepath.Join(pathA, utils.PathToDbInfo))
+		if err != nil {
+			return fmt.Errorf("cannot read state-db info of %v; %w", pathA, err)
+		}
+		block = info.Block
+	}
+
+	dbA, closeA, err := openStateDbView(*cfg, pathA, block)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeA())
+	}()
+
+	dbB, closeB, err := openStateDbView(*cfg, pathB, block)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeB())
+	}()
+
+	// state-dbs not supporting iteration are compared on the world-state of the block
+	var ws txcontext.WorldState
+	if !state.IsIterable(dbA) || !state.IsIterable(dbB) {
+		if cfg.AidaDb == "" {
+			return fmt.Errorf("state-dbs not supporting iteration require --%v", utils.AidaDbFlag.Name)
+		}
+		log.Warningf("State-db content cannot be enumerated; only accounts of the world-state are compared")
+
+		substate.SetSubstateDb(cfg.AidaDb)
+		substate.OpenSubstateDBReadOnly()
+		defer substate.CloseSubstateDB()
+
+		log.Noticef("Generating world-state of block %v", block)
+		alloc, err := utils.GenerateWorldStateFromUpdateDB(cfg, block)
+		if err != nil {
+			return fmt.Errorf("cannot generate world-state; %w", err)
+		}
+		ws = substatecontext.NewWorldState(alloc)
+	}
+
+	report := utildb.NewStateDiffReport(ctx.Int(flags.MaxDiffs.Name))
+	report.Block = block
+	report.DbA = pathA
+	report.DbB = pathB
+	if err = utildb.CompareStateDbs(dbA, dbB, ws, report); err != nil {
+		return err
+	}
+	report.Print(log)
+
+	if cfg.Output != "" {
+		if err = report.WriteJson(cfg.Output); err != nil {
+			return err
+		}
+		log.Noticef("Report written to %v", cfg.Output)
+	}
+
+	if n := report.NumDiffs(); n > 0 {
+		return fmt.Errorf("state-dbs differ in %v places", n)
+	}
+	return nil
+}
+
+// stateDbView is a read-only view of a state-db at a block.
+type stateDbView interface {
+	state.VmStateDB
+	GetHash() (common.Hash, error)
+}
+
+// openStateDbView opens the state-db stored in given directory read-only and returns its state
+// at given block together with a function releasing it. The live state is used if the state-db
+// is positioned at the block, otherwise the block is read from the archive.
+func openStateDbView(cfg utils.Config, path string, block uint64) (stateDbView, func() error, error) {
+	cfg.StateDbSrc = path
+	cfg.SrcDbReadonly = true
+	cfg.ShadowDb = false
+
+	info, err := utils.ReadStateDbInfo(filepath.Join(path, utils.PathToDbInfo))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot read state-db info of %v; %w", path, err)
+	}
+
+	db, _, err := utils.PrepareStateDB(&cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open state-db %v; %w", path, err)
+	}
+
+	if info.Block == block {
+		// reads require an open transaction, the block is never committed
+		if err = db.BeginBlock(block + 1); err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("cannot begin block; %w", err), db.Close())
+		}
+		if err = db.BeginTransaction(0); err != nil {
+			return nil, nil, errors.Join(fmt.Errorf("cannot begin transaction; %w", err), db.Close())
+		}
+		return db, func() error {
+			return errors.Join(db.EndTransaction(), db.Close())
+		}, nil
+	}
+
+	if !info.ArchiveMode {
+		return nil, nil, errors.Join(
+			fmt.Errorf("state-db %v is at block %v and has no archive to read block %v", path, info.Block, block),
+			db.Close(),
+		)
+	}
+
+	archive, err := db.GetArchiveState(block)
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("cannot get archive state of block %v; %w", block, err), db.Close())
+	}
+	if err = archive.BeginTransaction(0); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("cannot begin transaction; %w", err), archive.Release(), db.Close())
+	}
+	return archive, func() error {
+		return errors.Join(archive.EndTransaction(), archive.Release(), db.Close())
+	}, nil
+}
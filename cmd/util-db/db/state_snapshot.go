@@ -0,0 +1,228 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Aida/cmd/util-db/flags"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/utildb"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/urfave/cli/v2"
+)
+
+var StateExportCommand = cli.Command{
+	Action:    stateExport,
+	Name:      "state-export",
+	Usage:     "Exports content of a state-db at a block into a snapshot file",
+	ArgsUsage: "<state-db> <snapshot file>",
+	Flags: []cli.Flag{
+		&flags.Block,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The state-export command writes all accounts, code and storage of a state-db kept by
+--keep-db into a portable snapshot file which can be loaded into a state-db of any
+implementation by the state-import command. The state-db implementation must support
+iteration of its content, which is the case for geth and carmen. Carmen state-dbs are
+iterated using a key index recorded along with the state-db, hence carmen state-dbs
+created before key indexing cannot be exported. Carmen archive states are not iterable,
+so carmen state-dbs are exported only at their head block.`,
+}
+
+var StateImportCommand = cli.Command{
+	Action:    stateImport,
+	Name:      "state-import",
+	Usage:     "Creates a state-db from a snapshot file",
+	ArgsUsage: "<snapshot file>",
+	Flags: []cli.Flag{
+		&utils.AidaDbFlag,
+		&utils.StateDbImplementationFlag,
+		&utils.StateDbVariantFlag,
+		&utils.CarmenSchemaFlag,
+		&utils.DbTmpFlag,
+		&utils.CustomDbNameFlag,
+		&utils.ChainIDFlag,
+		&logger.LogLevelFlag,
+	},
+	Description: `
+The state-import command loads a snapshot written by state-export into a new state-db
+created in --db-tmp. If --aida-db is given, the state hash of the new state-db is checked
+against the state hash of the snapshot block recorded in AidaDb and the state-db is
+deleted on a mismatch. The state-db can be used by --db-src to start a run after the
+snapshot block.`,
+}
+
+// stateExport writes a snapshot of a state-db.
+func stateExport(ctx *cli.Context) (err error) {
+	if ctx.Args().Len() != 2 {
+		return fmt.Errorf("state-export command requires exactly 2 arguments")
+	}
+
+	cfg, err := utils.NewConfig(ctx, utils.OneToNArgs)
+	if err != nil {
+		return err
+	}
+	log := logger.NewLogger(cfg.LogLevel, "State-Export")
+
+	path, filename := ctx.Args().Get(0), ctx.Args().Get(1)
+	block := ctx.Uint64(flags.Block.Name)
+	if !ctx.IsSet(flags.Block.Name) {
+		info, err := utils.ReadStateDbInfo(filepath.Join(path, utils.PathToDbInfo))
+		if err != nil {
+			return fmt.Errorf("cannot read state-db info of %v; %w", path, err)
+		}
+		block = info.Block
+	}
+
+	db, closeDb, err := openStateDbView(*cfg, path, block)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, closeDb())
+	}()
+
+	if _, ok := db.(state.IterableState); !ok {
+		return fmt.Errorf("cannot export %v; %w", path, state.ErrIterationNotSupported)
+	}
+
+	root, err := db.GetHash()
+	if err != nil {
+		return fmt.Errorf("cannot get state hash; %w", err)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("cannot create snapshot file %v; %w", filename, err)
+	}
+	defer func() {
+		err = errors.Join(err, file.Close())
+	}()
+
+	log.Noticef("Exporting state of block %v with hash %v", block, root)
+	stats, err := utildb.ExportStateSnapshot(db, utildb.StateSnapshotHeader{Block: block, Root: root}, file)
+	if err != nil {
+		return fmt.Errorf("cannot export state; %w", err)
+	}
+	log.Noticef("Exported %v accounts, %v storage slots and %v distinct codes into %v", stats.Accounts, stats.StorageSlots, stats.Codes, filename)
+	return nil
+}
+
+// stateImport creates a new state-db from a snapshot.
+func stateImport(ctx *cli.Context) (err error) {
+	cfg, err := utils.NewConfig(ctx, utils.PathArg)
+	if err != nil {
+		return err
+	}
+	log := logger.NewLogger(cfg.LogLevel, "State-Import")
+
+	filename := ctx.Args().First()
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("cannot open snapshot file %v; %w", filename, err)
+	}
+	defer file.Close()
+
+	header, err := utildb.ReadStateSnapshotHeader(file)
+	if err != nil {
+		return fmt.Errorf("cannot read snapshot %v; %w", filename, err)
+	}
+
+	// the reference hash is read upfront, so that a correct import is never discarded
+	// because of an unreadable AidaDb
+	want, found, err := getReferenceStateHash(cfg, header.Block, log)
+	if err != nil {
+		return err
+	}
+
+	// snapshots are loaded into fresh state-dbs without archive
+	cfg.StateDbSrc = ""
+	cfg.ArchiveMode = false
+	cfg.ShadowDb = false
+	db, path, err := utils.PrepareStateDB(cfg)
+	if err != nil {
+		return err
+	}
+	cleanUp := func() error {
+		return errors.Join(db.Close(), os.RemoveAll(path))
+	}
+
+	log.Noticef("Importing state of block %v into %v state-db", header.Block, cfg.DbImpl)
+	stats, _, err := utildb.ImportStateSnapshot(file, db, 0, utils.OperationThreshold)
+	if err != nil {
+		return errors.Join(fmt.Errorf("cannot import snapshot; %w", err), cleanUp())
+	}
+	log.Noticef("Imported %v accounts, %v storage slots and %v distinct codes", stats.Accounts, stats.StorageSlots, stats.Codes)
+
+	root, err := db.GetHash()
+	if err != nil {
+		return errors.Join(fmt.Errorf("cannot get state hash; %w", err), cleanUp())
+	}
+	if found {
+		if root != want {
+			return errors.Join(fmt.Errorf("unexpected state hash of block %v; got: %v, want: %v", header.Block, root, want), cleanUp())
+		}
+		log.Noticef("State hash %v matches AidaDb", root)
+	}
+	if root != header.Root {
+		log.Warningf("State hash %v differs from hash %v of the exported state-db", root, header.Root)
+	}
+
+	if err = utils.WriteStateDbInfo(path, cfg, header.Block, root); err != nil {
+		return errors.Join(err, cleanUp())
+	}
+	if err = db.Close(); err != nil {
+		return fmt.Errorf("cannot close state-db; %w", err)
+	}
+	log.Noticef("State-db directory: %v", utils.RenameTempStateDbDirectory(cfg, path, header.Block))
+	return nil
+}
+
+// getReferenceStateHash returns the state hash of given block recorded in AidaDb. The returned
+// flag is false if the hash cannot be checked since no AidaDb is given or the block is not recorded.
+func getReferenceStateHash(cfg *utils.Config, block uint64, log logger.Logger) (hash common.Hash, found bool, err error) {
+	if cfg.AidaDb == "" {
+		log.Warningf("State hash is not checked since no AidaDb is given")
+		return common.Hash{}, false, nil
+	}
+	aidaDb, err := rawdb.NewLevelDBDatabase(cfg.AidaDb, 1024, 100, "profiling", true)
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("cannot open aida-db; %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, aidaDb.Close())
+	}()
+
+	hash, err = utils.MakeStateHashProvider(aidaDb).GetStateHash(int(block))
+	if errors.Is(err, leveldb.ErrNotFound) {
+		log.Warningf("State hash is not checked since AidaDb has no state hash of block %v", block)
+		return common.Hash{}, false, nil
+	}
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("cannot get state hash of block %v; %w", block, err)
+	}
+	return hash, true, nil
+}
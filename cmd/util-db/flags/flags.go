@@ -41,4 +41,13 @@ var (
 		Name:  "force",
 		Usage: "Forces generation even when dbHash is found.",
 	}
+	Block = cli.Uint64Flag{
+		Name:  "block",
+		Usage: "Block at which state-dbs are read, defaults to the last block of the (first) state-db",
+	}
+	MaxDiffs = cli.IntFlag{
+		Name:  "max-diffs",
+		Usage: "Maximum number of differences listed in the report, 0 lists all",
+		Value: 1000,
+	}
 )
@@ -41,6 +41,10 @@ var UtilDbApp = cli.App{
 		&db.MergeCommand,
 		&db.UpdateCommand,
 		&db.InfoCommand,
+		&db.JournalCommand,
+		&db.StateDiffCommand,
+		&db.StateExportCommand,
+		&db.StateImportCommand,
 		&db.ValidateCommand,
 		&db.GenDeletedAccountsCommand,
 		&db.SubstateDumpCommand,
@@ -81,6 +81,30 @@ import (
 //
 // Note that every worker has its own Context so any manipulation with this variable does not need to be thread safe.
 //
+// When running with multiple workers on OptimisticTransactionLevel granularity, blocks are processed in order
+// while the transactions of each block are executed speculatively in parallel. Each speculative execution
+// runs on a private overlay of the StateDB recording its read and write sets. Afterwards transactions are
+// committed in order; a transaction reading state written by any of its predecessors in the block is
+// re-executed on top of the updated StateDB before its modifications are applied:
+//
+//	PreRun()
+//	for each block {
+//	   PreBlock()
+//	   for transaction in parallel {
+//	       Processor.Process(transaction) on an overlay state
+//	   }
+//	   for each transaction {
+//	       PreTransaction()
+//	       if conflicting: Processor.Process(transaction) on an overlay state
+//	       apply overlay state to the StateDB
+//	       PostTransaction()
+//	   }
+//	   PostBlock()
+//	}
+//	PostRun()
+//
+// Note that the processor may be called more than once for a transaction in this mode.
+//
 // Each PreXXX() and PostXXX() is a hook-in point at which extensions may
 // track information and/or interfere with the execution. For more details on
 // the specific call-backs see the Extension interface below.
@@ -114,6 +138,7 @@ type ParallelismGranularity byte
 const (
 	TransactionLevel ParallelismGranularity = iota // Post and Pre Transactions() need to be Thread-Safe
 	BlockLevel
+	OptimisticTransactionLevel // Processor needs to be Thread-Safe
 )
 
 // Params summarizes input parameters for a run of the executor.
@@ -275,6 +300,8 @@ func (e *executor[T]) Run(params Params, processor Processor[T], extensions []Ex
 		return e.runTransactions(params, processor, extensions, &state, &ctx)
 	case BlockLevel:
 		return e.runBlocks(params, processor, extensions, &state, &ctx)
+	case OptimisticTransactionLevel:
+		return e.runOptimisticBlocks(params, processor, extensions, &state, &ctx)
 	default:
 		return fmt.Errorf("incorrect parallelism type: %v", params.ParallelismGranularity)
 	}
@@ -0,0 +1,320 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/utils"
+)
+
+const (
+	// rateInterval is the period in which transaction and gas rates are recomputed.
+	rateInterval = 5 * time.Second
+	// memorySampleInterval is the minimal period between two samples of StateDB memory usage.
+	memorySampleInterval = 5 * time.Second
+)
+
+// MakeMetricsServer creates an extension which runs a background HTTP server exposing
+// progress of the run in Prometheus text format at /metrics and in JSON at /status.
+func MakeMetricsServer[T any](cfg *utils.Config) executor.Extension[T] {
+	if cfg.MetricsServer < 1 || cfg.MetricsServer > math.MaxUint16 {
+		return extension.NilExtension[T]{}
+	}
+	addr := fmt.Sprintf("localhost:%d", cfg.MetricsServer)
+	return makeMetricsServer[T](cfg, addr, rateInterval, logger.NewLogger(cfg.LogLevel, "Metrics-Server"))
+}
+
+func makeMetricsServer[T any](cfg *utils.Config, addr string, interval time.Duration, log logger.Logger) *metricsServer[T] {
+	return &metricsServer[T]{
+		cfg:      cfg,
+		addr:     addr,
+		interval: interval,
+		log:      log,
+		quit:     make(chan struct{}),
+	}
+}
+
+// Status is a snapshot of the progress of a run served at /status.
+type Status struct {
+	Running        bool    `json:"running"`
+	First          uint64  `json:"first"`
+	Last           uint64  `json:"last"`
+	CurrentBlock   int     `json:"currentBlock"`
+	Transactions   uint64  `json:"transactions"`
+	Gas            uint64  `json:"gas"`
+	TxRate         float64 `json:"txRate"`        // transactions per second in last rate interval
+	GasRate        float64 `json:"gasRate"`       // gas per second in last rate interval
+	Errors         uint64  `json:"errors"`        // number of processing errors
+	StateDbMemory  uint64  `json:"stateDbMemory"` // bytes used by StateDB, zero if unknown
+	StateDbDisk    int64   `json:"stateDbDisk"`   // bytes used by StateDB directory, zero if unknown
+	ElapsedSeconds float64 `json:"elapsedSeconds"`
+}
+
+type metricsServer[T any] struct {
+	extension.NilExtension[T]
+	cfg      *utils.Config
+	addr     string
+	interval time.Duration
+	log      logger.Logger
+	server   *http.Server
+	quit     chan struct{}
+	wg       sync.WaitGroup
+
+	// processing errors are counted and forwarded to the original channel
+	errorInput    chan error
+	originalInput chan error
+	errorsDone    chan struct{}
+
+	mu          sync.Mutex
+	start       time.Time
+	running     bool
+	stateDbPath string
+	block       int
+	txs         uint64
+	gas         uint64
+	errors      uint64
+	txRate      float64
+	gasRate     float64
+	memory      uint64
+	lastMemory  time.Time
+	lastTxs     uint64    // number of transactions at last rate update
+	lastGas     uint64    // gas at last rate update
+	lastRate    time.Time // time of last rate update
+}
+
+// PreRun starts the HTTP server and starts counting processing errors.
+func (m *metricsServer[T]) PreRun(_ executor.State[T], ctx *executor.Context) error {
+	listener, err := net.Listen("tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("cannot start metrics server; %w", err)
+	}
+	m.addr = listener.Addr().String()
+
+	m.mu.Lock()
+	m.start = time.Now()
+	m.lastRate = m.start
+	m.running = true
+	m.mu.Unlock()
+
+	if ctx.ErrorInput != nil {
+		m.originalInput = ctx.ErrorInput
+		m.errorInput = make(chan error, cap(ctx.ErrorInput))
+		m.errorsDone = make(chan struct{})
+		ctx.ErrorInput = m.errorInput
+		go m.forwardErrors()
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", m.serveMetrics)
+	mux.HandleFunc("/status", m.serveStatus)
+	m.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	m.wg.Add(2)
+	go func() {
+		defer m.wg.Done()
+		if err := m.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			m.log.Errorf("metrics server failed; %v", err)
+		}
+	}()
+	go m.updateRates()
+
+	m.log.Noticef("Serving metrics at http://%v/metrics and status at http://%v/status", m.addr, m.addr)
+	return nil
+}
+
+// PostTransaction counts processed transactions and used gas.
+func (m *metricsServer[T]) PostTransaction(state executor.State[T], ctx *executor.Context) error {
+	var gas uint64
+	if ctx.ExecutionResult != nil {
+		gas = ctx.ExecutionResult.GetGasUsed()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.txs++
+	m.gas += gas
+	if state.Block > m.block {
+		m.block = state.Block
+	}
+	return nil
+}
+
+// PostBlock updates the current block and samples memory usage of the StateDB.
+func (m *metricsServer[T]) PostBlock(state executor.State[T], ctx *executor.Context) error {
+	m.mu.Lock()
+	if state.Block > m.block {
+		m.block = state.Block
+	}
+	// the StateDB may be created by an extension running after this one
+	m.stateDbPath = ctx.StateDbPath
+	sample := ctx.State != nil && time.Since(m.lastMemory) >= memorySampleInterval
+	if sample {
+		m.lastMemory = time.Now()
+	}
+	m.mu.Unlock()
+
+	// StateDB is not thread-safe, hence it is sampled here rather than by the HTTP server
+	if sample {
+		if usage := ctx.State.GetMemoryUsage(); usage != nil {
+			m.mu.Lock()
+			m.memory = usage.UsedBytes
+			m.mu.Unlock()
+		}
+	}
+	return nil
+}
+
+// PostRun stops the HTTP server and restores the original error channel.
+func (m *metricsServer[T]) PostRun(_ executor.State[T], ctx *executor.Context, _ error) error {
+	m.mu.Lock()
+	m.running = false
+	m.mu.Unlock()
+
+	if m.errorInput != nil {
+		close(m.errorInput)
+		<-m.errorsDone
+		ctx.ErrorInput = m.originalInput
+	}
+
+	close(m.quit)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := m.server.Shutdown(shutdownCtx)
+	m.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("cannot stop metrics server; %w", err)
+	}
+	return nil
+}
+
+// forwardErrors counts processing errors and passes them to the original channel.
+func (m *metricsServer[T]) forwardErrors() {
+	defer close(m.errorsDone)
+	for err := range m.errorInput {
+		m.mu.Lock()
+		m.errors++
+		m.mu.Unlock()
+		m.originalInput <- err
+	}
+}
+
+// updateRates periodically recomputes transaction and gas rates.
+func (m *metricsServer[T]) updateRates() {
+	defer m.wg.Done()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.quit:
+			return
+		case now := <-ticker.C:
+			m.updateRate(now)
+		}
+	}
+}
+
+// updateRate computes transaction and gas rates since the last update.
+func (m *metricsServer[T]) updateRate(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elapsed := now.Sub(m.lastRate).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	m.txRate = float64(m.txs-m.lastTxs) / elapsed
+	m.gasRate = float64(m.gas-m.lastGas) / elapsed
+	m.lastTxs, m.lastGas, m.lastRate = m.txs, m.gas, now
+}
+
+// status returns a snapshot of the current progress.
+func (m *metricsServer[T]) status() Status {
+	m.mu.Lock()
+	status := Status{
+		Running:        m.running,
+		First:          m.cfg.First,
+		Last:           m.cfg.Last,
+		CurrentBlock:   m.block,
+		Transactions:   m.txs,
+		Gas:            m.gas,
+		TxRate:         m.txRate,
+		GasRate:        m.gasRate,
+		Errors:         m.errors,
+		StateDbMemory:  m.memory,
+		ElapsedSeconds: time.Since(m.start).Seconds(),
+	}
+	path := m.stateDbPath
+	m.mu.Unlock()
+
+	if path != "" {
+		if size, err := utils.GetDirectorySize(path); err == nil {
+			status.StateDbDisk = size
+		}
+	}
+	return status
+}
+
+// serveStatus writes the current progress in JSON.
+func (m *metricsServer[T]) serveStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m.status()); err != nil {
+		m.log.Warningf("cannot write status; %v", err)
+	}
+}
+
+// serveMetrics writes the current progress in Prometheus text exposition format.
+func (m *metricsServer[T]) serveMetrics(w http.ResponseWriter, _ *http.Request) {
+	s := m.status()
+	running := 0
+	if s.Running {
+		running = 1
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, metric := range []struct {
+		name, kind, help string
+		value            any
+	}{
+		{"aida_running", "gauge", "Whether the run is in progress.", running},
+		{"aida_first_block", "gauge", "First block of the run.", s.First},
+		{"aida_last_block", "gauge", "Last block of the run.", s.Last},
+		{"aida_current_block", "gauge", "Highest processed block.", s.CurrentBlock},
+		{"aida_transactions_total", "counter", "Number of processed transactions.", s.Transactions},
+		{"aida_gas_total", "counter", "Gas used by processed transactions.", s.Gas},
+		{"aida_transactions_per_second", "gauge", "Transaction rate in the last interval.", s.TxRate},
+		{"aida_gas_per_second", "gauge", "Gas rate in the last interval.", s.GasRate},
+		{"aida_errors_total", "counter", "Number of processing errors.", s.Errors},
+		{"aida_statedb_memory_bytes", "gauge", "Memory used by the StateDB.", s.StateDbMemory},
+		{"aida_statedb_disk_bytes", "gauge", "Disk space used by the StateDB directory.", s.StateDbDisk},
+		{"aida_elapsed_seconds", "gauge", "Time since the start of the run.", s.ElapsedSeconds},
+	} {
+		if _, err := fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v %v\n%v %v\n", metric.name, metric.help, metric.name, metric.kind, metric.name, metric.value); err != nil {
+			m.log.Warningf("cannot write metrics; %v", err)
+			return
+		}
+	}
+}
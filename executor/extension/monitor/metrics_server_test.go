@@ -0,0 +1,160 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	substatecontext "github.com/Fantom-foundation/Aida/txcontext/substate"
+	"github.com/Fantom-foundation/Aida/utils"
+	substate "github.com/Fantom-foundation/Substate"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetricsServer_NoServerIsCreatedIfDisabled(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := MakeMetricsServer[any](cfg)
+
+	if _, ok := ext.(extension.NilExtension[any]); !ok {
+		t.Errorf("metrics server is enabled although not set in configuration")
+	}
+}
+
+func TestMetricsServer_ProgressIsServed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+	db.EXPECT().GetMemoryUsage().Return(&state.MemoryUsage{UsedBytes: 1234})
+
+	cfg := &utils.Config{First: 10, Last: 20}
+	ext := makeMetricsServer[any](cfg, "localhost:0", time.Hour, logger.NewLogger("critical", "test"))
+
+	errorInput := make(chan error, 10)
+	ctx := &executor.Context{State: db, StateDbPath: t.TempDir(), ErrorInput: errorInput}
+	if err := ext.PreRun(executor.State[any]{}, ctx); err != nil {
+		t.Fatalf("cannot start metrics server; %v", err)
+	}
+
+	ctx.ExecutionResult = substatecontext.NewResult(&substate.SubstateResult{GasUsed: 21000})
+	for tx := 0; tx < 3; tx++ {
+		if err := ext.PostTransaction(executor.State[any]{Block: 12, Transaction: tx}, ctx); err != nil {
+			t.Fatalf("post-transaction failed; %v", err)
+		}
+	}
+	if err := ext.PostBlock(executor.State[any]{Block: 12}, ctx); err != nil {
+		t.Fatalf("post-block failed; %v", err)
+	}
+	ctx.ErrorInput <- errors.New("processing error")
+	if got := <-errorInput; got == nil || got.Error() != "processing error" {
+		t.Errorf("error was not forwarded; got: %v", got)
+	}
+
+	metrics := httpGet(t, fmt.Sprintf("http://%v/metrics", ext.addr))
+	for _, want := range []string{
+		"aida_current_block 12\n",
+		"aida_transactions_total 3\n",
+		"aida_gas_total 63000\n",
+		"aida_errors_total 1\n",
+		"aida_statedb_memory_bytes 1234\n",
+		"# TYPE aida_gas_total counter\n",
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("metrics do not contain %q:\n%v", want, metrics)
+		}
+	}
+
+	var status Status
+	if err := json.Unmarshal([]byte(httpGet(t, fmt.Sprintf("http://%v/status", ext.addr))), &status); err != nil {
+		t.Fatalf("cannot decode status; %v", err)
+	}
+	if !status.Running || status.First != 10 || status.Last != 20 || status.CurrentBlock != 12 || status.Transactions != 3 || status.Errors != 1 {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if err := ext.PostRun(executor.State[any]{}, ctx, nil); err != nil {
+		t.Fatalf("cannot stop metrics server; %v", err)
+	}
+	if ctx.ErrorInput != errorInput {
+		t.Errorf("original error channel was not restored")
+	}
+	if _, err := http.Get(fmt.Sprintf("http://%v/status", ext.addr)); err == nil {
+		t.Errorf("server is still running after post-run")
+	}
+}
+
+func TestMetricsServer_RatesAreUpdated(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := makeMetricsServer[any](cfg, "localhost:0", time.Hour, logger.NewLogger("critical", "test"))
+	ctx := &executor.Context{ExecutionResult: substatecontext.NewResult(&substate.SubstateResult{GasUsed: 100})}
+	if err := ext.PreRun(executor.State[any]{}, ctx); err != nil {
+		t.Fatalf("cannot start metrics server; %v", err)
+	}
+	defer ext.PostRun(executor.State[any]{}, ctx, nil)
+
+	for i := 0; i < 4; i++ {
+		if err := ext.PostTransaction(executor.State[any]{Block: 1}, ctx); err != nil {
+			t.Fatalf("post-transaction failed; %v", err)
+		}
+	}
+	ext.updateRate(ext.lastRate.Add(2 * time.Second))
+	if status := ext.status(); status.TxRate != 2 || status.GasRate != 200 {
+		t.Errorf("unexpected rates; tx: %v, gas: %v", status.TxRate, status.GasRate)
+	}
+	ext.updateRate(ext.lastRate.Add(time.Second))
+	if status := ext.status(); status.TxRate != 0 {
+		t.Errorf("rate must only cover the last interval; got: %v", status.TxRate)
+	}
+}
+
+func TestMetricsServer_PreRunFailsIfPortIsUsed(t *testing.T) {
+	cfg := &utils.Config{}
+	first := makeMetricsServer[any](cfg, "localhost:0", time.Second, logger.NewLogger("critical", "test"))
+	ctx := &executor.Context{}
+	if err := first.PreRun(executor.State[any]{}, ctx); err != nil {
+		t.Fatalf("cannot start metrics server; %v", err)
+	}
+	defer first.PostRun(executor.State[any]{}, ctx, nil)
+
+	second := makeMetricsServer[any](cfg, first.addr, time.Second, logger.NewLogger("critical", "test"))
+	if err := second.PreRun(executor.State[any]{}, &executor.Context{}); err == nil {
+		t.Errorf("pre-run must fail if the port is already used")
+	}
+}
+
+func httpGet(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("cannot get %v; %v", url, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("cannot read response of %v; %v", url, err)
+	}
+	return string(body)
+}
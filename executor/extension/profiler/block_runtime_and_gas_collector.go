@@ -18,12 +18,15 @@ package profiler
 
 import (
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension"
 	"github.com/Fantom-foundation/Aida/logger"
 	"github.com/Fantom-foundation/Aida/profile/blockprofile"
+	"github.com/Fantom-foundation/Aida/state/proxy"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
 )
@@ -46,10 +49,12 @@ type BlockRuntimeAndGasCollector struct {
 	ctx        *blockprofile.Context
 	blockTimer time.Time
 	txTimer    time.Time
+	formats    []string           // formats of exported dependency graphs; empty if graphs are not exported
+	accesses   *proxy.AccessProxy // records state accesses of transactions; nil if disabled
 }
 
-// PreRun prepares the ProfileDB
-func (b *BlockRuntimeAndGasCollector) PreRun(executor.State[txcontext.TxContext], *executor.Context) error {
+// PreRun prepares the ProfileDB and wraps the StateDB into an access recording proxy if needed.
+func (b *BlockRuntimeAndGasCollector) PreRun(_ executor.State[txcontext.TxContext], ctx *executor.Context) error {
 	var err error
 	b.profileDb, err = blockprofile.NewProfileDB(b.cfg.ProfileDB)
 	if err != nil {
@@ -62,6 +67,27 @@ func (b *BlockRuntimeAndGasCollector) PreRun(executor.State[txcontext.TxContext]
 		return fmt.Errorf("cannot delete old data from profile-db; %v", err)
 	}
 
+	if b.cfg.ProfileBlocksGraph != "" {
+		for _, format := range strings.Split(b.cfg.ProfileBlocksGraphFmt, ",") {
+			format = strings.TrimSpace(format)
+			if format != "json" && format != "dot" {
+				return fmt.Errorf("unknown dependency graph format %q", format)
+			}
+			b.formats = append(b.formats, format)
+		}
+		if err = os.MkdirAll(b.cfg.ProfileBlocksGraph, 0755); err != nil {
+			return fmt.Errorf("cannot create directory for dependency graphs; %v", err)
+		}
+	}
+
+	if b.cfg.ProfileBlocksSlots {
+		if ctx == nil || ctx.State == nil {
+			return fmt.Errorf("slot-level block profiling requires a state-db")
+		}
+		b.accesses = proxy.NewAccessProxy(ctx.State)
+		ctx.State = b.accesses
+	}
+
 	return nil
 }
 
@@ -71,9 +97,18 @@ func (b *BlockRuntimeAndGasCollector) PreTransaction(executor.State[txcontext.Tx
 	return nil
 }
 
-// PostTransaction records tx into profile context.
+// PostTransaction records tx into profile context. It is received before the transaction
+// is ended by the transaction event emitter, hence the accesses of the current transaction
+// are recorded.
 func (b *BlockRuntimeAndGasCollector) PostTransaction(state executor.State[txcontext.TxContext], _ *executor.Context) error {
-	err := b.ctx.RecordTransaction(state, time.Since(b.txTimer))
+	var err error
+	tTransaction := time.Since(b.txTimer)
+	if b.accesses != nil {
+		reads, writes := b.accesses.TransactionAccesses()
+		err = b.ctx.RecordTransactionWithAccesses(state, tTransaction, reads, writes)
+	} else {
+		err = b.ctx.RecordTransaction(state, tTransaction)
+	}
 	if err != nil {
 		return fmt.Errorf("cannot record transaction; %v", err)
 	}
@@ -99,6 +134,13 @@ func (b *BlockRuntimeAndGasCollector) PostBlock(state executor.State[txcontext.T
 		return fmt.Errorf("cannot add data to profile-db; %v", err)
 	}
 
+	if len(b.formats) > 0 {
+		err = blockprofile.ExportBlockGraph(b.ctx.GetBlockGraph(uint64(state.Block)), b.cfg.ProfileBlocksGraph, b.formats)
+		if err != nil {
+			return fmt.Errorf("cannot export dependency graph; %v", err)
+		}
+	}
+
 	return nil
 }
 
@@ -17,14 +17,24 @@
 package profiler
 
 import (
+	"database/sql"
 	"errors"
+	"math/big"
 	"os"
 	"testing"
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/executor/extension/statedb"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/state/proxy"
 	"github.com/Fantom-foundation/Aida/txcontext"
+	substatecontext "github.com/Fantom-foundation/Aida/txcontext/substate"
 	"github.com/Fantom-foundation/Aida/utils"
+	substate "github.com/Fantom-foundation/Substate"
+	"github.com/ethereum/go-ethereum/common"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/mock/gomock"
 )
 
 func TestBlockProfilerExtension_NoProfileIsCollectedIfDisabled(t *testing.T) {
@@ -55,3 +65,138 @@ func TestBlockProfilerExtension_ProfileDbIsCreated(t *testing.T) {
 		t.Fatalf("unexpected error; %v", err)
 	}
 }
+
+func TestBlockProfilerExtension_GraphDirectoryIsCreated(t *testing.T) {
+	dir := t.TempDir()
+	config := &utils.Config{}
+	config.ProfileBlocks = true
+	config.ProfileDB = dir + "/profile.db"
+	config.ProfileBlocksGraph = dir + "/graphs"
+	config.ProfileBlocksGraphFmt = "json, dot"
+
+	ext := MakeBlockRuntimeAndGasCollector(config)
+
+	if err := ext.PreRun(executor.State[txcontext.TxContext]{}, nil); err != nil {
+		t.Fatalf("unexpected error during pre-run; %v", err)
+	}
+	if _, err := os.Stat(config.ProfileBlocksGraph); err != nil {
+		t.Fatalf("graph directory was not created; %v", err)
+	}
+}
+
+func TestBlockProfilerExtension_UnknownGraphFormatIsRejected(t *testing.T) {
+	dir := t.TempDir()
+	config := &utils.Config{}
+	config.ProfileBlocks = true
+	config.ProfileDB = dir + "/profile.db"
+	config.ProfileBlocksGraph = dir + "/graphs"
+	config.ProfileBlocksGraphFmt = "svg"
+
+	ext := MakeBlockRuntimeAndGasCollector(config)
+
+	if err := ext.PreRun(executor.State[txcontext.TxContext]{}, nil); err == nil {
+		t.Fatal("pre-run must fail for unknown graph format")
+	}
+}
+
+func TestBlockProfilerExtension_SlotProfilingWrapsStateDb(t *testing.T) {
+	config := &utils.Config{}
+	config.ProfileBlocks = true
+	config.ProfileBlocksSlots = true
+	config.ProfileDB = t.TempDir() + "/profile.db"
+
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	ctx := &executor.Context{State: db}
+	ext := MakeBlockRuntimeAndGasCollector(config)
+
+	if err = ext.PreRun(executor.State[txcontext.TxContext]{}, ctx); err != nil {
+		t.Fatalf("unexpected error during pre-run; %v", err)
+	}
+	if _, ok := ctx.State.(*proxy.AccessProxy); !ok {
+		t.Errorf("state-db was not wrapped into access proxy")
+	}
+}
+
+func TestBlockProfilerExtension_SlotProfilingRecordsAccessesOfEachTransaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := executor.NewMockProvider[txcontext.TxContext](ctrl)
+	processor := executor.NewMockProcessor[txcontext.TxContext](ctrl)
+
+	config := &utils.Config{}
+	config.ProfileBlocks = true
+	config.ProfileBlocksSlots = true
+	config.ProfileDB = t.TempDir() + "/profile.db"
+
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+
+	account := common.Address{0x12}
+	newTx := func(from common.Address) txcontext.TxContext {
+		return substatecontext.NewTxContext(&substate.Substate{
+			InputAlloc:  substate.SubstateAlloc{},
+			OutputAlloc: substate.SubstateAlloc{},
+			Message:     &substate.SubstateMessage{From: from, To: &common.Address{0x34}},
+			Result:      &substate.SubstateResult{GasUsed: 21000},
+		})
+	}
+
+	provider.EXPECT().
+		Run(1, 2, gomock.Any()).
+		DoAndReturn(func(_ int, _ int, consumer executor.Consumer[txcontext.TxContext]) error {
+			if err := consumer(executor.TransactionInfo[txcontext.TxContext]{Block: 1, Transaction: 0, Data: newTx(common.Address{1})}); err != nil {
+				return err
+			}
+			return consumer(executor.TransactionInfo[txcontext.TxContext]{Block: 1, Transaction: 1, Data: newTx(common.Address{2})})
+		})
+
+	// tx 0 modifies the balance read by tx 1
+	processor.EXPECT().
+		Process(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(s executor.State[txcontext.TxContext], ctx *executor.Context) error {
+			if s.Transaction == 0 {
+				ctx.State.AddBalance(account, big.NewInt(1))
+			} else {
+				ctx.State.GetBalance(account)
+			}
+			return nil
+		}).
+		Times(2)
+
+	// extensions are ordered as in aida-vm-sdb, hence the collector receives
+	// PostTransaction before the transaction is ended by the emitter
+	extensions := []executor.Extension[txcontext.TxContext]{
+		statedb.MakeBlockEventEmitter[txcontext.TxContext](),
+		statedb.MakeTransactionEventEmitter[txcontext.TxContext](),
+		MakeBlockRuntimeAndGasCollector(config),
+	}
+	err = executor.NewExecutor[txcontext.TxContext](provider, "critical").Run(
+		executor.Params{From: 1, To: 2, State: db},
+		processor,
+		extensions,
+	)
+	if err != nil {
+		t.Fatalf("run failed; %v", err)
+	}
+
+	profileDb, err := sql.Open("sqlite3", config.ProfileDB)
+	if err != nil {
+		t.Fatalf("cannot open profile-db; %v", err)
+	}
+	defer profileDb.Close()
+
+	// tx 0 must be recorded with its write and tx 1 with its read of the balance,
+	// otherwise the transactions are not in conflict
+	var numRead, numWrite int
+	err = profileDb.QueryRow("SELECT numConflictsRead, numConflictsWrite FROM blockSlotProfile WHERE block = 1").Scan(&numRead, &numWrite)
+	if err != nil {
+		t.Fatalf("cannot read slot profile; %v", err)
+	}
+	if numRead != 0 || numWrite != 1 {
+		t.Errorf("unexpected conflicts; read: %v, write: %v", numRead, numWrite)
+	}
+}
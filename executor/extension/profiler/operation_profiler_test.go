@@ -80,8 +80,10 @@ func TestOperationProfiler_WithEachOpOnce(t *testing.T) {
 
 		ctrl := gomock.NewController(t)
 		mockStateDB := state.NewMockStateDB(ctrl)
-		mockCtx := executor.Context{State: mockStateDB}
+		mockIterableState := state.NewMockIterableState(ctrl)
+		mockCtx := executor.Context{State: iterableMockStateDB{mockStateDB, mockIterableState}}
 		prepareMockStateDbOnce(mockStateDB)
+		prepareMockIterableStateOnce(ctrl, mockIterableState)
 
 		// PRE BLOCK
 		ext.PreRun(executor.State[any]{}, &mockCtx)
@@ -89,7 +91,7 @@ func TestOperationProfiler_WithEachOpOnce(t *testing.T) {
 		ext.PreTransaction(executor.State[any]{Transaction: int(0)}, nil)
 
 		// call each function once as a single tx in a single block
-		funcs := getStateDbFuncs(mockCtx.State)
+		funcs := append(getStateDbFuncs(mockCtx.State), getIteratorFuncs(mockCtx.State)...)
 		for _, f := range funcs {
 			f()
 		}
@@ -339,6 +341,25 @@ func getStateDbFuncs(db state.StateDB) []func() {
 	}
 }
 
+// iterableMockStateDB combines a MockStateDB with iteration support of a MockIterableState.
+type iterableMockStateDB struct {
+	*state.MockStateDB
+	*state.MockIterableState
+}
+
+// contains a list of iterator operations to be tested, they must be called in the listed order.
+func getIteratorFuncs(db state.StateDB) []func() {
+	mockAddress := common.HexToAddress("0x00000F1")
+	var accounts state.AccountIterator
+	var storage state.StorageIterator
+	return []func(){
+		func() { accounts, _ = state.NewAccountIterator(db) },
+		func() { accounts.Next() },
+		func() { storage, _ = state.NewStorageIterator(db, mockAddress) },
+		func() { storage.Next() },
+	}
+}
+
 // MockStateDB must be prepared before used (it needs to know how many time each function will be called).
 // This functions tell MockStateDB to expect any number of calls (0 or more) to each of the functions (for randomized test)
 func prepareMockStateDb(m *state.MockStateDB) {
@@ -433,6 +454,15 @@ func prepareMockStateDbOnce(m *state.MockStateDB) {
 	m.EXPECT().Close()
 }
 
+func prepareMockIterableStateOnce(ctrl *gomock.Controller, m *state.MockIterableState) {
+	accounts := state.NewMockAccountIterator(ctrl)
+	accounts.EXPECT().Next().Return(false)
+	storage := state.NewMockStorageIterator(ctrl)
+	storage.EXPECT().Next().Return(false)
+	m.EXPECT().NewAccountIterator().Return(accounts, nil)
+	m.EXPECT().NewStorageIterator(gomock.Any()).Return(storage, nil)
+}
+
 // Helper function to randomize an operation to be called
 func getRandomStateDbFunc(db state.StateDB, r *rand.Rand) func() {
 	funcs := getStateDbFuncs(db)
@@ -29,7 +29,6 @@ import (
 	"github.com/Fantom-foundation/Aida/executor/extension"
 	"github.com/Fantom-foundation/Aida/logger"
 	"github.com/Fantom-foundation/Aida/state"
-	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
 )
 
@@ -76,9 +75,9 @@ const (
 // MakeRegisterProgress creates an extention that
 //  1. Track Progress e.g. ProgressTracker
 //  2. Register the intermediate results to an external service (sqlite3 db)
-func MakeRegisterProgress(cfg *utils.Config, reportFrequency int) executor.Extension[txcontext.TxContext] {
+func MakeRegisterProgress[T any](cfg *utils.Config, reportFrequency int) executor.Extension[T] {
 	if cfg.RegisterRun == "" {
-		return extension.NilExtension[txcontext.TxContext]{}
+		return extension.NilExtension[T]{}
 	}
 
 	if reportFrequency == 0 {
@@ -98,7 +97,7 @@ func MakeRegisterProgress(cfg *utils.Config, reportFrequency int) executor.Exten
 		when = OnPreBlock
 	}
 
-	return &registerProgress{
+	return &registerProgress[T]{
 		cfg:      cfg,
 		log:      logger.NewLogger(cfg.LogLevel, "Register-Progress-Logger"),
 		interval: utils.NewInterval(cfg.First, cfg.Last, uint64(reportFrequency)),
@@ -110,8 +109,8 @@ func MakeRegisterProgress(cfg *utils.Config, reportFrequency int) executor.Exten
 
 // registerProgress logs progress every XXX blocks depending on reportFrequency.
 // Default is 100_000 blocks. This is mainly used for gathering information about process.
-type registerProgress struct {
-	extension.NilExtension[txcontext.TxContext]
+type registerProgress[T any] struct {
+	extension.NilExtension[T]
 
 	// Configuration
 	cfg  *utils.Config
@@ -143,7 +142,7 @@ type registerProgress struct {
 // 1. if directory does not exists -> fatal, throw error
 // 2. if database could not be created -> fatal, throw error
 // 3. if metadata table could not be created -> fatal, throw error
-func (rp *registerProgress) PreRun(_ executor.State[txcontext.TxContext], ctx *executor.Context) error {
+func (rp *registerProgress[T]) PreRun(_ executor.State[T], ctx *executor.Context) error {
 	connection := filepath.Join(rp.cfg.RegisterRun, fmt.Sprintf("%s.db", rp.GetId()))
 	rp.log.Noticef("Registering to: %s", connection)
 
@@ -197,7 +196,7 @@ func (rp *registerProgress) PreRun(_ executor.State[txcontext.TxContext], ctx *e
 	return nil
 }
 
-func (rp *registerProgress) PreBlock(state executor.State[txcontext.TxContext], ctx *executor.Context) error {
+func (rp *registerProgress[T]) PreBlock(state executor.State[T], ctx *executor.Context) error {
 	if rp.when != OnPreBlock {
 		return nil
 	}
@@ -209,7 +208,7 @@ func (rp *registerProgress) PreBlock(state executor.State[txcontext.TxContext],
 	return nil
 }
 
-func (rp *registerProgress) PreTransaction(state executor.State[txcontext.TxContext], ctx *executor.Context) error {
+func (rp *registerProgress[T]) PreTransaction(state executor.State[T], ctx *executor.Context) error {
 	if rp.when != OnPreTransaction {
 		return nil
 	}
@@ -222,7 +221,7 @@ func (rp *registerProgress) PreTransaction(state executor.State[txcontext.TxCont
 }
 
 // printAndReset sends the state to the report goroutine and reset current-interval tracker.
-func (rp *registerProgress) printAndReset(ctx *executor.Context) error {
+func (rp *registerProgress[T]) printAndReset(ctx *executor.Context) error {
 	rp.memory = ctx.State.GetMemoryUsage()
 	rp.ps.Print()
 	rp.Reset()
@@ -232,7 +231,7 @@ func (rp *registerProgress) printAndReset(ctx *executor.Context) error {
 }
 
 // PostTransaction increments number of transactions and saves gas used in last substate.
-func (rp *registerProgress) PostTransaction(state executor.State[txcontext.TxContext], ctx *executor.Context) error {
+func (rp *registerProgress[T]) PostTransaction(state executor.State[T], ctx *executor.Context) error {
 
 	res := ctx.ExecutionResult
 
@@ -242,14 +241,17 @@ func (rp *registerProgress) PostTransaction(state executor.State[txcontext.TxCon
 	rp.totalTxCount++
 	rp.txCount++
 
-	rp.totalGas += res.GetGasUsed()
-	rp.gas += res.GetGasUsed()
+	// processors without an execution result (e.g. stochastic replay) count transactions only
+	if res != nil {
+		rp.totalGas += res.GetGasUsed()
+		rp.gas += res.GetGasUsed()
+	}
 
 	return nil
 }
 
 // PostRun prints the remaining statistics and terminates any printer resources.
-func (rp *registerProgress) PostRun(_ executor.State[txcontext.TxContext], ctx *executor.Context, err error) error {
+func (rp *registerProgress[T]) PostRun(_ executor.State[T], ctx *executor.Context, err error) error {
 	rp.memory = ctx.State.GetMemoryUsage()
 	rp.ps.Print()
 	rp.Reset()
@@ -270,18 +272,18 @@ func (rp *registerProgress) PostRun(_ executor.State[txcontext.TxContext], ctx *
 }
 
 // Reset set local interval trackers to initial state for the next interval.
-func (rp *registerProgress) Reset() {
+func (rp *registerProgress[T]) Reset() {
 	rp.lastUpdate = time.Now()
 	rp.txCount = 0
 	rp.gas = 0
 }
 
 // GetId returns a unique id based on the run metadata.
-func (rp *registerProgress) GetId() string {
+func (rp *registerProgress[T]) GetId() string {
 	return rp.id.GetId()
 }
 
-func (rp *registerProgress) sqlite3(conn string) (string, string, string, func() [][]any) {
+func (rp *registerProgress[T]) sqlite3(conn string) (string, string, string, func() [][]any) {
 	return conn, RegisterProgressCreateTableIfNotExist, RegisterProgressInsertOrReplace,
 		func() [][]any {
 			values := [][]any{}
@@ -81,7 +81,7 @@ type metadataResponse struct {
 func TestRegisterProgress_DoNothingIfDisabled(t *testing.T) {
 	cfg := &utils.Config{}
 	cfg.RegisterRun = ""
-	ext := MakeRegisterProgress(cfg, 0)
+	ext := MakeRegisterProgress[txcontext.TxContext](cfg, 0)
 	if _, ok := ext.(extension.NilExtension[txcontext.TxContext]); !ok {
 		t.Fatalf("extension RegisterProgress is enabled even though not disabled in configuration.")
 	}
@@ -98,7 +98,7 @@ func TestRegisterProgress_TerminatesIfPathToRegisterDirDoesNotExist(t *testing.T
 	cfg.Last = 25
 	interval := 10
 
-	ext := MakeRegisterProgress(cfg, interval)
+	ext := MakeRegisterProgress[txcontext.TxContext](cfg, interval)
 	if _, err := ext.(extension.NilExtension[txcontext.TxContext]); err {
 		t.Fatalf("Extension RegisterProgress is disabled even though enabled in configuration.")
 	}
@@ -123,7 +123,7 @@ func TestRegisterProgress_TerminatesIfPathToStateDBDoesNotExist(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	stateDb := state.NewMockStateDB(ctrl)
 
-	ext := MakeRegisterProgress(cfg, interval)
+	ext := MakeRegisterProgress[txcontext.TxContext](cfg, interval)
 	if _, err := ext.(extension.NilExtension[txcontext.TxContext]); err {
 		t.Fatalf("Extension RegisterProgress is disabled even though enabled in configuration.")
 	}
@@ -185,7 +185,7 @@ func TestRegisterProgress_InsertToDbIfEnabled(t *testing.T) {
 	interval := 10
 	// expects [5-9]P[10-19]P[20-24]P, where P is print
 
-	ext := MakeRegisterProgress(cfg, interval)
+	ext := MakeRegisterProgress[txcontext.TxContext](cfg, interval)
 	if _, err := ext.(extension.NilExtension[txcontext.TxContext]); err {
 		t.Fatalf("Extension RegisterProgress is disabled even though enabled in configuration.")
 	}
@@ -325,7 +325,7 @@ func TestRegisterProgress_IfErrorRecordIntoMetadata(t *testing.T) {
 		stateDb.EXPECT().GetMemoryUsage().Return(&state.MemoryUsage{UsedBytes: 1234}),
 	)
 
-	ext := MakeRegisterProgress(cfg, 123)
+	ext := MakeRegisterProgress[txcontext.TxContext](cfg, 123)
 	if _, err := ext.(extension.NilExtension[txcontext.TxContext]); err {
 		t.Fatalf("RegisterProgress is disabled even though enabled in configuration.")
 	}
@@ -424,12 +424,12 @@ func TestRegisterProgress_ChecksDefaultReportInterval(t *testing.T) {
 	}
 
 	for cfg, expectedFreq := range tests {
-		ext := MakeRegisterProgress(cfg, 0) // 0 to see defaults
+		ext := MakeRegisterProgress[txcontext.TxContext](cfg, 0) // 0 to see defaults
 		if _, ok := ext.(extension.NilExtension[txcontext.TxContext]); ok {
 			t.Fatalf("Extension RegisterProgress is disabled even though enabled in configuration.")
 		}
 
-		rp, ok := ext.(*registerProgress)
+		rp, ok := ext.(*registerProgress[txcontext.TxContext])
 		if !ok {
 			t.Errorf("Could not cast extension to registerProgress even though it should be possible.")
 		}
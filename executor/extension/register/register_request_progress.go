@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -27,7 +28,9 @@ import (
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/executor/extension"
 	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/profile"
 	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
 )
 
@@ -50,6 +53,48 @@ const (
 			?, ?, ?, ?
 		)
 	`
+	RegisterRequestLatencyCreateTableIfNotExist = `
+		CREATE TABLE IF NOT EXISTS stats_rpc_latency (
+			method TEXT NOT NULL,
+			result TEXT NOT NULL,
+			count INTEGER NOT NULL,
+			p50_ms float,
+			p90_ms float,
+			p99_ms float,
+			max_ms float
+		)
+	`
+	RegisterRequestLatencyInsertOrReplace = `
+		INSERT or REPLACE INTO stats_rpc_latency (
+			method, result, count,
+			p50_ms, p90_ms, p99_ms, max_ms
+		) VALUES (
+			?, ?, ?,
+			?, ?, ?, ?
+		)
+	`
+	RegisterRequestLatencyHistogramCreateTableIfNotExist = `
+		CREATE TABLE IF NOT EXISTS stats_rpc_latency_histogram (
+			method TEXT NOT NULL,
+			result TEXT NOT NULL,
+			upper_bound_ms float NOT NULL,
+			count INTEGER NOT NULL
+		)
+	`
+	RegisterRequestLatencyHistogramInsertOrReplace = `
+		INSERT or REPLACE INTO stats_rpc_latency_histogram (
+			method, result, upper_bound_ms, count
+		) VALUES (
+			?, ?, ?, ?
+		)
+	`
+)
+
+// Result types of requests distinguished by latency statistics.
+const (
+	requestResultOk        = "ok"
+	requestResultError     = "error"
+	requestResultRecovered = "recovered"
 )
 
 // MakeRegisterRequestProgress creates a blockProgressTracker that depends on the
@@ -79,6 +124,8 @@ func makeRegisterRequestProgress(cfg *utils.Config, reportFrequency int, log log
 		log:             log,
 		reportFrequency: reportFrequency,
 		ps:              utils.NewPrinters(),
+		latencyPs:       utils.NewPrinters(),
+		latencies:       make(map[requestLatencyKey]*profile.LatencyHistogram),
 		id:              MakeRunIdentity(time.Now().Unix(), cfg),
 	}
 }
@@ -108,6 +155,10 @@ type registerRequestProgress struct {
 	overallReqRate  float64
 	overallGasRate  float64
 
+	// Latency
+	latencyPs *utils.Printers
+	latencies map[requestLatencyKey]*profile.LatencyHistogram
+
 	id   *RunIdentity
 	meta *RunMetadata
 }
@@ -117,6 +168,12 @@ type rpcProcessInfo struct {
 	gas         uint64
 }
 
+// requestLatencyKey identifies a latency histogram.
+type requestLatencyKey struct {
+	method string
+	result string
+}
+
 func (rp *registerRequestProgress) PreRun(executor.State[*rpc.RequestAndResults], *executor.Context) error {
 	connection := filepath.Join(rp.cfg.RegisterRun, fmt.Sprintf("%s.db", rp.id.GetId()))
 	rp.log.Noticef("Registering to: %s", connection)
@@ -133,6 +190,16 @@ func (rp *registerRequestProgress) PreRun(executor.State[*rpc.RequestAndResults]
 	}
 	rp.ps.AddPrinter(p2db)
 
+	latencyDb, err := utils.NewPrinterToSqlite3(rp.latencySqlite3(connection))
+	if err != nil {
+		return err
+	}
+	histogramDb, err := utils.NewPrinterToSqlite3(rp.latencyHistogramSqlite3(connection))
+	if err != nil {
+		return err
+	}
+	rp.latencyPs.AddPrinter(latencyDb).AddPrinter(histogramDb)
+
 	// 3. if metadata could be fetched -> continue without the failed metadata
 	rm, err := MakeRunMetadata(connection, rp.id)
 
@@ -153,11 +220,25 @@ func (rp *registerRequestProgress) PreRun(executor.State[*rpc.RequestAndResults]
 }
 
 // PostTransaction increments number of transactions and saves gas used in last substate.
+// Latency of the request is the time of its execution measured by the processor, recorded
+// by its method and result type. Time spent by other extensions, e.g. resending recovered
+// requests, is not included.
 func (rp *registerRequestProgress) PostTransaction(state executor.State[*rpc.RequestAndResults], ctx *executor.Context) error {
+	latency, executed := state.Data.ExecutionTime()
 
 	rp.lock.Lock()
 	defer rp.lock.Unlock()
 
+	if executed && ctx.ExecutionResult != nil {
+		key := requestLatencyKey{state.Data.Query.Method, requestResultType(state.Data, ctx.ExecutionResult)}
+		h, ok := rp.latencies[key]
+		if !ok {
+			h = new(profile.LatencyHistogram)
+			rp.latencies[key] = h
+		}
+		h.Add(latency)
+	}
+
 	rp.overallInfo.numRequests++
 	if ctx.ExecutionResult != nil {
 		rp.overallInfo.gas += ctx.ExecutionResult.GetGasUsed()
@@ -200,6 +281,10 @@ func (rp *registerRequestProgress) PostRun(_ executor.State[*rpc.RequestAndResul
 	rp.ps.Print()
 	rp.ps.Close()
 
+	rp.reportLatencies()
+	rp.latencyPs.Print()
+	rp.latencyPs.Close()
+
 	rp.meta.meta["Runtime"] = strconv.Itoa(int(time.Since(rp.startOfRun).Seconds()))
 	if err != nil {
 		rp.meta.meta["RunSucceed"] = strconv.FormatBool(false)
@@ -230,3 +315,79 @@ func (rp *registerRequestProgress) sqlite3(conn string) (string, string, string,
 			}
 		}
 }
+
+// reportLatencies logs latency percentiles of each method and result type.
+func (rp *registerRequestProgress) reportLatencies() {
+	for _, key := range rp.latencyKeys() {
+		h := rp.latencies[key]
+		rp.log.Noticef("Latency of %v (%v): requests %d, p50 %v, p90 %v, p99 %v, max %v",
+			key.method, key.result, h.Count(), h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Max())
+	}
+}
+
+// latencyKeys returns keys of recorded latency histograms sorted by method and result type.
+func (rp *registerRequestProgress) latencyKeys() []requestLatencyKey {
+	keys := make([]requestLatencyKey, 0, len(rp.latencies))
+	for key := range rp.latencies {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].result < keys[j].result
+	})
+	return keys
+}
+
+func (rp *registerRequestProgress) latencySqlite3(conn string) (string, string, string, func() [][]any) {
+	return conn,
+		RegisterRequestLatencyCreateTableIfNotExist,
+		RegisterRequestLatencyInsertOrReplace,
+		func() [][]any {
+			var rows [][]any
+			for _, key := range rp.latencyKeys() {
+				h := rp.latencies[key]
+				rows = append(rows, []any{
+					key.method,
+					key.result,
+					h.Count(),
+					milliseconds(h.Percentile(50)),
+					milliseconds(h.Percentile(90)),
+					milliseconds(h.Percentile(99)),
+					milliseconds(h.Max()),
+				})
+			}
+			return rows
+		}
+}
+
+func (rp *registerRequestProgress) latencyHistogramSqlite3(conn string) (string, string, string, func() [][]any) {
+	return conn,
+		RegisterRequestLatencyHistogramCreateTableIfNotExist,
+		RegisterRequestLatencyHistogramInsertOrReplace,
+		func() [][]any {
+			var rows [][]any
+			for _, key := range rp.latencyKeys() {
+				for _, bucket := range rp.latencies[key].Buckets() {
+					rows = append(rows, []any{key.method, key.result, milliseconds(bucket.UpperBound), bucket.Count})
+				}
+			}
+			return rows
+		}
+}
+
+// requestResultType classifies a processed request for latency statistics.
+func requestResultType(req *rpc.RequestAndResults, res txcontext.Result) string {
+	if req.IsRecovered {
+		return requestResultRecovered
+	}
+	if _, err := res.GetRawResult(); err != nil {
+		return requestResultError
+	}
+	return requestResultOk
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package register
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/jmoiron/sqlx"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+type latencyResponse struct {
+	Method string  `db:"method"`
+	Result string  `db:"result"`
+	Count  int     `db:"count"`
+	P50    float64 `db:"p50_ms"`
+	P90    float64 `db:"p90_ms"`
+	P99    float64 `db:"p99_ms"`
+	Max    float64 `db:"max_ms"`
+}
+
+func TestRegisterRequestProgress_DoNothingIfDisabled(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := MakeRegisterRequestProgress(cfg, 0)
+	if _, ok := ext.(extension.NilExtension[*rpc.RequestAndResults]); !ok {
+		t.Errorf("RegisterRequestProgress is enabled even though not set in configuration.")
+	}
+}
+
+func TestRegisterRequestProgress_InsertLatenciesToDb(t *testing.T) {
+	var (
+		tmpDir     = t.TempDir()
+		dbName     = "tmp"
+		connection = filepath.Join(tmpDir, fmt.Sprintf("%s.db", dbName))
+	)
+
+	cfg := &utils.Config{}
+	cfg.RegisterRun = tmpDir
+	cfg.OverwriteRunId = dbName
+
+	ext := MakeRegisterRequestProgress(cfg, 100)
+	if err := ext.PreRun(executor.State[*rpc.RequestAndResults]{}, &executor.Context{}); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	requests := []struct {
+		req    *rpc.RequestAndResults
+		result error
+	}{
+		{&rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_call"}}, nil},
+		{&rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_call"}}, nil},
+		{&rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_call"}}, errors.New("execution reverted")},
+		{&rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_getStorageAt"}, IsRecovered: true}, nil},
+	}
+	for _, r := range requests {
+		r.req.ExecutionStart = time.Now()
+		r.req.ExecutionEnd = r.req.ExecutionStart.Add(time.Millisecond)
+		state := executor.State[*rpc.RequestAndResults]{Data: r.req}
+		ctx := &executor.Context{ExecutionResult: rpc.NewResult([]byte("0x0"), r.result, 0)}
+		if err := ext.PostTransaction(state, ctx); err != nil {
+			t.Fatalf("unexpected error; %v", err)
+		}
+	}
+
+	if err := ext.PostRun(executor.State[*rpc.RequestAndResults]{}, &executor.Context{}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	sDb, err := sqlx.Open("sqlite3", connection)
+	if err != nil {
+		t.Fatalf("Failed to connect to database at %s.", connection)
+	}
+	defer sDb.Close()
+
+	var latencies []latencyResponse
+	if err = sDb.Select(&latencies, "select * from stats_rpc_latency"); err != nil {
+		t.Fatalf("cannot select latencies; %v", err)
+	}
+
+	want := []latencyResponse{
+		{Method: "eth_call", Result: requestResultError, Count: 1},
+		{Method: "eth_call", Result: requestResultOk, Count: 2},
+		{Method: "eth_getStorageAt", Result: requestResultRecovered, Count: 1},
+	}
+	if len(latencies) != len(want) {
+		t.Fatalf("unexpected number of latency rows; got: %v, want: %v", len(latencies), len(want))
+	}
+	for i, got := range latencies {
+		if got.Method != want[i].Method || got.Result != want[i].Result || got.Count != want[i].Count {
+			t.Errorf("unexpected latency row; got: %v, want: %v", got, want[i])
+		}
+		if got.P50 > got.P90 || got.P90 > got.P99 || got.P99 > got.Max {
+			t.Errorf("percentiles are not ordered; %v", got)
+		}
+	}
+
+	var buckets int
+	if err = sDb.Get(&buckets, "select sum(count) from stats_rpc_latency_histogram"); err != nil {
+		t.Fatalf("cannot select latency histogram; %v", err)
+	}
+	if buckets != len(requests) {
+		t.Errorf("unexpected number of requests in latency histogram; got: %v, want: %v", buckets, len(requests))
+	}
+}
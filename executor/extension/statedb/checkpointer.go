@@ -0,0 +1,145 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statedb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/utils"
+)
+
+// MakeCheckpointer creates an executor.Extension which every cfg.CheckpointInterval
+// blocks flushes the StateDb and writes a checkpoint descriptor next to it, so
+// that an interrupted run can be resumed from the last checkpoint. When resuming,
+// it verifies that the reopened StateDb matches the checkpoint.
+func MakeCheckpointer[T any](cfg *utils.Config) executor.Extension[T] {
+	if cfg.CheckpointInterval == 0 && !cfg.Resume {
+		return extension.NilExtension[T]{}
+	}
+
+	return makeCheckpointer[T](cfg, logger.NewLogger(cfg.LogLevel, "Checkpointer"))
+}
+
+func makeCheckpointer[T any](cfg *utils.Config, log logger.Logger) *checkpointer[T] {
+	return &checkpointer[T]{
+		cfg: cfg,
+		log: log,
+	}
+}
+
+type checkpointer[T any] struct {
+	extension.NilExtension[T]
+	cfg        *utils.Config
+	log        logger.Logger
+	configHash string
+}
+
+// PreRun verifies the root hash of a resumed StateDb and checks whether checkpoints
+// can be written for the current StateDb.
+func (c *checkpointer[T]) PreRun(_ executor.State[T], ctx *executor.Context) error {
+	if c.cfg.Resume {
+		if err := c.verifyResumedState(ctx); err != nil {
+			return err
+		}
+	}
+	if c.cfg.CheckpointInterval == 0 {
+		return nil
+	}
+
+	if c.cfg.ShadowDb {
+		return errors.New("checkpoints are not supported when using shadow db")
+	}
+	if ctx.StateDbPath == "" {
+		return errors.New("cannot write checkpoints; state-db path is unknown")
+	}
+
+	var err error
+	c.configHash, err = utils.GetCheckpointConfigHash(c.cfg)
+	if err != nil {
+		return fmt.Errorf("cannot compute config hash; %w", err)
+	}
+
+	c.log.Noticef("Writing checkpoint every %v blocks into %v", c.cfg.CheckpointInterval, ctx.StateDbPath)
+	return nil
+}
+
+// PostBlock writes a checkpoint once a block aligned to the checkpoint interval is completed.
+func (c *checkpointer[T]) PostBlock(state executor.State[T], ctx *executor.Context) error {
+	if c.cfg.CheckpointInterval == 0 {
+		return nil
+	}
+	block := uint64(state.Block)
+	if (block+1)%c.cfg.CheckpointInterval != 0 {
+		return nil
+	}
+	return c.checkpoint(block, ctx)
+}
+
+// PostRun writes a final checkpoint if the run finished successfully and the StateDb
+// is kept, so that the run can later be extended using --resume.
+func (c *checkpointer[T]) PostRun(state executor.State[T], ctx *executor.Context, err error) error {
+	if err != nil || ctx.State == nil || !c.cfg.KeepDb || state.Block == 0 || c.cfg.CheckpointInterval == 0 {
+		return nil
+	}
+	return c.checkpoint(uint64(state.Block)-1, ctx)
+}
+
+// checkpoint flushes the StateDb and writes checkpoint descriptor for given completed block.
+// Checkpoints are taken in the middle of sync periods, yet Flush alone persists the state of
+// the last completed block: geth commits the trie of that block to the disk while ending a
+// sync period only garbage-collects older tries, and Carmen ignores sync periods. The sync
+// period is not ended here since the period boundaries are owned by the TestSyncPeriodEmitter
+// and are part of recorded traces.
+func (c *checkpointer[T]) checkpoint(block uint64, ctx *executor.Context) error {
+	// the descriptor must only describe state which is persisted on the disk
+	if err := ctx.State.Flush(); err != nil {
+		return fmt.Errorf("cannot flush state-db at block %v; %w", block, err)
+	}
+
+	rootHash, err := ctx.State.GetHash()
+	if err != nil {
+		return fmt.Errorf("cannot get state hash; %w", err)
+	}
+
+	if err = utils.WriteCheckpoint(ctx.StateDbPath, c.cfg, c.configHash, block, rootHash); err != nil {
+		return fmt.Errorf("cannot write checkpoint at block %v; %w", block, err)
+	}
+
+	c.log.Infof("Checkpoint written at block %v", block)
+	return nil
+}
+
+// verifyResumedState checks that the reopened StateDb has the root hash recorded by its checkpoint.
+func (c *checkpointer[T]) verifyResumedState(ctx *executor.Context) error {
+	checkpoint, err := utils.ReadCheckpoint(ctx.StateDbPath)
+	if err != nil {
+		return fmt.Errorf("cannot read checkpoint; %w", err)
+	}
+	rootHash, err := ctx.State.GetHash()
+	if err != nil {
+		return fmt.Errorf("cannot get state hash; %w", err)
+	}
+	if rootHash != checkpoint.RootHash {
+		return fmt.Errorf("root hash of state-db %v does not match checkpoint root hash %v at block %v", rootHash, checkpoint.RootHash, checkpoint.Block)
+	}
+	c.log.Noticef("Verified root hash %v of checkpoint at block %v", rootHash, checkpoint.Block)
+	return nil
+}
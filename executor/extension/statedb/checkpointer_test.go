@@ -0,0 +1,174 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statedb
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCheckpointer_NoCheckpointerIsCreatedIfDisabled(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := MakeCheckpointer[any](cfg)
+
+	if _, ok := ext.(extension.NilExtension[any]); !ok {
+		t.Errorf("checkpointer is enabled although not set in configuration")
+	}
+}
+
+func TestCheckpointer_PreRunFailsWithShadowDb(t *testing.T) {
+	cfg := &utils.Config{CheckpointInterval: 10, ShadowDb: true}
+	ext := MakeCheckpointer[any](cfg)
+
+	ctx := &executor.Context{StateDbPath: t.TempDir()}
+	if err := ext.PreRun(executor.State[any]{}, ctx); err == nil {
+		t.Fatal("pre-run must fail with shadow db")
+	}
+}
+
+func TestCheckpointer_CheckpointIsWrittenAtInterval(t *testing.T) {
+	cfg := &utils.Config{CheckpointInterval: 10, DbImpl: "geth", ChainID: utils.MainnetChainID}
+	ext := MakeCheckpointer[any](cfg)
+
+	mockCtrl := gomock.NewController(t)
+	db := state.NewMockStateDB(mockCtrl)
+	hash := common.Hash{0x12}
+
+	gomock.InOrder(
+		db.EXPECT().Flush(),
+		db.EXPECT().GetHash().Return(hash, nil),
+	)
+
+	ctx := &executor.Context{State: db, StateDbPath: t.TempDir()}
+	if err := ext.PreRun(executor.State[any]{Block: 0}, ctx); err != nil {
+		t.Fatalf("failed to run pre-run: %v", err)
+	}
+
+	// blocks not completing an interval are ignored
+	for _, block := range []int{8, 10, 18} {
+		if err := ext.PostBlock(executor.State[any]{Block: block}, ctx); err != nil {
+			t.Fatalf("failed to run post-block: %v", err)
+		}
+	}
+	if _, err := utils.ReadCheckpoint(ctx.StateDbPath); err == nil {
+		t.Fatal("checkpoint must not be written before the interval is completed")
+	}
+
+	if err := ext.PostBlock(executor.State[any]{Block: 19}, ctx); err != nil {
+		t.Fatalf("failed to run post-block: %v", err)
+	}
+
+	checkpoint, err := utils.ReadCheckpoint(ctx.StateDbPath)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+	if got, want := checkpoint.Block, uint64(19); got != want {
+		t.Errorf("unexpected checkpoint block; got: %v, want: %v", got, want)
+	}
+	if got, want := checkpoint.RootHash, hash; got != want {
+		t.Errorf("unexpected checkpoint root hash; got: %v, want: %v", got, want)
+	}
+	if err = checkpoint.Validate(ctx.StateDbPath, cfg); err != nil {
+		t.Errorf("written checkpoint is not valid: %v", err)
+	}
+}
+
+func TestCheckpointer_FinalCheckpointIsWrittenOnlyIfDbIsKept(t *testing.T) {
+	cfg := &utils.Config{CheckpointInterval: 10, DbImpl: "geth", ChainID: utils.MainnetChainID}
+	ext := MakeCheckpointer[any](cfg)
+
+	mockCtrl := gomock.NewController(t)
+	db := state.NewMockStateDB(mockCtrl)
+
+	ctx := &executor.Context{State: db, StateDbPath: t.TempDir()}
+	if err := ext.PreRun(executor.State[any]{Block: 0}, ctx); err != nil {
+		t.Fatalf("failed to run pre-run: %v", err)
+	}
+
+	// db is not kept - no checkpoint expected
+	if err := ext.PostRun(executor.State[any]{Block: 15}, ctx, nil); err != nil {
+		t.Fatalf("failed to run post-run: %v", err)
+	}
+
+	cfg.KeepDb = true
+	gomock.InOrder(
+		db.EXPECT().Flush(),
+		db.EXPECT().GetHash().Return(common.Hash{}, nil),
+	)
+	if err := ext.PostRun(executor.State[any]{Block: 15}, ctx, nil); err != nil {
+		t.Fatalf("failed to run post-run: %v", err)
+	}
+
+	checkpoint, err := utils.ReadCheckpoint(ctx.StateDbPath)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+	if got, want := checkpoint.Block, uint64(14); got != want {
+		t.Errorf("unexpected checkpoint block; got: %v, want: %v", got, want)
+	}
+}
+
+func TestCheckpointer_ResumeVerifiesRootHash(t *testing.T) {
+	cfg := &utils.Config{DbImpl: "geth", ChainID: utils.MainnetChainID, Resume: true}
+	dir := t.TempDir()
+	hash := common.Hash{0x12}
+	if err := utils.WriteCheckpoint(dir, cfg, "", 9, hash); err != nil {
+		t.Fatalf("cannot write checkpoint: %v", err)
+	}
+
+	mockCtrl := gomock.NewController(t)
+	db := state.NewMockStateDB(mockCtrl)
+	ext := MakeCheckpointer[any](cfg)
+	ctx := &executor.Context{State: db, StateDbPath: dir}
+
+	db.EXPECT().GetHash().Return(hash, nil)
+	if err := ext.PreRun(executor.State[any]{Block: 10}, ctx); err != nil {
+		t.Errorf("pre-run must succeed with matching root hash: %v", err)
+	}
+
+	db.EXPECT().GetHash().Return(common.Hash{0x13}, nil)
+	if err := ext.PreRun(executor.State[any]{Block: 10}, ctx); err == nil {
+		t.Errorf("pre-run must fail with different root hash")
+	}
+}
+
+func TestCheckpointer_NoCheckpointIsWrittenIfFlushFails(t *testing.T) {
+	cfg := &utils.Config{CheckpointInterval: 10, DbImpl: "geth", ChainID: utils.MainnetChainID}
+	ext := MakeCheckpointer[any](cfg)
+
+	mockCtrl := gomock.NewController(t)
+	db := state.NewMockStateDB(mockCtrl)
+	db.EXPECT().Flush().Return(errors.New("flush failed"))
+
+	ctx := &executor.Context{State: db, StateDbPath: t.TempDir()}
+	if err := ext.PreRun(executor.State[any]{Block: 0}, ctx); err != nil {
+		t.Fatalf("failed to run pre-run: %v", err)
+	}
+	if err := ext.PostBlock(executor.State[any]{Block: 9}, ctx); err == nil {
+		t.Fatal("post-block must fail if state-db cannot be flushed")
+	}
+	if _, err := utils.ReadCheckpoint(ctx.StateDbPath); err == nil {
+		t.Error("checkpoint must not be written if state-db cannot be flushed")
+	}
+}
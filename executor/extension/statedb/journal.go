@@ -0,0 +1,99 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statedb
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state/proxy"
+	"github.com/Fantom-foundation/Aida/utils"
+)
+
+// MakeJournal creates an executor.Extension which records StateDb operations of the
+// current block into a write-ahead journal stored inside the StateDb directory. When
+// an existing StateDb is used, a journal left behind by a crashed run is recovered first.
+func MakeJournal[T any](cfg *utils.Config) executor.Extension[T] {
+	if !cfg.Journal {
+		return extension.NilExtension[T]{}
+	}
+
+	return makeJournal[T](cfg, logger.NewLogger(cfg.LogLevel, "Journal"))
+}
+
+func makeJournal[T any](cfg *utils.Config, log logger.Logger) *journal[T] {
+	return &journal[T]{
+		cfg: cfg,
+		log: log,
+	}
+}
+
+type journal[T any] struct {
+	extension.NilExtension[T]
+	cfg *utils.Config
+	log logger.Logger
+}
+
+// PreRun recovers a journal of an existing StateDb and wraps the StateDb into a journal proxy.
+func (j *journal[T]) PreRun(state executor.State[T], ctx *executor.Context) error {
+	if ctx.State == nil || ctx.StateDbPath == "" {
+		return errors.New("journal requires a state-db stored on the disk")
+	}
+
+	filename := filepath.Join(ctx.StateDbPath, proxy.JournalFileName)
+	if j.cfg.IsExistingStateDb {
+		if err := j.recover(state, ctx, filename); err != nil {
+			return err
+		}
+	}
+
+	db, err := proxy.NewJournalProxy(ctx.State, filename)
+	if err != nil {
+		return err
+	}
+	ctx.State = db
+	return nil
+}
+
+// recover brings the StateDb to a block boundary and checks that the run continues right after it.
+func (j *journal[T]) recover(state executor.State[T], ctx *executor.Context, filename string) error {
+	recovery, err := proxy.RecoverJournal(ctx.State, filename)
+	if err != nil {
+		return fmt.Errorf("cannot recover journal; %w", err)
+	}
+	if !recovery.HasBlock {
+		return nil
+	}
+
+	next := recovery.Block + 1
+	switch recovery.Outcome {
+	case proxy.JournalStale:
+		return fmt.Errorf("state-db matches neither the state before nor after journaled block %v; restore the state-db from a checkpoint", recovery.Block)
+	case proxy.JournalDiscarded:
+		next = recovery.Block
+	}
+
+	j.log.Noticef("Journal of block %v was %v; state-db continues at block %v", recovery.Block, recovery.Outcome, next)
+	if uint64(state.Block) != next {
+		return fmt.Errorf("state-db recovered from journal continues at block %v but the run starts at block %v", next, state.Block)
+	}
+	return nil
+}
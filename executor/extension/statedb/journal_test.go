@@ -0,0 +1,179 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statedb
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/state/proxy"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestJournal_NoJournalIsCreatedIfDisabled(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := MakeJournal[any](cfg)
+
+	if _, ok := ext.(extension.NilExtension[any]); !ok {
+		t.Errorf("journal is enabled although not set in configuration")
+	}
+}
+
+func TestJournal_PreRunFailsWithoutStateDbPath(t *testing.T) {
+	cfg := &utils.Config{Journal: true}
+	ext := MakeJournal[any](cfg)
+
+	db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	if err = ext.PreRun(executor.State[any]{}, &executor.Context{State: db}); err == nil {
+		t.Fatal("pre-run must fail without state-db path")
+	}
+}
+
+func TestJournal_PreRunWrapsStateDb(t *testing.T) {
+	cfg := &utils.Config{Journal: true}
+	ext := MakeJournal[any](cfg)
+
+	dir := t.TempDir()
+	db, err := state.MakeGethStateDB(dir, "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	ctx := &executor.Context{State: db, StateDbPath: dir}
+	if err = ext.PreRun(executor.State[any]{}, ctx); err != nil {
+		t.Fatalf("failed to run pre-run: %v", err)
+	}
+	if _, ok := ctx.State.(*proxy.JournalProxy); !ok {
+		t.Errorf("state-db was not wrapped into journal proxy")
+	}
+	if _, err = os.Stat(filepath.Join(dir, proxy.JournalFileName)); err != nil {
+		t.Errorf("journal file was not created; %v", err)
+	}
+	if err = ctx.State.Close(); err != nil {
+		t.Fatalf("cannot close state-db; %v", err)
+	}
+}
+
+func TestJournal_RecoveryChecksFirstBlockOfRun(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, proxy.JournalFileName)
+
+	// simulate a run which was interrupted in the middle of block 5
+	db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	journaled, err := proxy.NewJournalProxy(db, filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	if err = journaled.BeginBlock(5); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	journaled.CreateAccount(common.Address{1})
+	if err = journaled.Close(); err != nil {
+		t.Fatalf("cannot close state-db; %v", err)
+	}
+
+	tests := map[string]struct {
+		first   int
+		success bool
+	}{
+		"discarded block": {5, true},
+		"skipped block":   {7, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			journal, err := os.ReadFile(filename)
+			if err != nil {
+				t.Fatalf("cannot read journal; %v", err)
+			}
+			stateDbPath := t.TempDir()
+			if err = os.WriteFile(filepath.Join(stateDbPath, proxy.JournalFileName), journal, 0644); err != nil {
+				t.Fatalf("cannot write journal; %v", err)
+			}
+
+			db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			ext := MakeJournal[any](&utils.Config{Journal: true, IsExistingStateDb: true})
+			ctx := &executor.Context{State: db, StateDbPath: stateDbPath}
+			err = ext.PreRun(executor.State[any]{Block: test.first}, ctx)
+			if test.success && err != nil {
+				t.Errorf("unexpected error; %v", err)
+			}
+			if !test.success && err == nil {
+				t.Errorf("pre-run must fail")
+			}
+		})
+	}
+}
+
+func TestJournal_RecoveryFailsOnStaleStateDb(t *testing.T) {
+	stateDbPath := t.TempDir()
+	filename := filepath.Join(stateDbPath, proxy.JournalFileName)
+
+	// simulate a run which was interrupted in the middle of block 5
+	db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	journaled, err := proxy.NewJournalProxy(db, filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	if err = journaled.BeginBlock(5); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	journaled.CreateAccount(common.Address{1})
+	if err = journaled.Close(); err != nil {
+		t.Fatalf("cannot close state-db; %v", err)
+	}
+
+	// the reopened state-db differs from the state before the journaled block
+	stale, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	if err = stale.BeginBlock(1); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	stale.CreateAccount(common.Address{2})
+	stale.AddBalance(common.Address{2}, big.NewInt(1))
+	if err = stale.EndBlock(); err != nil {
+		t.Fatalf("cannot end block; %v", err)
+	}
+
+	ext := MakeJournal[any](&utils.Config{Journal: true, IsExistingStateDb: true})
+	ctx := &executor.Context{State: stale, StateDbPath: stateDbPath}
+	if err = ext.PreRun(executor.State[any]{Block: 5}, ctx); err == nil {
+		t.Fatal("pre-run must fail on stale state-db")
+	}
+	if _, err = os.Stat(filename); err != nil {
+		t.Errorf("journal of stale state-db must be kept; %v", err)
+	}
+}
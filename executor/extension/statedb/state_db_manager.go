@@ -108,6 +108,7 @@ func (m *stateDbManager[T]) PostRun(state executor.State[T], ctx *executor.Conte
 		if err := ctx.State.Close(); err != nil {
 			return fmt.Errorf("failed to close state-db; %v", err)
 		}
+		m.logDiskUsage(ctx.StateDbPath)
 
 		if !m.cfg.SrcDbReadonly {
 			return os.RemoveAll(ctx.StateDbPath)
@@ -140,12 +141,29 @@ func (m *stateDbManager[T]) PostRun(state executor.State[T], ctx *executor.Conte
 	if err := ctx.State.Close(); err != nil {
 		return fmt.Errorf("failed to close state-db; %v", err)
 	}
+	m.logDiskUsage(ctx.StateDbPath)
+
+	// resumed state-db is updated in place hence it keeps its name
+	if m.cfg.Resume {
+		m.log.Noticef("State-db directory: %v", ctx.StateDbPath)
+		return nil
+	}
 
 	newName := utils.RenameTempStateDbDirectory(m.cfg, ctx.StateDbPath, lastProcessedBlock)
 	m.log.Noticef("State-db directory: %v", newName)
 	return nil
 }
 
+// logDiskUsage reports the size of the closed state-db.
+func (m *stateDbManager[T]) logDiskUsage(path string) {
+	size, err := utils.GetDirectorySize(path)
+	if err != nil {
+		m.log.Warningf("cannot get size of state-db (%v); %v", path, err)
+		return
+	}
+	m.log.Noticef("Final disk usage: %v MiB", float32(size)/float32(1024*1024))
+}
+
 func (m *stateDbManager[T]) logDbMode(prefix, impl, variant string) {
 	if m.cfg.DbImpl == "carmen" {
 		m.log.Noticef("%s: %v; Variant: %v, Carmen Schema: %d", prefix, impl, variant, m.cfg.CarmenSchema)
@@ -0,0 +1,122 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracker
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/profile"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+)
+
+const (
+	latencyTrackerReportInterval = 15 * time.Second
+	latencyTrackerReportFormat   = "Latency: requests %d, p50 %v, p90 %v, p99 %v, max %v"
+	latencyTrackerMethodFormat   = "Latency of %v: requests %d, p50 %v, p90 %v, p99 %v, max %v"
+)
+
+// MakeRequestLatencyTracker creates an extension measuring latency of requests in timed replay
+// from the time a request was due until its execution ended. Latency percentiles are reported
+// periodically and per method at the end of the run.
+func MakeRequestLatencyTracker(cfg *utils.Config) executor.Extension[*rpc.RequestAndResults] {
+	if cfg.RpcSpeedUp <= 0 {
+		return extension.NilExtension[*rpc.RequestAndResults]{}
+	}
+
+	return makeRequestLatencyTracker(latencyTrackerReportInterval, logger.NewLogger(cfg.LogLevel, "LatencyTracker"))
+}
+
+func makeRequestLatencyTracker(reportInterval time.Duration, log logger.Logger) *requestLatencyTracker {
+	return &requestLatencyTracker{
+		log:            log,
+		reportInterval: reportInterval,
+		methods:        make(map[string]*profile.LatencyHistogram),
+	}
+}
+
+type requestLatencyTracker struct {
+	extension.NilExtension[*rpc.RequestAndResults]
+	log            logger.Logger
+	reportInterval time.Duration
+	lastReport     time.Time
+
+	lock    sync.Mutex
+	overall profile.LatencyHistogram
+	methods map[string]*profile.LatencyHistogram
+}
+
+func (t *requestLatencyTracker) PreRun(executor.State[*rpc.RequestAndResults], *executor.Context) error {
+	t.lastReport = time.Now()
+	return nil
+}
+
+// PostTransaction records latency of the request from its due time until the end of its
+// execution and periodically reports overall latency.
+func (t *requestLatencyTracker) PostTransaction(state executor.State[*rpc.RequestAndResults], _ *executor.Context) error {
+	req := state.Data
+	if req.ScheduledAt.IsZero() || req.ExecutionEnd.IsZero() {
+		return nil
+	}
+	latency := req.ExecutionEnd.Sub(req.ScheduledAt)
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.overall.Add(latency)
+	h, ok := t.methods[req.Query.Method]
+	if !ok {
+		h = new(profile.LatencyHistogram)
+		t.methods[req.Query.Method] = h
+	}
+	h.Add(latency)
+
+	if time.Since(t.lastReport) >= t.reportInterval {
+		t.reportOverall()
+		t.lastReport = time.Now()
+	}
+	return nil
+}
+
+// PostRun reports latency of each method.
+func (t *requestLatencyTracker) PostRun(executor.State[*rpc.RequestAndResults], *executor.Context, error) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	methods := make([]string, 0, len(t.methods))
+	for method := range t.methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	t.reportOverall()
+	for _, method := range methods {
+		h := t.methods[method]
+		t.log.Noticef(latencyTrackerMethodFormat, method, h.Count(), h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Max())
+	}
+	return nil
+}
+
+func (t *requestLatencyTracker) reportOverall() {
+	h := &t.overall
+	t.log.Noticef(latencyTrackerReportFormat, h.Count(), h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Max())
+}
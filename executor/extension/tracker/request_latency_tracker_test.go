@@ -0,0 +1,127 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/executor/extension"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequestLatencyTracker_NoTrackerIsCreatedIfDisabled(t *testing.T) {
+	cfg := &utils.Config{}
+	ext := MakeRequestLatencyTracker(cfg)
+	if _, ok := ext.(extension.NilExtension[*rpc.RequestAndResults]); !ok {
+		t.Errorf("tracker is enabled although timed replay is not set in configuration")
+	}
+}
+
+func TestRequestLatencyTracker_LatencyIsReportedPerMethod(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	log := logger.NewMockLogger(ctrl)
+
+	ext := makeRequestLatencyTracker(time.Hour, log)
+	if err := ext.PreRun(executor.State[*rpc.RequestAndResults]{}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	now := time.Now()
+	requests := []*rpc.RequestAndResults{
+		{Query: &rpc.Body{Method: "eth_getBalance"}, ScheduledAt: now.Add(-time.Second), ExecutionEnd: now},
+		{Query: &rpc.Body{Method: "eth_getBalance"}, ScheduledAt: now.Add(-time.Second), ExecutionEnd: now},
+		{Query: &rpc.Body{Method: "eth_call"}, ScheduledAt: now.Add(-time.Minute), ExecutionEnd: now},
+		// requests not replayed in timed mode are ignored
+		{Query: &rpc.Body{Method: "eth_call"}, ExecutionEnd: now},
+		// requests which were not executed are ignored
+		{Query: &rpc.Body{Method: "eth_call"}, ScheduledAt: now.Add(-time.Minute)},
+	}
+	for _, req := range requests {
+		if err := ext.PostTransaction(executor.State[*rpc.RequestAndResults]{Data: req}, nil); err != nil {
+			t.Fatalf("unexpected error; %v", err)
+		}
+	}
+
+	aboveSecond := latencyBetween(time.Second, 2*time.Second)
+	aboveMinute := latencyBetween(time.Minute, 2*time.Minute)
+	gomock.InOrder(
+		log.EXPECT().Noticef(latencyTrackerReportFormat, uint64(3), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
+		log.EXPECT().Noticef(latencyTrackerMethodFormat, "eth_call", uint64(1), aboveMinute, aboveMinute, aboveMinute, aboveMinute),
+		log.EXPECT().Noticef(latencyTrackerMethodFormat, "eth_getBalance", uint64(2), aboveSecond, aboveSecond, aboveSecond, aboveSecond),
+	)
+
+	if err := ext.PostRun(executor.State[*rpc.RequestAndResults]{}, nil, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+}
+
+func TestRequestLatencyTracker_LatencyIsReportedPeriodically(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	log := logger.NewMockLogger(ctrl)
+
+	ext := makeRequestLatencyTracker(0, log)
+	if err := ext.PreRun(executor.State[*rpc.RequestAndResults]{}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	log.EXPECT().Noticef(latencyTrackerReportFormat, uint64(1), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any())
+
+	now := time.Now()
+	req := &rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_call"}, ScheduledAt: now, ExecutionEnd: now}
+	if err := ext.PostTransaction(executor.State[*rpc.RequestAndResults]{Data: req}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+}
+
+func TestRequestLatencyTracker_LatencyEndsWithExecution(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	log := logger.NewMockLogger(ctrl)
+
+	ext := makeRequestLatencyTracker(time.Hour, log)
+	if err := ext.PreRun(executor.State[*rpc.RequestAndResults]{}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	// time spent after the execution, e.g. by comparing results, is not included
+	end := time.Now().Add(-time.Minute)
+	req := &rpc.RequestAndResults{Query: &rpc.Body{Method: "eth_call"}, ScheduledAt: end.Add(-time.Second), ExecutionEnd: end}
+	if err := ext.PostTransaction(executor.State[*rpc.RequestAndResults]{Data: req}, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	aboveSecond := latencyBetween(time.Second, 2*time.Second)
+	gomock.InOrder(
+		log.EXPECT().Noticef(latencyTrackerReportFormat, uint64(1), aboveSecond, aboveSecond, aboveSecond, aboveSecond),
+		log.EXPECT().Noticef(latencyTrackerMethodFormat, "eth_call", uint64(1), aboveSecond, aboveSecond, aboveSecond, aboveSecond),
+	)
+
+	if err := ext.PostRun(executor.State[*rpc.RequestAndResults]{}, nil, nil); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+}
+
+func latencyBetween(low, high time.Duration) gomock.Matcher {
+	return gomock.Cond(func(x any) bool {
+		latency, ok := x.(time.Duration)
+		return ok && latency >= low && latency <= high
+	})
+}
@@ -20,6 +20,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -29,7 +31,9 @@ import (
 	"github.com/Fantom-foundation/Aida/rpc"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/Fantom-foundation/go-opera/ethapi"
 	"github.com/Fantom-foundation/lachesis-base/common/littleendian"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 )
 
@@ -77,10 +81,43 @@ var EvmErrors = map[int][]string{
 	invalidArgumentErrCode: {"invalid argument"},
 }
 
+// evmMethods are method bases of requests executed in EVM.
+var evmMethods = map[string]bool{
+	"call":             true,
+	"createAccessList": true,
+	"traceCall":        true,
+}
+
+// String returns the name of the error type used in divergence reports.
+func (t comparatorErrorType) String() string {
+	switch t {
+	case noMatchingResult:
+		return "noMatchingResult"
+	case noMatchingErrors:
+		return "noMatchingErrors"
+	case expectedErrorGotResult:
+		return "expectedErrorGotResult"
+	case expectedResultGotError:
+		return "expectedResultGotError"
+	case unexpectedDataType:
+		return "unexpectedDataType"
+	case cannotUnmarshalResult:
+		return "cannotUnmarshalResult"
+	case cannotSendRpcRequest:
+		return "cannotSendRpcRequest"
+	case internalError:
+		return "internalError"
+	default:
+		return "default"
+	}
+}
+
 // comparatorError is returned when state.Data returned by StateDB does not match recorded state.Data
 type comparatorError struct {
 	error
-	typ comparatorErrorType
+	typ      comparatorErrorType
+	computed any // value returned by StateDB, nil if unknown
+	recorded any // recorded value, nil if unknown
 }
 
 // MakeRpcComparator returns extension which handles comparison of result created by the StateDb and the recording.
@@ -106,6 +143,30 @@ type rpcComparator struct {
 	numberOfRetriedRequests int
 	totalNumberOfRequests   int
 	numberOfErrors          int
+	divergences             *divergenceReport
+}
+
+// PreRun opens the divergence report if it is enabled.
+func (c *rpcComparator) PreRun(executor.State[*rpc.RequestAndResults], *executor.Context) error {
+	if c.cfg.RpcDivergenceReport == "" {
+		return nil
+	}
+	report, err := newDivergenceReport(c.cfg.RpcDivergenceReport)
+	if err != nil {
+		return err
+	}
+	c.divergences = report
+	c.log.Noticef("Writing divergences to %v", c.cfg.RpcDivergenceReport)
+	return nil
+}
+
+// PostRun closes the divergence report.
+func (c *rpcComparator) PostRun(executor.State[*rpc.RequestAndResults], *executor.Context, error) error {
+	if c.divergences == nil {
+		return nil
+	}
+	c.log.Noticef("Written %v divergences to %v", c.divergences.count, c.cfg.RpcDivergenceReport)
+	return c.divergences.close()
 }
 
 // PostTransaction compares result with recording. If ContinueOnFailure
@@ -120,14 +181,17 @@ func (c *rpcComparator) PostTransaction(state executor.State[*rpc.RequestAndResu
 
 	compareErr := compare(ctx.ExecutionResult, state)
 	if compareErr != nil {
-		// request method base 'call' cannot be resent, because we need timestamp of the block that executed
+		// requests executed in EVM cannot be resent, because we need timestamp of the block that executed
 		// this request. As of right now there we cannot get the timestamp, hence we skip these requests
-		if state.Data.Query.MethodBase == "call" {
+		if evmMethods[state.Data.Query.MethodBase] {
 			// Only requests containing an error result are not being treated as data
 			// mismatch, request with a non-error result are recorded correctly
 			if state.Data.Error != nil {
 				return nil
 			} else {
+				if err := c.reportDivergence(state, ctx.ExecutionResult, compareErr); err != nil {
+					return err
+				}
 				return compareErr
 			}
 		}
@@ -146,8 +210,13 @@ func (c *rpcComparator) PostTransaction(state executor.State[*rpc.RequestAndResu
 			}
 		}
 
+		// results which cannot be unmarshalled are reported, but not counted as failures
 		if compareErr.typ == cannotUnmarshalResult {
-			return nil
+			return c.reportDivergence(state, ctx.ExecutionResult, compareErr)
+		}
+
+		if err := c.reportDivergence(state, ctx.ExecutionResult, compareErr); err != nil {
+			return err
 		}
 
 		if !c.cfg.ContinueOnFailure {
@@ -170,6 +239,14 @@ func (c *rpcComparator) PostTransaction(state executor.State[*rpc.RequestAndResu
 	return nil
 }
 
+// reportDivergence writes the mismatch into the divergence report if it is enabled.
+func (c *rpcComparator) reportDivergence(state executor.State[*rpc.RequestAndResults], result txcontext.Result, compareErr *comparatorError) error {
+	if c.divergences == nil {
+		return nil
+	}
+	return c.divergences.write(newDivergenceRecord(state, result, compareErr))
+}
+
 func compare(result txcontext.Result, state executor.State[*rpc.RequestAndResults]) *comparatorError {
 	switch state.Data.Query.MethodBase {
 	case "getBalance":
@@ -185,6 +262,12 @@ func compare(result txcontext.Result, state executor.State[*rpc.RequestAndResult
 		return compareCode(result, state.Data, state.Block)
 	case "getStorageAt":
 		return compareStorageAt(result, state.Data, state.Block)
+	case "getProof":
+		return compareProof(result, state.Data, state.Block)
+	case "createAccessList":
+		return compareAccessList(result, state.Data, state.Block)
+	case "traceCall":
+		return compareTraceCall(result, state.Data, state.Block)
 	}
 
 	return nil
@@ -230,9 +313,9 @@ func (c *rpcComparator) resendRequest(result txcontext.Result, state executor.St
 	state.Data.Response = nil
 	state.Data.Error = nil
 
-	s, ok := m["result"].(string)
-	if ok { // valid result
-		resentResult, err := json.Marshal(s)
+	r, ok := m["result"]
+	if ok && r != nil { // valid result; getProof returns an object
+		resentResult, err := json.Marshal(r)
 		if err != nil {
 			return newComparatorError(result, nil, nil, state.Data, state.Block, cannotUnmarshalResult)
 		}
@@ -571,32 +654,204 @@ func compareStorageAt(result txcontext.Result, data *rpc.RequestAndResults, bloc
 	return nil
 }
 
+// provenAccount represents account and storage values of a getProof result which are compared.
+// Merkle proofs and the storage root depend on the trie of the recording node and are not compared.
+type provenAccount struct {
+	Balance  string
+	Nonce    uint64
+	CodeHash common.Hash
+	Storage  []string
+}
+
+func newProvenAccount(res *ethapi.AccountResult) provenAccount {
+	account := provenAccount{
+		Balance:  bigToHex((*big.Int)(res.Balance)),
+		Nonce:    uint64(res.Nonce),
+		CodeHash: res.CodeHash,
+	}
+	for _, s := range res.StorageProof {
+		account.Storage = append(account.Storage, common.HexToHash(s.Key).Hex()+"="+bigToHex((*big.Int)(s.Value)))
+	}
+	return account
+}
+
+// compareProof compares getProof data recorded on API server with data returned by StateDB
+func compareProof(result txcontext.Result, data *rpc.RequestAndResults, block int) *comparatorError {
+	res, err := result.GetRawResult()
+	if err != nil {
+		return newComparatorError(result, err, data.Response, data, block, expectedResultGotError)
+	}
+
+	if data.Error != nil {
+		// internal error?
+		if data.Error.Error.Code == internalErrorCode {
+			return newComparatorError(result, string(res), data.Error.Error, data, block, internalError)
+		}
+		return newComparatorError(result, string(res), data.Error.Error, data, block, expectedErrorGotResult)
+	}
+
+	var dbResult, recordedResult ethapi.AccountResult
+	if err = json.Unmarshal(res, &dbResult); err != nil {
+		return newUnexpectedDataTypeErr(data)
+	}
+	if err = json.Unmarshal(data.Response.Result, &recordedResult); err != nil {
+		return newComparatorError(result, string(res), string(data.Response.Result), data, block, cannotUnmarshalResult)
+	}
+
+	dbAccount, recordedAccount := newProvenAccount(&dbResult), newProvenAccount(&recordedResult)
+	if !reflect.DeepEqual(dbAccount, recordedAccount) {
+		return newComparatorError(result, dbAccount, recordedAccount, data, block, noMatchingResult)
+	}
+
+	return nil
+}
+
+// accessList represents a createAccessList result which is compared. Access lists are
+// compared as sets since order of accounts and storage slots is not defined.
+type accessList struct {
+	GasUsed  uint64
+	Error    string
+	Accesses []string
+}
+
+func newAccessList(res *rpc.AccessListResult) accessList {
+	list := accessList{
+		GasUsed: uint64(res.GasUsed),
+		Error:   res.Error,
+	}
+	if res.AccessList != nil {
+		for _, tuple := range *res.AccessList {
+			list.Accesses = append(list.Accesses, tuple.Address.Hex())
+			for _, key := range tuple.StorageKeys {
+				list.Accesses = append(list.Accesses, tuple.Address.Hex()+"/"+key.Hex())
+			}
+		}
+	}
+	sort.Strings(list.Accesses)
+	return list
+}
+
+// compareAccessList compares createAccessList data recorded on API server with data returned by StateDB
+func compareAccessList(result txcontext.Result, data *rpc.RequestAndResults, block int) *comparatorError {
+	res, err := result.GetRawResult()
+	if err != nil {
+		return compareEVMStateDBError(result, err, data, block)
+	}
+
+	if data.Error != nil {
+		// internal error?
+		if data.Error.Error.Code == internalErrorCode {
+			return newComparatorError(result, string(res), data.Error.Error, data, block, internalError)
+		}
+		return newComparatorError(result, string(res), data.Error.Error, data, block, expectedErrorGotResult)
+	}
+
+	var dbResult, recordedResult rpc.AccessListResult
+	if err = json.Unmarshal(res, &dbResult); err != nil {
+		return newUnexpectedDataTypeErr(data)
+	}
+	if err = json.Unmarshal(data.Response.Result, &recordedResult); err != nil {
+		return newComparatorError(result, string(res), string(data.Response.Result), data, block, cannotUnmarshalResult)
+	}
+
+	dbList, recordedList := newAccessList(&dbResult), newAccessList(&recordedResult)
+	if !reflect.DeepEqual(dbList, recordedList) {
+		return newComparatorError(result, dbList, recordedList, data, block, noMatchingResult)
+	}
+
+	return nil
+}
+
+// compareTraceCall compares traceCall data recorded on API server with data returned by StateDB.
+// Structured logs are compared by their position, operation, gas and depth.
+func compareTraceCall(result txcontext.Result, data *rpc.RequestAndResults, block int) *comparatorError {
+	res, err := result.GetRawResult()
+	if err != nil {
+		return compareEVMStateDBError(result, err, data, block)
+	}
+
+	if data.Error != nil {
+		// internal error?
+		if data.Error.Error.Code == internalErrorCode {
+			return newComparatorError(result, string(res), data.Error.Error, data, block, internalError)
+		}
+		return newComparatorError(result, string(res), data.Error.Error, data, block, expectedErrorGotResult)
+	}
+
+	var dbResult, recordedResult ethapi.ExecutionResult
+	if err = json.Unmarshal(res, &dbResult); err != nil {
+		return newUnexpectedDataTypeErr(data)
+	}
+	if err = json.Unmarshal(data.Response.Result, &recordedResult); err != nil {
+		return newComparatorError(result, string(res), string(data.Response.Result), data, block, cannotUnmarshalResult)
+	}
+
+	if dbDiff, recordedDiff, ok := diffTraces(&dbResult, &recordedResult); !ok {
+		return newComparatorError(result, dbDiff, recordedDiff, data, block, noMatchingResult)
+	}
+
+	return nil
+}
+
+// diffTraces returns the first difference of two traces or false if the traces are equal.
+func diffTraces(db, recorded *ethapi.ExecutionResult) (any, any, bool) {
+	if db.Gas != recorded.Gas || db.Failed != recorded.Failed || !strings.EqualFold(db.ReturnValue, recorded.ReturnValue) {
+		summary := func(r *ethapi.ExecutionResult) string {
+			return fmt.Sprintf("gas: %v, failed: %v, return value: %v", r.Gas, r.Failed, r.ReturnValue)
+		}
+		return summary(db), summary(recorded), false
+	}
+	if len(db.StructLogs) != len(recorded.StructLogs) {
+		return fmt.Sprintf("%v steps", len(db.StructLogs)), fmt.Sprintf("%v steps", len(recorded.StructLogs)), false
+	}
+	for i := range db.StructLogs {
+		step := func(l ethapi.StructLogRes) string {
+			return fmt.Sprintf("step %v: pc: %v, op: %v, gas: %v, gas cost: %v, depth: %v, error: %v", i, l.Pc, l.Op, l.Gas, l.GasCost, l.Depth, l.Error)
+		}
+		if dbStep, recordedStep := step(db.StructLogs[i]), step(recorded.StructLogs[i]); dbStep != recordedStep {
+			return dbStep, recordedStep, false
+		}
+	}
+	return nil, nil, true
+}
+
+// bigToHex returns hex representation of a possibly nil big integer.
+func bigToHex(v *big.Int) string {
+	if v == nil {
+		return "0x0"
+	}
+	return hexutil.EncodeBig(v)
+}
+
 // newComparatorError returns new comparatorError with given StateDB and recorded data based on the typ.
 func newComparatorError(result txcontext.Result, stateDB, expected any, data *rpc.RequestAndResults, block int, typ comparatorErrorType) *comparatorError {
+	var err *comparatorError
 	switch typ {
 	case noMatchingResult:
-		return newNoMatchingResultErr(stateDB, expected, data, block)
+		err = newNoMatchingResultErr(stateDB, expected, data, block)
 	case noMatchingErrors:
-		return newNoMatchingErrorsErr(stateDB, expected, data, block)
+		err = newNoMatchingErrorsErr(stateDB, expected, data, block)
 	case expectedResultGotError:
-		return newExpectedResultGotErrorErr(stateDB, expected, data, block)
+		err = newExpectedResultGotErrorErr(stateDB, expected, data, block)
 	case expectedErrorGotResult:
-		return newExpectedErrorGotResultErr(stateDB, expected, data, block)
+		err = newExpectedErrorGotResultErr(stateDB, expected, data, block)
 	case unexpectedDataType:
-		return newUnexpectedDataTypeErr(data)
+		err = newUnexpectedDataTypeErr(data)
 	case cannotUnmarshalResult:
-		return newCannotUnmarshalResult(result, data, block)
+		err = newCannotUnmarshalResult(result, data, block)
 	case internalError:
 		// internalError is caused by opera, adding this to the error list does not make sense
 		return nil
 	case cannotSendRpcRequest:
-		return newCannotSendRPCRequestErr(result, data, block)
+		err = newCannotSendRPCRequestErr(result, data, block)
 	default:
-		return &comparatorError{
+		err = &comparatorError{
 			error: fmt.Errorf("default error:\n%v", data),
 			typ:   0,
 		}
 	}
+	err.computed, err.recorded = stateDB, expected
+	return err
 }
 
 func newCannotSendRPCRequestErr(result txcontext.Result, data *rpc.RequestAndResults, block int) *comparatorError {
@@ -514,3 +514,177 @@ func Test_compareStorageAtErrorNoMatchingResult(t *testing.T) {
 	}
 
 }
+
+const (
+	// proof recorded on API server including Merkle proofs which are not compared
+	recordedProof = `{"address":"0x0000000000000000000000000000000000000012","accountProof":["0xf8"],"balance":"0xa","codeHash":"0x3400000000000000000000000000000000000000000000000000000000000000","nonce":"0x3","storageHash":"0x5600000000000000000000000000000000000000000000000000000000000000","storageProof":[{"key":"0x1","value":"0x2","proof":["0xf9"]}]}`
+
+	// access list recorded on API server
+	recordedAccessList = `{"accessList":[{"address":"0x0000000000000000000000000000000000000012","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000001","0x0000000000000000000000000000000000000000000000000000000000000002"]}],"gasUsed":"0x5208"}`
+
+	// trace recorded on API server
+	recordedTrace = `{"gas":21000,"failed":false,"returnValue":"01","structLogs":[{"pc":0,"op":"PUSH1","gas":100,"gasCost":3,"depth":1,"stack":[]},{"pc":2,"op":"STOP","gas":97,"gasCost":0,"depth":1,"stack":["0x1"]}]}`
+)
+
+// Test_compareProofOK tests compare func for getProof method
+// It expects no error since account and storage values are same
+func Test_compareProofOK(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "eth_getProof",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedProof),
+		},
+	}
+
+	db := `{"address":"0x0000000000000000000000000000000000000012","accountProof":[],"balance":"0xa","codeHash":"0x3400000000000000000000000000000000000000000000000000000000000000","nonce":"0x3","storageHash":"0x0000000000000000000000000000000000000000000000000000000000000000","storageProof":[{"key":"0x0000000000000000000000000000000000000000000000000000000000000001","value":"0x2","proof":[]}]}`
+	res := rpc.NewResult([]byte(db), nil, 0)
+	err := compareProof(res, data, 0)
+	if err != nil {
+		t.Errorf("error must be nil; err: %v", err)
+	}
+}
+
+// Test_compareProofErrorNoMatchingResult tests compare func for getProof method
+// It expects an error of no matching results since storage values are different
+func Test_compareProofErrorNoMatchingResult(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "eth_getProof",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedProof),
+		},
+	}
+
+	db := strings.Replace(recordedProof, `"value":"0x2"`, `"value":"0x3"`, 1)
+	res := rpc.NewResult([]byte(db), nil, 0)
+	err := compareProof(res, data, 0)
+	if err == nil {
+		t.Errorf("error must not be nil; err: %v", err)
+		return
+	}
+
+	if err.typ != noMatchingResult {
+		t.Errorf("error must be type 'noMatchingResult'; err: %v", err)
+	}
+}
+
+// Test_compareAccessListOK tests compare func for createAccessList method
+// It expects no error since access lists contain same accesses in different order
+func Test_compareAccessListOK(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "eth_createAccessList",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedAccessList),
+		},
+	}
+
+	db := `{"accessList":[{"address":"0x0000000000000000000000000000000000000012","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000002","0x0000000000000000000000000000000000000000000000000000000000000001"]}],"gasUsed":"0x5208"}`
+	res := rpc.NewResult([]byte(db), nil, 21000)
+	err := compareAccessList(res, data, 0)
+	if err != nil {
+		t.Errorf("error must be nil; err: %v", err)
+	}
+}
+
+// Test_compareAccessListErrorNoMatchingResult tests compare func for createAccessList method
+// It expects an error of no matching results since access lists are different
+func Test_compareAccessListErrorNoMatchingResult(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "eth_createAccessList",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedAccessList),
+		},
+	}
+
+	db := `{"accessList":[{"address":"0x0000000000000000000000000000000000000012","storageKeys":["0x0000000000000000000000000000000000000000000000000000000000000001"]}],"gasUsed":"0x5208"}`
+	res := rpc.NewResult([]byte(db), nil, 21000)
+	err := compareAccessList(res, data, 0)
+	if err == nil {
+		t.Errorf("error must not be nil; err: %v", err)
+		return
+	}
+
+	if err.typ != noMatchingResult {
+		t.Errorf("error must be type 'noMatchingResult'; err: %v", err)
+	}
+}
+
+// Test_compareAccessListErrorExpectedResultGotErr tests compare func for createAccessList method
+// It expects an error of expected result got error since StateDB returned an error
+func Test_compareAccessListErrorExpectedResultGotErr(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "eth_createAccessList",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedAccessList),
+		},
+	}
+
+	res := rpc.NewResult(nil, errors.New("failed to apply transaction"), 0)
+	err := compareAccessList(res, data, 0)
+	if err == nil {
+		t.Errorf("error must not be nil; err: %v", err)
+		return
+	}
+
+	if err.typ != expectedResultGotError {
+		t.Errorf("error must be type 'expectedResultGotError'; err: %v", err)
+	}
+}
+
+// Test_compareTraceCallOK tests compare func for traceCall method
+// It expects no error since executed steps are same
+func Test_compareTraceCallOK(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "debug_traceCall",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedTrace),
+		},
+	}
+
+	// stack is not compared
+	db := strings.Replace(recordedTrace, `"stack":["0x1"]`, `"stack":["0x2"]`, 1)
+	res := rpc.NewResult([]byte(db), nil, 21000)
+	err := compareTraceCall(res, data, 0)
+	if err != nil {
+		t.Errorf("error must be nil; err: %v", err)
+	}
+}
+
+// Test_compareTraceCallErrorNoMatchingResult tests compare func for traceCall method
+// It expects an error of no matching results since gas of a step is different
+func Test_compareTraceCallErrorNoMatchingResult(t *testing.T) {
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			Method: "debug_traceCall",
+		},
+		Response: &rpc.Response{
+			Result: json.RawMessage(recordedTrace),
+		},
+	}
+
+	db := strings.Replace(recordedTrace, `"gas":97`, `"gas":98`, 1)
+	res := rpc.NewResult([]byte(db), nil, 21000)
+	err := compareTraceCall(res, data, 0)
+	if err == nil {
+		t.Errorf("error must not be nil; err: %v", err)
+		return
+	}
+
+	if err.typ != noMatchingResult {
+		t.Errorf("error must be type 'noMatchingResult'; err: %v", err)
+	}
+	if !strings.Contains(err.Error(), "step 1") {
+		t.Errorf("error must report the differing step; err: %v", err)
+	}
+}
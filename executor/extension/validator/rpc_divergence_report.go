@@ -0,0 +1,217 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package validator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// DivergenceRecord describes a mismatch between a recorded result and the result returned by StateDB.
+// Divergence reports contain one JSON encoded record per line.
+type DivergenceRecord struct {
+	Method         string        `json:"method"`
+	Params         []interface{} `json:"params"`
+	Block          int           `json:"block"`
+	RequestedBlock int           `json:"requestedBlock"`
+	Contract       string        `json:"contract,omitempty"` // queried account or callee, empty if unknown
+	Recorded       any           `json:"recorded"`
+	Computed       any           `json:"computed"`
+	ErrorType      string        `json:"errorType"`
+	Message        string        `json:"message"`
+}
+
+func newDivergenceRecord(state executor.State[*rpc.RequestAndResults], result txcontext.Result, compareErr *comparatorError) DivergenceRecord {
+	data := state.Data
+	rec := DivergenceRecord{
+		Method:         data.Query.Method,
+		Params:         data.Query.Params,
+		Block:          state.Block,
+		RequestedBlock: data.RequestedBlock,
+		Contract:       requestContract(data),
+		Recorded:       divergenceValue(compareErr.recorded),
+		Computed:       divergenceValue(compareErr.computed),
+		ErrorType:      compareErr.typ.String(),
+		Message:        compareErr.Error(),
+	}
+
+	// fall back to raw data if the comparison did not provide the values
+	if rec.Computed == nil && result != nil {
+		if res, err := result.GetRawResult(); err != nil {
+			rec.Computed = err.Error()
+		} else {
+			rec.Computed = hexutil.Encode(res)
+		}
+	}
+	if rec.Recorded == nil {
+		if data.Response != nil && len(data.Response.Result) > 0 {
+			rec.Recorded = data.Response.Result
+		} else if data.Error != nil {
+			rec.Recorded = data.Error.Error
+		}
+	}
+	return rec
+}
+
+// divergenceValue converts values which cannot be encoded in JSON.
+func divergenceValue(v any) any {
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// requestContract returns the account queried by the request or the callee of a call.
+func requestContract(data *rpc.RequestAndResults) string {
+	if len(data.Query.Params) == 0 {
+		return ""
+	}
+	switch p := data.Query.Params[0].(type) {
+	case string:
+		if common.IsHexAddress(p) {
+			return strings.ToLower(p)
+		}
+	case map[string]interface{}:
+		if to, ok := p["to"].(string); ok && common.IsHexAddress(to) {
+			return strings.ToLower(to)
+		}
+	}
+	return ""
+}
+
+// divergenceReport writes divergence records into a file. It is safe for concurrent use.
+type divergenceReport struct {
+	mu     sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	count  int
+}
+
+func newDivergenceReport(path string) (*divergenceReport, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create divergence report; %w", err)
+	}
+	return &divergenceReport{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+func (r *divergenceReport) write(rec DivergenceRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("cannot encode divergence of %v request; %w", rec.Method, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err = r.writer.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("cannot write divergence report; %w", err)
+	}
+	r.count++
+	return nil
+}
+
+func (r *divergenceReport) close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return errors.Join(r.writer.Flush(), r.file.Close())
+}
+
+// ReadDivergenceReport reads all records of a divergence report.
+func ReadDivergenceReport(path string) ([]DivergenceRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open divergence report; %w", err)
+	}
+	defer file.Close()
+
+	var records []DivergenceRecord
+	decoder := json.NewDecoder(file)
+	for {
+		var rec DivergenceRecord
+		if err = decoder.Decode(&rec); err == io.EOF {
+			return records, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot decode divergence record %v; %w", len(records)+1, err)
+		}
+		records = append(records, rec)
+	}
+}
+
+// DivergenceGroup is the number of divergences sharing a key.
+type DivergenceGroup struct {
+	Key   string
+	Count int
+}
+
+// DivergenceSummary groups divergences by method, contract and error type.
+// Groups are sorted by descending count.
+type DivergenceSummary struct {
+	Total       int
+	ByMethod    []DivergenceGroup
+	ByContract  []DivergenceGroup
+	ByErrorType []DivergenceGroup
+}
+
+// SummarizeDivergences groups divergence records for triage.
+func SummarizeDivergences(records []DivergenceRecord) DivergenceSummary {
+	methods := make(map[string]int)
+	contracts := make(map[string]int)
+	errorTypes := make(map[string]int)
+	for _, rec := range records {
+		methods[rec.Method]++
+		errorTypes[rec.ErrorType]++
+		if rec.Contract != "" {
+			contracts[rec.Contract]++
+		}
+	}
+	return DivergenceSummary{
+		Total:       len(records),
+		ByMethod:    sortDivergenceGroups(methods),
+		ByContract:  sortDivergenceGroups(contracts),
+		ByErrorType: sortDivergenceGroups(errorTypes),
+	}
+}
+
+func sortDivergenceGroups(counts map[string]int) []DivergenceGroup {
+	groups := make([]DivergenceGroup, 0, len(counts))
+	for key, count := range counts {
+		groups = append(groups, DivergenceGroup{key, count})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
@@ -0,0 +1,189 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package validator
+
+import (
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/executor"
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"github.com/Fantom-foundation/Aida/utils"
+)
+
+func TestRPCComparator_MismatchIsWrittenIntoDivergenceReport(t *testing.T) {
+	cfg := &utils.Config{}
+	cfg.Validate = true
+	cfg.ContinueOnFailure = true
+	cfg.RpcDivergenceReport = filepath.Join(t.TempDir(), "divergences.jsonl")
+
+	bigRes, _ := new(big.Int).SetString("1", 16)
+	rec, _ := json.Marshal(hexZero)
+	address := "0x0000000000000000000000000000000000000ABC"
+
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			MethodBase: "getBalance",
+			Method:     "eth_getBalance",
+			Params:     []interface{}{address, "0x10"},
+		},
+		Response: &rpc.Response{
+			Result: rec,
+		},
+		RequestedBlock: 16,
+		IsRecovered:    true,
+	}
+
+	c := makeRPCComparator(cfg, logger.NewLogger("critical", "rpc-test"))
+	if err := c.PreRun(executor.State[*rpc.RequestAndResults]{}, nil); err != nil {
+		t.Fatalf("unexpected error in pre run; %v", err)
+	}
+
+	ctx := new(executor.Context)
+	ctx.ErrorInput = make(chan error, 10)
+	ctx.ExecutionResult = rpc.NewResult(bigRes.Bytes(), nil, 10)
+	if err := c.PostTransaction(executor.State[*rpc.RequestAndResults]{Block: 17, Data: data}, ctx); err != nil {
+		t.Fatalf("unexpected error in post transaction; %v", err)
+	}
+	if err := c.PostRun(executor.State[*rpc.RequestAndResults]{}, ctx, nil); err != nil {
+		t.Fatalf("unexpected error in post run; %v", err)
+	}
+
+	records, err := ReadDivergenceReport(cfg.RpcDivergenceReport)
+	if err != nil {
+		t.Fatalf("cannot read divergence report; %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("unexpected number of divergences; got: %v, want: 1", len(records))
+	}
+
+	got := records[0]
+	if got.Method != "eth_getBalance" || got.Block != 17 || got.RequestedBlock != 16 {
+		t.Errorf("unexpected request of divergence; %+v", got)
+	}
+	if got.Contract != "0x0000000000000000000000000000000000000abc" {
+		t.Errorf("unexpected contract; got: %v", got.Contract)
+	}
+	if got.Computed != "1" || got.Recorded != "0" {
+		t.Errorf("unexpected results; got computed: %v, recorded: %v", got.Computed, got.Recorded)
+	}
+	if got.ErrorType != noMatchingResult.String() {
+		t.Errorf("unexpected error type; got: %v, want: %v", got.ErrorType, noMatchingResult)
+	}
+}
+
+func TestRPCComparator_UnmarshalableResultIsReportedButDoesNotFail(t *testing.T) {
+	cfg := &utils.Config{}
+	cfg.Validate = true
+	cfg.RpcDivergenceReport = filepath.Join(t.TempDir(), "divergences.jsonl")
+
+	bigRes, _ := new(big.Int).SetString("1", 16)
+	data := &rpc.RequestAndResults{
+		Query: &rpc.Body{
+			MethodBase: "getBalance",
+			Method:     "eth_getBalance",
+			Params:     []interface{}{"0x0000000000000000000000000000000000000ABC", "0x10"},
+		},
+		Response: &rpc.Response{
+			Result: []byte(`{}`),
+		},
+		RequestedBlock: 16,
+		IsRecovered:    true,
+	}
+
+	c := makeRPCComparator(cfg, logger.NewLogger("critical", "rpc-test"))
+	if err := c.PreRun(executor.State[*rpc.RequestAndResults]{}, nil); err != nil {
+		t.Fatalf("unexpected error in pre run; %v", err)
+	}
+
+	ctx := &executor.Context{ExecutionResult: rpc.NewResult(bigRes.Bytes(), nil, 10)}
+	if err := c.PostTransaction(executor.State[*rpc.RequestAndResults]{Block: 17, Data: data}, ctx); err != nil {
+		t.Fatalf("unmarshal failures must not fail the run; %v", err)
+	}
+	if c.numberOfErrors != 0 {
+		t.Errorf("unmarshal failures must not be counted; got: %v", c.numberOfErrors)
+	}
+	if err := c.PostRun(executor.State[*rpc.RequestAndResults]{}, ctx, nil); err != nil {
+		t.Fatalf("unexpected error in post run; %v", err)
+	}
+
+	records, err := ReadDivergenceReport(cfg.RpcDivergenceReport)
+	if err != nil {
+		t.Fatalf("cannot read divergence report; %v", err)
+	}
+	if len(records) != 1 {
+		t.Fatalf("unexpected number of divergences; got: %v, want: 1", len(records))
+	}
+	if got := records[0].ErrorType; got != cannotUnmarshalResult.String() {
+		t.Errorf("unexpected error type; got: %v, want: %v", got, cannotUnmarshalResult)
+	}
+}
+
+func TestRequestContract_AccountOrCalleeIsFound(t *testing.T) {
+	address := "0x0000000000000000000000000000000000000abc"
+	tests := []struct {
+		name   string
+		params []interface{}
+		want   string
+	}{
+		{"account", []interface{}{address, "latest"}, address},
+		{"callee", []interface{}{map[string]interface{}{"to": address}, "latest"}, address},
+		{"contract creation", []interface{}{map[string]interface{}{"data": "0x00"}, "latest"}, ""},
+		{"no address", []interface{}{"0x10"}, ""},
+		{"no params", nil, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data := &rpc.RequestAndResults{Query: &rpc.Body{Params: test.params}}
+			if got := requestContract(data); got != test.want {
+				t.Errorf("unexpected contract; got: %v, want: %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeDivergences_GroupsAreSortedByCount(t *testing.T) {
+	records := []DivergenceRecord{
+		{Method: "eth_call", Contract: "0xa", ErrorType: "noMatchingResult"},
+		{Method: "eth_call", Contract: "0xb", ErrorType: "expectedErrorGotResult"},
+		{Method: "eth_getBalance", Contract: "0xa", ErrorType: "noMatchingResult"},
+		{Method: "eth_getStorageAt", ErrorType: "noMatchingResult"},
+	}
+
+	summary := SummarizeDivergences(records)
+	if summary.Total != 4 {
+		t.Errorf("unexpected total; got: %v, want: 4", summary.Total)
+	}
+
+	check := func(name string, got, want []DivergenceGroup) {
+		if len(got) != len(want) {
+			t.Fatalf("unexpected groups by %v; got: %v, want: %v", name, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("unexpected groups by %v; got: %v, want: %v", name, got, want)
+			}
+		}
+	}
+	check("method", summary.ByMethod, []DivergenceGroup{{"eth_call", 2}, {"eth_getBalance", 1}, {"eth_getStorageAt", 1}})
+	check("contract", summary.ByContract, []DivergenceGroup{{"0xa", 2}, {"0xb", 1}})
+	check("error type", summary.ByErrorType, []DivergenceGroup{{"noMatchingResult", 3}, {"expectedErrorGotResult", 1}})
+}
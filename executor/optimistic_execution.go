@@ -0,0 +1,252 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// overlayResult summarizes the execution of a single transaction on an overlay state.
+type overlayResult struct {
+	overlay *overlayState
+	result  txcontext.Result
+	errs    []error // non-fatal errors reported by the processor
+	err     error   // error returned by the processor
+	failed  bool    // speculative execution panicked, hence it needs to be repeated
+}
+
+// blockWrites accumulates the state locations modified by already committed
+// transactions of a block.
+type blockWrites struct {
+	locations accessSet
+	accounts  map[common.Address]struct{}
+}
+
+func newBlockWrites() *blockWrites {
+	return &blockWrites{
+		locations: make(accessSet),
+		accounts:  make(map[common.Address]struct{}),
+	}
+}
+
+func (w *blockWrites) add(writes accessSet) {
+	for location := range writes {
+		w.locations[location] = struct{}{}
+		w.accounts[location.addr] = struct{}{}
+	}
+}
+
+// conflictsWith returns true if any of the given reads observes a location
+// modified by committed transactions.
+func (w *blockWrites) conflictsWith(reads accessSet) bool {
+	for location := range reads {
+		if _, found := w.locations[location]; found {
+			return true
+		}
+		if _, found := w.accounts[location.addr]; !found {
+			continue
+		}
+		// existence of an account depends on all of its properties
+		if location.kind == accountAccess {
+			return true
+		}
+		// a re-created or deleted account invalidates all its properties
+		if _, found := w.locations[accessKey{kind: accountAccess, addr: location.addr}]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *executor[T]) runOptimisticBlocks(params Params, processor Processor[T], extensions []Extension[T], state *State[T], ctx *Context) error {
+	if ctx.State == nil {
+		return errors.New("optimistic execution requires a StateDB")
+	}
+
+	// An event for signaling an abort of the execution.
+	abort := utils.MakeEvent()
+
+	// Start one go-routine forwarding blocks from the provider to a local channel.
+	blocks, forwardErr := e.forwardBlocks(params, abort)
+
+	var numTransactions, numReExecuted int
+	e.log.Debugf("Starting %v workers run on Optimistic Transaction granularity...", params.NumWorkers)
+	for block := range blocks {
+		if len(block) == 0 {
+			continue
+		}
+		reExecuted, err := runOptimisticBlock(params.NumWorkers, block, processor, extensions, state, ctx)
+		numTransactions += len(block)
+		numReExecuted += reExecuted
+		if err != nil {
+			abort.Signal()
+			// wait for the forwarder to terminate
+			for range blocks {
+			}
+			return err
+		}
+	}
+
+	if numTransactions > 0 {
+		e.log.Noticef("Optimistic execution: %v transactions, %v re-executed due to conflicts (%.2f%%)",
+			numTransactions, numReExecuted, float64(numReExecuted)/float64(numTransactions)*100)
+	}
+
+	if *forwardErr != nil {
+		return *forwardErr
+	}
+	state.Block = params.To
+	return nil
+}
+
+// runOptimisticBlock executes all transactions of a block speculatively in parallel and then
+// commits them in order, re-executing those which observed state modified by a predecessor.
+// The number of re-executed transactions is returned.
+func runOptimisticBlock[T any](
+	numWorkers int,
+	transactions []*TransactionInfo[T],
+	processor Processor[T],
+	extensions []Extension[T],
+	state *State[T],
+	ctx *Context,
+) (int, error) {
+	state.Block = transactions[0].Block
+	state.Transaction = transactions[0].Transaction
+	state.Data = transactions[0].Data
+	if err := signalPreBlock(*state, ctx, extensions); err != nil {
+		return 0, err
+	}
+
+	var results []overlayResult
+	writes := newBlockWrites()
+	reExecuted := 0
+	for i, tx := range transactions {
+		state.Transaction = tx.Transaction
+		state.Data = tx.Data
+		if err := signalPreTransaction(*state, ctx, extensions); err != nil {
+			return reExecuted, err
+		}
+
+		// Speculation reads the StateDB within the transaction opened for the first
+		// commit, so no additional transaction is issued to the StateDB. No overlay is
+		// applied yet, hence the speculation observes the state at the block start.
+		if i == 0 {
+			results = speculate(numWorkers, transactions, processor, *state, ctx)
+		}
+
+		res := results[i]
+		if res.failed || writes.conflictsWith(res.overlay.reads) {
+			res = executeOnOverlay(*state, ctx, processor, &lockedStateReader{db: ctx.State})
+			reExecuted++
+		}
+		if res.err != nil {
+			return reExecuted, res.err
+		}
+
+		res.overlay.apply(ctx.State)
+		writes.add(res.overlay.getWrites())
+		if ctx.ErrorInput != nil {
+			for _, err := range res.errs {
+				ctx.ErrorInput <- err
+			}
+		}
+
+		ctx.ExecutionResult = res.result
+		if err := signalPostTransaction(*state, ctx, extensions); err != nil {
+			return reExecuted, err
+		}
+	}
+
+	return reExecuted, signalPostBlock(*state, ctx, extensions)
+}
+
+// speculate executes all given transactions in parallel, each on its own overlay
+// of the current StateDB. The StateDB is only read, no modifications are applied.
+func speculate[T any](numWorkers int, transactions []*TransactionInfo[T], processor Processor[T], state State[T], ctx *Context) []overlayResult {
+	reader := &lockedStateReader{db: ctx.State}
+	results := make([]overlayResult, len(transactions))
+
+	indices := make(chan int, len(transactions))
+	for i := range transactions {
+		indices <- i
+	}
+	close(indices)
+
+	wg := new(sync.WaitGroup)
+	wg.Add(numWorkers)
+	for i := 0; i < numWorkers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range indices {
+				localState := state
+				localState.Transaction = transactions[j].Transaction
+				localState.Data = transactions[j].Data
+				results[j] = speculateTransaction(localState, ctx, processor, reader)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// speculateTransaction executes a single transaction on an overlay. Since it may observe a state
+// inconsistent with its predecessors, any panic only marks the execution as failed.
+func speculateTransaction[T any](state State[T], ctx *Context, processor Processor[T], parent stateReader) (res overlayResult) {
+	defer func() {
+		if r := recover(); r != nil {
+			res = overlayResult{failed: true}
+		}
+	}()
+	return executeOnOverlay(state, ctx, processor, parent)
+}
+
+// executeOnOverlay runs the processor on a fresh overlay of the given state. Non-fatal
+// errors reported by the processor are collected, so they can be forwarded only if the
+// execution gets committed.
+func executeOnOverlay[T any](state State[T], ctx *Context, processor Processor[T], parent stateReader) overlayResult {
+	overlay := newOverlayState(parent)
+	localCtx := *ctx
+	localCtx.State = overlay
+
+	errs := make(chan error)
+	collected := make(chan []error, 1)
+	go func() {
+		var list []error
+		for err := range errs {
+			list = append(list, err)
+		}
+		collected <- list
+	}()
+	localCtx.ErrorInput = errs
+
+	err := func() error {
+		defer close(errs)
+		return processor.Process(state, &localCtx)
+	}()
+
+	return overlayResult{
+		overlay: overlay,
+		result:  localCtx.ExecutionResult,
+		errs:    <-collected,
+		err:     err,
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+// counterProcessor increments a storage counter. If shared is set, all transactions
+// increment the same counter, otherwise each transaction uses its own slot.
+type counterProcessor struct {
+	shared bool
+}
+
+func (p counterProcessor) Process(state State[any], ctx *Context) error {
+	key := common.Hash{byte(state.Transaction + 1)}
+	if p.shared {
+		key = common.Hash{}
+	}
+	value := ctx.State.GetState(common.Address{1}, key).Big()
+	value.Add(value, common.Big1)
+	ctx.State.SetState(common.Address{1}, key, common.BigToHash(value))
+	return nil
+}
+
+func runOptimisticCounter(t *testing.T, shared bool, numTransactions int) state.StateDB {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[any](ctrl)
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+
+	provider.EXPECT().
+		Run(10, 11, gomock.Any()).
+		DoAndReturn(func(from int, to int, consume Consumer[any]) error {
+			for i := 0; i < numTransactions; i++ {
+				consume(TransactionInfo[any]{from, i, nil})
+			}
+			return nil
+		})
+
+	params := Params{From: 10, To: 11, NumWorkers: 4, State: db, ParallelismGranularity: OptimisticTransactionLevel}
+	if err = NewExecutor[any](provider, "CRITICAL").Run(params, counterProcessor{shared: shared}, nil); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	return db
+}
+
+func TestOptimisticExecution_IndependentTransactionsAreCommitted(t *testing.T) {
+	db := runOptimisticCounter(t, false, 10)
+	for i := 0; i < 10; i++ {
+		if got, want := db.GetState(common.Address{1}, common.Hash{byte(i + 1)}), common.BigToHash(common.Big1); got != want {
+			t.Errorf("unexpected value of slot %d; got: %v, want: %v", i, got, want)
+		}
+	}
+}
+
+func TestOptimisticExecution_ConflictingTransactionsAreReExecuted(t *testing.T) {
+	db := runOptimisticCounter(t, true, 10)
+	if got, want := db.GetState(common.Address{1}, common.Hash{}).Big().Int64(), int64(10); got != want {
+		t.Errorf("unexpected counter value; got: %v, want: %v", got, want)
+	}
+}
+
+func TestOptimisticExecution_TransactionEventsAreSignaledInOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[any](ctrl)
+	extension := NewMockExtension[any](ctrl)
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+
+	provider.EXPECT().
+		Run(10, 12, gomock.Any()).
+		DoAndReturn(func(from int, to int, consume Consumer[any]) error {
+			for i := from; i < to; i++ {
+				consume(TransactionInfo[any]{i, 0, nil})
+				consume(TransactionInfo[any]{i, 1, nil})
+			}
+			return nil
+		})
+
+	gomock.InOrder(
+		extension.EXPECT().PreRun(AtBlock[any](10), gomock.Any()),
+		extension.EXPECT().PreBlock(AtBlock[any](10), gomock.Any()),
+		extension.EXPECT().PreTransaction(AtTransaction[any](10, 0), gomock.Any()),
+		extension.EXPECT().PostTransaction(AtTransaction[any](10, 0), gomock.Any()),
+		extension.EXPECT().PreTransaction(AtTransaction[any](10, 1), gomock.Any()),
+		extension.EXPECT().PostTransaction(AtTransaction[any](10, 1), gomock.Any()),
+		extension.EXPECT().PostBlock(AtTransaction[any](10, 1), gomock.Any()),
+		extension.EXPECT().PreBlock(AtBlock[any](11), gomock.Any()),
+		extension.EXPECT().PreTransaction(AtTransaction[any](11, 0), gomock.Any()),
+		extension.EXPECT().PostTransaction(AtTransaction[any](11, 0), gomock.Any()),
+		extension.EXPECT().PreTransaction(AtTransaction[any](11, 1), gomock.Any()),
+		extension.EXPECT().PostTransaction(AtTransaction[any](11, 1), gomock.Any()),
+		extension.EXPECT().PostBlock(AtTransaction[any](11, 1), gomock.Any()),
+		extension.EXPECT().PostRun(AtBlock[any](12), gomock.Any(), nil),
+	)
+
+	params := Params{From: 10, To: 12, NumWorkers: 2, State: db, ParallelismGranularity: OptimisticTransactionLevel}
+	if err = NewExecutor[any](provider, "CRITICAL").Run(params, counterProcessor{}, []Extension[any]{extension}); err != nil {
+		t.Errorf("execution failed: %v", err)
+	}
+}
+
+func TestOptimisticExecution_ProcessorErrorIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[any](ctrl)
+	processor := NewMockProcessor[any](ctrl)
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+
+	provider.EXPECT().
+		Run(10, 11, gomock.Any()).
+		DoAndReturn(func(from int, to int, consume Consumer[any]) error {
+			consume(TransactionInfo[any]{from, 0, nil})
+			return nil
+		})
+
+	injectedErr := errors.New("injected error")
+	processor.EXPECT().Process(AtTransaction[any](10, 0), gomock.Any()).Return(injectedErr)
+
+	params := Params{From: 10, To: 11, NumWorkers: 2, State: db, ParallelismGranularity: OptimisticTransactionLevel}
+	err = NewExecutor[any](provider, "CRITICAL").Run(params, processor, nil)
+	if err == nil || !strings.Contains(err.Error(), injectedErr.Error()) {
+		t.Errorf("unexpected error; got: %v, want: %v", err, injectedErr)
+	}
+}
+
+func TestOptimisticExecution_FailsWithoutStateDb(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[any](ctrl)
+	processor := NewMockProcessor[any](ctrl)
+
+	params := Params{From: 10, To: 11, NumWorkers: 2, ParallelismGranularity: OptimisticTransactionLevel}
+	if err := NewExecutor[any](provider, "CRITICAL").Run(params, processor, nil); err == nil {
+		t.Errorf("run without state-db must fail")
+	}
+}
+
+// transactionCountingStateDB counts the transactions begun on the wrapped StateDB.
+type transactionCountingStateDB struct {
+	state.StateDB
+	numTransactions int
+}
+
+func (s *transactionCountingStateDB) BeginTransaction(number uint32) error {
+	s.numTransactions++
+	return s.StateDB.BeginTransaction(number)
+}
+
+// transactionExtension opens a StateDB transaction for each transaction
+// the way the transaction event emitter does.
+type transactionExtension struct{}
+
+func (transactionExtension) PreRun(State[any], *Context) error         { return nil }
+func (transactionExtension) PostRun(State[any], *Context, error) error { return nil }
+func (transactionExtension) PreBlock(State[any], *Context) error       { return nil }
+func (transactionExtension) PostBlock(State[any], *Context) error      { return nil }
+
+func (transactionExtension) PreTransaction(state State[any], ctx *Context) error {
+	return ctx.State.BeginTransaction(uint32(state.Transaction))
+}
+
+func (transactionExtension) PostTransaction(_ State[any], ctx *Context) error {
+	return ctx.State.EndTransaction()
+}
+
+func TestOptimisticExecution_SpeculationDoesNotBeginTransactions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[any](ctrl)
+	geth, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	db := &transactionCountingStateDB{StateDB: geth}
+
+	provider.EXPECT().
+		Run(10, 11, gomock.Any()).
+		DoAndReturn(func(from int, to int, consume Consumer[any]) error {
+			for i := 0; i < 4; i++ {
+				consume(TransactionInfo[any]{from, i, nil})
+			}
+			return nil
+		})
+
+	params := Params{From: 10, To: 11, NumWorkers: 2, State: db, ParallelismGranularity: OptimisticTransactionLevel}
+	if err = NewExecutor[any](provider, "CRITICAL").Run(params, counterProcessor{}, []Extension[any]{transactionExtension{}}); err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+	if got, want := db.numTransactions, 4; got != want {
+		t.Errorf("unexpected number of transactions; got: %v, want: %v", got, want)
+	}
+}
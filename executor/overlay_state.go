@@ -0,0 +1,589 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stateReader is the read-only part of the state.VmStateDB interface an
+// overlayState is based on.
+type stateReader interface {
+	Exist(common.Address) bool
+	GetBalance(common.Address) *big.Int
+	GetNonce(common.Address) uint64
+	GetState(common.Address, common.Hash) common.Hash
+	GetCode(common.Address) []byte
+	GetCodeHash(common.Address) common.Hash
+	GetCodeSize(common.Address) int
+}
+
+// lockedStateReader serializes read accesses to a StateDB which is shared
+// between multiple overlay states. StateDB implementations are in general
+// not safe for concurrent use, not even for reading.
+type lockedStateReader struct {
+	db stateReader
+	mu sync.Mutex
+}
+
+func (r *lockedStateReader) Exist(addr common.Address) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.Exist(addr)
+}
+
+func (r *lockedStateReader) GetBalance(addr common.Address) *big.Int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return new(big.Int).Set(r.db.GetBalance(addr))
+}
+
+func (r *lockedStateReader) GetNonce(addr common.Address) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.GetNonce(addr)
+}
+
+func (r *lockedStateReader) GetState(addr common.Address, key common.Hash) common.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.GetState(addr, key)
+}
+
+func (r *lockedStateReader) GetCode(addr common.Address) []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return bytes.Clone(r.db.GetCode(addr))
+}
+
+func (r *lockedStateReader) GetCodeHash(addr common.Address) common.Hash {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.GetCodeHash(addr)
+}
+
+func (r *lockedStateReader) GetCodeSize(addr common.Address) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.db.GetCodeSize(addr)
+}
+
+// accessKind distinguishes the parts of an account a transaction may access.
+type accessKind byte
+
+const (
+	accountAccess accessKind = iota // existence and life-cycle of an account
+	balanceAccess
+	nonceAccess
+	codeAccess
+	storageAccess
+)
+
+// accessKey identifies a single piece of state read or written by a transaction.
+// The key is only used for storage accesses.
+type accessKey struct {
+	kind accessKind
+	addr common.Address
+	key  common.Hash
+}
+
+// accessSet is a set of state locations accessed by a transaction.
+type accessSet map[accessKey]struct{}
+
+// overlayAccount holds the modifications of a single account made by the
+// transaction running on an overlayState.
+type overlayAccount struct {
+	balance  *big.Int // nil if not modified
+	nonce    *uint64  // nil if not modified
+	code     []byte
+	hasCode  bool // code was modified
+	storage  map[common.Hash]common.Hash
+	created  bool // storage of the underlying state is not visible
+	suicided bool
+}
+
+// overlayState is a transaction-local StateDB buffering all modifications of a
+// single transaction on top of an underlying state. It records the read set of
+// the transaction so that conflicts with other transactions can be detected, and
+// its modifications can later be applied to the real StateDB. Only operations of
+// the state.VmStateDB interface are supported, DB management operations are
+// either ignored or fail.
+type overlayState struct {
+	parent   stateReader
+	accounts map[common.Address]*overlayAccount
+	reads    accessSet
+	journal  []func()
+
+	refund     uint64
+	accessList map[common.Address]map[common.Hash]struct{}
+	logs       []*types.Log
+	txHash     common.Hash
+	txIndex    int
+}
+
+func newOverlayState(parent stateReader) *overlayState {
+	return &overlayState{
+		parent:     parent,
+		accounts:   make(map[common.Address]*overlayAccount),
+		reads:      make(accessSet),
+		accessList: make(map[common.Address]map[common.Hash]struct{}),
+	}
+}
+
+func (s *overlayState) read(kind accessKind, addr common.Address, key common.Hash) {
+	s.reads[accessKey{kind: kind, addr: addr, key: key}] = struct{}{}
+}
+
+// getOrNewAccount returns the modifications of given account, creating the
+// record if needed. Any modification implicitly makes the account exist.
+func (s *overlayState) getOrNewAccount(addr common.Address) *overlayAccount {
+	if acc, found := s.accounts[addr]; found {
+		return acc
+	}
+	acc := &overlayAccount{storage: make(map[common.Hash]common.Hash)}
+	s.accounts[addr] = acc
+	s.journal = append(s.journal, func() { delete(s.accounts, addr) })
+	return acc
+}
+
+func (s *overlayState) CreateAccount(addr common.Address) {
+	// the balance of an existing account is retained
+	balance := s.GetBalance(addr)
+	acc := s.getOrNewAccount(addr)
+	prev := *acc
+	s.journal = append(s.journal, func() { *acc = prev })
+
+	var nonce uint64
+	acc.balance = balance
+	acc.nonce = &nonce
+	acc.code = nil
+	acc.hasCode = true
+	acc.storage = make(map[common.Hash]common.Hash)
+	acc.created = true
+	acc.suicided = false
+}
+
+func (s *overlayState) Exist(addr common.Address) bool {
+	if _, found := s.accounts[addr]; found {
+		return true
+	}
+	s.read(accountAccess, addr, common.Hash{})
+	return s.parent.Exist(addr)
+}
+
+func (s *overlayState) Empty(addr common.Address) bool {
+	if !s.Exist(addr) {
+		return true
+	}
+	return s.GetNonce(addr) == 0 && s.GetBalance(addr).Sign() == 0 && s.GetCodeSize(addr) == 0
+}
+
+func (s *overlayState) Suicide(addr common.Address) bool {
+	if !s.Exist(addr) {
+		return false
+	}
+	acc := s.getOrNewAccount(addr)
+	prevBalance, prevSuicided := acc.balance, acc.suicided
+	s.journal = append(s.journal, func() { acc.balance, acc.suicided = prevBalance, prevSuicided })
+	acc.balance = new(big.Int)
+	acc.suicided = true
+	return true
+}
+
+func (s *overlayState) HasSuicided(addr common.Address) bool {
+	if acc, found := s.accounts[addr]; found {
+		return acc.suicided
+	}
+	return false
+}
+
+func (s *overlayState) GetBalance(addr common.Address) *big.Int {
+	if acc, found := s.accounts[addr]; found && acc.balance != nil {
+		return new(big.Int).Set(acc.balance)
+	}
+	s.read(balanceAccess, addr, common.Hash{})
+	return s.parent.GetBalance(addr)
+}
+
+func (s *overlayState) setBalance(addr common.Address, value *big.Int) {
+	acc := s.getOrNewAccount(addr)
+	prev := acc.balance
+	s.journal = append(s.journal, func() { acc.balance = prev })
+	acc.balance = value
+}
+
+func (s *overlayState) AddBalance(addr common.Address, value *big.Int) {
+	s.setBalance(addr, new(big.Int).Add(s.GetBalance(addr), value))
+}
+
+func (s *overlayState) SubBalance(addr common.Address, value *big.Int) {
+	s.setBalance(addr, new(big.Int).Sub(s.GetBalance(addr), value))
+}
+
+func (s *overlayState) GetNonce(addr common.Address) uint64 {
+	if acc, found := s.accounts[addr]; found && acc.nonce != nil {
+		return *acc.nonce
+	}
+	s.read(nonceAccess, addr, common.Hash{})
+	return s.parent.GetNonce(addr)
+}
+
+func (s *overlayState) SetNonce(addr common.Address, value uint64) {
+	acc := s.getOrNewAccount(addr)
+	prev := acc.nonce
+	s.journal = append(s.journal, func() { acc.nonce = prev })
+	acc.nonce = &value
+}
+
+func (s *overlayState) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	if acc, found := s.accounts[addr]; found && acc.created {
+		return common.Hash{}
+	}
+	s.read(storageAccess, addr, key)
+	return s.parent.GetState(addr, key)
+}
+
+func (s *overlayState) GetState(addr common.Address, key common.Hash) common.Hash {
+	if acc, found := s.accounts[addr]; found {
+		if value, found := acc.storage[key]; found {
+			return value
+		}
+		if acc.created {
+			return common.Hash{}
+		}
+	}
+	s.read(storageAccess, addr, key)
+	return s.parent.GetState(addr, key)
+}
+
+func (s *overlayState) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	acc := s.getOrNewAccount(addr)
+	prev, found := acc.storage[key]
+	s.journal = append(s.journal, func() {
+		if found {
+			acc.storage[key] = prev
+		} else {
+			delete(acc.storage, key)
+		}
+	})
+	acc.storage[key] = value
+}
+
+func (s *overlayState) GetCode(addr common.Address) []byte {
+	if acc, found := s.accounts[addr]; found && acc.hasCode {
+		return acc.code
+	}
+	s.read(codeAccess, addr, common.Hash{})
+	return s.parent.GetCode(addr)
+}
+
+func (s *overlayState) GetCodeHash(addr common.Address) common.Hash {
+	if acc, found := s.accounts[addr]; found && acc.hasCode {
+		return crypto.Keccak256Hash(acc.code)
+	}
+	s.read(codeAccess, addr, common.Hash{})
+	return s.parent.GetCodeHash(addr)
+}
+
+func (s *overlayState) GetCodeSize(addr common.Address) int {
+	if acc, found := s.accounts[addr]; found && acc.hasCode {
+		return len(acc.code)
+	}
+	s.read(codeAccess, addr, common.Hash{})
+	return s.parent.GetCodeSize(addr)
+}
+
+func (s *overlayState) SetCode(addr common.Address, code []byte) {
+	acc := s.getOrNewAccount(addr)
+	prevCode, prevHasCode := acc.code, acc.hasCode
+	s.journal = append(s.journal, func() { acc.code, acc.hasCode = prevCode, prevHasCode })
+	acc.code = bytes.Clone(code)
+	acc.hasCode = true
+}
+
+func (s *overlayState) AddRefund(amount uint64) {
+	prev := s.refund
+	s.journal = append(s.journal, func() { s.refund = prev })
+	s.refund += amount
+}
+
+func (s *overlayState) SubRefund(amount uint64) {
+	prev := s.refund
+	s.journal = append(s.journal, func() { s.refund = prev })
+	if amount > s.refund {
+		panic("refund counter below zero")
+	}
+	s.refund -= amount
+}
+
+func (s *overlayState) GetRefund() uint64 {
+	return s.refund
+}
+
+func (s *overlayState) PrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	s.accessList = make(map[common.Address]map[common.Hash]struct{})
+	s.AddAddressToAccessList(sender)
+	if dest != nil {
+		s.AddAddressToAccessList(*dest)
+	}
+	for _, addr := range precompiles {
+		s.AddAddressToAccessList(addr)
+	}
+	for _, el := range txAccesses {
+		s.AddAddressToAccessList(el.Address)
+		for _, key := range el.StorageKeys {
+			s.AddSlotToAccessList(el.Address, key)
+		}
+	}
+}
+
+func (s *overlayState) AddressInAccessList(addr common.Address) bool {
+	_, found := s.accessList[addr]
+	return found
+}
+
+func (s *overlayState) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	slots, addressOk := s.accessList[addr]
+	if !addressOk {
+		return false, false
+	}
+	_, slotOk := slots[slot]
+	return true, slotOk
+}
+
+func (s *overlayState) AddAddressToAccessList(addr common.Address) {
+	if _, found := s.accessList[addr]; found {
+		return
+	}
+	s.accessList[addr] = make(map[common.Hash]struct{})
+	s.journal = append(s.journal, func() { delete(s.accessList, addr) })
+}
+
+func (s *overlayState) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	s.AddAddressToAccessList(addr)
+	slots := s.accessList[addr]
+	if _, found := slots[slot]; found {
+		return
+	}
+	slots[slot] = struct{}{}
+	s.journal = append(s.journal, func() { delete(slots, slot) })
+}
+
+func (s *overlayState) AddLog(log *types.Log) {
+	log.TxHash = s.txHash
+	log.TxIndex = uint(s.txIndex)
+	log.Index = uint(len(s.logs))
+	numLogs := len(s.logs)
+	s.journal = append(s.journal, func() { s.logs = s.logs[:numLogs] })
+	s.logs = append(s.logs, log)
+}
+
+func (s *overlayState) GetLogs(_ common.Hash, blockHash common.Hash) []*types.Log {
+	for _, log := range s.logs {
+		log.BlockHash = blockHash
+	}
+	return s.logs
+}
+
+func (s *overlayState) Snapshot() int {
+	return len(s.journal)
+}
+
+func (s *overlayState) RevertToSnapshot(id int) {
+	for i := len(s.journal) - 1; i >= id; i-- {
+		s.journal[i]()
+	}
+	s.journal = s.journal[:id]
+}
+
+func (s *overlayState) BeginTransaction(uint32) error {
+	// ignored, an overlay state covers a single transaction
+	return nil
+}
+
+func (s *overlayState) EndTransaction() error {
+	// ignored, an overlay state covers a single transaction
+	return nil
+}
+
+func (s *overlayState) Prepare(txHash common.Hash, txIndex int) {
+	s.txHash = txHash
+	s.txIndex = txIndex
+}
+
+func (s *overlayState) AddPreimage(common.Hash, []byte) {
+	// ignored
+}
+
+func (s *overlayState) ForEachStorage(common.Address, func(common.Hash, common.Hash) bool) error {
+	return errors.New("ForEachStorage is not supported by overlay state")
+}
+
+func (s *overlayState) GetSubstatePostAlloc() txcontext.WorldState {
+	return nil
+}
+
+func (s *overlayState) BeginBlock(uint64) error {
+	return errors.New("blocks are not supported by overlay state")
+}
+
+func (s *overlayState) EndBlock() error {
+	return errors.New("blocks are not supported by overlay state")
+}
+
+func (s *overlayState) BeginSyncPeriod(uint64) {
+	// ignored
+}
+
+func (s *overlayState) EndSyncPeriod() {
+	// ignored
+}
+
+func (s *overlayState) GetHash() (common.Hash, error) {
+	return common.Hash{}, errors.New("state hash is not supported by overlay state")
+}
+
+func (s *overlayState) Error() error {
+	return nil
+}
+
+func (s *overlayState) Close() error {
+	return nil
+}
+
+func (s *overlayState) Flush() error {
+	return nil
+}
+
+func (s *overlayState) StartBulkLoad(uint64) (state.BulkLoad, error) {
+	return nil, errors.New("bulk load is not supported by overlay state")
+}
+
+func (s *overlayState) GetArchiveState(uint64) (state.NonCommittableStateDB, error) {
+	return nil, errors.New("archive is not supported by overlay state")
+}
+
+func (s *overlayState) GetArchiveBlockHeight() (uint64, bool, error) {
+	return 0, false, errors.New("archive is not supported by overlay state")
+}
+
+func (s *overlayState) GetMemoryUsage() *state.MemoryUsage {
+	return nil
+}
+
+func (s *overlayState) Finalise(bool) {
+	// ignored
+}
+
+func (s *overlayState) IntermediateRoot(bool) common.Hash {
+	// ignored
+	return common.Hash{}
+}
+
+func (s *overlayState) Commit(bool) (common.Hash, error) {
+	// ignored
+	return common.Hash{}, nil
+}
+
+func (s *overlayState) PrepareSubstate(txcontext.WorldState, uint64) {
+	// ignored
+}
+
+func (s *overlayState) GetShadowDB() state.StateDB {
+	return nil
+}
+
+// getWrites returns the set of state locations modified by the transaction.
+func (s *overlayState) getWrites() accessSet {
+	writes := make(accessSet)
+	for addr, acc := range s.accounts {
+		if acc.created || acc.suicided {
+			writes[accessKey{kind: accountAccess, addr: addr}] = struct{}{}
+		}
+		if acc.balance != nil {
+			writes[accessKey{kind: balanceAccess, addr: addr}] = struct{}{}
+		}
+		if acc.nonce != nil {
+			writes[accessKey{kind: nonceAccess, addr: addr}] = struct{}{}
+		}
+		if acc.hasCode {
+			writes[accessKey{kind: codeAccess, addr: addr}] = struct{}{}
+		}
+		for key := range acc.storage {
+			writes[accessKey{kind: storageAccess, addr: addr, key: key}] = struct{}{}
+		}
+	}
+	return writes
+}
+
+// apply transfers all modifications of the transaction into given StateDB.
+// Accounts are processed in a deterministic order.
+func (s *overlayState) apply(db state.VmStateDB) {
+	addresses := make([]common.Address, 0, len(s.accounts))
+	for addr := range s.accounts {
+		addresses = append(addresses, addr)
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	for _, addr := range addresses {
+		acc := s.accounts[addr]
+		if acc.created {
+			db.CreateAccount(addr)
+		}
+		if acc.balance != nil {
+			diff := new(big.Int).Sub(acc.balance, db.GetBalance(addr))
+			if diff.Sign() >= 0 {
+				db.AddBalance(addr, diff)
+			} else {
+				db.SubBalance(addr, diff.Neg(diff))
+			}
+		}
+		if acc.nonce != nil {
+			db.SetNonce(addr, *acc.nonce)
+		}
+		if acc.hasCode {
+			db.SetCode(addr, acc.code)
+		}
+
+		keys := make([]common.Hash, 0, len(acc.storage))
+		for key := range acc.storage {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i][:], keys[j][:]) < 0
+		})
+		for _, key := range keys {
+			db.SetState(addr, key, acc.storage[key])
+		}
+
+		if acc.suicided {
+			db.Suicide(addr)
+		}
+	}
+}
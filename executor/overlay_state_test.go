@@ -0,0 +1,137 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func makeTestOverlayParent(t *testing.T) state.StateDB {
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	db.CreateAccount(common.Address{1})
+	db.AddBalance(common.Address{1}, big.NewInt(100))
+	db.SetNonce(common.Address{1}, 5)
+	db.SetState(common.Address{1}, common.Hash{1}, common.Hash{2})
+	return db
+}
+
+func TestOverlayState_ReadsAreServedByParentAndRecorded(t *testing.T) {
+	overlay := newOverlayState(makeTestOverlayParent(t))
+
+	if got, want := overlay.GetBalance(common.Address{1}), big.NewInt(100); got.Cmp(want) != 0 {
+		t.Errorf("unexpected balance; got: %v, want: %v", got, want)
+	}
+	if got, want := overlay.GetState(common.Address{1}, common.Hash{1}), (common.Hash{2}); got != want {
+		t.Errorf("unexpected storage value; got: %v, want: %v", got, want)
+	}
+
+	for _, key := range []accessKey{
+		{kind: balanceAccess, addr: common.Address{1}},
+		{kind: storageAccess, addr: common.Address{1}, key: common.Hash{1}},
+	} {
+		if _, found := overlay.reads[key]; !found {
+			t.Errorf("read of %v was not recorded", key)
+		}
+	}
+	if len(overlay.getWrites()) != 0 {
+		t.Errorf("reads must not produce writes")
+	}
+}
+
+func TestOverlayState_WritesAreNotVisibleInParentUntilApplied(t *testing.T) {
+	parent := makeTestOverlayParent(t)
+	overlay := newOverlayState(parent)
+
+	overlay.SubBalance(common.Address{1}, big.NewInt(30))
+	overlay.AddBalance(common.Address{2}, big.NewInt(30))
+	overlay.SetNonce(common.Address{1}, 6)
+	overlay.SetState(common.Address{1}, common.Hash{1}, common.Hash{3})
+	overlay.SetCode(common.Address{2}, []byte{0x60})
+
+	if got, want := parent.GetBalance(common.Address{1}), big.NewInt(100); got.Cmp(want) != 0 {
+		t.Fatalf("parent was modified; got: %v, want: %v", got, want)
+	}
+
+	overlay.apply(parent)
+
+	if got, want := parent.GetBalance(common.Address{1}), big.NewInt(70); got.Cmp(want) != 0 {
+		t.Errorf("unexpected balance; got: %v, want: %v", got, want)
+	}
+	if got, want := parent.GetBalance(common.Address{2}), big.NewInt(30); got.Cmp(want) != 0 {
+		t.Errorf("unexpected balance; got: %v, want: %v", got, want)
+	}
+	if got, want := parent.GetNonce(common.Address{1}), uint64(6); got != want {
+		t.Errorf("unexpected nonce; got: %v, want: %v", got, want)
+	}
+	if got, want := parent.GetState(common.Address{1}, common.Hash{1}), (common.Hash{3}); got != want {
+		t.Errorf("unexpected storage value; got: %v, want: %v", got, want)
+	}
+	if got, want := parent.GetCodeSize(common.Address{2}), 1; got != want {
+		t.Errorf("unexpected code size; got: %v, want: %v", got, want)
+	}
+}
+
+func TestOverlayState_RevertToSnapshotUndoesWrites(t *testing.T) {
+	overlay := newOverlayState(makeTestOverlayParent(t))
+
+	overlay.SetState(common.Address{1}, common.Hash{1}, common.Hash{3})
+	id := overlay.Snapshot()
+	overlay.SetState(common.Address{1}, common.Hash{1}, common.Hash{4})
+	overlay.AddBalance(common.Address{1}, big.NewInt(1))
+	overlay.RevertToSnapshot(id)
+
+	if got, want := overlay.GetState(common.Address{1}, common.Hash{1}), (common.Hash{3}); got != want {
+		t.Errorf("unexpected storage value; got: %v, want: %v", got, want)
+	}
+	if got, want := overlay.GetBalance(common.Address{1}), big.NewInt(100); got.Cmp(want) != 0 {
+		t.Errorf("unexpected balance; got: %v, want: %v", got, want)
+	}
+}
+
+func TestBlockWrites_ConflictsWith(t *testing.T) {
+	writes := newBlockWrites()
+	writes.add(accessSet{
+		{kind: storageAccess, addr: common.Address{1}, key: common.Hash{1}}: {},
+		{kind: accountAccess, addr: common.Address{2}}:                      {},
+	})
+
+	tests := map[string]struct {
+		read     accessKey
+		conflict bool
+	}{
+		"same slot":            {accessKey{kind: storageAccess, addr: common.Address{1}, key: common.Hash{1}}, true},
+		"other slot":           {accessKey{kind: storageAccess, addr: common.Address{1}, key: common.Hash{2}}, false},
+		"existence of written": {accessKey{kind: accountAccess, addr: common.Address{1}}, true},
+		"re-created account":   {accessKey{kind: balanceAccess, addr: common.Address{2}}, true},
+		"untouched account":    {accessKey{kind: balanceAccess, addr: common.Address{3}}, false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := writes.conflictsWith(accessSet{test.read: {}}); got != test.conflict {
+				t.Errorf("unexpected conflict; got: %v, want: %v", got, test.conflict)
+			}
+		})
+	}
+}
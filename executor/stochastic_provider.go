@@ -0,0 +1,99 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"math/rand"
+
+	"github.com/Fantom-foundation/Aida/stochastic"
+)
+
+// NewStochasticProvider creates a provider sampling StateDB operations from
+// the Markovian process of the simulation model using the random generator.
+func NewStochasticProvider(e *stochastic.EstimationModelJSON, rg *rand.Rand) Provider[[]stochastic.Operation] {
+	return &stochasticProvider{
+		model: e,
+		rg:    rg,
+	}
+}
+
+type stochasticProvider struct {
+	model *stochastic.EstimationModelJSON
+	rg    *rand.Rand
+}
+
+// Run samples operations and unifies them by transactions. Operations ending
+// a block or a sync-period are appended to the transaction preceding them.
+// The first sampled block has the number from.
+func (p *stochasticProvider) Run(from int, to int, consumer Consumer[[]stochastic.Operation]) error {
+	if from >= to {
+		return nil
+	}
+
+	generator := stochastic.NewOperationGenerator(p.model, p.rg)
+
+	var (
+		currentBlockNumber = from
+		nextTransaction    int  // number of the next transaction in the current block
+		transactionBlock   int  // block number of the transaction
+		transactionNumber  int  // number of the transaction
+		lastOperation      bool // true if the transaction has been ended
+	)
+	tx := make([]stochastic.Operation, 0)
+	for {
+		op, err := generator.Next()
+		if err != nil {
+			return err
+		}
+
+		// any operation other than ending a block or a sync-period starts the next transaction
+		if lastOperation && op.ID != stochastic.EndBlockID && op.ID != stochastic.EndSyncPeriodID {
+			if err = consumer(TransactionInfo[[]stochastic.Operation]{transactionBlock, transactionNumber, tx}); err != nil {
+				return err
+			}
+			tx = make([]stochastic.Operation, 0)
+			lastOperation = false
+		}
+
+		if len(tx) == 0 {
+			transactionBlock = currentBlockNumber
+		}
+		tx = append(tx, op)
+
+		switch op.ID {
+		case stochastic.BeginBlockID:
+			// blocks without transactions must not keep the number of the previous transaction
+			nextTransaction = 0
+			transactionNumber = 0
+		case stochastic.BeginTransactionID:
+			transactionNumber = nextTransaction
+		case stochastic.EndTransactionID:
+			nextTransaction++
+			lastOperation = true
+		case stochastic.EndBlockID:
+			currentBlockNumber++
+			lastOperation = true
+			if currentBlockNumber >= to {
+				return consumer(TransactionInfo[[]stochastic.Operation]{transactionBlock, transactionNumber, tx})
+			}
+		}
+	}
+}
+
+func (p *stochasticProvider) Close() {
+	// ignored
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/stochastic"
+)
+
+// newCyclicSimulation creates a simulation model whose Markovian process
+// deterministically cycles through the given operations.
+func newCyclicSimulation(ops ...int) *stochastic.EstimationModelJSON {
+	n := len(ops)
+	operations := make([]string, n)
+	A := make([][]float64, n)
+	for i, op := range ops {
+		operations[i] = stochastic.OpMnemo(op)
+		A[i] = make([]float64, n)
+		A[i][(i+1)%n] = 1.0
+	}
+	return &stochastic.EstimationModelJSON{
+		Operations:       operations,
+		StochasticMatrix: A,
+	}
+}
+
+func TestStochasticProvider_OperationsAreUnitedByTransactions(t *testing.T) {
+	e := newCyclicSimulation(
+		stochastic.BeginSyncPeriodID,
+		stochastic.BeginBlockID,
+		stochastic.BeginTransactionID,
+		stochastic.EndTransactionID,
+		stochastic.BeginTransactionID,
+		stochastic.EndTransactionID,
+		stochastic.EndBlockID,
+		stochastic.EndSyncPeriodID,
+	)
+	provider := NewStochasticProvider(e, rand.New(rand.NewSource(999)))
+	defer provider.Close()
+
+	type tx struct{ block, transaction, numOps int }
+	var got []tx
+	err := provider.Run(1, 3, func(info TransactionInfo[[]stochastic.Operation]) error {
+		got = append(got, tx{info.Block, info.Transaction, len(info.Data)})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate through operations: %v", err)
+	}
+
+	// the first transaction of a block includes the operations beginning the block,
+	// the last transaction includes the operations ending the block.
+	want := []tx{{1, 0, 4}, {1, 1, 4}, {2, 0, 4}, {2, 1, 3}}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of transactions; got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected transaction %d; got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStochasticProvider_BlockWithoutTransactionsStartsAtTransactionZero(t *testing.T) {
+	e := newCyclicSimulation(
+		stochastic.BeginSyncPeriodID,
+		stochastic.BeginBlockID,
+		stochastic.BeginTransactionID,
+		stochastic.EndTransactionID,
+		stochastic.BeginTransactionID,
+		stochastic.EndTransactionID,
+		stochastic.EndBlockID,
+		stochastic.BeginBlockID,
+		stochastic.EndBlockID,
+		stochastic.EndSyncPeriodID,
+	)
+	provider := NewStochasticProvider(e, rand.New(rand.NewSource(999)))
+	defer provider.Close()
+
+	type tx struct{ block, transaction, numOps int }
+	var got []tx
+	err := provider.Run(1, 3, func(info TransactionInfo[[]stochastic.Operation]) error {
+		got = append(got, tx{info.Block, info.Transaction, len(info.Data)})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to iterate through operations: %v", err)
+	}
+
+	want := []tx{{1, 0, 4}, {1, 1, 3}, {2, 0, 2}}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of transactions; got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected transaction %d; got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStochasticProvider_EmptyRangeProducesNoTransactions(t *testing.T) {
+	e := newCyclicSimulation(stochastic.BeginSyncPeriodID, stochastic.EndSyncPeriodID)
+	provider := NewStochasticProvider(e, rand.New(rand.NewSource(999)))
+	defer provider.Close()
+
+	err := provider.Run(5, 5, func(info TransactionInfo[[]stochastic.Operation]) error {
+		t.Errorf("unexpected transaction %d/%d", info.Block, info.Transaction)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
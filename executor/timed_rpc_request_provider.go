@@ -0,0 +1,170 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+)
+
+const (
+	// maxScheduleGap is the longest pause between two recorded timestamps kept by timed replay.
+	// Longer pauses, e.g. interruptions of the recording, are collapsed to collapsedScheduleGap.
+	maxScheduleGap       = 10 * time.Second
+	collapsedScheduleGap = time.Second
+	// lagWarningThreshold is the delay behind the schedule at which a request is considered late.
+	lagWarningThreshold = time.Second
+	// lagWarningInterval is the minimal period between two warnings about late requests.
+	lagWarningInterval = 15 * time.Second
+)
+
+// MakeTimedRpcRequestProvider wraps a provider of recorded requests so that requests are passed
+// to the consumer in their recorded timing accelerated by speedUp. Recordings only keep the timestamp
+// of the block a request was executed in, hence requests sharing a timestamp are spread evenly until
+// the next recorded timestamp. Each request is marked with the time it was due. If the consumer
+// blocks because all workers are busy, the replay falls behind the schedule which is reported.
+func MakeTimedRpcRequestProvider(provider Provider[*rpc.RequestAndResults], speedUp float64, log logger.Logger) Provider[*rpc.RequestAndResults] {
+	return &timedRpcRequestProvider{
+		provider: provider,
+		speedUp:  speedUp,
+		log:      log,
+	}
+}
+
+type timedRpcRequestProvider struct {
+	provider Provider[*rpc.RequestAndResults]
+	speedUp  float64
+	log      logger.Logger
+}
+
+func (p *timedRpcRequestProvider) Run(from int, to int, consumer Consumer[*rpc.RequestAndResults]) error {
+	s := &requestSchedule{
+		speedUp:  p.speedUp,
+		log:      p.log,
+		consumer: consumer,
+	}
+	if err := p.provider.Run(from, to, s.add); err != nil {
+		return err
+	}
+	// the last group has no successor to spread to
+	if err := s.dispatch(collapsedScheduleGap); err != nil {
+		return err
+	}
+	s.report()
+	return nil
+}
+
+func (p *timedRpcRequestProvider) Close() {
+	p.provider.Close()
+}
+
+// requestSchedule groups consecutive requests sharing a recorded timestamp
+// and dispatches them to the consumer according to the schedule.
+type requestSchedule struct {
+	speedUp  float64
+	log      logger.Logger
+	consumer Consumer[*rpc.RequestAndResults]
+
+	start     time.Time     // wall-clock time of the first request
+	offset    time.Duration // recorded time between the first request and the current group
+	timestamp uint64        // recorded timestamp of the current group
+	group     []TransactionInfo[*rpc.RequestAndResults]
+
+	dispatched  uint64
+	late        uint64
+	maxLag      time.Duration
+	lastWarning time.Time
+}
+
+// add appends the request to the current group. Once a request with a different
+// timestamp arrives, the current group is dispatched.
+func (s *requestSchedule) add(info TransactionInfo[*rpc.RequestAndResults]) error {
+	ts := info.Data.Timestamp
+	if len(s.group) > 0 && ts != s.timestamp {
+		var window time.Duration
+		// timestamps going back in time are dispatched right away
+		if ts > s.timestamp {
+			window = time.Duration(ts-s.timestamp) * time.Second
+		}
+		if window > maxScheduleGap {
+			s.log.Noticef("Collapsing %v pause in recording before block %v", window, info.Block)
+			window = collapsedScheduleGap
+		}
+		if err := s.dispatch(window); err != nil {
+			return err
+		}
+	}
+	if len(s.group) == 0 {
+		s.timestamp = ts
+	}
+	s.group = append(s.group, info)
+	return nil
+}
+
+// dispatch passes requests of the current group to the consumer spreading them evenly over window.
+func (s *requestSchedule) dispatch(window time.Duration) error {
+	if s.start.IsZero() {
+		s.start = time.Now()
+	}
+
+	n := time.Duration(len(s.group))
+	for i, info := range s.group {
+		recorded := s.offset + window*time.Duration(i)/n
+		due := s.start.Add(time.Duration(float64(recorded) / s.speedUp))
+		if wait := time.Until(due); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		// consumer blocks while all workers are busy, hence following requests are late
+		s.track(time.Since(due))
+		info.Data.ScheduledAt = due
+		if err := s.consumer(info); err != nil {
+			return err
+		}
+	}
+
+	s.offset += window
+	s.group = s.group[:0]
+	return nil
+}
+
+// track counts requests which were dispatched late and warns about them periodically.
+func (s *requestSchedule) track(lag time.Duration) {
+	s.dispatched++
+	if lag > s.maxLag {
+		s.maxLag = lag
+	}
+	if lag <= lagWarningThreshold {
+		return
+	}
+	s.late++
+	if time.Since(s.lastWarning) >= lagWarningInterval {
+		s.log.Warningf("Archive cannot keep up with the recorded request rate; replay is %v behind schedule", lag.Round(time.Millisecond))
+		s.lastWarning = time.Now()
+	}
+}
+
+// report logs how well the replay kept up with the schedule.
+func (s *requestSchedule) report() {
+	if s.dispatched == 0 {
+		return
+	}
+	s.log.Noticef("Timed replay dispatched %v requests, %v (%.2f%%) were more than %v behind schedule, maximum lag %v",
+		s.dispatched, s.late, 100*float64(s.late)/float64(s.dispatched), lagWarningThreshold, s.maxLag.Round(time.Millisecond))
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package executor
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/rpc"
+	"go.uber.org/mock/gomock"
+)
+
+// provideRequests returns a Run implementation passing requests with given timestamps to the consumer.
+func provideRequests(timestamps ...uint64) func(int, int, Consumer[*rpc.RequestAndResults]) error {
+	return func(_ int, _ int, consumer Consumer[*rpc.RequestAndResults]) error {
+		for i, ts := range timestamps {
+			req := &rpc.RequestAndResults{RecordedBlock: i, Timestamp: ts}
+			if err := consumer(TransactionInfo[*rpc.RequestAndResults]{Block: i, Data: req}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func TestTimedRpcRequestProvider_RequestsAreDispatchedAccordingToSchedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[*rpc.RequestAndResults](ctrl)
+	provider.EXPECT().Run(0, 10, gomock.Any()).DoAndReturn(provideRequests(100, 100, 101, 102))
+
+	var requests []*rpc.RequestAndResults
+	consumer := func(info TransactionInfo[*rpc.RequestAndResults]) error {
+		if time.Now().Before(info.Data.ScheduledAt) {
+			t.Errorf("request %v was dispatched before it was due", info.Block)
+		}
+		requests = append(requests, info.Data)
+		return nil
+	}
+
+	timed := MakeTimedRpcRequestProvider(provider, 100, logger.NewLogger("critical", "timed-provider-test"))
+	if err := timed.Run(0, 10, consumer); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	// requests sharing a timestamp are spread until the next timestamp, one second is 10ms at speed-up 100
+	want := []time.Duration{0, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+	if len(requests) != len(want) {
+		t.Fatalf("unexpected number of requests; got: %v, want: %v", len(requests), len(want))
+	}
+	for i, req := range requests {
+		if got := req.ScheduledAt.Sub(requests[0].ScheduledAt); got != want[i] {
+			t.Errorf("unexpected schedule of request %v; got: %v, want: %v", i, got, want[i])
+		}
+	}
+}
+
+func TestTimedRpcRequestProvider_LongPausesAreCollapsed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[*rpc.RequestAndResults](ctrl)
+	log := logger.NewMockLogger(ctrl)
+
+	provider.EXPECT().Run(0, 10, gomock.Any()).DoAndReturn(provideRequests(100, 1000, 1001))
+	log.EXPECT().Noticef("Collapsing %v pause in recording before block %v", 900*time.Second, 1)
+	log.EXPECT().Noticef(gomock.Any(), gomock.Any()).AnyTimes()
+
+	var requests []*rpc.RequestAndResults
+	consumer := func(info TransactionInfo[*rpc.RequestAndResults]) error {
+		requests = append(requests, info.Data)
+		return nil
+	}
+
+	timed := MakeTimedRpcRequestProvider(provider, 1000, log)
+	if err := timed.Run(0, 10, consumer); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+
+	if got, want := requests[1].ScheduledAt.Sub(requests[0].ScheduledAt), time.Millisecond; got != want {
+		t.Errorf("unexpected schedule after pause; got: %v, want: %v", got, want)
+	}
+	if got, want := requests[2].ScheduledAt.Sub(requests[1].ScheduledAt), time.Millisecond; got != want {
+		t.Errorf("unexpected schedule after pause; got: %v, want: %v", got, want)
+	}
+}
+
+func TestTimedRpcRequestProvider_LagBehindScheduleIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[*rpc.RequestAndResults](ctrl)
+	log := logger.NewMockLogger(ctrl)
+
+	// all requests are due at once, the slow first request delays the others
+	provider.EXPECT().Run(0, 10, gomock.Any()).DoAndReturn(provideRequests(100, 101, 102))
+	log.EXPECT().Warningf("Archive cannot keep up with the recorded request rate; replay is %v behind schedule", gomock.Any())
+	log.EXPECT().Noticef(gomock.Any(), uint64(3), uint64(2), gomock.Any(), lagWarningThreshold, gomock.Any())
+
+	consumer := func(info TransactionInfo[*rpc.RequestAndResults]) error {
+		if info.Block == 0 {
+			time.Sleep(lagWarningThreshold + 10*time.Millisecond)
+		}
+		return nil
+	}
+
+	timed := MakeTimedRpcRequestProvider(provider, 1e9, log)
+	if err := timed.Run(0, 10, consumer); err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+}
+
+func TestTimedRpcRequestProvider_ConsumerErrorIsReturned(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	provider := NewMockProvider[*rpc.RequestAndResults](ctrl)
+	provider.EXPECT().Run(0, 10, gomock.Any()).DoAndReturn(provideRequests(100, 101))
+
+	want := errors.New("consumer error")
+	consumer := func(info TransactionInfo[*rpc.RequestAndResults]) error {
+		return want
+	}
+
+	timed := MakeTimedRpcRequestProvider(provider, 1, logger.NewLogger("critical", "timed-provider-test"))
+	if err := timed.Run(0, 10, consumer); !errors.Is(err, want) {
+		t.Errorf("unexpected error; got: %v, want: %v", err, want)
+	}
+}
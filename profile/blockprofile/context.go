@@ -22,6 +22,7 @@ import (
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/profile/graphutil"
+	"github.com/Fantom-foundation/Aida/state/proxy"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/ethereum/go-ethereum/common"
 )
@@ -69,6 +70,17 @@ type Context struct {
 
 	gasTransactions []uint64 // gas used for transactions
 	gasBlock        uint64   // gas used for a block
+
+	// slot-level book-keeping, only used if state accesses are recorded
+	slotLevel          bool                         // true if state accesses of transactions were recorded
+	txReads            []proxy.AccessSet            // state read by a transaction
+	txWrites           []proxy.AccessSet            // state modified by a transaction
+	txDependenciesSlot graphutil.StrictPartialOrder // transaction dependencies derived from state accesses
+	tCompletionSlot    TxTime                       // earliest completion time of a transaction derived from state accesses
+	tCriticalSlot      time.Duration                // critical path runtime derived from state accesses
+	numConflictsAddr   int                          // number of transaction pairs sharing an address
+	numConflictsRead   int                          // number of transaction pairs sharing only read state
+	numConflictsWrite  int                          // number of transaction pairs where one modifies state accessed by the other
 }
 
 var (
@@ -205,20 +217,74 @@ func (ctx *Context) RecordTransaction(state executor.State[txcontext.TxContext],
 	return nil
 }
 
+// RecordTransactionWithAccesses records a transaction like RecordTransaction and in addition derives
+// its dependencies from the state it read and modified. Only transactions where one modifies a part
+// of the state accessed by the other are dependent; transactions reading the same state are not.
+func (ctx *Context) RecordTransactionWithAccesses(state executor.State[txcontext.TxContext], tTransaction time.Duration, reads, writes proxy.AccessSet) error {
+	if err := ctx.RecordTransaction(state, tTransaction); err != nil {
+		return err
+	}
+	overheadTimer := time.Now()
+
+	ctx.slotLevel = true
+	current := ctx.n - 1
+	tEarliest := time.Duration(0)
+	dependentOn := graphutil.OrdinalSet{}
+	for i := 0; i < current; i++ {
+		if interfere(ctx.txAddresses[current], ctx.txAddresses[i]) {
+			ctx.numConflictsAddr++
+		}
+		if writes.Intersects(ctx.txWrites[i]) || writes.Intersects(ctx.txReads[i]) || reads.Intersects(ctx.txWrites[i]) {
+			ctx.numConflictsWrite++
+			if tEarliest < ctx.tCompletionSlot[i] {
+				tEarliest = ctx.tCompletionSlot[i]
+			}
+			dependentOn[i] = struct{}{}
+			for j := range ctx.txDependenciesSlot[i] {
+				dependentOn[j] = struct{}{}
+			}
+		} else if reads.Intersects(ctx.txReads[i]) {
+			ctx.numConflictsRead++
+		}
+	}
+
+	ctx.tCompletionSlot = append(ctx.tCompletionSlot, tEarliest+tTransaction)
+	if ctx.tCriticalSlot < tEarliest+tTransaction {
+		ctx.tCriticalSlot = tEarliest + tTransaction
+	}
+	ctx.txDependenciesSlot = append(ctx.txDependenciesSlot, dependentOn)
+	ctx.txReads = append(ctx.txReads, reads)
+	ctx.txWrites = append(ctx.txWrites, writes)
+
+	ctx.tOverheads += time.Since(overheadTimer)
+	return nil
+}
+
 // ProfileData for a block.
 type ProfileData struct {
-	curBlock        uint64   // current block number
-	numTx           int      // number of transactions
-	tBlock          int64    // block runtime
-	tSequential     int64    // total transaction runtime
-	tCritical       int64    // critical path runtime for transactions
-	tCommit         int64    // commit runtime
-	tTransactions   []int64  // runtime per transaction
-	tTypes          []TxType // a list of transaction type
-	speedup         float64  // speedup value for experiment
-	ubNumProc       int64    // upper bound on the number of processors (i.e. width of task graph)
-	gasTransactions []uint64 // gas consumption per transaction
-	gasBlock        uint64   // gas consumption of block
+	curBlock        uint64           // current block number
+	numTx           int              // number of transactions
+	tBlock          int64            // block runtime
+	tSequential     int64            // total transaction runtime
+	tCritical       int64            // critical path runtime for transactions
+	tCommit         int64            // commit runtime
+	tTransactions   []int64          // runtime per transaction
+	tTypes          []TxType         // a list of transaction type
+	speedup         float64          // speedup value for experiment
+	ubNumProc       int64            // upper bound on the number of processors (i.e. width of task graph)
+	gasTransactions []uint64         // gas consumption per transaction
+	gasBlock        uint64           // gas consumption of block
+	slot            *SlotProfileData // slot-level estimates, nil if state accesses were not recorded
+}
+
+// SlotProfileData contains the estimates of a block derived from the state accessed by its transactions.
+type SlotProfileData struct {
+	tCritical         int64   // critical path runtime for transactions
+	speedup           float64 // speedup value for experiment
+	ubNumProc         int64   // upper bound on the number of processors
+	numConflictsAddr  int     // number of transaction pairs sharing an address
+	numConflictsRead  int     // number of transaction pairs sharing only read state
+	numConflictsWrite int     // number of transaction pairs where one modifies state accessed by the other
 }
 
 // GetProfileData produces a profile record for the profiling database.
@@ -272,6 +338,16 @@ func (ctx *Context) GetProfileData(curBlock uint64, tBlock time.Duration) (*Prof
 		gasTransactions: gasTransactions,
 		gasBlock:        ctx.gasBlock,
 	}
+	if ctx.slotLevel {
+		data.slot = &SlotProfileData{
+			tCritical:         ctx.tCriticalSlot.Nanoseconds(),
+			speedup:           float64(tBlock) / float64(tCommit+ctx.tCriticalSlot),
+			ubNumProc:         int64(len(graphutil.MinChainCover(ctx.txDependenciesSlot))),
+			numConflictsAddr:  ctx.numConflictsAddr,
+			numConflictsRead:  ctx.numConflictsRead,
+			numConflictsWrite: ctx.numConflictsWrite,
+		}
+	}
 	return &data, nil
 }
 
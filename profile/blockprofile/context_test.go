@@ -25,6 +25,7 @@ import (
 
 	"github.com/Fantom-foundation/Aida/executor"
 	"github.com/Fantom-foundation/Aida/profile/graphutil"
+	"github.com/Fantom-foundation/Aida/state/proxy"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	substatecontext "github.com/Fantom-foundation/Aida/txcontext/substate"
 	"github.com/ethereum/go-ethereum/common"
@@ -545,3 +546,69 @@ func TestGetTransactionType(t *testing.T) {
 		t.Errorf("incorrect transaction type, got: %v, expected %v", TypeLabel[tt], TypeLabel[MaintenanceTx])
 	}
 }
+
+// TestRecordTransactionWithAccesses tests that read-only sharing does not create slot-level dependencies
+func TestRecordTransactionWithAccesses(t *testing.T) {
+	ctx := NewContext()
+	router := common.HexToAddress("0xFC00FACE00000000000000000000000000000010")
+	makeTx := func(from common.Address) executor.State[txcontext.TxContext] {
+		return executor.State[txcontext.TxContext]{
+			Data: substatecontext.NewTxContext(&substate.Substate{
+				InputAlloc:  substate.SubstateAlloc{router: &substate.SubstateAccount{}},
+				OutputAlloc: substate.SubstateAlloc{router: &substate.SubstateAccount{}},
+				Message:     &substate.SubstateMessage{From: from, To: &router},
+				Result:      &substate.SubstateResult{GasUsed: 21000},
+			}),
+		}
+	}
+	slot := func(key byte) proxy.StateAccess {
+		return proxy.StateAccess{Kind: proxy.StorageAccess, Address: router, Key: common.Hash{key}}
+	}
+
+	// tx 0 and 1 only read the same slot, tx 2 writes it
+	steps := []struct {
+		reads, writes proxy.AccessSet
+	}{
+		{proxy.AccessSet{slot(1): {}}, proxy.AccessSet{slot(2): {}}},
+		{proxy.AccessSet{slot(1): {}}, proxy.AccessSet{slot(3): {}}},
+		{proxy.AccessSet{}, proxy.AccessSet{slot(1): {}}},
+	}
+	for i, step := range steps {
+		from := common.Address{byte(i + 1)}
+		if err := ctx.RecordTransactionWithAccesses(makeTx(from), time.Duration(10), step.reads, step.writes); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if ctx.tCritical != 30 {
+		t.Errorf("unexpected address-level critical path; got: %v, want: 30", ctx.tCritical)
+	}
+	if ctx.tCriticalSlot != 20 {
+		t.Errorf("unexpected slot-level critical path; got: %v, want: 20", ctx.tCriticalSlot)
+	}
+	if ctx.numConflictsAddr != 3 || ctx.numConflictsRead != 1 || ctx.numConflictsWrite != 2 {
+		t.Errorf("unexpected conflicts; addr: %v, read: %v, write: %v", ctx.numConflictsAddr, ctx.numConflictsRead, ctx.numConflictsWrite)
+	}
+	if len(ctx.txDependenciesSlot[1]) != 0 || len(ctx.txDependenciesSlot[2]) != 2 {
+		t.Errorf("unexpected slot-level dependencies: %v", ctx.txDependenciesSlot)
+	}
+
+	pd, err := ctx.GetProfileData(0, time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pd.slot == nil || pd.slot.ubNumProc != 2 || pd.ubNumProc != 1 || pd.slot.speedup <= pd.speedup {
+		t.Errorf("unexpected slot-level profile data: %+v", pd.slot)
+	}
+}
+
+// TestGetProfileDataWithoutAccesses tests that slot-level data are only produced if accesses were recorded
+func TestGetProfileDataWithoutAccesses(t *testing.T) {
+	pd, err := NewContext().GetProfileData(0, time.Duration(100))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pd.slot != nil {
+		t.Errorf("slot-level data must not be produced without recorded accesses")
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package blockprofile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/profile/graphutil"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// GraphNode is a transaction of a block dependency graph.
+type GraphNode struct {
+	Tx       int           `json:"tx"`       // index of the transaction in the block
+	Type     string        `json:"type"`     // transaction type label
+	Duration time.Duration `json:"duration"` // runtime of the transaction in nanoseconds
+	Gas      uint64        `json:"gas"`      // gas used by the transaction
+}
+
+// GraphEdge is a dependency between two transactions of a block. The
+// transaction To cannot start before transaction From has completed.
+type GraphEdge struct {
+	From      int              `json:"from"`      // index of the earlier transaction
+	To        int              `json:"to"`        // index of the later transaction
+	Addresses []common.Address `json:"addresses"` // addresses used by both transactions
+}
+
+// BlockGraph is the transaction dependency graph of a block. Edges connect
+// transactions using a common address, i.e. the graph is not transitively reduced.
+type BlockGraph struct {
+	Block uint64      `json:"block"`
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// GetBlockGraph produces the dependency graph of all transactions recorded so far.
+func (ctx *Context) GetBlockGraph(curBlock uint64) *BlockGraph {
+	graph := &BlockGraph{
+		Block: curBlock,
+		Nodes: make([]GraphNode, 0, ctx.n),
+		Edges: []GraphEdge{},
+	}
+	for i := 0; i < ctx.n; i++ {
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			Tx:       i,
+			Type:     TypeLabel[ctx.tTypes[i]],
+			Duration: ctx.tTransactions[i],
+			Gas:      ctx.gasTransactions[i],
+		})
+		for j := 0; j < i; j++ {
+			if shared := sharedAddresses(ctx.txAddresses[j], ctx.txAddresses[i]); len(shared) > 0 {
+				graph.Edges = append(graph.Edges, GraphEdge{From: j, To: i, Addresses: shared})
+			}
+		}
+	}
+	return graph
+}
+
+// sharedAddresses returns the sorted intersection of two address sets.
+func sharedAddresses(u, v AddressSet) []common.Address {
+	if len(u) > len(v) {
+		u, v = v, u
+	}
+	var shared []common.Address
+	for addr := range u {
+		if _, ok := v[addr]; ok {
+			shared = append(shared, addr)
+		}
+	}
+	sort.Slice(shared, func(i, j int) bool {
+		return bytes.Compare(shared[i][:], shared[j][:]) < 0
+	})
+	return shared
+}
+
+// SequentialTime returns the total runtime of all transactions.
+func (g *BlockGraph) SequentialTime() time.Duration {
+	var total time.Duration
+	for _, node := range g.Nodes {
+		total += node.Duration
+	}
+	return total
+}
+
+// CriticalPath returns the runtime and the transactions of the longest path
+// through the graph, i.e. the runtime of the block with unbounded parallelism.
+func (g *BlockGraph) CriticalPath() (time.Duration, []int) {
+	n := len(g.Nodes)
+	if n == 0 {
+		return 0, nil
+	}
+	predecessors := make([][]int, n)
+	for _, edge := range g.Edges {
+		predecessors[edge.To] = append(predecessors[edge.To], edge.From)
+	}
+
+	// nodes are topologically ordered by their transaction index
+	completion := make([]time.Duration, n)
+	previous := make([]int, n)
+	last := 0
+	for i := 0; i < n; i++ {
+		previous[i] = -1
+		var earliest time.Duration
+		for _, j := range predecessors[i] {
+			if completion[j] > earliest {
+				earliest = completion[j]
+				previous[i] = j
+			}
+		}
+		completion[i] = earliest + g.Nodes[i].Duration
+		if completion[i] > completion[last] {
+			last = i
+		}
+	}
+
+	var path []int
+	for i := last; i >= 0; i = previous[i] {
+		path = append([]int{i}, path...)
+	}
+	return completion[last], path
+}
+
+// Speedup returns the theoretical speedup of running the transactions of the
+// block in parallel with unbounded parallelism over running them sequentially.
+func (g *BlockGraph) Speedup() float64 {
+	critical, _ := g.CriticalPath()
+	if critical == 0 {
+		return 1
+	}
+	return float64(g.SequentialTime()) / float64(critical)
+}
+
+// Width returns the largest number of transactions which can run independently.
+func (g *BlockGraph) Width() int {
+	predecessors := make([][]int, len(g.Nodes))
+	for _, edge := range g.Edges {
+		predecessors[edge.To] = append(predecessors[edge.To], edge.From)
+	}
+	// the minimum chain cover requires the transitive closure of the dependencies
+	order := make(graphutil.StrictPartialOrder, len(g.Nodes))
+	for i := range order {
+		order[i] = graphutil.OrdinalSet{}
+		for _, j := range predecessors[i] {
+			order[i][j] = struct{}{}
+			for k := range order[j] {
+				order[i][k] = struct{}{}
+			}
+		}
+	}
+	return len(graphutil.MinChainCover(order))
+}
+
+// WriteJson writes the graph in JSON format.
+func (g *BlockGraph) WriteJson(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(g); err != nil {
+		return fmt.Errorf("cannot encode graph of block %v; %w", g.Block, err)
+	}
+	return nil
+}
+
+// WriteDot writes the graph in the DOT format of Graphviz.
+func (g *BlockGraph) WriteDot(w io.Writer) error {
+	_, critical := g.CriticalPath()
+	onPath := make(map[int]bool, len(critical))
+	for _, i := range critical {
+		onPath[i] = true
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "digraph block_%d {\n", g.Block)
+	fmt.Fprintf(buf, "\tnode [shape=box];\n")
+	for _, node := range g.Nodes {
+		color := ""
+		if onPath[node.Tx] {
+			color = ", color=red"
+		}
+		fmt.Fprintf(buf, "\ttx%d [label=\"tx %d\\n%v\\n%v\\ngas %d\"%v];\n", node.Tx, node.Tx, node.Type, node.Duration, node.Gas, color)
+	}
+	for _, edge := range g.Edges {
+		labels := make([]string, 0, len(edge.Addresses))
+		for _, addr := range edge.Addresses {
+			labels = append(labels, addr.Hex())
+		}
+		fmt.Fprintf(buf, "\ttx%d -> tx%d [tooltip=%q];\n", edge.From, edge.To, fmt.Sprint(labels))
+	}
+	fmt.Fprintf(buf, "}\n")
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("cannot write graph of block %v; %w", g.Block, err)
+	}
+	return nil
+}
+
+// ReadBlockGraph reads a graph in JSON format.
+func ReadBlockGraph(r io.Reader) (*BlockGraph, error) {
+	graph := new(BlockGraph)
+	if err := json.NewDecoder(r).Decode(graph); err != nil {
+		return nil, fmt.Errorf("cannot decode block graph; %w", err)
+	}
+	for _, edge := range graph.Edges {
+		if edge.From < 0 || edge.From >= edge.To || edge.To >= len(graph.Nodes) {
+			return nil, fmt.Errorf("invalid edge %v -> %v in graph of block %v", edge.From, edge.To, graph.Block)
+		}
+	}
+	return graph, nil
+}
+
+// BlockGraphFile returns the path of the file storing the graph of given block in given format.
+func BlockGraphFile(dir string, block uint64, format string) string {
+	return filepath.Join(dir, fmt.Sprintf("block_%d.%v", block, format))
+}
+
+// ExportBlockGraph writes the graph into given directory in all requested formats ("json" and/or "dot").
+func ExportBlockGraph(g *BlockGraph, dir string, formats []string) error {
+	for _, format := range formats {
+		var write func(io.Writer) error
+		switch format {
+		case "json":
+			write = g.WriteJson
+		case "dot":
+			write = g.WriteDot
+		default:
+			return fmt.Errorf("unknown graph format %v", format)
+		}
+		file, err := os.Create(BlockGraphFile(dir, g.Block, format))
+		if err != nil {
+			return fmt.Errorf("cannot create graph file; %w", err)
+		}
+		if err = write(file); err != nil {
+			file.Close()
+			return err
+		}
+		if err = file.Close(); err != nil {
+			return fmt.Errorf("cannot close graph file; %w", err)
+		}
+	}
+	return nil
+}
+
+// Distribution summarizes a sample of values.
+type Distribution struct {
+	Min  float64
+	Mean float64
+	P50  float64
+	P90  float64
+	P99  float64
+	Max  float64
+}
+
+// newDistribution computes the distribution of given values; the values are sorted in place.
+func newDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sort.Float64s(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	percentile := func(p int) float64 {
+		return values[(len(values)-1)*p/100]
+	}
+	return Distribution{
+		Min:  values[0],
+		Mean: sum / float64(len(values)),
+		P50:  percentile(50),
+		P90:  percentile(90),
+		P99:  percentile(99),
+		Max:  values[len(values)-1],
+	}
+}
+
+// GraphSummary collects critical path and speedup statistics over many block graphs.
+type GraphSummary struct {
+	Blocks       int   // number of summarized blocks
+	Transactions int   // number of summarized transactions
+	Edges        int   // number of summarized dependencies
+	sequential   int64 // total sequential runtime in nanoseconds
+	critical     int64 // total critical path runtime in nanoseconds
+
+	criticalTimes   []float64 // critical path runtime per block in nanoseconds
+	criticalLengths []float64 // number of transactions on the critical path per block
+	speedups        []float64 // theoretical speedup per block
+	widths          []float64 // largest number of independent transactions per block
+}
+
+// Add includes the graph of a block into the summary.
+func (s *GraphSummary) Add(g *BlockGraph) {
+	critical, path := g.CriticalPath()
+	sequential := g.SequentialTime()
+
+	s.Blocks++
+	s.Transactions += len(g.Nodes)
+	s.Edges += len(g.Edges)
+	s.sequential += sequential.Nanoseconds()
+	s.critical += critical.Nanoseconds()
+
+	s.criticalTimes = append(s.criticalTimes, float64(critical.Nanoseconds()))
+	s.criticalLengths = append(s.criticalLengths, float64(len(path)))
+	s.speedups = append(s.speedups, g.Speedup())
+	s.widths = append(s.widths, float64(g.Width()))
+}
+
+// CriticalTime returns the distribution of critical path runtimes in nanoseconds.
+func (s *GraphSummary) CriticalTime() Distribution {
+	return newDistribution(s.criticalTimes)
+}
+
+// CriticalLength returns the distribution of the number of transactions on critical paths.
+func (s *GraphSummary) CriticalLength() Distribution {
+	return newDistribution(s.criticalLengths)
+}
+
+// Speedup returns the distribution of theoretical speedups of blocks.
+func (s *GraphSummary) Speedup() Distribution {
+	return newDistribution(s.speedups)
+}
+
+// Width returns the distribution of the largest number of independent transactions of blocks.
+func (s *GraphSummary) Width() Distribution {
+	return newDistribution(s.widths)
+}
+
+// TotalSpeedup returns the theoretical speedup of the whole block range.
+func (s *GraphSummary) TotalSpeedup() float64 {
+	if s.critical == 0 {
+		return 1
+	}
+	return float64(s.sequential) / float64(s.critical)
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package blockprofile
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// makeTestContext creates a context with four transactions where transactions 0 -> 1 -> 3 share
+// addresses and transaction 2 is independent.
+func makeTestContext() *Context {
+	a, b, c, d := common.Address{1}, common.Address{2}, common.Address{3}, common.Address{4}
+	ctx := NewContext()
+	for i, addresses := range []AddressSet{
+		{a: {}},
+		{a: {}, b: {}},
+		{c: {}},
+		{b: {}, d: {}},
+	} {
+		ctx.txAddresses = append(ctx.txAddresses, addresses)
+		ctx.tTransactions = append(ctx.tTransactions, time.Duration(10*(i+1)))
+		ctx.tTypes = append(ctx.tTypes, CallTx)
+		ctx.gasTransactions = append(ctx.gasTransactions, uint64(21000+i))
+		ctx.n++
+	}
+	return ctx
+}
+
+func TestBlockGraph_EdgesContainSharedAddresses(t *testing.T) {
+	graph := makeTestContext().GetBlockGraph(5)
+
+	if got, want := len(graph.Nodes), 4; got != want {
+		t.Fatalf("unexpected number of nodes; got: %v, want: %v", got, want)
+	}
+	if graph.Nodes[3].Duration != 40 || graph.Nodes[3].Gas != 21003 || graph.Nodes[3].Type != "call" {
+		t.Errorf("unexpected node: %+v", graph.Nodes[3])
+	}
+	want := []GraphEdge{
+		{From: 0, To: 1, Addresses: []common.Address{{1}}},
+		{From: 1, To: 3, Addresses: []common.Address{{2}}},
+	}
+	if len(graph.Edges) != len(want) {
+		t.Fatalf("unexpected edges: %v", graph.Edges)
+	}
+	for i := range want {
+		got := graph.Edges[i]
+		if got.From != want[i].From || got.To != want[i].To || len(got.Addresses) != 1 || got.Addresses[0] != want[i].Addresses[0] {
+			t.Errorf("unexpected edge %d; got: %v, want: %v", i, got, want[i])
+		}
+	}
+}
+
+func TestBlockGraph_CriticalPathAndSpeedup(t *testing.T) {
+	graph := makeTestContext().GetBlockGraph(5)
+
+	critical, path := graph.CriticalPath()
+	if got, want := critical, time.Duration(10+20+40); got != want {
+		t.Errorf("unexpected critical path time; got: %v, want: %v", got, want)
+	}
+	if got, want := len(path), 3; got != want || path[0] != 0 || path[1] != 1 || path[2] != 3 {
+		t.Errorf("unexpected critical path; got: %v", path)
+	}
+	if got, want := graph.Speedup(), 100.0/70.0; got != want {
+		t.Errorf("unexpected speedup; got: %v, want: %v", got, want)
+	}
+	if got, want := graph.Width(), 2; got != want {
+		t.Errorf("unexpected width; got: %v, want: %v", got, want)
+	}
+}
+
+func TestBlockGraph_EmptyGraph(t *testing.T) {
+	graph := NewContext().GetBlockGraph(1)
+	if critical, path := graph.CriticalPath(); critical != 0 || len(path) != 0 {
+		t.Errorf("unexpected critical path of empty graph; %v %v", critical, path)
+	}
+	if got := graph.Speedup(); got != 1 {
+		t.Errorf("unexpected speedup of empty graph; got: %v", got)
+	}
+}
+
+func TestBlockGraph_JsonRoundTrip(t *testing.T) {
+	graph := makeTestContext().GetBlockGraph(5)
+
+	buf := new(bytes.Buffer)
+	if err := graph.WriteJson(buf); err != nil {
+		t.Fatalf("cannot write graph; %v", err)
+	}
+	restored, err := ReadBlockGraph(buf)
+	if err != nil {
+		t.Fatalf("cannot read graph; %v", err)
+	}
+	if restored.Block != 5 || len(restored.Nodes) != 4 || len(restored.Edges) != 2 || restored.Edges[1].Addresses[0] != (common.Address{2}) {
+		t.Errorf("unexpected restored graph: %+v", restored)
+	}
+}
+
+func TestBlockGraph_ReadRejectsInvalidEdges(t *testing.T) {
+	_, err := ReadBlockGraph(strings.NewReader(`{"block":1,"nodes":[{"tx":0},{"tx":1}],"edges":[{"from":1,"to":0}]}`))
+	if err == nil {
+		t.Errorf("backward edge must be rejected")
+	}
+}
+
+func TestBlockGraph_ExportWritesAllFormats(t *testing.T) {
+	dir := t.TempDir()
+	graph := makeTestContext().GetBlockGraph(7)
+	if err := ExportBlockGraph(graph, dir, []string{"json", "dot"}); err != nil {
+		t.Fatalf("cannot export graph; %v", err)
+	}
+	dot, err := os.ReadFile(filepath.Join(dir, "block_7.dot"))
+	if err != nil {
+		t.Fatalf("cannot read dot file; %v", err)
+	}
+	if !strings.HasPrefix(string(dot), "digraph block_7 {") || !strings.Contains(string(dot), "tx1 -> tx3") {
+		t.Errorf("unexpected dot content:\n%s", dot)
+	}
+	if _, err = os.Stat(BlockGraphFile(dir, 7, "json")); err != nil {
+		t.Errorf("json file was not written; %v", err)
+	}
+	if err = ExportBlockGraph(graph, dir, []string{"svg"}); err == nil {
+		t.Errorf("unknown format must be rejected")
+	}
+}
+
+func TestGraphSummary_DistributionsAreComputed(t *testing.T) {
+	summary := new(GraphSummary)
+	summary.Add(makeTestContext().GetBlockGraph(1))
+	summary.Add(NewContext().GetBlockGraph(2))
+
+	if summary.Blocks != 2 || summary.Transactions != 4 || summary.Edges != 2 {
+		t.Errorf("unexpected totals: %+v", summary)
+	}
+	speedup := summary.Speedup()
+	if speedup.Min != 1 || speedup.Max != 100.0/70.0 {
+		t.Errorf("unexpected speedup distribution: %+v", speedup)
+	}
+	if length := summary.CriticalLength(); length.Min != 0 || length.Max != 3 || length.Mean != 1.5 {
+		t.Errorf("unexpected critical path length distribution: %+v", length)
+	}
+	if got, want := summary.TotalSpeedup(), 100.0/70.0; got != want {
+		t.Errorf("unexpected total speedup; got: %v, want: %v", got, want)
+	}
+}
@@ -43,6 +43,15 @@ block, tx, txType, duration, gas
 ) VALUES (
 ?, ?, ?, ?, ?
 )
+`
+
+	// SQL statement for inserting a slot-level profile record of a new block
+	insertSlotSQL = `
+INSERT INTO blockSlotProfile (
+	block, tCritical, speedup, ubNumProc, numConflictsAddr, numConflictsRead, numConflictsWrite
+) VALUES (
+	?, ?, ?, ?, ?, ?, ?
+)
 `
 
 	// SQL statement for creating profiling tables
@@ -66,6 +75,28 @@ CREATE TABLE IF NOT EXISTS txProfile (
 	duration INTEGER,
 	gas INTEGER
 );
+CREATE TABLE IF NOT EXISTS blockSlotProfile (
+	block INTEGER,
+	tCritical INTEGER,
+	speedup FLOAT,
+	ubNumProc INTEGER,
+	numConflictsAddr INTEGER,
+	numConflictsRead INTEGER,
+	numConflictsWrite INTEGER
+);
+CREATE VIEW IF NOT EXISTS blockSpeedup AS
+SELECT
+	b.block,
+	b.tCritical AS tCriticalAddr,
+	s.tCritical AS tCriticalSlot,
+	b.speedup AS speedupAddr,
+	s.speedup AS speedupSlot,
+	b.ubNumProc AS ubNumProcAddr,
+	s.ubNumProc AS ubNumProcSlot,
+	s.numConflictsAddr,
+	s.numConflictsRead,
+	s.numConflictsWrite
+FROM blockProfile b JOIN blockSlotProfile s ON b.block = s.block;
 `
 )
 
@@ -74,6 +105,7 @@ type ProfileDB struct {
 	sql       *sql.DB       // Sqlite3 database
 	blockStmt *sql.Stmt     // Prepared insert statement for a block
 	txStmt    *sql.Stmt     // Prepared insert statement for a transaction
+	slotStmt  *sql.Stmt     // Prepared insert statement for slot-level data of a block
 	buffer    []ProfileData // record buffer
 }
 
@@ -97,11 +129,16 @@ func NewProfileDB(dbFile string) (*ProfileDB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare a SQL statement for tx profile; %v", err)
 	}
+	slotStmt, err := sqlDB.Prepare(insertSlotSQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare a SQL statement for slot profile; %v", err)
+	}
 
 	return &ProfileDB{
 		sql:       sqlDB,
 		blockStmt: blockStmt,
 		txStmt:    txStmt,
+		slotStmt:  slotStmt,
 		buffer:    make([]ProfileData, 0, bufferSize),
 	}, nil
 }
@@ -109,6 +146,7 @@ func NewProfileDB(dbFile string) (*ProfileDB, error) {
 // Close flushes buffers of profiling database and closes the profiling database.
 func (db *ProfileDB) Close() error {
 	defer func() {
+		db.slotStmt.Close()
 		db.txStmt.Close()
 		db.blockStmt.Close()
 		db.sql.Close()
@@ -146,6 +184,15 @@ func (db *ProfileDB) Flush() error {
 			_ = tx.Rollback()
 			return err
 		}
+		// write slot-level data
+		if slot := ProfileData.slot; slot != nil {
+			_, err = tx.Stmt(db.slotStmt).Exec(ProfileData.curBlock, slot.tCritical, slot.speedup, slot.ubNumProc,
+				slot.numConflictsAddr, slot.numConflictsRead, slot.numConflictsWrite)
+			if err != nil {
+				_ = tx.Rollback()
+				return err
+			}
+		}
 		// write transactions
 		for i, tTransaction := range ProfileData.tTransactions {
 			_, err = tx.Stmt(db.txStmt).Exec(ProfileData.curBlock, i, ProfileData.tTypes[i], tTransaction, ProfileData.gasTransactions[i])
@@ -164,8 +211,9 @@ func (db *ProfileDB) Flush() error {
 // DeleteByBlockRange deletes information for a block range; used prior insertion
 func (db *ProfileDB) DeleteByBlockRange(firstBlock, lastBlock uint64) (int64, error) {
 	const (
-		blockProfile = "blockProfile"
-		txProfile    = "txProfile"
+		blockProfile     = "blockProfile"
+		txProfile        = "txProfile"
+		blockSlotProfile = "blockSlotProfile"
 	)
 	var totalNumRows int64
 
@@ -174,7 +222,7 @@ func (db *ProfileDB) DeleteByBlockRange(firstBlock, lastBlock uint64) (int64, er
 		return 0, err
 	}
 
-	for _, table := range []string{blockProfile, txProfile} {
+	for _, table := range []string{blockProfile, txProfile, blockSlotProfile} {
 		deleteSql := fmt.Sprintf("DELETE FROM %s WHERE block >= %d AND block <= %d;", table, firstBlock, lastBlock)
 		res, err := db.sql.Exec(deleteSql)
 		if err != nil {
@@ -454,3 +454,50 @@ func TestFlushProfileData(t *testing.T) {
 	}
 	require.NoError(tx.Commit())
 }
+
+func TestFlushSlotProfileData(t *testing.T) {
+	require := require.New(t)
+	dbFile := tempFile(require)
+	defer os.Remove(dbFile)
+	db, err := NewProfileDB(dbFile)
+	require.NoError(err)
+
+	require.NoError(db.Add(ProfileData{curBlock: 10, tCritical: 30, speedup: 1.5, ubNumProc: 1}))
+	require.NoError(db.Add(ProfileData{
+		curBlock:  11,
+		tCritical: 30,
+		speedup:   1.5,
+		ubNumProc: 1,
+		slot: &SlotProfileData{
+			tCritical:         20,
+			speedup:           2.0,
+			ubNumProc:         2,
+			numConflictsAddr:  3,
+			numConflictsRead:  1,
+			numConflictsWrite: 2,
+		},
+	}))
+	require.NoError(db.Flush())
+
+	// only blocks with slot-level data are listed side by side
+	rows, err := db.sql.Query("SELECT block, speedupAddr, speedupSlot, numConflictsRead FROM blockSpeedup")
+	require.NoError(err)
+	count := 0
+	for rows.Next() {
+		var block, numConflictsRead int64
+		var speedupAddr, speedupSlot float64
+		require.NoError(rows.Scan(&block, &speedupAddr, &speedupSlot, &numConflictsRead))
+		require.Equal(int64(11), block)
+		require.Equal(1.5, speedupAddr)
+		require.Equal(2.0, speedupSlot)
+		require.Equal(int64(1), numConflictsRead)
+		count++
+	}
+	require.NoError(rows.Close())
+	require.Equal(1, count)
+
+	numRows, err := db.DeleteByBlockRange(11, 11)
+	require.NoError(err)
+	require.Equal(int64(2), numRows)
+	require.NoError(db.Close())
+}
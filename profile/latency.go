@@ -0,0 +1,122 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package profile
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// latencySubBucketBits is the number of bits splitting each power of two into buckets
+// of a LatencyHistogram, bounding the relative error of percentiles to 1/2^latencySubBucketBits.
+const latencySubBucketBits = 3
+
+const latencySubBuckets = 1 << latencySubBucketBits
+
+// LatencyHistogram records durations in logarithmic buckets of nanoseconds. Its zero value
+// is an empty histogram ready to use. It is not safe for concurrent use.
+type LatencyHistogram struct {
+	buckets []uint64
+	count   uint64
+	max     time.Duration
+}
+
+// Add records a duration, negative durations are recorded as zero.
+func (h *LatencyHistogram) Add(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	i := latencyBucket(uint64(d))
+	if i >= len(h.buckets) {
+		buckets := make([]uint64, i+1)
+		copy(buckets, h.buckets)
+		h.buckets = buckets
+	}
+	h.buckets[i]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// Count returns the number of recorded durations.
+func (h *LatencyHistogram) Count() uint64 {
+	return h.count
+}
+
+// Max returns the longest recorded duration.
+func (h *LatencyHistogram) Max() time.Duration {
+	return h.max
+}
+
+// LatencyBucket is a non-empty bucket of a LatencyHistogram.
+type LatencyBucket struct {
+	UpperBound time.Duration // largest duration contained in the bucket
+	Count      uint64
+}
+
+// Buckets returns non-empty buckets in ascending order.
+func (h *LatencyHistogram) Buckets() []LatencyBucket {
+	var res []LatencyBucket
+	for i, n := range h.buckets {
+		if n > 0 {
+			res = append(res, LatencyBucket{UpperBound: time.Duration(latencyBucketUpperBound(i)), Count: n})
+		}
+	}
+	return res
+}
+
+// Percentile returns an upper bound of the p-th percentile of recorded durations, p ranging from 0 to 100.
+func (h *LatencyHistogram) Percentile(p float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p / 100 * float64(h.count)))
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, n := range h.buckets {
+		seen += n
+		if seen >= rank {
+			return min(time.Duration(latencyBucketUpperBound(i)), h.max)
+		}
+	}
+	return h.max
+}
+
+// latencyBucket returns the index of the bucket containing v. Values smaller
+// than latencySubBuckets have their own bucket, larger values are bucketed by
+// their most significant latencySubBucketBits+1 bits.
+func latencyBucket(v uint64) int {
+	if v < latencySubBuckets {
+		return int(v)
+	}
+	shift := bits.Len64(v) - latencySubBucketBits - 1
+	return (shift+1)*latencySubBuckets + int(v>>shift) - latencySubBuckets
+}
+
+// latencyBucketUpperBound returns the largest value contained in the i-th bucket.
+func latencyBucketUpperBound(i int) uint64 {
+	if i < latencySubBuckets {
+		return uint64(i)
+	}
+	shift := i/latencySubBuckets - 1
+	lower := uint64(i%latencySubBuckets+latencySubBuckets) << shift
+	return lower + 1<<shift - 1
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package profile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_EmptyHistogramReportsZero(t *testing.T) {
+	var h LatencyHistogram
+	if got := h.Percentile(50); got != 0 {
+		t.Errorf("unexpected percentile; got: %v, want: 0", got)
+	}
+	if h.Count() != 0 || h.Max() != 0 {
+		t.Errorf("unexpected count or max; got: %v, %v", h.Count(), h.Max())
+	}
+}
+
+func TestLatencyHistogram_PercentilesAreWithinRelativeError(t *testing.T) {
+	var h LatencyHistogram
+	for i := 1; i <= 1000; i++ {
+		h.Add(time.Duration(i) * time.Millisecond)
+	}
+
+	if got, want := h.Count(), uint64(1000); got != want {
+		t.Errorf("unexpected count; got: %v, want: %v", got, want)
+	}
+	if got, want := h.Max(), time.Second; got != want {
+		t.Errorf("unexpected max; got: %v, want: %v", got, want)
+	}
+	for _, p := range []float64{1, 50, 90, 99} {
+		want := time.Duration(p*10) * time.Millisecond
+		got := h.Percentile(p)
+		if got < want || got > want+want/latencySubBuckets {
+			t.Errorf("unexpected p%v; got: %v, want: %v within %v%%", p, got, want, 100/latencySubBuckets)
+		}
+	}
+	if got, want := h.Percentile(100), time.Second; got != want {
+		t.Errorf("unexpected p100; got: %v, want: %v", got, want)
+	}
+}
+
+func TestLatencyHistogram_NegativeDurationsAreRecordedAsZero(t *testing.T) {
+	var h LatencyHistogram
+	h.Add(-time.Second)
+	if got := h.Percentile(100); got != 0 {
+		t.Errorf("unexpected percentile; got: %v, want: 0", got)
+	}
+}
+
+func TestLatencyHistogram_BucketsAreContiguous(t *testing.T) {
+	for v := uint64(0); v < 1<<16; v++ {
+		i := latencyBucket(v)
+		if v > latencyBucketUpperBound(i) || (i > 0 && v <= latencyBucketUpperBound(i-1)) {
+			t.Fatalf("value %v is not within bucket %v", v, i)
+		}
+	}
+}
+
+func TestLatencyHistogram_BucketsContainRecordedDurations(t *testing.T) {
+	var h LatencyHistogram
+	h.Add(3)
+	h.Add(3)
+	h.Add(100)
+
+	want := []LatencyBucket{{UpperBound: 3, Count: 2}, {UpperBound: 103, Count: 1}}
+	got := h.Buckets()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected buckets; got: %v, want: %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected bucket %v; got: %v, want: %v", i, got[i], want[i])
+		}
+	}
+}
@@ -17,6 +17,7 @@
 package rpc
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -33,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core"
 	eth "github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/params"
 	"github.com/status-im/keycard-go/hexutils"
 )
@@ -46,6 +48,7 @@ type EvmExecutor struct {
 	vmImpl    string
 	blockId   *big.Int
 	rules     opera.EconomyRules
+	tracer    vm.Tracer // optional tracer attached to the EVM
 }
 
 const maxGasLimit = 9995800     // used when request does not specify gas
@@ -108,6 +111,16 @@ func newTxArgs(params map[string]interface{}) ethapi.TransactionArgs {
 		args.Data = (*hexutil.Bytes)(&data)
 	}
 
+	if v, ok := params["accessList"]; ok && v != nil {
+		// access list is decoded by its json representation
+		if b, err := json.Marshal(v); err == nil {
+			accessList := new(eth.AccessList)
+			if err = json.Unmarshal(b, accessList); err == nil {
+				args.AccessList = accessList
+			}
+		}
+	}
+
 	return args
 }
 
@@ -141,6 +154,12 @@ func (e *EvmExecutor) newEVM(msg eth.Message, hashErr *error) *vm.EVM {
 	vmConfig = opera.DefaultVMConfig
 	vmConfig.NoBaseFee = true
 	vmConfig.InterpreterImpl = e.vmImpl
+	if e.tracer != nil {
+		vmConfig.Debug = true
+		vmConfig.Tracer = e.tracer
+		// only geth interpreter supports tracing
+		vmConfig.InterpreterImpl = "geth"
+	}
 
 	txCtx = evmcore.NewEVMTxContext(msg)
 
@@ -186,6 +205,105 @@ func (e *EvmExecutor) sendCall() (*evmcore.ExecutionResult, error) {
 
 }
 
+// applyMessage executes the request in the EvmExecutor with the given tracer attached to the EVM.
+// Unlike sendCall, a failed execution is not considered an error.
+func (e *EvmExecutor) applyMessage(tracer vm.Tracer) (*evmcore.ExecutionResult, error) {
+	msg, err := e.args.ToMessage(globalGasCap, e.rules.MinGasPrice)
+	if err != nil {
+		return nil, err
+	}
+
+	e.tracer = tracer
+	defer func() {
+		e.tracer = nil
+	}()
+
+	var hashErr error
+	evm := e.newEVM(msg, &hashErr)
+	res, err := evmcore.ApplyMessage(evm, msg, new(evmcore.GasPool).AddGas(math.MaxUint64))
+	if err != nil {
+		return nil, err
+	}
+	if hashErr != nil {
+		return nil, fmt.Errorf("cannot get state hash; %w", hashErr)
+	}
+	return res, nil
+}
+
+// sendTraceCall executes the call method in the EvmExecutor with a struct logger
+// and returns the structured logs of the execution.
+func (e *EvmExecutor) sendTraceCall(cfg *vm.LogConfig) (*ethapi.ExecutionResult, error) {
+	tracer := vm.NewStructLogger(cfg)
+	res, err := e.applyMessage(tracer)
+	if err != nil {
+		return nil, fmt.Errorf("tracing failed; %w", err)
+	}
+
+	// if the result contains a revert reason, return it
+	returnVal := fmt.Sprintf("%x", res.Return())
+	if len(res.Revert()) > 0 {
+		returnVal = fmt.Sprintf("%x", res.Revert())
+	}
+	return &ethapi.ExecutionResult{
+		Gas:         res.UsedGas,
+		Failed:      res.Failed(),
+		ReturnValue: returnVal,
+		StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
+	}, nil
+}
+
+// AccessListResult represents the result of the createAccessList method.
+type AccessListResult struct {
+	AccessList *eth.AccessList `json:"accessList"`
+	Error      string          `json:"error,omitempty"`
+	GasUsed    hexutil.Uint64  `json:"gasUsed"`
+}
+
+// sendCreateAccessList executes the call method in the EvmExecutor repeatedly until the
+// accounts and storage slots accessed by the call do not change anymore.
+func (e *EvmExecutor) sendCreateAccessList() (*AccessListResult, error) {
+	var from, to common.Address
+	if e.args.From != nil {
+		from = *e.args.From
+	}
+	if e.args.To != nil {
+		to = *e.args.To
+	} else {
+		to = crypto.CreateAddress(from, e.archive.GetNonce(from))
+	}
+	// precompiles do not need to be added to the access list
+	precompiles := vm.ActivePrecompiles(e.chainCfg.Rules(e.blockId))
+
+	var prevTracer *vm.AccessListTracer
+	if e.args.AccessList != nil {
+		prevTracer = vm.NewAccessListTracer(*e.args.AccessList, from, to, precompiles)
+	} else {
+		prevTracer = vm.NewAccessListTracer(nil, from, to, precompiles)
+	}
+	for {
+		accessList := prevTracer.AccessList()
+		e.args.AccessList = &accessList
+		tracer := vm.NewAccessListTracer(accessList, from, to, precompiles)
+
+		// every iteration starts from the original state
+		snapshot := e.archive.Snapshot()
+		res, err := e.applyMessage(tracer)
+		e.archive.RevertToSnapshot(snapshot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply transaction; %w", err)
+		}
+
+		if tracer.Equal(prevTracer) {
+			result := &AccessListResult{AccessList: &accessList, GasUsed: hexutil.Uint64(res.UsedGas)}
+			if res.Err != nil {
+				result.Error = res.Err.Error()
+			}
+			return result, nil
+		}
+		prevTracer = tracer
+	}
+}
+
 // sendEstimateGas executes estimateGas method in the EvmExecutor
 // It calculates how much gas would transaction need if it was executed
 func (e *EvmExecutor) sendEstimateGas() (hexutil.Uint64, error) {
@@ -18,19 +18,28 @@ package rpc
 
 import (
 	"encoding/binary"
+	"encoding/json"
+	"math/big"
 	"strings"
 	"unsafe"
 
 	"github.com/Fantom-foundation/Aida/state"
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/Fantom-foundation/go-opera/ethapi"
 	"github.com/Fantom-foundation/lachesis-base/common/littleendian"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // TODO FIX!
 const falsyContract = "0xe0c38b2a8d09aad53f1c67734b9a95e43d5981c0"
 
+// Execute replays the recorded request on the given archive. Methods which cannot be
+// replayed using the archive state alone (e.g. estimateGas or feeHistory, which is
+// calculated from block headers and included transactions) are not executed and nil is returned.
 func Execute(block uint64, rec *RequestAndResults, archive state.NonCommittableStateDB, cfg *utils.Config) txcontext.Result {
 	switch rec.Query.MethodBase {
 	case "getBalance":
@@ -44,7 +53,11 @@ func Execute(block uint64, rec *RequestAndResults, archive state.NonCommittableS
 		if rec.Timestamp == 0 {
 			return nil
 		}
-		evm := newEvmExecutor(block, archive, cfg, rec.Query.Params[0].(map[string]interface{}), rec.Timestamp)
+		args, ok := callArgs(rec)
+		if !ok {
+			return nil
+		}
+		evm := newEvmExecutor(block, archive, cfg, args, rec.Timestamp)
 		// calls to this contract are excluded for now,
 		// this contract causes issues in validation
 		if strings.Compare(falsyContract, strings.ToLower(evm.args.To.String())) == 0 {
@@ -61,12 +74,56 @@ func Execute(block uint64, rec *RequestAndResults, archive state.NonCommittableS
 	case "getStorageAt":
 		return executeGetStorageAt(rec.Query.Params, archive)
 
+	case "getProof":
+		return executeGetProof(rec.Query.Params, archive)
+
+	case "createAccessList":
+		if rec.Timestamp == 0 {
+			return nil
+		}
+		args, ok := callArgs(rec)
+		if !ok {
+			return nil
+		}
+		evm := newEvmExecutor(block, archive, cfg, args, rec.Timestamp)
+		return executeCreateAccessList(evm)
+
+	case "traceCall":
+		if rec.Timestamp == 0 {
+			return nil
+		}
+		args, ok := callArgs(rec)
+		if !ok {
+			return nil
+		}
+		logCfg, ok := traceLogConfig(rec)
+		if !ok {
+			return nil
+		}
+		evm := newEvmExecutor(block, archive, cfg, args, rec.Timestamp)
+		return executeTraceCall(evm, logCfg)
+
 	default:
 		break
 	}
 	return nil
 }
 
+// callArgs returns the transaction arguments of a call-like request. Requests with
+// malformed arguments cannot be executed, hence their validation is skipped.
+func callArgs(rec *RequestAndResults) (map[string]interface{}, bool) {
+	if len(rec.Query.Params) == 0 {
+		rec.SkipValidation = true
+		return nil, false
+	}
+	args, ok := rec.Query.Params[0].(map[string]interface{})
+	if !ok {
+		rec.SkipValidation = true
+		return nil, false
+	}
+	return args, true
+}
+
 // executeGetBalance request into given archive and send result to comparator
 func executeGetBalance(param interface{}, archive state.VmStateDB) *result {
 	address := common.HexToAddress(param.(string))
@@ -137,3 +194,105 @@ func executeGetStorageAt(params []interface{}, archive state.VmStateDB) *result
 		result: archive.GetState(address, hash).Bytes(),
 	}
 }
+
+// executeGetProof request into given archive and send result to comparator
+// Merkle proofs and storage roots depend on the trie of the recording node, hence
+// only account and storage values are provided.
+func executeGetProof(params []interface{}, archive state.VmStateDB) *result {
+	address := common.HexToAddress(params[0].(string))
+
+	var keys []interface{}
+	if len(params) > 1 {
+		keys, _ = params[1].([]interface{})
+	}
+
+	exists := archive.Exist(address)
+	codeHash := archive.GetCodeHash(address)
+	if !exists {
+		// the code hash of a non-existing account is the hash of an empty code
+		codeHash = crypto.Keccak256Hash(nil)
+	}
+
+	storage := make([]ethapi.StorageResult, len(keys))
+	for i, k := range keys {
+		key, _ := k.(string)
+		value := new(big.Int)
+		if exists {
+			value = archive.GetState(address, common.HexToHash(key)).Big()
+		}
+		storage[i] = ethapi.StorageResult{Key: key, Value: (*hexutil.Big)(value), Proof: []string{}}
+	}
+
+	b, err := json.Marshal(&ethapi.AccountResult{
+		Address:      address,
+		AccountProof: []string{},
+		Balance:      (*hexutil.Big)(archive.GetBalance(address)),
+		CodeHash:     codeHash,
+		Nonce:        hexutil.Uint64(archive.GetNonce(address)),
+		StorageProof: storage,
+	})
+	return &result{
+		result: b,
+		err:    err,
+	}
+}
+
+// executeCreateAccessList into EvmExecutor which creates access list of a transaction
+func executeCreateAccessList(evm *EvmExecutor) *result {
+	res, err := evm.sendCreateAccessList()
+	if err != nil {
+		return &result{err: err}
+	}
+
+	b, err := json.Marshal(res)
+	return &result{
+		gasUsed: uint64(res.GasUsed),
+		result:  b,
+		err:     err,
+	}
+}
+
+// traceLogConfig returns the struct logger configuration of a traceCall request. Calls using
+// other tracers or a malformed configuration are not executed, hence their validation is skipped.
+func traceLogConfig(rec *RequestAndResults) (*vm.LogConfig, bool) {
+	cfg := new(vm.LogConfig)
+	params := rec.Query.Params
+	if len(params) <= 2 || params[2] == nil {
+		return cfg, true
+	}
+
+	config, ok := params[2].(map[string]interface{})
+	if !ok {
+		rec.SkipValidation = true
+		return nil, false
+	}
+	if _, ok = config["tracer"]; ok {
+		rec.SkipValidation = true
+		return nil, false
+	}
+	// log config is decoded by its json representation
+	b, err := json.Marshal(config)
+	if err == nil {
+		err = json.Unmarshal(b, cfg)
+	}
+	if err != nil {
+		rec.SkipValidation = true
+		return nil, false
+	}
+	return cfg, true
+}
+
+// executeTraceCall into EvmExecutor which traces execution of a call using the struct logger.
+func executeTraceCall(evm *EvmExecutor, cfg *vm.LogConfig) *result {
+	res, err := evm.sendTraceCall(cfg)
+	if err != nil {
+		return &result{err: err}
+	}
+
+	b, err := json.Marshal(res)
+	return &result{
+		gasUsed: res.Gas,
+		result:  b,
+		err:     err,
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/go-opera/ethapi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExecute_GetProofReturnsAccountAndStorage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	archive := state.NewMockVmStateDB(ctrl)
+	address := common.Address{0x12}
+	codeHash := common.Hash{0x34}
+
+	archive.EXPECT().Exist(address).Return(true)
+	archive.EXPECT().GetCodeHash(address).Return(codeHash)
+	archive.EXPECT().GetState(address, common.Hash{31: 1}).Return(common.Hash{31: 2})
+	archive.EXPECT().GetBalance(address).Return(big.NewInt(10))
+	archive.EXPECT().GetNonce(address).Return(uint64(3))
+
+	res := executeGetProof([]interface{}{address.Hex(), []interface{}{"0x1"}, "latest"}, archive)
+	b, err := res.GetRawResult()
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	var proof ethapi.AccountResult
+	if err = json.Unmarshal(b, &proof); err != nil {
+		t.Fatalf("cannot decode result; %v", err)
+	}
+
+	if proof.Address != address || proof.CodeHash != codeHash || proof.Balance.ToInt().Int64() != 10 || proof.Nonce != 3 {
+		t.Errorf("unexpected account; %+v", proof)
+	}
+	if len(proof.StorageProof) != 1 || proof.StorageProof[0].Key != "0x1" || proof.StorageProof[0].Value.ToInt().Int64() != 2 {
+		t.Errorf("unexpected storage; %+v", proof.StorageProof)
+	}
+}
+
+func TestExecute_GetProofOfMissingAccountHasEmptyCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	archive := state.NewMockVmStateDB(ctrl)
+	address := common.Address{0x12}
+
+	archive.EXPECT().Exist(address).Return(false)
+	archive.EXPECT().GetCodeHash(address).Return(common.Hash{})
+	archive.EXPECT().GetBalance(address).Return(new(big.Int))
+	archive.EXPECT().GetNonce(address).Return(uint64(0))
+
+	res := executeGetProof([]interface{}{address.Hex(), []interface{}{"0x1"}, "latest"}, archive)
+	b, err := res.GetRawResult()
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	var proof ethapi.AccountResult
+	if err = json.Unmarshal(b, &proof); err != nil {
+		t.Fatalf("cannot decode result; %v", err)
+	}
+
+	if got, want := proof.CodeHash, crypto.Keccak256Hash(nil); got != want {
+		t.Errorf("unexpected code hash; got %v, want %v", got, want)
+	}
+	if len(proof.StorageProof) != 1 || proof.StorageProof[0].Value.ToInt().Sign() != 0 {
+		t.Errorf("unexpected storage; %+v", proof.StorageProof)
+	}
+}
+
+func TestExecute_CallWithMalformedArgumentsIsSkipped(t *testing.T) {
+	for _, method := range []string{"call", "createAccessList", "traceCall"} {
+		t.Run(method, func(t *testing.T) {
+			rec := &RequestAndResults{
+				Query: &Body{
+					MethodBase: method,
+					Params:     []interface{}{"0x12", "latest"},
+				},
+				Timestamp: 1,
+			}
+
+			if res := Execute(1, rec, nil, nil); res != nil {
+				t.Errorf("request with malformed arguments must not be executed; got: %v", res)
+			}
+			if !rec.SkipValidation {
+				t.Error("validation of request with malformed arguments must be skipped")
+			}
+		})
+	}
+}
+
+func TestExecute_TraceCallWithUnsupportedConfigIsSkipped(t *testing.T) {
+	configs := map[string]interface{}{
+		"tracer":    map[string]interface{}{"tracer": "callTracer"},
+		"malformed": "callTracer",
+	}
+	for name, config := range configs {
+		t.Run(name, func(t *testing.T) {
+			rec := &RequestAndResults{
+				Query: &Body{
+					MethodBase: "traceCall",
+					Params:     []interface{}{map[string]interface{}{"to": "0x12"}, "latest", config},
+				},
+				Timestamp: 1,
+			}
+
+			// the result must be an untyped nil, otherwise consumers of the result panic
+			if res := Execute(1, rec, nil, nil); res != nil {
+				t.Errorf("trace call with unsupported config must not be executed; got: %v", res)
+			}
+			if !rec.SkipValidation {
+				t.Error("validation of trace call with unsupported config must be skipped")
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// proxyRequest is a JSON-RPC request forwarded by the RecordingProxy.
+type proxyRequest struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// proxyResponse is a JSON-RPC response forwarded by the RecordingProxy.
+type proxyResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *ErrorMessage   `json:"error"`
+}
+
+// blockHeader contains fields of a block needed to record a request.
+type blockHeader struct {
+	Number    hexutil.Uint64 `json:"number"`
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// RecordingProxy is a reverse proxy forwarding JSON-RPC requests to an upstream
+// endpoint. Recordable requests are written together with their responses and
+// the latest block of the upstream at the time of the request.
+type RecordingProxy struct {
+	upstream string
+	client   *http.Client
+	log      logger.Logger
+
+	mu       sync.Mutex // guards the writer and the counters
+	writer   *Writer
+	recorded uint64
+	skipped  uint64
+}
+
+// NewRecordingProxy creates a proxy recording requests sent to the upstream endpoint into the writer.
+func NewRecordingProxy(upstream string, writer *Writer, log logger.Logger) *RecordingProxy {
+	return &RecordingProxy{
+		upstream: upstream,
+		client:   &http.Client{Timeout: time.Minute},
+		log:      log,
+		writer:   writer,
+	}
+}
+
+// Stats returns the number of recorded and skipped requests.
+func (p *RecordingProxy) Stats() (recorded, skipped uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.recorded, p.skipped
+}
+
+// ServeHTTP forwards a request to the upstream endpoint and records it.
+func (p *RecordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// requests are recorded with the block being the head of the chain when the request is executed
+	header, err := p.latestBlock()
+	if err != nil {
+		p.log.Warningf("cannot get latest block of upstream; %v", err)
+	} else {
+		// the head may move before the request is executed, hence the request is pinned to the recorded block
+		pinned, err := pinBlockTags(body, header.Number)
+		if err != nil {
+			p.log.Debugf("cannot pin block of request; %v", err)
+		} else {
+			body = pinned
+		}
+	}
+
+	resp, err := p.client.Post(p.upstream, "application/json", bytes.NewReader(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+	w.WriteHeader(resp.StatusCode)
+	if _, err = w.Write(respBody); err != nil {
+		p.log.Debugf("cannot forward response; %v", err)
+	}
+
+	if header == nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+	if err = p.record(body, respBody, header); err != nil {
+		p.log.Warningf("cannot record request; %v", err)
+	}
+}
+
+// record writes recordable requests of a single or batch call together with their responses.
+func (p *RecordingProxy) record(body, respBody []byte, header *blockHeader) error {
+	var (
+		requests  []proxyRequest
+		responses []proxyResponse
+	)
+	body, respBody = bytes.TrimSpace(body), bytes.TrimSpace(respBody)
+	if len(body) > 0 && body[0] == '[' {
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(respBody, &responses); err != nil {
+			return err
+		}
+	} else {
+		requests, responses = make([]proxyRequest, 1), make([]proxyResponse, 1)
+		if err := json.Unmarshal(body, &requests[0]); err != nil {
+			return err
+		}
+		if err := json.Unmarshal(respBody, &responses[0]); err != nil {
+			return err
+		}
+	}
+
+	// responses of a batch may be in any order
+	byID := make(map[string]*proxyResponse, len(responses))
+	for i := range responses {
+		byID[string(responses[i].ID)] = &responses[i]
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, req := range requests {
+		res, found := byID[string(req.ID)]
+		rec := newRecordedRequest(req, res, header)
+		if !found || rec == nil {
+			p.skipped++
+			continue
+		}
+		if err := p.writer.Write(rec); err != nil {
+			return fmt.Errorf("cannot write %v; %w", req.Method, err)
+		}
+		p.recorded++
+	}
+	return nil
+}
+
+// pinBlockTags replaces the "latest" and "pending" block tags of recordable requests
+// of a single or batch call by the given block. The body is returned unchanged if
+// no request refers to a block by these tags.
+func pinBlockTags(body []byte, block hexutil.Uint64) ([]byte, error) {
+	var (
+		requests []map[string]json.RawMessage
+		batch    bool
+	)
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		batch = true
+		if err := json.Unmarshal(body, &requests); err != nil {
+			return nil, err
+		}
+	} else {
+		requests = make([]map[string]json.RawMessage, 1)
+		if err := json.Unmarshal(body, &requests[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	changed := false
+	for _, req := range requests {
+		pinned, err := pinRequestBlockTag(req, block)
+		if err != nil {
+			return nil, err
+		}
+		changed = changed || pinned
+	}
+	if !changed {
+		return body, nil
+	}
+
+	if batch {
+		return json.Marshal(requests)
+	}
+	return json.Marshal(requests[0])
+}
+
+// pinRequestBlockTag replaces the "latest" or "pending" block tag of a single recordable
+// request by the given block. It returns true if the request has been modified.
+func pinRequestBlockTag(req map[string]json.RawMessage, block hexutil.Uint64) (bool, error) {
+	var method string
+	if err := json.Unmarshal(req["method"], &method); err != nil {
+		return false, nil
+	}
+	namespace, methodBase, found := strings.Cut(method, "_")
+	if !found || !CanRecord(namespace, methodBase) {
+		return false, nil
+	}
+
+	var params []json.RawMessage
+	if err := json.Unmarshal(req["params"], &params); err != nil {
+		return false, nil
+	}
+	pos, ok := blockParamPosition(methodBase, len(params))
+	if !ok {
+		return false, nil
+	}
+
+	var tag string
+	if err := json.Unmarshal(params[pos], &tag); err != nil || (tag != "latest" && tag != "pending") {
+		return false, nil
+	}
+	b, err := json.Marshal(block)
+	if err != nil {
+		return false, err
+	}
+	params[pos] = b
+	if req["params"], err = json.Marshal(params); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// newRecordedRequest creates a recorded request of a forwarded request and its response.
+// Nil is returned if the request cannot be recorded.
+func newRecordedRequest(req proxyRequest, res *proxyResponse, header *blockHeader) *RequestAndResults {
+	namespace, method, found := strings.Cut(req.Method, "_")
+	if !found || !CanRecord(namespace, method) || res == nil {
+		return nil
+	}
+
+	params := req.Params
+	if len(params) == 0 || string(params) == "null" {
+		params = json.RawMessage("[]")
+	}
+	rec := &RequestAndResults{
+		Query: &Body{
+			Method:     req.Method,
+			Namespace:  namespace,
+			MethodBase: method,
+		},
+		ParamsRaw: params,
+	}
+
+	// timestamps are recorded in nanoseconds
+	timestamp := uint64(header.Timestamp) * uint64(time.Second)
+	switch {
+	case res.Error != nil:
+		rec.Error = &ErrorResponse{
+			BlockID:   uint64(header.Number),
+			Timestamp: timestamp,
+			Error:     *res.Error,
+		}
+	case len(res.Result) > 0:
+		rec.Response = &Response{
+			BlockID:   uint64(header.Number),
+			Timestamp: timestamp,
+			Result:    res.Result,
+		}
+	default:
+		return nil
+	}
+	return rec
+}
+
+// latestBlock returns the head block of the upstream endpoint.
+func (p *RecordingProxy) latestBlock() (*blockHeader, error) {
+	req := []byte(`{"jsonrpc":"2.0","id":1,"method":"eth_getBlockByNumber","params":["latest",false]}`)
+	resp, err := p.client.Post(p.upstream, "application/json", bytes.NewReader(req))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res struct {
+		Result *blockHeader  `json:"result"`
+		Error  *ErrorMessage `json:"error"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, fmt.Errorf("upstream returned error %v: %v", res.Error.Code, res.Error.Message)
+	}
+	if res.Result == nil {
+		return nil, fmt.Errorf("upstream returned no block")
+	}
+	return res.Result, nil
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/logger"
+)
+
+// newTestUpstream creates a JSON-RPC endpoint answering requests by the given results indexed by method.
+func newTestUpstream(t *testing.T, results map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		answer := func(req proxyRequest) string {
+			if req.Method == "eth_getBlockByNumber" {
+				return `{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":{"number":"0x10","timestamp":"0x5"}}`
+			}
+			res, ok := results[req.Method]
+			if !ok {
+				return `{"jsonrpc":"2.0","id":` + string(req.ID) + `,"error":{"code":-32601,"message":"not found"}}`
+			}
+			return `{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":` + res + `}`
+		}
+
+		if body[0] == '[' {
+			var reqs []proxyRequest
+			if err := json.Unmarshal(body, &reqs); err != nil {
+				t.Errorf("cannot decode batch; %v", err)
+			}
+			// answer in reverse order
+			var answers []string
+			for i := len(reqs) - 1; i >= 0; i-- {
+				answers = append(answers, answer(reqs[i]))
+			}
+			_, _ = w.Write([]byte("[" + strings.Join(answers, ",") + "]"))
+			return
+		}
+		var req proxyRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("cannot decode request; %v", err)
+		}
+		_, _ = w.Write([]byte(answer(req)))
+	}))
+}
+
+func sendToProxy(t *testing.T, p *RecordingProxy, request string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code; got %v, want %v", rec.Code, http.StatusOK)
+	}
+	return rec.Body.String()
+}
+
+func TestRecordingProxy_RequestsAreForwardedAndRecorded(t *testing.T) {
+	upstream := newTestUpstream(t, map[string]string{
+		"eth_getBalance": `"0xff"`,
+		"eth_chainId":    `"0xfa"`,
+	})
+	defer upstream.Close()
+
+	var buf bytes.Buffer
+	p := NewRecordingProxy(upstream.URL, NewWriter(&buf), logger.NewLogger("critical", "rpc-test"))
+
+	res := sendToProxy(t, p, `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000012","latest"]}`)
+	if !strings.Contains(res, `"result":"0xff"`) {
+		t.Errorf("unexpected response; %v", res)
+	}
+	sendToProxy(t, p, `[
+		{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]},
+		{"jsonrpc":"2.0","id":3,"method":"eth_getCode","params":["0x0000000000000000000000000000000000000012","0x1"]}
+	]`)
+
+	if recorded, skipped := p.Stats(); recorded != 2 || skipped != 1 {
+		t.Errorf("unexpected stats; recorded %v, skipped %v", recorded, skipped)
+	}
+
+	got := readRecording(t, newIterator(context.Background(), io.NopCloser(&buf), 10))
+	checkRecords(t, got, []*RequestAndResults{
+		{
+			Query:     &Body{MethodBase: "getBalance"},
+			ParamsRaw: []byte(`["0x0000000000000000000000000000000000000012","0x10"]`),
+			Response:  &Response{BlockID: 16, Timestamp: 5_000_000_000, Result: []byte(`"0xff"`)},
+		},
+		{
+			Query:     &Body{MethodBase: "getCode"},
+			ParamsRaw: []byte(`["0x0000000000000000000000000000000000000012","0x1"]`),
+			Error:     &ErrorResponse{BlockID: 16, Timestamp: 5_000_000_000, Error: ErrorMessage{Code: -32601}},
+		},
+	})
+}
+
+func TestRecordingProxy_LatestAndPendingBlocksArePinnedToRecordedBlock(t *testing.T) {
+	var forwarded []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req proxyRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("cannot decode request; %v", err)
+		}
+		if req.Method == "eth_getBlockByNumber" {
+			_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"number":"0x10","timestamp":"0x5"}}`))
+			return
+		}
+		forwarded = append(forwarded, string(req.Params))
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":` + string(req.ID) + `,"result":"0xff"}`))
+	}))
+	defer upstream.Close()
+
+	var buf bytes.Buffer
+	p := NewRecordingProxy(upstream.URL, NewWriter(&buf), logger.NewLogger("critical", "rpc-test"))
+
+	sendToProxy(t, p, `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000012","latest"]}`)
+	sendToProxy(t, p, `{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["0x0000000000000000000000000000000000000012","pending"]}`)
+	sendToProxy(t, p, `{"jsonrpc":"2.0","id":3,"method":"eth_getStorageAt","params":["0x0000000000000000000000000000000000000012","0x0","0x1"]}`)
+
+	want := []string{
+		`["0x0000000000000000000000000000000000000012","0x10"]`,
+		`["0x0000000000000000000000000000000000000012","0x10"]`,
+		`["0x0000000000000000000000000000000000000012","0x0","0x1"]`,
+	}
+	if len(forwarded) != len(want) {
+		t.Fatalf("unexpected number of forwarded requests; got: %v, want: %v", len(forwarded), len(want))
+	}
+	for i := range want {
+		if forwarded[i] != want[i] {
+			t.Errorf("unexpected params of request %d; got: %v, want: %v", i, forwarded[i], want[i])
+		}
+	}
+}
+
+func TestPinBlockTags_BatchIsPinned(t *testing.T) {
+	body := []byte(`[{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x12","latest"]},{"jsonrpc":"2.0","id":2,"method":"eth_chainId","params":[]}]`)
+
+	got, err := pinBlockTags(body, 16)
+	if err != nil {
+		t.Fatalf("unexpected error; %v", err)
+	}
+	var requests []proxyRequest
+	if err = json.Unmarshal(got, &requests); err != nil {
+		t.Fatalf("cannot decode batch; %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("unexpected number of requests; got: %v", len(requests))
+	}
+	if want := `["0x12","0x10"]`; string(requests[0].Params) != want {
+		t.Errorf("unexpected params; got: %v, want: %v", string(requests[0].Params), want)
+	}
+	if want := `[]`; string(requests[1].Params) != want {
+		t.Errorf("unexpected params; got: %v, want: %v", string(requests[1].Params), want)
+	}
+}
@@ -34,6 +34,17 @@ type RequestAndResults struct {
 	IsRecovered                   bool
 	RecordedBlock, RequestedBlock int
 	Timestamp                     uint64
+	ScheduledAt                   time.Time // time the request is due in timed replay, zero otherwise
+	ExecutionStart, ExecutionEnd  time.Time // time span of executing the request on the archive, zero if not executed
+}
+
+// ExecutionTime returns the time spent executing the request on the archive.
+// False is returned if the request has not been executed.
+func (r *RequestAndResults) ExecutionTime() (time.Duration, bool) {
+	if r.ExecutionStart.IsZero() || r.ExecutionEnd.IsZero() {
+		return 0, false
+	}
+	return r.ExecutionEnd.Sub(r.ExecutionStart), true
 }
 
 // Body represents a decoded payload of a balancer.
@@ -85,14 +96,40 @@ func (r *RequestAndResults) DecodeInfo() {
 	r.findRequestedBlock()
 }
 
+// blockParamPositions lists methods whose block parameter is not the last parameter.
+var blockParamPositions = map[string]int{
+	"feeHistory": 1,
+	"traceCall":  1,
+}
+
+// blockParamPosition returns the position of the block parameter of a method
+// called with the given number of parameters.
+func blockParamPosition(method string, params int) (int, bool) {
+	pos, ok := blockParamPositions[method]
+	if !ok {
+		pos = params - 1
+	}
+	if params < 2 || pos >= params {
+		return 0, false
+	}
+	return pos, true
+}
+
 func (r *RequestAndResults) findRequestedBlock() {
-	l := len(r.Query.Params)
-	if l < 2 {
+	pos, ok := blockParamPosition(r.Query.MethodBase, len(r.Query.Params))
+	if !ok {
+		r.RequestedBlock = r.RecordedBlock
+		return
+	}
+
+	str, ok := r.Query.Params[pos].(string)
+	if !ok {
+		// blocks requested by hash cannot be resolved, skip them
+		r.SkipValidation = true
 		r.RequestedBlock = r.RecordedBlock
 		return
 	}
 
-	str := r.Query.Params[l-1].(string)
 	switch str {
 	case "pending":
 		// validation for pending requests does not work, skip them
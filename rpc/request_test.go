@@ -36,3 +36,37 @@ var r = &RequestAndResults{
 	},
 	SkipValidation: false,
 }
+
+func TestRequestAndResults_DecodeInfoFindsBlockOfTraceCall(t *testing.T) {
+	r := &RequestAndResults{
+		Response: &Response{BlockID: 10},
+		Query: &Body{
+			MethodBase: "traceCall",
+			Params:     []interface{}{map[string]interface{}{}, "0x5", map[string]interface{}{"disableStack": true}},
+		},
+	}
+	r.DecodeInfo()
+	if r.RequestedBlock != 5 {
+		t.Errorf("unexpected requested block; got %v, want 5", r.RequestedBlock)
+	}
+	if r.SkipValidation {
+		t.Error("skip validation must be false")
+	}
+}
+
+func TestRequestAndResults_DecodeInfoBlockHashesSkipValidation(t *testing.T) {
+	r := &RequestAndResults{
+		Response: &Response{BlockID: 10},
+		Query: &Body{
+			MethodBase: "getBalance",
+			Params:     []interface{}{"test", map[string]interface{}{"blockHash": "0x01"}},
+		},
+	}
+	r.DecodeInfo()
+	if !r.SkipValidation {
+		t.Error("skip validation must be true")
+	}
+	if r.RequestedBlock != 10 {
+		t.Errorf("unexpected requested block; got %v, want 10", r.RequestedBlock)
+	}
+}
@@ -0,0 +1,351 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// JSON-RPC error codes, see https://www.jsonrpc.org/specification#error_object
+const (
+	parseErrorCode     = -32700
+	invalidRequestCode = -32600
+	methodNotFoundCode = -32601
+	invalidParamsCode  = -32602
+	serverErrorCode    = -32000
+)
+
+// maxRequestSize limits the size of a request body accepted by the Server.
+const maxRequestSize = 5 * 1024 * 1024
+
+// serverRequest is a JSON-RPC request received by the Server.
+type serverRequest struct {
+	Version string            `json:"jsonrpc"`
+	ID      json.RawMessage   `json:"id,omitempty"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+}
+
+// serverResponse is a JSON-RPC response sent by the Server.
+type serverResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *ErrorMessage   `json:"error,omitempty"`
+}
+
+// rpcError is an error carrying a JSON-RPC error code.
+type rpcError struct {
+	code int
+	err  error
+}
+
+func (e *rpcError) Error() string {
+	return e.err.Error()
+}
+
+func invalidParams(format string, args ...any) error {
+	return &rpcError{code: invalidParamsCode, err: fmt.Errorf(format, args...)}
+}
+
+// methodHandler executes a request on the archive state of the requested block.
+type methodHandler struct {
+	blockParam int // index of the block parameter
+	execute    func(s *Server, block uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error)
+}
+
+// methods served by the Server indexed by their JSON-RPC name.
+var methods = map[string]methodHandler{
+	"eth_getBalance":          {blockParam: 1, execute: (*Server).getBalance},
+	"eth_getTransactionCount": {blockParam: 1, execute: (*Server).getTransactionCount},
+	"eth_call":                {blockParam: 1, execute: (*Server).call},
+	"eth_estimateGas":         {blockParam: 1, execute: (*Server).estimateGas},
+	"eth_getCode":             {blockParam: 1, execute: (*Server).getCode},
+	"eth_getStorageAt":        {blockParam: 2, execute: (*Server).getStorageAt},
+}
+
+// Server answers JSON-RPC requests over HTTP using historic states of a StateDB archive.
+// Block tags are resolved against the block height of the archive. Since the archive
+// does not record block timestamps, EVM requests are executed with the current time.
+type Server struct {
+	cfg *utils.Config
+	log logger.Logger
+
+	mu sync.Mutex // guards access to the StateDB which is not thread-safe
+	db state.StateDB
+}
+
+// NewServer creates a JSON-RPC server for the archive of the given StateDB.
+func NewServer(db state.StateDB, cfg *utils.Config) *Server {
+	return &Server{
+		cfg: cfg,
+		log: logger.NewLogger(cfg.LogLevel, "Rpc-Server"),
+		db:  db,
+	}
+}
+
+// ServeHTTP answers single and batch JSON-RPC requests.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "JSON-RPC requests must be sent by POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var response interface{}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var requests []json.RawMessage
+		if err = json.Unmarshal(body, &requests); err != nil {
+			response = newErrorResponse(nil, parseErrorCode, err)
+		} else if len(requests) == 0 {
+			response = newErrorResponse(nil, invalidRequestCode, errors.New("empty batch"))
+		} else {
+			responses := make([]*serverResponse, len(requests))
+			for i, request := range requests {
+				responses[i] = s.handle(request)
+			}
+			response = responses
+		}
+	} else {
+		response = s.handle(body)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err = json.NewEncoder(w).Encode(response); err != nil {
+		s.log.Errorf("cannot write response; %v", err)
+	}
+}
+
+// handle decodes and executes a single request.
+func (s *Server) handle(raw json.RawMessage) *serverResponse {
+	var req serverRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return newErrorResponse(nil, parseErrorCode, err)
+	}
+	if req.Method == "" {
+		return newErrorResponse(req.ID, invalidRequestCode, errors.New("method is missing"))
+	}
+	handler, ok := methods[req.Method]
+	if !ok {
+		return newErrorResponse(req.ID, methodNotFoundCode, fmt.Errorf("the method %v does not exist/is not available", req.Method))
+	}
+
+	res, err := s.execute(handler, req.Params)
+	if err != nil {
+		code := serverErrorCode
+		var rErr *rpcError
+		if errors.As(err, &rErr) {
+			code = rErr.code
+		}
+		s.log.Debugf("%v failed; %v", req.Method, err)
+		return newErrorResponse(req.ID, code, err)
+	}
+	return &serverResponse{Version: "2.0", ID: req.ID, Result: res}
+}
+
+// execute runs the handler on the archive state of the requested block.
+func (s *Server) execute(handler methodHandler, params []json.RawMessage) (res interface{}, err error) {
+	var blockParam json.RawMessage
+	if len(params) > handler.blockParam {
+		blockParam = params[handler.blockParam]
+	}
+	block, err := s.resolveBlock(blockParam)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	archive, err := s.db.GetArchiveState(block)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("cannot get archive state of block %v; %w", block, err)
+	}
+	defer func() {
+		err = errors.Join(err, archive.Release())
+	}()
+
+	// a failing EVM execution must not bring down the server
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, fmt.Errorf("execution failed; %v", r)
+		}
+	}()
+	return handler.execute(s, block, archive, params)
+}
+
+// resolveBlock returns the block number of a block parameter which may be
+// a block number, a block tag or an object with a block number (EIP-1898).
+func (s *Server) resolveBlock(param json.RawMessage) (uint64, error) {
+	tag := "latest"
+	if len(param) > 0 && string(param) != "null" {
+		if err := json.Unmarshal(param, &tag); err != nil {
+			var obj struct {
+				BlockNumber *hexutil.Uint64 `json:"blockNumber"`
+				BlockHash   *common.Hash    `json:"blockHash"`
+			}
+			if err = json.Unmarshal(param, &obj); err != nil {
+				return 0, invalidParams("invalid block %s", param)
+			}
+			if obj.BlockNumber == nil {
+				return 0, invalidParams("blocks can only be requested by number")
+			}
+			tag = obj.BlockNumber.String()
+		}
+	}
+
+	s.mu.Lock()
+	height, empty, err := s.db.GetArchiveBlockHeight()
+	s.mu.Unlock()
+	if err != nil {
+		return 0, fmt.Errorf("cannot get archive block height; %w", err)
+	}
+	if empty {
+		return 0, errors.New("archive is empty")
+	}
+
+	switch tag {
+	case "latest", "pending", "safe", "finalized":
+		return height, nil
+	case "earliest":
+		return 0, nil
+	}
+	block, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, invalidParams("invalid block %v; %v", tag, err)
+	}
+	if block > height {
+		return 0, fmt.Errorf("block %v is not in archive; archive block height is %v", block, height)
+	}
+	return block, nil
+}
+
+// getBalance returns the balance of an account.
+func (s *Server) getBalance(_ uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	address, err := addressParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(archive.GetBalance(address)), nil
+}
+
+// getTransactionCount returns the nonce of an account.
+func (s *Server) getTransactionCount(_ uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	address, err := addressParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(archive.GetNonce(address)), nil
+}
+
+// getCode returns the code of an account.
+func (s *Server) getCode(_ uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	address, err := addressParam(params)
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(archive.GetCode(address)), nil
+}
+
+// getStorageAt returns the value of a storage slot of an account.
+func (s *Server) getStorageAt(_ uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	address, err := addressParam(params)
+	if err != nil {
+		return nil, err
+	}
+	var key string
+	if len(params) < 2 || json.Unmarshal(params[1], &key) != nil {
+		return nil, invalidParams("missing or invalid storage key")
+	}
+	value := archive.GetState(address, common.HexToHash(key))
+	return hexutil.Bytes(value.Bytes()), nil
+}
+
+// call executes a message call on the EVM and returns its output.
+func (s *Server) call(block uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	evm, err := s.newEvmExecutor(block, archive, params)
+	if err != nil {
+		return nil, err
+	}
+	res, err := evm.sendCall()
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(res.ReturnData), nil
+}
+
+// estimateGas returns the gas needed by a message call.
+func (s *Server) estimateGas(block uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (interface{}, error) {
+	evm, err := s.newEvmExecutor(block, archive, params)
+	if err != nil {
+		return nil, err
+	}
+	return evm.sendEstimateGas()
+}
+
+// newEvmExecutor creates an EvmExecutor for the transaction arguments of a request.
+func (s *Server) newEvmExecutor(block uint64, archive state.NonCommittableStateDB, params []json.RawMessage) (*EvmExecutor, error) {
+	var args map[string]interface{}
+	if len(params) < 1 || json.Unmarshal(params[0], &args) != nil || args == nil {
+		return nil, invalidParams("missing or invalid transaction arguments")
+	}
+	// the recorder uses "data", newer clients send "input"
+	if input, ok := args["input"]; ok && args["data"] == nil {
+		args["data"] = input
+	}
+	for _, field := range []string{"from", "to", "value", "gas", "gasPrice", "data"} {
+		if v, ok := args[field]; ok && v != nil {
+			if _, isString := v.(string); !isString {
+				return nil, invalidParams("invalid transaction argument %v", field)
+			}
+		}
+	}
+	return newEvmExecutor(block, archive, s.cfg, args, uint64(time.Now().Unix())), nil
+}
+
+// addressParam decodes the account address of a request.
+func addressParam(params []json.RawMessage) (common.Address, error) {
+	var address common.Address
+	if len(params) < 1 || json.Unmarshal(params[0], &address) != nil {
+		return address, invalidParams("missing or invalid address")
+	}
+	return address, nil
+}
+
+func newErrorResponse(id json.RawMessage, code int, err error) *serverResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &serverResponse{Version: "2.0", ID: id, Error: &ErrorMessage{Code: code, Message: err.Error()}}
+}
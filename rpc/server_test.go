@@ -0,0 +1,184 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/utils"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+// sendToServer posts a request to the server and decodes its response.
+func sendToServer(t *testing.T, s *Server, request string, response any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(request))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code; got %v, want %v", rec.Code, http.StatusOK)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), response); err != nil {
+		t.Fatalf("cannot decode response %v; %v", rec.Body.String(), err)
+	}
+}
+
+type testResponse struct {
+	ID     int           `json:"id"`
+	Result string        `json:"result"`
+	Error  *ErrorMessage `json:"error"`
+}
+
+func newTestServer(db state.StateDB) *Server {
+	return NewServer(db, &utils.Config{ChainID: utils.MainnetChainID})
+}
+
+func TestServer_GetBalanceOfLatestBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+	archive := state.NewMockNonCommittableStateDB(ctrl)
+	address := common.Address{0x12}
+
+	gomock.InOrder(
+		db.EXPECT().GetArchiveBlockHeight().Return(uint64(100), false, nil),
+		db.EXPECT().GetArchiveState(uint64(100)).Return(archive, nil),
+		archive.EXPECT().GetBalance(address).Return(big.NewInt(255)),
+		archive.EXPECT().Release(),
+	)
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["`+address.Hex()+`","latest"]}`, &res)
+	if res.Error != nil {
+		t.Fatalf("unexpected error; %v", res.Error.Message)
+	}
+	if got, want := res.Result, "0xff"; got != want {
+		t.Errorf("unexpected balance; got %v, want %v", got, want)
+	}
+}
+
+func TestServer_GetStorageAtOfNumberedBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+	archive := state.NewMockNonCommittableStateDB(ctrl)
+	address := common.Address{0x12}
+
+	gomock.InOrder(
+		db.EXPECT().GetArchiveBlockHeight().Return(uint64(100), false, nil),
+		db.EXPECT().GetArchiveState(uint64(16)).Return(archive, nil),
+		archive.EXPECT().GetState(address, common.Hash{31: 1}).Return(common.Hash{31: 2}),
+		archive.EXPECT().Release(),
+	)
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_getStorageAt","params":["`+address.Hex()+`","0x1","0x10"]}`, &res)
+	if res.Error != nil {
+		t.Fatalf("unexpected error; %v", res.Error.Message)
+	}
+	if got, want := res.Result, (common.Hash{31: 2}).Hex(); got != want {
+		t.Errorf("unexpected value; got %v, want %v", got, want)
+	}
+}
+
+func TestServer_BatchRequestsAreAnsweredInOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+	archive := state.NewMockNonCommittableStateDB(ctrl)
+	address := common.Address{0x12}
+
+	db.EXPECT().GetArchiveBlockHeight().Return(uint64(100), false, nil).Times(2)
+	db.EXPECT().GetArchiveState(uint64(0)).Return(archive, nil)
+	db.EXPECT().GetArchiveState(uint64(100)).Return(archive, nil)
+	archive.EXPECT().GetNonce(address).Return(uint64(7))
+	archive.EXPECT().GetCode(address).Return([]byte{0x60, 0x80})
+	archive.EXPECT().Release().Times(2)
+
+	var res []testResponse
+	sendToServer(t, newTestServer(db), `[
+		{"jsonrpc":"2.0","id":1,"method":"eth_getTransactionCount","params":["`+address.Hex()+`","earliest"]},
+		{"jsonrpc":"2.0","id":2,"method":"eth_getCode","params":["`+address.Hex()+`"]}
+	]`, &res)
+	if len(res) != 2 {
+		t.Fatalf("unexpected number of responses; got %v, want 2", len(res))
+	}
+	if res[0].ID != 1 || res[0].Result != "0x7" {
+		t.Errorf("unexpected first response; %+v", res[0])
+	}
+	if res[1].ID != 2 || res[1].Result != "0x6080" {
+		t.Errorf("unexpected second response; %+v", res[1])
+	}
+}
+
+func TestServer_UnknownMethodIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_sendRawTransaction","params":[]}`, &res)
+	if res.Error == nil || res.Error.Code != methodNotFoundCode {
+		t.Errorf("unexpected response; %+v", res)
+	}
+}
+
+func TestServer_BlockAboveArchiveHeightIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+
+	db.EXPECT().GetArchiveBlockHeight().Return(uint64(100), false, nil)
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":["0x0000000000000000000000000000000000000012","0x65"]}`, &res)
+	if res.Error == nil || res.Error.Code != serverErrorCode {
+		t.Errorf("unexpected response; %+v", res)
+	}
+}
+
+func TestServer_InvalidParamsAreReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+	archive := state.NewMockNonCommittableStateDB(ctrl)
+
+	db.EXPECT().GetArchiveBlockHeight().Return(uint64(100), false, nil)
+	db.EXPECT().GetArchiveState(uint64(100)).Return(archive, nil)
+	archive.EXPECT().Release()
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_getBalance","params":[42]}`, &res)
+	if res.Error == nil || res.Error.Code != invalidParamsCode {
+		t.Errorf("unexpected response; %+v", res)
+	}
+}
+
+func TestServer_ArchiveErrorIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := state.NewMockStateDB(ctrl)
+
+	db.EXPECT().GetArchiveBlockHeight().Return(uint64(0), false, errors.New("no archive"))
+
+	var res testResponse
+	sendToServer(t, newTestServer(db), `{"jsonrpc":"2.0","id":1,"method":"eth_getCode","params":["0x0000000000000000000000000000000000000012"]}`, &res)
+	if res.Error == nil || !strings.Contains(res.Error.Message, "no archive") {
+		t.Errorf("unexpected response; %+v", res)
+	}
+}
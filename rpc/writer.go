@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/gzip"
+)
+
+// Writer writes requests and their results into a recording readable by the FileReader.
+type Writer struct {
+	out io.Writer
+}
+
+// NewWriter creates a new writer of recorded requests.
+func NewWriter(out io.Writer) *Writer {
+	return &Writer{out: out}
+}
+
+// Write writes a request with its response or error. Block ID and timestamp
+// are taken from the response or error. Requests of methods not recordable
+// by the Header are rejected.
+func (w *Writer) Write(rec *RequestAndResults) error {
+	hdr := new(Header)
+	if err := hdr.SetMethod(rec.Query.Namespace, rec.Query.MethodBase); err != nil {
+		return err
+	}
+	if err := hdr.SetQueryLength(len(rec.ParamsRaw)); err != nil {
+		return err
+	}
+
+	var result []byte
+	switch {
+	case rec.Response != nil:
+		if len(rec.Response.Result) == 0 {
+			return fmt.Errorf("response of %v is empty", rec.Query.Method)
+		}
+		result = rec.Response.Result
+		hdr.SetResponseLength(len(result))
+		hdr.SetBlockID(rec.Response.BlockID)
+		hdr.SetBlockTimestamp(rec.Response.Timestamp)
+	case rec.Error != nil:
+		hdr.SetError(rec.Error.Error.Code)
+		hdr.SetBlockID(rec.Error.BlockID)
+		hdr.SetBlockTimestamp(rec.Error.Timestamp)
+	default:
+		return fmt.Errorf("request %v has neither response nor error", rec.Query.Method)
+	}
+
+	if _, err := hdr.WriteTo(w.out); err != nil {
+		return err
+	}
+	if _, err := w.out.Write(rec.ParamsRaw); err != nil {
+		return err
+	}
+	_, err := w.out.Write(result)
+	return err
+}
+
+// FileWriter implements writer of a recording stored in a file. Files
+// with the .gz extension are compressed.
+type FileWriter struct {
+	*Writer
+	f  *os.File
+	bw *bufio.Writer
+	zw *gzip.Writer
+}
+
+// NewFileWriter creates a new recording file.
+func NewFileWriter(path string) (*FileWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	fw := &FileWriter{f: f, bw: bufio.NewWriter(f)}
+	var out io.Writer = fw.bw
+
+	// gzipped file?
+	if strings.EqualFold(filepath.Ext(path), ".gz") {
+		fw.zw = gzip.NewWriter(fw.bw)
+		out = fw.zw
+	}
+
+	fw.Writer = NewWriter(out)
+	return fw, nil
+}
+
+// Close flushes the recording and closes the file.
+func (fw *FileWriter) Close() error {
+	var err error
+	if fw.zw != nil {
+		err = fw.zw.Close()
+	}
+	return errors.Join(err, fw.bw.Flush(), fw.f.Close())
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readRecording reads all requests of a recording.
+func readRecording(t *testing.T, it Iterator) []*RequestAndResults {
+	t.Helper()
+	defer it.Close()
+	var recs []*RequestAndResults
+	for it.Next() {
+		recs = append(recs, it.Value())
+	}
+	if err := it.Error(); err != nil {
+		t.Fatalf("cannot read recording; %v", err)
+	}
+	return recs
+}
+
+func makeTestRecords() []*RequestAndResults {
+	return []*RequestAndResults{
+		{
+			Query:     &Body{Namespace: "eth", MethodBase: "getBalance", Method: "eth_getBalance"},
+			ParamsRaw: []byte(`["0x0000000000000000000000000000000000000012","latest"]`),
+			Response:  &Response{BlockID: 10, Timestamp: 1_000_000_000, Result: []byte(`"0x1"`)},
+		},
+		{
+			Query:     &Body{Namespace: "eth", MethodBase: "call", Method: "eth_call"},
+			ParamsRaw: []byte(`[{"to":"0x0000000000000000000000000000000000000012","data":"0x` + strings.Repeat("00", 4096) + `"},"0xa"]`),
+			Error:     &ErrorResponse{BlockID: 11, Timestamp: 2_000_000_000, Error: ErrorMessage{Code: -32000}},
+		},
+		{
+			Query:     &Body{Namespace: "debug", MethodBase: "traceCall", Method: "debug_traceCall"},
+			ParamsRaw: []byte(`[{},"latest",{}]`),
+			Response:  &Response{BlockID: 12, Timestamp: 3_000_000_000, Result: []byte(`{"gas":0,"failed":false,"returnValue":"","structLogs":[]}`)},
+		},
+	}
+}
+
+func checkRecords(t *testing.T, got, want []*RequestAndResults) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of records; got %v, want %v", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].Query.MethodBase != want[i].Query.MethodBase || !bytes.Equal(got[i].ParamsRaw, want[i].ParamsRaw) {
+			t.Errorf("unexpected query of record %v; got %v %s", i, got[i].Query.Method, got[i].ParamsRaw)
+		}
+		if want[i].Response != nil {
+			if got[i].Response == nil || !bytes.Equal(got[i].Response.Result, want[i].Response.Result) ||
+				got[i].Response.BlockID != want[i].Response.BlockID || got[i].Response.Timestamp != want[i].Response.Timestamp {
+				t.Errorf("unexpected response of record %v; got %+v, want %+v", i, got[i].Response, want[i].Response)
+			}
+		}
+		if want[i].Error != nil {
+			if got[i].Error == nil || got[i].Error.Error.Code != want[i].Error.Error.Code ||
+				got[i].Error.BlockID != want[i].Error.BlockID || got[i].Error.Timestamp != want[i].Error.Timestamp {
+				t.Errorf("unexpected error of record %v; got %+v, want %+v", i, got[i].Error, want[i].Error)
+			}
+		}
+	}
+}
+
+func TestWriter_WrittenRecordsAreRead(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	want := makeTestRecords()
+	for _, rec := range want {
+		if err := w.Write(rec); err != nil {
+			t.Fatalf("cannot write record; %v", err)
+		}
+	}
+
+	got := readRecording(t, newIterator(context.Background(), io.NopCloser(&buf), 10))
+	checkRecords(t, got, want)
+
+	// decoded params are usable by the replay
+	got[0].DecodeInfo()
+	if got[0].RecordedBlock != 10 || got[0].Timestamp != 1 {
+		t.Errorf("unexpected decoded info; block %v, timestamp %v", got[0].RecordedBlock, got[0].Timestamp)
+	}
+}
+
+func TestWriter_UnrecordableMethodIsRejected(t *testing.T) {
+	w := NewWriter(io.Discard)
+	err := w.Write(&RequestAndResults{
+		Query:     &Body{Namespace: "eth", MethodBase: "sendRawTransaction"},
+		ParamsRaw: []byte(`[]`),
+		Response:  &Response{Result: json.RawMessage(`"0x1"`)},
+	})
+	if err == nil {
+		t.Errorf("writing an unrecordable method must fail")
+	}
+}
+
+func TestFileWriter_CompressedRecordingIsRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recording.gz")
+	w, err := NewFileWriter(path)
+	if err != nil {
+		t.Fatalf("cannot create recording; %v", err)
+	}
+	want := makeTestRecords()
+	for _, rec := range want {
+		if err = w.Write(rec); err != nil {
+			t.Fatalf("cannot write record; %v", err)
+		}
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("cannot close recording; %v", err)
+	}
+
+	reader, err := NewFileReader(context.Background(), path)
+	if err != nil {
+		t.Fatalf("cannot open recording; %v", err)
+	}
+	checkRecords(t, readRecording(t, reader), want)
+}
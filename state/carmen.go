@@ -17,6 +17,7 @@
 package state
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"strings"
@@ -72,15 +73,26 @@ func MakeCarmenStateDBWithCacheSize(dir string, variant string, schema int, arch
 		properties.SetInteger(carmen.ArchiveCache, archiveCacheSize)
 	}
 
+	isNew, err := isEmptyDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot inspect carmen database directory; %w", err)
+	}
+
 	db, err := carmen.OpenDatabase(dir, cfg, properties)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open carmen database; %w", err)
 	}
 
+	index, err := openCarmenKeyIndex(dir, isNew)
+	if err != nil {
+		return nil, errors.Join(err, db.Close())
+	}
+
 	return &carmenHeadState{
 		carmenStateDB: carmenStateDB{
 			db: db,
 		},
+		index: index,
 	}, nil
 }
 
@@ -93,6 +105,7 @@ type carmenStateDB struct {
 type carmenHeadState struct {
 	carmenStateDB
 	blkCtx carmen.HeadBlockContext
+	index  *carmenKeyIndex // nil for state-dbs created before key indexing
 }
 
 type carmenHistoricState struct {
@@ -169,6 +182,50 @@ func (s *carmenStateDB) SetCode(addr common.Address, code []byte) {
 	s.txCtx.SetCode(carmen.Address(addr), code)
 }
 
+func (s *carmenHeadState) CreateAccount(addr common.Address) {
+	s.carmenStateDB.CreateAccount(addr)
+	s.touchAccount(addr)
+}
+
+func (s *carmenHeadState) Suicide(addr common.Address) bool {
+	res := s.carmenStateDB.Suicide(addr)
+	s.touchAccount(addr)
+	return res
+}
+
+func (s *carmenHeadState) AddBalance(addr common.Address, value *big.Int) {
+	s.carmenStateDB.AddBalance(addr, value)
+	s.touchAccount(addr)
+}
+
+func (s *carmenHeadState) SubBalance(addr common.Address, value *big.Int) {
+	s.carmenStateDB.SubBalance(addr, value)
+	s.touchAccount(addr)
+}
+
+func (s *carmenHeadState) SetNonce(addr common.Address, value uint64) {
+	s.carmenStateDB.SetNonce(addr, value)
+	s.touchAccount(addr)
+}
+
+func (s *carmenHeadState) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	s.carmenStateDB.SetState(addr, key, value)
+	if s.index != nil {
+		s.index.touchSlot(addr, key)
+	}
+}
+
+func (s *carmenHeadState) SetCode(addr common.Address, code []byte) {
+	s.carmenStateDB.SetCode(addr, code)
+	s.touchAccount(addr)
+}
+
+func (s *carmenHeadState) touchAccount(addr common.Address) {
+	if s.index != nil {
+		s.index.touchAccount(addr)
+	}
+}
+
 func (s *carmenStateDB) Snapshot() int {
 	return s.txCtx.Snapshot()
 }
@@ -187,6 +244,15 @@ func (s *carmenStateDB) EndTransaction() error {
 	return s.txCtx.Commit()
 }
 
+func (s *carmenHeadState) EndTransaction() error {
+	if s.index != nil {
+		if err := s.index.update(s.txCtx); err != nil {
+			return err
+		}
+	}
+	return s.carmenStateDB.EndTransaction()
+}
+
 func (s *carmenHeadState) BeginBlock(block uint64) error {
 	var err error
 	s.blkCtx, err = s.db.BeginBlock(block)
@@ -217,6 +283,18 @@ func (s *carmenStateDB) Close() error {
 	return s.db.Close()
 }
 
+func (s *carmenHeadState) Close() error {
+	err := s.carmenStateDB.Close()
+	if s.index != nil {
+		err = errors.Join(err, s.index.close())
+	}
+	return err
+}
+
+func (s *carmenStateDB) Flush() error {
+	return s.db.Flush()
+}
+
 func (s *carmenStateDB) AddRefund(amount uint64) {
 	s.txCtx.AddRefund(amount)
 }
@@ -346,12 +424,16 @@ func (s *carmenHistoricState) GetHash() (common.Hash, error) {
 	return common.Hash(h), err
 }
 
-func (s *carmenStateDB) StartBulkLoad(block uint64) (BulkLoad, error) {
+func (s *carmenHeadState) StartBulkLoad(block uint64) (BulkLoad, error) {
 	bl, err := s.db.StartBulkLoad(block)
 	if err != nil {
 		return nil, fmt.Errorf("cannot start bulkload; %w", err)
 	}
-	return &carmenBulkLoad{bl}, nil
+	load := &carmenBulkLoad{load: bl}
+	if s.index != nil {
+		load.index = newCarmenKeyIndexBulkLoad(s.index)
+	}
+	return load, nil
 }
 
 func (s *carmenHeadState) GetArchiveState(block uint64) (NonCommittableStateDB, error) {
@@ -395,34 +477,70 @@ func (s *carmenHistoricState) Release() error {
 	return s.blkCtx.Close()
 }
 
+// NewAccountIterator iterates accounts of the head state recorded in the key index of
+// the state-db. Accounts are visited in ascending order of their addresses.
+func (s *carmenHeadState) NewAccountIterator() (AccountIterator, error) {
+	if s.index == nil {
+		return nil, errCarmenNoKeyIndex
+	}
+	return s.index.newAccountIterator(), nil
+}
+
+// NewStorageIterator iterates storage of an account of the head state recorded in the
+// key index of the state-db. Slots are visited in ascending order of their keys.
+func (s *carmenHeadState) NewStorageIterator(addr common.Address) (StorageIterator, error) {
+	if s.index == nil {
+		return nil, errCarmenNoKeyIndex
+	}
+	return s.index.newStorageIterator(s.db, addr), nil
+}
+
 // ----------------------------------------------------------------------------
 //                                  BulkLoad
 // ----------------------------------------------------------------------------
 
 type carmenBulkLoad struct {
-	load carmen.BulkLoad
+	load  carmen.BulkLoad
+	index *carmenKeyIndexBulkLoad // nil for state-dbs created before key indexing
 }
 
 func (l *carmenBulkLoad) CreateAccount(addr common.Address) {
 	l.load.CreateAccount(carmen.Address(addr))
+	l.putAccount(addr)
 }
 
 func (l *carmenBulkLoad) SetBalance(addr common.Address, value *big.Int) {
 	l.load.SetBalance(carmen.Address(addr), value)
+	l.putAccount(addr)
 }
 
 func (l *carmenBulkLoad) SetNonce(addr common.Address, nonce uint64) {
 	l.load.SetNonce(carmen.Address(addr), nonce)
+	l.putAccount(addr)
 }
 
 func (l *carmenBulkLoad) SetState(addr common.Address, key common.Hash, value common.Hash) {
 	l.load.SetState(carmen.Address(addr), carmen.Key(key), carmen.Value(value))
+	if l.index != nil {
+		l.index.setSlot(addr, key, value)
+	}
 }
 
 func (l *carmenBulkLoad) SetCode(addr common.Address, code []byte) {
 	l.load.SetCode(carmen.Address(addr), code)
+	l.putAccount(addr)
+}
+
+func (l *carmenBulkLoad) putAccount(addr common.Address) {
+	if l.index != nil {
+		l.index.putAccount(addr)
+	}
 }
 
 func (l *carmenBulkLoad) Close() error {
-	return l.load.Finalize()
+	err := l.load.Finalize()
+	if l.index != nil {
+		err = errors.Join(err, l.index.close())
+	}
+	return err
 }
@@ -0,0 +1,321 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Carmen/go/carmen"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// carmenKeyIndexDir is the directory inside a Carmen state-db holding its key index.
+const carmenKeyIndexDir = "aida-key-index"
+
+// carmenKeyIndexBatchSize is the number of bulk-load updates buffered before they are written.
+const carmenKeyIndexBatchSize = 100_000
+
+var (
+	carmenKeyIndexAccountPrefix = []byte{'a'}
+	carmenKeyIndexSlotPrefix    = []byte{'s'}
+
+	// carmenKeyIndexCompleteKey marks an index tracking all keys since the creation of its state-db.
+	carmenKeyIndexCompleteKey = []byte("complete")
+)
+
+// errCarmenNoKeyIndex is reported when iterating a Carmen state-db created before key indexing.
+var errCarmenNoKeyIndex = fmt.Errorf("carmen state-db has no key index since it was created before key indexing; %w", ErrIterationNotSupported)
+
+// carmenKeyIndex records addresses of accounts and keys of storage slots of a Carmen
+// state-db since the Carmen API offers no means for enumerating them. Values are not
+// indexed, they are read from the state-db while iterating.
+type carmenKeyIndex struct {
+	db       *leveldb.DB
+	accounts map[common.Address]struct{}                 // accounts touched by the current transaction
+	slots    map[common.Address]map[common.Hash]struct{} // slots touched by the current transaction
+}
+
+// isEmptyDir reports whether dir does not exist or contains no entries.
+func isEmptyDir(dir string) (bool, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(entries) == 0, nil
+}
+
+// openCarmenKeyIndex opens the key index of the Carmen state-db in dir. If create is set,
+// a new index is created for a new state-db. Otherwise, nil is returned if the state-db
+// has no complete index.
+func openCarmenKeyIndex(dir string, create bool) (*carmenKeyIndex, error) {
+	path := filepath.Join(dir, carmenKeyIndexDir)
+	if !create {
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open key index; %w", err)
+	}
+
+	if create {
+		err = db.Put(carmenKeyIndexCompleteKey, nil, nil)
+	} else {
+		var complete bool
+		complete, err = db.Has(carmenKeyIndexCompleteKey, nil)
+		if err == nil && !complete {
+			return nil, db.Close()
+		}
+	}
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot initialize key index; %w", err), db.Close())
+	}
+
+	return &carmenKeyIndex{
+		db:       db,
+		accounts: make(map[common.Address]struct{}),
+		slots:    make(map[common.Address]map[common.Hash]struct{}),
+	}, nil
+}
+
+func carmenKeyIndexAccountKey(addr common.Address) []byte {
+	return append(bytes.Clone(carmenKeyIndexAccountPrefix), addr[:]...)
+}
+
+func carmenKeyIndexStoragePrefix(addr common.Address) []byte {
+	return append(bytes.Clone(carmenKeyIndexSlotPrefix), addr[:]...)
+}
+
+func carmenKeyIndexSlotKey(addr common.Address, key common.Hash) []byte {
+	return append(carmenKeyIndexStoragePrefix(addr), key[:]...)
+}
+
+// touchAccount records an account modified by the current transaction.
+func (i *carmenKeyIndex) touchAccount(addr common.Address) {
+	i.accounts[addr] = struct{}{}
+}
+
+// touchSlot records a storage slot modified by the current transaction.
+func (i *carmenKeyIndex) touchSlot(addr common.Address, key common.Hash) {
+	i.touchAccount(addr)
+	keys, found := i.slots[addr]
+	if !found {
+		keys = make(map[common.Hash]struct{})
+		i.slots[addr] = keys
+	}
+	keys[key] = struct{}{}
+}
+
+// update writes the final state of accounts and slots touched by the transaction of txCtx
+// to the index. It must be called before the transaction is committed. Self-destructed
+// accounts are removed with their storage, empty accounts are removed as they are deleted
+// at the end of the transaction.
+func (i *carmenKeyIndex) update(txCtx carmen.TransactionContext) error {
+	batch := new(leveldb.Batch)
+	for addr := range i.accounts {
+		if txCtx.HasSelfDestructed(carmen.Address(addr)) {
+			batch.Delete(carmenKeyIndexAccountKey(addr))
+			if err := i.deleteStorage(batch, addr); err != nil {
+				return err
+			}
+			delete(i.slots, addr)
+			continue
+		}
+		if txCtx.Empty(carmen.Address(addr)) {
+			batch.Delete(carmenKeyIndexAccountKey(addr))
+		} else {
+			batch.Put(carmenKeyIndexAccountKey(addr), nil)
+		}
+	}
+	for addr, keys := range i.slots {
+		for key := range keys {
+			if txCtx.GetState(carmen.Address(addr), carmen.Key(key)) == (carmen.Value{}) {
+				batch.Delete(carmenKeyIndexSlotKey(addr, key))
+			} else {
+				batch.Put(carmenKeyIndexSlotKey(addr, key), nil)
+			}
+		}
+	}
+	clear(i.accounts)
+	clear(i.slots)
+
+	if err := i.db.Write(batch, nil); err != nil {
+		return fmt.Errorf("cannot update key index; %w", err)
+	}
+	return nil
+}
+
+// deleteStorage adds deletions of all indexed slots of the given account to batch.
+func (i *carmenKeyIndex) deleteStorage(batch *leveldb.Batch, addr common.Address) error {
+	it := i.db.NewIterator(util.BytesPrefix(carmenKeyIndexStoragePrefix(addr)), nil)
+	defer it.Release()
+	for it.Next() {
+		batch.Delete(bytes.Clone(it.Key()))
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("cannot read storage keys of %v from key index; %w", addr, err)
+	}
+	return nil
+}
+
+// newAccountIterator creates an iterator over indexed accounts in ascending order of addresses.
+func (i *carmenKeyIndex) newAccountIterator() AccountIterator {
+	return &carmenAccountIterator{
+		it: i.db.NewIterator(util.BytesPrefix(carmenKeyIndexAccountPrefix), nil),
+	}
+}
+
+// newStorageIterator creates an iterator over non-empty slots of an account in ascending
+// order of keys. Values of the slots are read from the head state of db.
+func (i *carmenKeyIndex) newStorageIterator(db carmen.Database, addr common.Address) StorageIterator {
+	return &carmenStorageIterator{
+		db:   db,
+		addr: addr,
+		it:   i.db.NewIterator(util.BytesPrefix(carmenKeyIndexStoragePrefix(addr)), nil),
+	}
+}
+
+func (i *carmenKeyIndex) close() error {
+	return i.db.Close()
+}
+
+// carmenAccountIterator iterates accounts recorded in a carmenKeyIndex.
+type carmenAccountIterator struct {
+	it iterator.Iterator
+}
+
+func (i *carmenAccountIterator) Next() bool {
+	return i.it.Next()
+}
+
+func (i *carmenAccountIterator) Address() common.Address {
+	return common.BytesToAddress(i.it.Key()[len(carmenKeyIndexAccountPrefix):])
+}
+
+func (i *carmenAccountIterator) Error() error {
+	return i.it.Error()
+}
+
+func (i *carmenAccountIterator) Release() {
+	i.it.Release()
+}
+
+// carmenStorageIterator iterates slots of an account recorded in a carmenKeyIndex,
+// skipping slots cleared in the state-db.
+type carmenStorageIterator struct {
+	db    carmen.Database
+	addr  common.Address
+	it    iterator.Iterator
+	key   common.Hash
+	value common.Hash
+	err   error
+}
+
+func (i *carmenStorageIterator) Next() bool {
+	for i.err == nil && i.it.Next() {
+		key := common.BytesToHash(i.it.Key()[len(carmenKeyIndexSlotPrefix)+common.AddressLength:])
+		var value common.Hash
+		i.err = i.db.QueryHeadState(func(ctxt carmen.QueryContext) {
+			value = common.Hash(ctxt.GetState(carmen.Address(i.addr), carmen.Key(key)))
+		})
+		if i.err == nil && value != (common.Hash{}) {
+			i.key, i.value = key, value
+			return true
+		}
+	}
+	return false
+}
+
+func (i *carmenStorageIterator) Key() common.Hash {
+	return i.key
+}
+
+func (i *carmenStorageIterator) Value() common.Hash {
+	return i.value
+}
+
+func (i *carmenStorageIterator) Error() error {
+	if i.err != nil {
+		return fmt.Errorf("cannot read slot of %v; %w", i.addr, i.err)
+	}
+	return i.it.Error()
+}
+
+func (i *carmenStorageIterator) Release() {
+	i.it.Release()
+}
+
+// carmenKeyIndexBulkLoad records keys written by a bulk-load into a carmenKeyIndex. The first
+// failed write is retained and reported by close.
+type carmenKeyIndexBulkLoad struct {
+	index *carmenKeyIndex
+	batch *leveldb.Batch
+	err   error
+}
+
+func newCarmenKeyIndexBulkLoad(index *carmenKeyIndex) *carmenKeyIndexBulkLoad {
+	return &carmenKeyIndexBulkLoad{index: index, batch: new(leveldb.Batch)}
+}
+
+func (l *carmenKeyIndexBulkLoad) putAccount(addr common.Address) {
+	l.batch.Put(carmenKeyIndexAccountKey(addr), nil)
+	l.writeIfFull()
+}
+
+func (l *carmenKeyIndexBulkLoad) setSlot(addr common.Address, key common.Hash, value common.Hash) {
+	l.batch.Put(carmenKeyIndexAccountKey(addr), nil)
+	if value == (common.Hash{}) {
+		l.batch.Delete(carmenKeyIndexSlotKey(addr, key))
+	} else {
+		l.batch.Put(carmenKeyIndexSlotKey(addr, key), nil)
+	}
+	l.writeIfFull()
+}
+
+func (l *carmenKeyIndexBulkLoad) writeIfFull() {
+	if l.batch.Len() >= carmenKeyIndexBatchSize {
+		l.write()
+	}
+}
+
+func (l *carmenKeyIndexBulkLoad) write() {
+	if l.err == nil {
+		l.err = l.index.db.Write(l.batch, nil)
+	}
+	l.batch.Reset()
+}
+
+// close writes remaining updates and reports the first failed write.
+func (l *carmenKeyIndexBulkLoad) close() error {
+	l.write()
+	if l.err != nil {
+		return fmt.Errorf("cannot update key index; %w", l.err)
+	}
+	return nil
+}
@@ -28,7 +28,10 @@ import (
 	geth "github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
 )
 
 const (
@@ -38,14 +41,26 @@ const (
 )
 
 func MakeGethStateDB(directory, variant string, rootHash common.Hash, isArchiveMode bool, chainConduit *ChainConduit) (StateDB, error) {
-	if variant != "" {
-		return nil, fmt.Errorf("unknown variant: %v", variant)
-	}
 	const cacheSize = 512
 	const fileHandle = 128
-	ldb, err := rawdb.NewLevelDBDatabase(directory, cacheSize, fileHandle, "", false)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create a new Level DB. %v", err)
+	var (
+		ldb    ethdb.Database
+		pebble *pebbleDatabase
+		err    error
+	)
+	switch variant {
+	case "", "leveldb":
+		ldb, err = rawdb.NewLevelDBDatabase(directory, cacheSize, fileHandle, "", false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a new Level DB. %v", err)
+		}
+	case "pebble":
+		pebble, ldb, err = newPebbleDatabase(directory, cacheSize, fileHandle, false)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create a new Pebble DB. %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown variant: %v", variant)
 	}
 	evmState := geth.NewDatabase(ldb)
 	db, err := geth.New(rootHash, evmState, nil)
@@ -60,6 +75,7 @@ func MakeGethStateDB(directory, variant string, rootHash common.Hash, isArchiveM
 		triegc:        prque.New(nil),
 		isArchiveMode: isArchiveMode,
 		chainConduit:  chainConduit,
+		pebble:        pebble,
 	}, nil
 }
 
@@ -78,6 +94,7 @@ type gethStateDB struct {
 	isArchiveMode bool
 	chainConduit  *ChainConduit // chain configuration
 	block         *big.Int
+	pebble        *pebbleDatabase // key-value store of the pebble variant, nil otherwise
 }
 
 func (s *gethStateDB) CreateAccount(addr common.Address) {
@@ -278,6 +295,17 @@ func (s *gethStateDB) Close() error {
 	return db.DiskDB().Close()
 }
 
+func (s *gethStateDB) Flush() error {
+	if s.evmState == nil {
+		return nil
+	}
+	// commit the trie of the last completed block to the disk
+	if err := s.evmState.TrieDB().Commit(s.stateRoot, false, nil); err != nil {
+		return fmt.Errorf("cannot flush trie DB into main DB; %w", err)
+	}
+	return nil
+}
+
 func (s *gethStateDB) AddRefund(gas uint64) {
 	s.db.AddRefund(gas)
 }
@@ -341,6 +369,9 @@ func (s *gethStateDB) GetArchiveBlockHeight() (uint64, bool, error) {
 }
 
 func (s *gethStateDB) GetMemoryUsage() *MemoryUsage {
+	if s.pebble != nil {
+		return s.pebble.memoryUsage()
+	}
 	// not supported yet
 	return &MemoryUsage{uint64(0), nil}
 }
@@ -448,3 +479,127 @@ func (s *gethStateDB) trieCap() {
 func (s *gethStateDB) GetShadowDB() StateDB {
 	return nil
 }
+
+func (s *gethStateDB) NewAccountIterator() (AccountIterator, error) {
+	tr, err := s.evmState.OpenTrie(s.stateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open state trie; %w", err)
+	}
+	return &gethAccountIterator{trie: tr, it: trie.NewIterator(tr.NodeIterator(nil))}, nil
+}
+
+func (s *gethStateDB) NewStorageIterator(addr common.Address) (StorageIterator, error) {
+	tr, err := s.evmState.OpenTrie(s.stateRoot)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open state trie; %w", err)
+	}
+	enc, err := tr.TryGet(addr.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read account %v; %w", addr, err)
+	}
+	if len(enc) == 0 {
+		return &gethStorageIterator{}, nil
+	}
+	var acc geth.Account
+	if err = rlp.DecodeBytes(enc, &acc); err != nil {
+		return nil, fmt.Errorf("cannot decode account %v; %w", addr, err)
+	}
+	storage, err := s.evmState.OpenStorageTrie(crypto.Keccak256Hash(addr.Bytes()), acc.Root)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open storage trie of %v; %w", addr, err)
+	}
+	return &gethStorageIterator{trie: storage, it: trie.NewIterator(storage.NodeIterator(nil))}, nil
+}
+
+// gethAccountIterator iterates leaves of the state trie. Since the trie is keyed by
+// hashed addresses, addresses are recovered from the preimages kept by the trie database.
+type gethAccountIterator struct {
+	trie geth.Trie
+	it   *trie.Iterator
+	addr common.Address
+	err  error
+}
+
+func (i *gethAccountIterator) Next() bool {
+	if i.it == nil || i.err != nil || !i.it.Next() {
+		return false
+	}
+	preimage := i.trie.GetKey(i.it.Key)
+	if preimage == nil {
+		i.err = fmt.Errorf("missing preimage of account hash %x", i.it.Key)
+		return false
+	}
+	i.addr = common.BytesToAddress(preimage)
+	return true
+}
+
+func (i *gethAccountIterator) Address() common.Address {
+	return i.addr
+}
+
+func (i *gethAccountIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	if i.it != nil {
+		return i.it.Err
+	}
+	return nil
+}
+
+func (i *gethAccountIterator) Release() {
+	i.it = nil
+	i.trie = nil
+}
+
+// gethStorageIterator iterates leaves of a storage trie recovering slot keys from preimages.
+type gethStorageIterator struct {
+	trie  geth.Trie
+	it    *trie.Iterator
+	key   common.Hash
+	value common.Hash
+	err   error
+}
+
+func (i *gethStorageIterator) Next() bool {
+	if i.it == nil || i.err != nil || !i.it.Next() {
+		return false
+	}
+	preimage := i.trie.GetKey(i.it.Key)
+	if preimage == nil {
+		i.err = fmt.Errorf("missing preimage of storage key hash %x", i.it.Key)
+		return false
+	}
+	// values are stored RLP encoded with leading zeros trimmed
+	_, content, _, err := rlp.Split(i.it.Value)
+	if err != nil {
+		i.err = fmt.Errorf("cannot decode storage value of %x; %w", preimage, err)
+		return false
+	}
+	i.key = common.BytesToHash(preimage)
+	i.value = common.BytesToHash(content)
+	return true
+}
+
+func (i *gethStorageIterator) Key() common.Hash {
+	return i.key
+}
+
+func (i *gethStorageIterator) Value() common.Hash {
+	return i.value
+}
+
+func (i *gethStorageIterator) Error() error {
+	if i.err != nil {
+		return i.err
+	}
+	if i.it != nil {
+		return i.it.Err
+	}
+	return nil
+}
+
+func (i *gethStorageIterator) Release() {
+	i.it = nil
+	i.trie = nil
+}
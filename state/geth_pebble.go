@@ -0,0 +1,263 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/bloom"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	// pebbleMinCache is the minimum amount of memory in megabytes to allocate to
+	// pebble read and write caching, split half and half.
+	pebbleMinCache = 16
+
+	// pebbleMinHandles is the minimum number of files handles to allocate to the
+	// open database files.
+	pebbleMinHandles = 16
+)
+
+// pebbleDatabase is a key-value store backed by pebble used as an alternative to
+// leveldb underneath the Geth state trie.
+type pebbleDatabase struct {
+	db *pebble.DB
+
+	quitLock sync.Mutex
+	closed   bool
+}
+
+// newPebbleDatabase opens a pebble database in given directory using cache megabytes
+// of memory and at most handles open files. The returned database has no freezer.
+func newPebbleDatabase(directory string, cache int, handles int, readonly bool) (*pebbleDatabase, ethdb.Database, error) {
+	if cache < pebbleMinCache {
+		cache = pebbleMinCache
+	}
+	if handles < pebbleMinHandles {
+		handles = pebbleMinHandles
+	}
+
+	// half of the cache is used for memory tables; two of them may be in use at
+	// the same time, one being flushed while the other is filled
+	memTableSize := cache * 1024 * 1024 / 2 / 2
+	opts := &pebble.Options{
+		Cache:                       pebble.NewCache(int64(cache * 1024 * 1024 / 2)),
+		MaxOpenFiles:                handles,
+		MemTableSize:                memTableSize,
+		MemTableStopWritesThreshold: 2,
+		MaxConcurrentCompactions:    runtime.NumCPU,
+		ReadOnly:                    readonly,
+	}
+	opts.Levels = make([]pebble.LevelOptions, 7)
+	for i := range opts.Levels {
+		opts.Levels[i] = pebble.LevelOptions{
+			TargetFileSize: int64(2*1024*1024) << i,
+			FilterPolicy:   bloom.FilterPolicy(10),
+		}
+	}
+	opts.Experimental.ReadSamplingMultiplier = -1
+
+	db, err := pebble.Open(directory, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot open pebble database; %w", err)
+	}
+	store := &pebbleDatabase{db: db}
+	return store, rawdb.NewDatabase(store), nil
+}
+
+func (d *pebbleDatabase) Has(key []byte) (bool, error) {
+	_, closer, err := d.db.Get(key)
+	if err == pebble.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, closer.Close()
+}
+
+func (d *pebbleDatabase) Get(key []byte) ([]byte, error) {
+	value, closer, err := d.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]byte, len(value))
+	copy(res, value)
+	return res, closer.Close()
+}
+
+func (d *pebbleDatabase) Put(key []byte, value []byte) error {
+	return d.db.Set(key, value, pebble.NoSync)
+}
+
+func (d *pebbleDatabase) Delete(key []byte) error {
+	return d.db.Delete(key, pebble.NoSync)
+}
+
+func (d *pebbleDatabase) NewBatch() ethdb.Batch {
+	return &pebbleBatch{batch: d.db.NewBatch()}
+}
+
+func (d *pebbleDatabase) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
+	it := d.db.NewIter(&pebble.IterOptions{
+		LowerBound: append(append([]byte{}, prefix...), start...),
+		UpperBound: upperBound(prefix),
+	})
+	it.First()
+	return &pebbleIterator{it: it, moved: true}
+}
+
+// Stat returns the internal metrics of pebble, the property is ignored.
+func (d *pebbleDatabase) Stat(string) (string, error) {
+	return d.db.Metrics().String(), nil
+}
+
+func (d *pebbleDatabase) Compact(start []byte, limit []byte) error {
+	// pebble requires an upper bound, an open range is closed by the largest key
+	if limit == nil {
+		it := d.db.NewIter(nil)
+		if it.Last() {
+			limit = append(append([]byte{}, it.Key()...), 0)
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+		if limit == nil {
+			return nil
+		}
+	}
+	return d.db.Compact(start, limit, true)
+}
+
+func (d *pebbleDatabase) Close() error {
+	d.quitLock.Lock()
+	defer d.quitLock.Unlock()
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+	return d.db.Close()
+}
+
+// memoryUsage reports memory used by the block cache and memory tables of pebble.
+func (d *pebbleDatabase) memoryUsage() *MemoryUsage {
+	metrics := d.db.Metrics()
+	return &MemoryUsage{
+		UsedBytes: uint64(metrics.BlockCache.Size) + metrics.MemTable.Size,
+		Breakdown: metrics,
+	}
+}
+
+// upperBound returns the smallest key larger than all keys with the given prefix,
+// nil if there is no such key.
+func upperBound(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit := make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
+
+// pebbleBatch buffers writes until they are applied to the database at once.
+type pebbleBatch struct {
+	batch *pebble.Batch
+	size  int
+}
+
+func (b *pebbleBatch) Put(key []byte, value []byte) error {
+	b.size += len(key) + len(value)
+	return b.batch.Set(key, value, nil)
+}
+
+func (b *pebbleBatch) Delete(key []byte) error {
+	b.size += len(key)
+	return b.batch.Delete(key, nil)
+}
+
+func (b *pebbleBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *pebbleBatch) Write() error {
+	return b.batch.Commit(pebble.NoSync)
+}
+
+func (b *pebbleBatch) Reset() {
+	b.batch.Reset()
+	b.size = 0
+}
+
+func (b *pebbleBatch) Replay(w ethdb.KeyValueWriter) error {
+	reader := b.batch.Reader()
+	for {
+		kind, key, value, ok := reader.Next()
+		if !ok {
+			return nil
+		}
+		var err error
+		switch kind {
+		case pebble.InternalKeyKindSet:
+			err = w.Put(key, value)
+		case pebble.InternalKeyKindDelete:
+			err = w.Delete(key)
+		default:
+			err = fmt.Errorf("unsupported batch operation %v", kind)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// pebbleIterator adapts a pebble iterator, which is positioned at its first element
+// on creation, to the ethdb iterator protocol requiring Next to be called first.
+type pebbleIterator struct {
+	it    *pebble.Iterator
+	moved bool
+}
+
+func (i *pebbleIterator) Next() bool {
+	if i.moved {
+		i.moved = false
+		return i.it.Valid()
+	}
+	return i.it.Next()
+}
+
+func (i *pebbleIterator) Error() error {
+	return i.it.Error()
+}
+
+func (i *pebbleIterator) Key() []byte {
+	return i.it.Key()
+}
+
+func (i *pebbleIterator) Value() []byte {
+	return i.it.Value()
+}
+
+func (i *pebbleIterator) Release() {
+	i.it.Close()
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+)
+
+func TestGethPebble_DataIsPersisted(t *testing.T) {
+	dir := t.TempDir()
+	db, err := MakeGethStateDB(dir, "pebble", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	fillIterationTestDb(t, db)
+	root, err := db.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get state hash; %v", err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatalf("cannot close state-db; %v", err)
+	}
+
+	db, err = MakeGethStateDB(dir, "pebble", root, false, nil)
+	if err != nil {
+		t.Fatalf("cannot reopen state-db; %v", err)
+	}
+	defer db.Close()
+	checkIterationTestDb(t, db)
+}
+
+func TestGethPebble_HashMatchesLevelDbVariant(t *testing.T) {
+	var roots []common.Hash
+	for _, variant := range []string{"leveldb", "pebble"} {
+		db, err := MakeGethStateDB(t.TempDir(), variant, common.Hash{}, true, nil)
+		if err != nil {
+			t.Fatalf("cannot create %v state-db; %v", variant, err)
+		}
+		fillIterationTestDb(t, db)
+		root, err := db.GetHash()
+		if err != nil {
+			t.Fatalf("cannot get state hash; %v", err)
+		}
+		roots = append(roots, root)
+		if err = db.Close(); err != nil {
+			t.Fatalf("cannot close state-db; %v", err)
+		}
+	}
+	if roots[0] != roots[1] {
+		t.Errorf("state hashes of variants differ; leveldb: %v, pebble: %v", roots[0], roots[1])
+	}
+}
+
+func TestGethPebble_MemoryUsageIsReported(t *testing.T) {
+	db, err := MakeGethStateDB(t.TempDir(), "pebble", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	defer db.Close()
+	fillIterationTestDb(t, db)
+
+	usage := db.GetMemoryUsage()
+	if usage == nil || usage.UsedBytes == 0 || usage.Breakdown == nil {
+		t.Errorf("memory usage is not reported: %v", usage)
+	}
+}
+
+func TestGethPebble_UnknownVariantIsRejected(t *testing.T) {
+	if _, err := MakeGethStateDB(t.TempDir(), "rocksdb", common.Hash{}, false, nil); err == nil {
+		t.Errorf("unknown variant must be rejected")
+	}
+}
+
+func TestPebbleDatabase_BatchesAndIterators(t *testing.T) {
+	store, _, err := newPebbleDatabase(t.TempDir(), 0, 0, false)
+	if err != nil {
+		t.Fatalf("cannot open pebble database; %v", err)
+	}
+	defer store.Close()
+
+	batch := store.NewBatch()
+	for _, key := range []string{"a1", "a2", "a3", "b1"} {
+		if err = batch.Put([]byte(key), []byte("v"+key)); err != nil {
+			t.Fatalf("cannot put %v; %v", key, err)
+		}
+	}
+	if err = batch.Delete([]byte("a2")); err != nil {
+		t.Fatalf("cannot delete; %v", err)
+	}
+	if err = batch.Write(); err != nil {
+		t.Fatalf("cannot write batch; %v", err)
+	}
+
+	replayed := memorydb.New()
+	if err = batch.Replay(replayed); err != nil {
+		t.Fatalf("cannot replay batch; %v", err)
+	}
+	if has, _ := replayed.Has([]byte("a2")); has {
+		t.Errorf("deletion was not replayed")
+	}
+
+	if value, err := store.Get([]byte("a1")); err != nil || !bytes.Equal(value, []byte("va1")) {
+		t.Errorf("unexpected value of a1; got: %s, %v", value, err)
+	}
+	if has, err := store.Has([]byte("a2")); err != nil || has {
+		t.Errorf("deleted key is still present")
+	}
+
+	it := store.NewIterator([]byte("a"), []byte("2"))
+	defer it.Release()
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	if len(keys) != 1 || keys[0] != "a3" {
+		t.Errorf("unexpected iterated keys %v", keys)
+	}
+
+	if err = store.Compact(nil, nil); err != nil {
+		t.Errorf("cannot compact; %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrIterationNotSupported is reported when iterating a state not implementing IterableState.
+var ErrIterationNotSupported = errors.New("iteration is not supported by this state-db implementation")
+
+// NewAccountIterator creates an iterator over all accounts of db if it implements IterableState.
+func NewAccountIterator(db VmStateDB) (AccountIterator, error) {
+	if iterable, ok := db.(IterableState); ok {
+		return iterable.NewAccountIterator()
+	}
+	return nil, ErrIterationNotSupported
+}
+
+// NewStorageIterator creates an iterator over the storage of an account of db if it implements IterableState.
+func NewStorageIterator(db VmStateDB, addr common.Address) (StorageIterator, error) {
+	if iterable, ok := db.(IterableState); ok {
+		return iterable.NewStorageIterator(addr)
+	}
+	return nil, ErrIterationNotSupported
+}
+
+// IsIterable reports whether the content of db can be enumerated. States implementing
+// IterableState may still not support iteration, e.g. Carmen state-dbs without key index.
+func IsIterable(db VmStateDB) bool {
+	it, err := NewAccountIterator(db)
+	if errors.Is(err, ErrIterationNotSupported) {
+		return false
+	}
+	if it != nil {
+		it.Release()
+	}
+	return true
+}
+
+// sliceAccountIterator iterates a list of addresses collected upfront.
+type sliceAccountIterator struct {
+	addresses []common.Address
+	pos       int
+}
+
+// newSliceAccountIterator creates an iterator visiting given addresses in ascending order.
+func newSliceAccountIterator(addresses []common.Address) *sliceAccountIterator {
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	return &sliceAccountIterator{addresses: addresses, pos: -1}
+}
+
+func (i *sliceAccountIterator) Next() bool {
+	if i.pos+1 >= len(i.addresses) {
+		i.pos = len(i.addresses)
+		return false
+	}
+	i.pos++
+	return true
+}
+
+func (i *sliceAccountIterator) Address() common.Address {
+	return i.addresses[i.pos]
+}
+
+func (i *sliceAccountIterator) Error() error {
+	return nil
+}
+
+func (i *sliceAccountIterator) Release() {
+	i.addresses = nil
+}
+
+// sliceStorageIterator iterates a set of storage slots collected upfront.
+type sliceStorageIterator struct {
+	keys   []common.Hash
+	values map[common.Hash]common.Hash
+	pos    int
+}
+
+// newSliceStorageIterator creates an iterator visiting non-empty slots of given storage
+// in ascending order of their keys.
+func newSliceStorageIterator(storage map[common.Hash]common.Hash) *sliceStorageIterator {
+	keys := make([]common.Hash, 0, len(storage))
+	for key, value := range storage {
+		if value != (common.Hash{}) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+	return &sliceStorageIterator{keys: keys, values: storage, pos: -1}
+}
+
+func (i *sliceStorageIterator) Next() bool {
+	if i.pos+1 >= len(i.keys) {
+		i.pos = len(i.keys)
+		return false
+	}
+	i.pos++
+	return true
+}
+
+func (i *sliceStorageIterator) Key() common.Hash {
+	return i.keys[i.pos]
+}
+
+func (i *sliceStorageIterator) Value() common.Hash {
+	return i.values[i.keys[i.pos]]
+}
+
+func (i *sliceStorageIterator) Error() error {
+	return nil
+}
+
+func (i *sliceStorageIterator) Release() {
+	i.keys = nil
+	i.values = nil
+}
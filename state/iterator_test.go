@@ -0,0 +1,346 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/carmen"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+// fillIterationTestDb adds three accounts with storage to the given DB in a single block.
+func fillIterationTestDb(t *testing.T, db StateDB) {
+	if err := db.BeginBlock(1); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	if err := db.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	for i := byte(1); i <= 3; i++ {
+		addr := common.Address{i}
+		db.CreateAccount(addr)
+		db.AddBalance(addr, big.NewInt(int64(i)))
+		db.SetNonce(addr, uint64(i))
+		for j := byte(1); j <= i; j++ {
+			db.SetState(addr, common.Hash{j}, common.Hash{i, j})
+		}
+	}
+	if err := db.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if err := db.EndBlock(); err != nil {
+		t.Fatalf("cannot end block; %v", err)
+	}
+}
+
+// collectIterationTestDb returns all accounts and their storage visible through iterators.
+func collectIterationTestDb(t *testing.T, db VmStateDB) map[common.Address]map[common.Hash]common.Hash {
+	accounts, err := NewAccountIterator(db)
+	if err != nil {
+		t.Fatalf("cannot create account iterator; %v", err)
+	}
+	defer accounts.Release()
+
+	res := make(map[common.Address]map[common.Hash]common.Hash)
+	for accounts.Next() {
+		addr := accounts.Address()
+		storage, err := NewStorageIterator(db, addr)
+		if err != nil {
+			t.Fatalf("cannot create storage iterator of %v; %v", addr, err)
+		}
+		res[addr] = make(map[common.Hash]common.Hash)
+		for storage.Next() {
+			res[addr][storage.Key()] = storage.Value()
+		}
+		if err = storage.Error(); err != nil {
+			t.Fatalf("storage iteration of %v failed; %v", addr, err)
+		}
+		storage.Release()
+	}
+	if err = accounts.Error(); err != nil {
+		t.Fatalf("account iteration failed; %v", err)
+	}
+	return res
+}
+
+func checkIterationTestDb(t *testing.T, db VmStateDB) {
+	got := collectIterationTestDb(t, db)
+	if len(got) != 3 {
+		t.Fatalf("unexpected number of accounts; got: %v, want: 3", len(got))
+	}
+	for i := byte(1); i <= 3; i++ {
+		storage, found := got[common.Address{i}]
+		if !found {
+			t.Fatalf("account %v was not iterated", common.Address{i})
+		}
+		if len(storage) != int(i) {
+			t.Errorf("unexpected number of storage slots of %v; got: %v, want: %v", common.Address{i}, len(storage), i)
+		}
+		for j := byte(1); j <= i; j++ {
+			if want := (common.Hash{i, j}); storage[common.Hash{j}] != want {
+				t.Errorf("unexpected value of slot %v of %v; got: %v, want: %v", common.Hash{j}, common.Address{i}, storage[common.Hash{j}], want)
+			}
+		}
+	}
+}
+
+func TestIterator_InMemoryDbIteratesAccountsAndStorage(t *testing.T) {
+	db, err := MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	fillIterationTestDb(t, db)
+	checkIterationTestDb(t, db)
+}
+
+func TestIterator_InMemoryDbSkipsDeletedAccountsAndClearedSlots(t *testing.T) {
+	db, err := MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	fillIterationTestDb(t, db)
+	db.Suicide(common.Address{1})
+	db.SetState(common.Address{2}, common.Hash{1}, common.Hash{})
+
+	got := collectIterationTestDb(t, db)
+	if _, found := got[common.Address{1}]; found {
+		t.Errorf("deleted account must not be iterated")
+	}
+	if storage := got[common.Address{2}]; len(storage) != 1 {
+		t.Errorf("cleared storage slot must not be iterated; got: %v", storage)
+	}
+}
+
+func TestIterator_GethDbIteratesAccountsAndStorage(t *testing.T) {
+	db, err := MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	defer db.Close()
+	fillIterationTestDb(t, db)
+	checkIterationTestDb(t, db)
+}
+
+func TestIterator_GethDbIteratesReopenedDb(t *testing.T) {
+	dir := t.TempDir()
+	db, err := MakeGethStateDB(dir, "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	fillIterationTestDb(t, db)
+	root, err := db.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get state hash; %v", err)
+	}
+	if err = db.Close(); err != nil {
+		t.Fatalf("cannot close state-db; %v", err)
+	}
+
+	db, err = MakeGethStateDB(dir, "", root, false, nil)
+	if err != nil {
+		t.Fatalf("cannot reopen state-db; %v", err)
+	}
+	defer db.Close()
+	checkIterationTestDb(t, db)
+}
+
+func TestIterator_GethDbIteratesNoStorageOfUnknownAccount(t *testing.T) {
+	db, err := MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	defer db.Close()
+	fillIterationTestDb(t, db)
+
+	it, err := NewStorageIterator(db, common.Address{0xff})
+	if err != nil {
+		t.Fatalf("cannot create storage iterator; %v", err)
+	}
+	defer it.Release()
+	if it.Next() {
+		t.Errorf("unknown account must not have storage")
+	}
+	if err = it.Error(); err != nil {
+		t.Errorf("unexpected error; %v", err)
+	}
+}
+
+func TestIterator_CarmenDbIteratesAccountsAndStorage(t *testing.T) {
+	for _, tc := range GetCarmenStateTestCases() {
+		t.Run(tc.String(), func(t *testing.T) {
+			db, err := MakeCarmenStateDB(t.TempDir(), tc.Variant, tc.Schema, tc.Archive)
+			if errors.Is(err, carmen.UnsupportedConfiguration) {
+				t.Skip("unsupported configuration")
+			}
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			defer db.Close()
+			fillIterationTestDb(t, db)
+			checkIterationTestDb(t, db)
+		})
+	}
+}
+
+func TestIterator_CarmenDbSkipsDeletedAccountsAndClearedSlots(t *testing.T) {
+	for _, tc := range GetCarmenStateTestCases() {
+		t.Run(tc.String(), func(t *testing.T) {
+			db, err := MakeCarmenStateDB(t.TempDir(), tc.Variant, tc.Schema, tc.Archive)
+			if errors.Is(err, carmen.UnsupportedConfiguration) {
+				t.Skip("unsupported configuration")
+			}
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			defer db.Close()
+			fillIterationTestDb(t, db)
+
+			if err = db.BeginBlock(2); err != nil {
+				t.Fatalf("cannot begin block; %v", err)
+			}
+			if err = db.BeginTransaction(0); err != nil {
+				t.Fatalf("cannot begin transaction; %v", err)
+			}
+			db.Suicide(common.Address{1})
+			db.SetState(common.Address{2}, common.Hash{1}, common.Hash{})
+			if err = db.EndTransaction(); err != nil {
+				t.Fatalf("cannot end transaction; %v", err)
+			}
+			if err = db.EndBlock(); err != nil {
+				t.Fatalf("cannot end block; %v", err)
+			}
+
+			got := collectIterationTestDb(t, db)
+			if _, found := got[common.Address{1}]; found {
+				t.Errorf("deleted account must not be iterated")
+			}
+			if storage := got[common.Address{2}]; len(storage) != 1 {
+				t.Errorf("cleared storage slot must not be iterated; got: %v", storage)
+			}
+		})
+	}
+}
+
+func TestIterator_CarmenDbIteratesReopenedDb(t *testing.T) {
+	for _, tc := range GetCarmenStateTestCases() {
+		t.Run(tc.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := MakeCarmenStateDB(dir, tc.Variant, tc.Schema, tc.Archive)
+			if errors.Is(err, carmen.UnsupportedConfiguration) {
+				t.Skip("unsupported configuration")
+			}
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			fillIterationTestDb(t, db)
+			if err = db.Close(); err != nil {
+				t.Fatalf("cannot close state-db; %v", err)
+			}
+
+			db, err = MakeCarmenStateDB(dir, tc.Variant, tc.Schema, tc.Archive)
+			if err != nil {
+				t.Fatalf("cannot reopen state-db; %v", err)
+			}
+			defer db.Close()
+			checkIterationTestDb(t, db)
+		})
+	}
+}
+
+func TestIterator_CarmenDbIteratesBulkLoadedDb(t *testing.T) {
+	for _, tc := range GetCarmenStateTestCases() {
+		t.Run(tc.String(), func(t *testing.T) {
+			db, err := MakeCarmenStateDB(t.TempDir(), tc.Variant, tc.Schema, tc.Archive)
+			if errors.Is(err, carmen.UnsupportedConfiguration) {
+				t.Skip("unsupported configuration")
+			}
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			defer db.Close()
+
+			load, err := db.StartBulkLoad(1)
+			if err != nil {
+				t.Fatalf("cannot start bulk-load; %v", err)
+			}
+			for i := byte(1); i <= 3; i++ {
+				addr := common.Address{i}
+				load.CreateAccount(addr)
+				load.SetBalance(addr, big.NewInt(int64(i)))
+				load.SetNonce(addr, uint64(i))
+				for j := byte(1); j <= i; j++ {
+					load.SetState(addr, common.Hash{j}, common.Hash{i, j})
+				}
+			}
+			if err = load.Close(); err != nil {
+				t.Fatalf("cannot finish bulk-load; %v", err)
+			}
+			checkIterationTestDb(t, db)
+		})
+	}
+}
+
+func TestIterator_CarmenDbWithoutKeyIndexIsReported(t *testing.T) {
+	for _, tc := range GetCarmenStateTestCases() {
+		t.Run(tc.String(), func(t *testing.T) {
+			dir := t.TempDir()
+			db, err := MakeCarmenStateDB(dir, tc.Variant, tc.Schema, tc.Archive)
+			if errors.Is(err, carmen.UnsupportedConfiguration) {
+				t.Skip("unsupported configuration")
+			}
+			if err != nil {
+				t.Fatalf("cannot create state-db; %v", err)
+			}
+			fillIterationTestDb(t, db)
+			if err = db.Close(); err != nil {
+				t.Fatalf("cannot close state-db; %v", err)
+			}
+
+			// a state-db created before key indexing has no index
+			if err = os.RemoveAll(filepath.Join(dir, carmenKeyIndexDir)); err != nil {
+				t.Fatalf("cannot remove key index; %v", err)
+			}
+			db, err = MakeCarmenStateDB(dir, tc.Variant, tc.Schema, tc.Archive)
+			if err != nil {
+				t.Fatalf("cannot reopen state-db; %v", err)
+			}
+			defer db.Close()
+
+			if _, err = NewAccountIterator(db); !errors.Is(err, ErrIterationNotSupported) {
+				t.Errorf("unexpected error; got: %v, want: %v", err, ErrIterationNotSupported)
+			}
+		})
+	}
+}
+
+func TestIterator_StateWithoutIterationIsReported(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	db := NewMockStateDB(ctrl)
+
+	if _, err := NewAccountIterator(db); !errors.Is(err, ErrIterationNotSupported) {
+		t.Errorf("unexpected error; got: %v, want: %v", err, ErrIterationNotSupported)
+	}
+	if _, err := NewStorageIterator(db, common.Address{1}); !errors.Is(err, ErrIterationNotSupported) {
+		t.Errorf("unexpected error; got: %v, want: %v", err, ErrIterationNotSupported)
+	}
+}
@@ -489,6 +489,11 @@ func (db *inMemoryStateDB) Close() error {
 	return nil
 }
 
+func (db *inMemoryStateDB) Flush() error {
+	// Nothing to do.
+	return nil
+}
+
 func (db *inMemoryStateDB) GetMemoryUsage() *MemoryUsage {
 	// not supported yet
 	return &MemoryUsage{uint64(0), nil}
@@ -542,3 +547,36 @@ func (l *gethInMemoryBulkLoad) Close() error {
 	// ignored
 	return nil
 }
+
+func (db *inMemoryStateDB) NewAccountIterator() (AccountIterator, error) {
+	// the in-memory DB has no notion of committed state, the current state is iterated
+	alloc := db.GetSubstatePostAlloc()
+	addresses := make([]common.Address, 0, alloc.Len())
+	alloc.ForEachAccount(func(addr common.Address, _ txcontext.Account) {
+		addresses = append(addresses, addr)
+	})
+	return newSliceAccountIterator(addresses), nil
+}
+
+func (db *inMemoryStateDB) NewStorageIterator(addr common.Address) (StorageIterator, error) {
+	storage := make(map[common.Hash]common.Hash)
+	if !db.Exist(addr) || db.HasSuicided(addr) {
+		return newSliceStorageIterator(storage), nil
+	}
+	// newer snapshots take precedence over their parents and the world-state
+	for state := db.state; state != nil; state = state.parent {
+		for slot, value := range state.storage {
+			if _, seen := storage[slot.key]; slot.addr == addr && !seen {
+				storage[slot.key] = value
+			}
+		}
+	}
+	if db.ws.Has(addr) {
+		db.ws.Get(addr).ForEachStorage(func(key common.Hash, value common.Hash) {
+			if _, seen := storage[key]; !seen {
+				storage[key] = value
+			}
+		})
+	}
+	return newSliceStorageIterator(storage), nil
+}
@@ -0,0 +1,406 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"maps"
+	"math/big"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// AccessKind classifies a part of the state accessed by a transaction.
+type AccessKind byte
+
+const (
+	AccountAccess AccessKind = iota // existence of an account
+	BalanceAccess                   // balance of an account
+	NonceAccess                     // nonce of an account
+	CodeAccess                      // code of an account
+	StorageAccess                   // a single storage slot of an account
+)
+
+// StateAccess identifies a part of the state accessed by a transaction. The key
+// is only used by storage accesses.
+type StateAccess struct {
+	Kind    AccessKind
+	Address common.Address
+	Key     common.Hash
+}
+
+// AccessSet is a set of state accesses.
+type AccessSet map[StateAccess]struct{}
+
+// Intersects returns true if both sets contain a common access.
+func (s AccessSet) Intersects(other AccessSet) bool {
+	if len(s) > len(other) {
+		s, other = other, s
+	}
+	for access := range s {
+		if _, found := other[access]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessProxy data structure for recording the parts of the state read
+// and written by the current transaction. Accesses of reverted snapshots
+// are kept, hence the recorded sets are an over-approximation.
+type AccessProxy struct {
+	db     state.StateDB // state db
+	reads  AccessSet     // accesses reading the state in current transaction
+	writes AccessSet     // accesses modifying the state in current transaction
+}
+
+// NewAccessProxy creates a new StateDB proxy recording state accesses.
+func NewAccessProxy(db state.StateDB) *AccessProxy {
+	return &AccessProxy{
+		db:     db,
+		reads:  make(AccessSet),
+		writes: make(AccessSet),
+	}
+}
+
+// Reads returns the accesses reading the state in the current transaction.
+func (r *AccessProxy) Reads() AccessSet {
+	return r.reads
+}
+
+// Writes returns the accesses modifying the state in the current transaction.
+func (r *AccessProxy) Writes() AccessSet {
+	return r.writes
+}
+
+// TransactionAccesses returns copies of the accesses of the current transaction.
+// The copies are not modified by accesses issued afterwards.
+func (r *AccessProxy) TransactionAccesses() (reads AccessSet, writes AccessSet) {
+	return maps.Clone(r.reads), maps.Clone(r.writes)
+}
+
+func (r *AccessProxy) read(kind AccessKind, addr common.Address, key common.Hash) {
+	r.reads[StateAccess{Kind: kind, Address: addr, Key: key}] = struct{}{}
+}
+
+func (r *AccessProxy) write(kind AccessKind, addr common.Address, key common.Hash) {
+	r.writes[StateAccess{Kind: kind, Address: addr, Key: key}] = struct{}{}
+}
+
+// CreateAccount creates a new account.
+func (r *AccessProxy) CreateAccount(addr common.Address) {
+	r.write(AccountAccess, addr, common.Hash{})
+	r.db.CreateAccount(addr)
+}
+
+// SubBalance subtracts amount from a contract address.
+func (r *AccessProxy) SubBalance(addr common.Address, amount *big.Int) {
+	r.write(BalanceAccess, addr, common.Hash{})
+	r.db.SubBalance(addr, amount)
+}
+
+// AddBalance adds amount to a contract address.
+func (r *AccessProxy) AddBalance(addr common.Address, amount *big.Int) {
+	r.write(BalanceAccess, addr, common.Hash{})
+	r.db.AddBalance(addr, amount)
+}
+
+// GetBalance retrieves the amount of a contract address.
+func (r *AccessProxy) GetBalance(addr common.Address) *big.Int {
+	r.read(BalanceAccess, addr, common.Hash{})
+	balance := r.db.GetBalance(addr)
+	return balance
+}
+
+// GetNonce retrieves the nonce of a contract address.
+func (r *AccessProxy) GetNonce(addr common.Address) uint64 {
+	r.read(NonceAccess, addr, common.Hash{})
+	nonce := r.db.GetNonce(addr)
+	return nonce
+}
+
+// SetNonce sets the nonce of a contract address.
+func (r *AccessProxy) SetNonce(addr common.Address, nonce uint64) {
+	r.write(NonceAccess, addr, common.Hash{})
+	r.db.SetNonce(addr, nonce)
+}
+
+// GetCodeHash returns the hash of the EVM bytecode.
+func (r *AccessProxy) GetCodeHash(addr common.Address) common.Hash {
+	r.read(CodeAccess, addr, common.Hash{})
+	hash := r.db.GetCodeHash(addr)
+	return hash
+}
+
+// GetCode returns the EVM bytecode of a contract.
+func (r *AccessProxy) GetCode(addr common.Address) []byte {
+	r.read(CodeAccess, addr, common.Hash{})
+	code := r.db.GetCode(addr)
+	return code
+}
+
+// SetCode sets the EVM bytecode of a contract.
+func (r *AccessProxy) SetCode(addr common.Address, code []byte) {
+	r.write(CodeAccess, addr, common.Hash{})
+	r.db.SetCode(addr, code)
+}
+
+// GetCodeSize returns the EVM bytecode's size.
+func (r *AccessProxy) GetCodeSize(addr common.Address) int {
+	r.read(CodeAccess, addr, common.Hash{})
+	size := r.db.GetCodeSize(addr)
+	return size
+}
+
+// AddRefund adds gas to the refund counter.
+func (r *AccessProxy) AddRefund(gas uint64) {
+	r.db.AddRefund(gas)
+}
+
+// SubRefund subtracts gas to the refund counter.
+func (r *AccessProxy) SubRefund(gas uint64) {
+	r.db.SubRefund(gas)
+}
+
+// GetRefund returns the current value of the refund counter.
+func (r *AccessProxy) GetRefund() uint64 {
+	gas := r.db.GetRefund()
+	return gas
+}
+
+// GetCommittedState retrieves a value that is already committed.
+func (r *AccessProxy) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	r.read(StorageAccess, addr, key)
+	value := r.db.GetCommittedState(addr, key)
+	return value
+}
+
+// GetState retrieves a value from the StateDB.
+func (r *AccessProxy) GetState(addr common.Address, key common.Hash) common.Hash {
+	r.read(StorageAccess, addr, key)
+	value := r.db.GetState(addr, key)
+	return value
+}
+
+// SetState sets a value in the StateDB.
+func (r *AccessProxy) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	r.write(StorageAccess, addr, key)
+	r.db.SetState(addr, key, value)
+}
+
+// Suicide marks the given account as suicided. This clears the account balance.
+// The account is still available until the state is committed;
+// return a non-nil account after Suicide.
+func (r *AccessProxy) Suicide(addr common.Address) bool {
+	r.write(AccountAccess, addr, common.Hash{})
+	r.write(BalanceAccess, addr, common.Hash{})
+	return r.db.Suicide(addr)
+}
+
+// HasSuicided checks whether a contract has been suicided.
+func (r *AccessProxy) HasSuicided(addr common.Address) bool {
+	r.read(AccountAccess, addr, common.Hash{})
+	hasSuicided := r.db.HasSuicided(addr)
+	return hasSuicided
+}
+
+// Exist checks whether the contract exists in the StateDB.
+// Notably this also returns true for suicided accounts.
+func (r *AccessProxy) Exist(addr common.Address) bool {
+	r.read(AccountAccess, addr, common.Hash{})
+	return r.db.Exist(addr)
+}
+
+// Empty checks whether the contract is either non-existent
+// or empty according to the EIP161 specification (balance = nonce = code = 0).
+func (r *AccessProxy) Empty(addr common.Address) bool {
+	r.read(AccountAccess, addr, common.Hash{})
+	r.read(BalanceAccess, addr, common.Hash{})
+	r.read(NonceAccess, addr, common.Hash{})
+	r.read(CodeAccess, addr, common.Hash{})
+	empty := r.db.Empty(addr)
+	return empty
+}
+
+// PrepareAccessList handles the preparatory steps for executing a state transition with
+// regards to both EIP-2929 and EIP-2930:
+//
+// - Add sender to access list (2929)
+// - Add destination to access list (2929)
+// - Add precompiles to access list (2929)
+// - Add the contents of the optional tx access list (2930)
+//
+// This method should only be called if Berlin/2929+2930 is applicable at the current number.
+func (r *AccessProxy) PrepareAccessList(render common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	r.db.PrepareAccessList(render, dest, precompiles, txAccesses)
+}
+
+// AddAddressToAccessList adds an address to the access list.
+func (r *AccessProxy) AddAddressToAccessList(addr common.Address) {
+	r.db.AddAddressToAccessList(addr)
+}
+
+// AddressInAccessList checks whether an address is in the access list.
+func (r *AccessProxy) AddressInAccessList(addr common.Address) bool {
+	ok := r.db.AddressInAccessList(addr)
+	return ok
+}
+
+// SlotInAccessList checks whether the (address, slot)-tuple is in the access list.
+func (r *AccessProxy) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	addressOk, slotOk := r.db.SlotInAccessList(addr, slot)
+	return addressOk, slotOk
+}
+
+// AddSlotToAccessList adds the given (address, slot)-tuple to the access list
+func (r *AccessProxy) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	r.db.AddSlotToAccessList(addr, slot)
+}
+
+// RevertToSnapshot reverts all state changes from a given revision.
+func (r *AccessProxy) RevertToSnapshot(snapshot int) {
+	r.db.RevertToSnapshot(snapshot)
+}
+
+// Snapshot returns an identifier for the current revision of the state.
+func (r *AccessProxy) Snapshot() int {
+	snapshot := r.db.Snapshot()
+	return snapshot
+}
+
+// AddLog adds a log entry.
+func (r *AccessProxy) AddLog(log *types.Log) {
+	r.db.AddLog(log)
+}
+
+// GetLogs retrieves log entries.
+func (r *AccessProxy) GetLogs(hash common.Hash, blockHash common.Hash) []*types.Log {
+	return r.db.GetLogs(hash, blockHash)
+}
+
+// AddPreimage adds a SHA3 preimage.
+func (r *AccessProxy) AddPreimage(addr common.Hash, image []byte) {
+	r.db.AddPreimage(addr, image)
+}
+
+// ForEachStorage performs a function over all storage locations in a contract.
+func (r *AccessProxy) ForEachStorage(addr common.Address, fn func(common.Hash, common.Hash) bool) error {
+	return r.db.ForEachStorage(addr, fn)
+}
+
+// Prepare sets the current transaction hash and index.
+func (r *AccessProxy) Prepare(thash common.Hash, ti int) {
+	r.db.Prepare(thash, ti)
+}
+
+// Finalise the state in StateDB.
+func (r *AccessProxy) Finalise(deleteEmptyObjects bool) {
+	r.db.Finalise(deleteEmptyObjects)
+}
+
+// IntermediateRoot computes the current hash of the StateDB.
+// It is called in between transactions to get the root hash that
+// goes into transaction receipts.
+func (r *AccessProxy) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
+	return r.db.IntermediateRoot(deleteEmptyObjects)
+}
+
+func (r *AccessProxy) Commit(deleteEmptyObjects bool) (common.Hash, error) {
+	return r.db.Commit(deleteEmptyObjects)
+}
+
+func (r *AccessProxy) GetHash() (common.Hash, error) {
+	return r.db.GetHash()
+}
+
+func (r *AccessProxy) Error() error {
+	return r.db.Error()
+}
+
+// GetSubstatePostAlloc gets substate post allocation.
+func (r *AccessProxy) GetSubstatePostAlloc() txcontext.WorldState {
+	return r.db.GetSubstatePostAlloc()
+}
+
+func (r *AccessProxy) PrepareSubstate(substate txcontext.WorldState, block uint64) {
+	r.db.PrepareSubstate(substate, block)
+}
+
+// BeginTransaction starts a new transaction and clears the recorded accesses.
+func (r *AccessProxy) BeginTransaction(number uint32) error {
+	r.reads = make(AccessSet)
+	r.writes = make(AccessSet)
+	return r.db.BeginTransaction(number)
+}
+
+func (r *AccessProxy) EndTransaction() error {
+	return r.db.EndTransaction()
+}
+
+func (r *AccessProxy) BeginBlock(number uint64) error {
+	return r.db.BeginBlock(number)
+}
+
+func (r *AccessProxy) EndBlock() error {
+	return r.db.EndBlock()
+}
+
+func (r *AccessProxy) BeginSyncPeriod(number uint64) {
+	r.db.BeginSyncPeriod(number)
+}
+
+func (r *AccessProxy) EndSyncPeriod() {
+	r.db.EndSyncPeriod()
+}
+
+func (r *AccessProxy) GetArchiveState(block uint64) (state.NonCommittableStateDB, error) {
+	return r.db.GetArchiveState(block)
+}
+
+func (r *AccessProxy) GetArchiveBlockHeight() (uint64, bool, error) {
+	return r.db.GetArchiveBlockHeight()
+}
+
+func (r *AccessProxy) Close() error {
+	return r.db.Close()
+}
+
+func (r *AccessProxy) Flush() error {
+	return r.db.Flush()
+}
+
+func (r *AccessProxy) StartBulkLoad(uint64) (state.BulkLoad, error) {
+	panic("StartBulkLoad not supported by AccessProxy")
+}
+
+func (r *AccessProxy) GetMemoryUsage() *state.MemoryUsage {
+	return r.db.GetMemoryUsage()
+}
+
+func (r *AccessProxy) GetShadowDB() state.StateDB {
+	return r.db.GetShadowDB()
+}
+
+func (r *AccessProxy) NewAccountIterator() (state.AccountIterator, error) {
+	return state.NewAccountIterator(r.db)
+}
+
+func (r *AccessProxy) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return state.NewStorageIterator(r.db, addr)
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestAccessProxy_ReadsAndWritesAreRecorded(t *testing.T) {
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	var _ state.StateDB = NewAccessProxy(db)
+	p := NewAccessProxy(db)
+	addr := common.Address{1}
+
+	if err = p.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	p.GetState(addr, common.Hash{1})
+	p.SetState(addr, common.Hash{2}, common.Hash{2})
+	p.AddBalance(addr, big.NewInt(1))
+	p.GetNonce(addr)
+
+	reads := AccessSet{
+		{Kind: StorageAccess, Address: addr, Key: common.Hash{1}}: {},
+		{Kind: NonceAccess, Address: addr}:                        {},
+	}
+	writes := AccessSet{
+		{Kind: StorageAccess, Address: addr, Key: common.Hash{2}}: {},
+		{Kind: BalanceAccess, Address: addr}:                      {},
+	}
+	if got, want := len(p.Reads()), len(reads); got != want {
+		t.Errorf("unexpected number of reads; got: %v, want: %v", got, want)
+	}
+	for access := range reads {
+		if _, found := p.Reads()[access]; !found {
+			t.Errorf("read %v was not recorded", access)
+		}
+	}
+	for access := range writes {
+		if _, found := p.Writes()[access]; !found {
+			t.Errorf("write %v was not recorded", access)
+		}
+	}
+
+	if err = p.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if err = p.BeginTransaction(1); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	if len(p.Reads()) != 0 || len(p.Writes()) != 0 {
+		t.Errorf("accesses were not cleared at the beginning of a transaction")
+	}
+}
+
+func TestAccessProxy_TransactionAccessesAreNotModifiedLater(t *testing.T) {
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	p := NewAccessProxy(db)
+	addr := common.Address{1}
+
+	if err = p.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	p.GetNonce(addr)
+	reads, writes := p.TransactionAccesses()
+
+	// accesses after taking the sets must not leak into them
+	p.GetBalance(addr)
+	p.AddBalance(addr, big.NewInt(1))
+	if err = p.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if err = p.BeginTransaction(1); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	p.GetCode(addr)
+
+	if got, want := len(reads), 1; got != want {
+		t.Fatalf("unexpected number of reads; got: %v, want: %v", got, want)
+	}
+	if _, found := reads[StateAccess{Kind: NonceAccess, Address: addr}]; !found {
+		t.Errorf("nonce read was not recorded")
+	}
+	if len(writes) != 0 {
+		t.Errorf("unexpected writes %v", writes)
+	}
+}
+
+func TestAccessSet_Intersects(t *testing.T) {
+	a := AccessSet{{Kind: BalanceAccess, Address: common.Address{1}}: {}}
+	b := AccessSet{{Kind: NonceAccess, Address: common.Address{1}}: {}}
+	if a.Intersects(b) {
+		t.Errorf("different kinds of accesses must not intersect")
+	}
+	b[StateAccess{Kind: BalanceAccess, Address: common.Address{1}}] = struct{}{}
+	if !a.Intersects(b) || !b.Intersects(a) {
+		t.Errorf("sets with common access must intersect")
+	}
+}
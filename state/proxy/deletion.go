@@ -307,6 +307,10 @@ func (r *DeletionProxy) Close() error {
 	return r.db.Close()
 }
 
+func (r *DeletionProxy) Flush() error {
+	return r.db.Flush()
+}
+
 func (r *DeletionProxy) StartBulkLoad(uint64) (state.BulkLoad, error) {
 	r.log.Fatal("StartBulkLoad not supported by DeletionProxy")
 	return nil, nil
@@ -319,3 +323,11 @@ func (r *DeletionProxy) GetMemoryUsage() *state.MemoryUsage {
 func (r *DeletionProxy) GetShadowDB() state.StateDB {
 	return r.db.GetShadowDB()
 }
+
+func (r *DeletionProxy) NewAccountIterator() (state.AccountIterator, error) {
+	return state.NewAccountIterator(r.db)
+}
+
+func (r *DeletionProxy) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return state.NewStorageIterator(r.db, addr)
+}
@@ -0,0 +1,562 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"os"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// JournalProxy records every mutating StateDB operation of the current block
+// into an append-only journal file. The journal is synced before a block is
+// committed, so that after a crash the incomplete block can be either replayed
+// or discarded using RecoverJournal. The StateDB is flushed before the journal
+// of a block is dropped, hence a journal is kept until its block is persisted.
+type JournalProxy struct {
+	db        state.StateDB // state db
+	file      *os.File      // journal file
+	writer    *bufio.Writer // buffered writer of the journal file
+	root      common.Hash   // state root hash before the current block
+	rootKnown bool          // true if root reflects the current state
+	inBlock   bool          // true if a block was started but not ended
+	err       error         // first error encountered while writing the journal
+}
+
+// NewJournalProxy creates a new StateDB proxy recording into given journal file.
+// An existing journal is overwritten, hence it has to be recovered beforehand.
+func NewJournalProxy(db state.StateDB, filename string) (*JournalProxy, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create journal %v; %w", filename, err)
+	}
+	if _, err = file.Write(journalMagic); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("cannot write journal header; %w", err)
+	}
+	return &JournalProxy{
+		db:     db,
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+// write appends a new entry to the journal.
+func (p *JournalProxy) write(entry JournalEntry) {
+	if p.err != nil {
+		return
+	}
+	if err := writeJournalRecord(p.writer, entry); err != nil {
+		p.err = fmt.Errorf("cannot write journal record %v; %w", entry.Op, err)
+	}
+}
+
+// sync flushes buffered records and persists them on the disk.
+func (p *JournalProxy) sync() error {
+	if p.err != nil {
+		return p.err
+	}
+	if err := p.writer.Flush(); err != nil {
+		return fmt.Errorf("cannot flush journal; %w", err)
+	}
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("cannot sync journal; %w", err)
+	}
+	return nil
+}
+
+// reset drops all records of the previous block.
+func (p *JournalProxy) reset() error {
+	if err := p.writer.Flush(); err != nil {
+		return fmt.Errorf("cannot flush journal; %w", err)
+	}
+	if err := p.file.Truncate(int64(len(journalMagic))); err != nil {
+		return fmt.Errorf("cannot truncate journal; %w", err)
+	}
+	if _, err := p.file.Seek(int64(len(journalMagic)), 0); err != nil {
+		return fmt.Errorf("cannot seek in journal; %w", err)
+	}
+	p.writer.Reset(p.file)
+	return nil
+}
+
+// CreateAccount creates a new account.
+func (p *JournalProxy) CreateAccount(addr common.Address) {
+	p.write(JournalEntry{Op: JournalCreateAccount, Address: addr})
+	p.db.CreateAccount(addr)
+}
+
+// SubBalance subtracts amount from a contract address.
+func (p *JournalProxy) SubBalance(addr common.Address, amount *big.Int) {
+	p.write(JournalEntry{Op: JournalSubBalance, Address: addr, Amount: amount})
+	p.db.SubBalance(addr, amount)
+}
+
+// AddBalance adds amount to a contract address.
+func (p *JournalProxy) AddBalance(addr common.Address, amount *big.Int) {
+	p.write(JournalEntry{Op: JournalAddBalance, Address: addr, Amount: amount})
+	p.db.AddBalance(addr, amount)
+}
+
+// GetBalance retrieves the amount of a contract address.
+func (p *JournalProxy) GetBalance(addr common.Address) *big.Int {
+	return p.db.GetBalance(addr)
+}
+
+// GetNonce retrieves the nonce of a contract address.
+func (p *JournalProxy) GetNonce(addr common.Address) uint64 {
+	return p.db.GetNonce(addr)
+}
+
+// SetNonce sets the nonce of a contract address.
+func (p *JournalProxy) SetNonce(addr common.Address, nonce uint64) {
+	p.write(JournalEntry{Op: JournalSetNonce, Address: addr, Number: nonce})
+	p.db.SetNonce(addr, nonce)
+}
+
+// GetCodeHash returns the hash of the EVM bytecode.
+func (p *JournalProxy) GetCodeHash(addr common.Address) common.Hash {
+	return p.db.GetCodeHash(addr)
+}
+
+// GetCode returns the EVM bytecode of a contract.
+func (p *JournalProxy) GetCode(addr common.Address) []byte {
+	return p.db.GetCode(addr)
+}
+
+// SetCode sets the EVM bytecode of a contract.
+func (p *JournalProxy) SetCode(addr common.Address, code []byte) {
+	p.write(JournalEntry{Op: JournalSetCode, Address: addr, Code: code})
+	p.db.SetCode(addr, code)
+}
+
+// GetCodeSize returns the EVM bytecode's size.
+func (p *JournalProxy) GetCodeSize(addr common.Address) int {
+	return p.db.GetCodeSize(addr)
+}
+
+// AddRefund adds gas to the refund counter.
+func (p *JournalProxy) AddRefund(gas uint64) {
+	p.db.AddRefund(gas)
+}
+
+// SubRefund subtracts gas to the refund counter.
+func (p *JournalProxy) SubRefund(gas uint64) {
+	p.db.SubRefund(gas)
+}
+
+// GetRefund returns the current value of the refund counter.
+func (p *JournalProxy) GetRefund() uint64 {
+	return p.db.GetRefund()
+}
+
+// GetCommittedState retrieves a value that is already committed.
+func (p *JournalProxy) GetCommittedState(addr common.Address, key common.Hash) common.Hash {
+	return p.db.GetCommittedState(addr, key)
+}
+
+// GetState retrieves a value from the StateDB.
+func (p *JournalProxy) GetState(addr common.Address, key common.Hash) common.Hash {
+	return p.db.GetState(addr, key)
+}
+
+// SetState sets a value in the StateDB.
+func (p *JournalProxy) SetState(addr common.Address, key common.Hash, value common.Hash) {
+	p.write(JournalEntry{Op: JournalSetState, Address: addr, Key: key, Value: value})
+	p.db.SetState(addr, key, value)
+}
+
+// Suicide marks the given account as suicided. This clears the account balance.
+func (p *JournalProxy) Suicide(addr common.Address) bool {
+	p.write(JournalEntry{Op: JournalSuicide, Address: addr})
+	return p.db.Suicide(addr)
+}
+
+// HasSuicided checks whether a contract has been suicided.
+func (p *JournalProxy) HasSuicided(addr common.Address) bool {
+	return p.db.HasSuicided(addr)
+}
+
+// Exist checks whether the contract exists in the StateDB.
+func (p *JournalProxy) Exist(addr common.Address) bool {
+	return p.db.Exist(addr)
+}
+
+// Empty checks whether the contract is either non-existent
+// or empty according to the EIP161 specification (balance = nonce = code = 0).
+func (p *JournalProxy) Empty(addr common.Address) bool {
+	return p.db.Empty(addr)
+}
+
+// PrepareAccessList handles the preparatory steps for executing a state transition.
+func (p *JournalProxy) PrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	p.db.PrepareAccessList(sender, dest, precompiles, txAccesses)
+}
+
+// AddAddressToAccessList adds an address to the access list.
+func (p *JournalProxy) AddAddressToAccessList(addr common.Address) {
+	p.db.AddAddressToAccessList(addr)
+}
+
+// AddressInAccessList checks whether an address is in the access list.
+func (p *JournalProxy) AddressInAccessList(addr common.Address) bool {
+	return p.db.AddressInAccessList(addr)
+}
+
+// SlotInAccessList checks whether the (address, slot)-tuple is in the access list.
+func (p *JournalProxy) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	return p.db.SlotInAccessList(addr, slot)
+}
+
+// AddSlotToAccessList adds the given (address, slot)-tuple to the access list
+func (p *JournalProxy) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	p.db.AddSlotToAccessList(addr, slot)
+}
+
+// RevertToSnapshot reverts all state changes from a given revision.
+func (p *JournalProxy) RevertToSnapshot(snapshot int) {
+	p.write(JournalEntry{Op: JournalRevertToSnapshot, Number: uint64(snapshot)})
+	p.db.RevertToSnapshot(snapshot)
+}
+
+// Snapshot returns an identifier for the current revision of the state.
+func (p *JournalProxy) Snapshot() int {
+	snapshot := p.db.Snapshot()
+	p.write(JournalEntry{Op: JournalSnapshot, Number: uint64(snapshot)})
+	return snapshot
+}
+
+// AddLog adds a log entry.
+func (p *JournalProxy) AddLog(log *types.Log) {
+	p.db.AddLog(log)
+}
+
+// GetLogs retrieves log entries.
+func (p *JournalProxy) GetLogs(hash common.Hash, blockHash common.Hash) []*types.Log {
+	return p.db.GetLogs(hash, blockHash)
+}
+
+// AddPreimage adds a SHA3 preimage.
+func (p *JournalProxy) AddPreimage(hash common.Hash, image []byte) {
+	p.db.AddPreimage(hash, image)
+}
+
+// ForEachStorage performs a function over all storage locations in a contract.
+func (p *JournalProxy) ForEachStorage(addr common.Address, fn func(common.Hash, common.Hash) bool) error {
+	return p.db.ForEachStorage(addr, fn)
+}
+
+// Prepare sets the current transaction hash and index.
+func (p *JournalProxy) Prepare(thash common.Hash, ti int) {
+	p.db.Prepare(thash, ti)
+}
+
+// Finalise the state in StateDB.
+func (p *JournalProxy) Finalise(deleteEmptyObjects bool) {
+	p.write(JournalEntry{Op: JournalFinalise, Flag: deleteEmptyObjects})
+	p.db.Finalise(deleteEmptyObjects)
+}
+
+// IntermediateRoot computes the current hash of the StateDB.
+func (p *JournalProxy) IntermediateRoot(deleteEmptyObjects bool) common.Hash {
+	return p.db.IntermediateRoot(deleteEmptyObjects)
+}
+
+func (p *JournalProxy) Commit(deleteEmptyObjects bool) (common.Hash, error) {
+	return p.db.Commit(deleteEmptyObjects)
+}
+
+func (p *JournalProxy) Error() error {
+	if p.err != nil {
+		return p.err
+	}
+	return p.db.Error()
+}
+
+// GetSubstatePostAlloc gets substate post allocation.
+func (p *JournalProxy) GetSubstatePostAlloc() txcontext.WorldState {
+	return p.db.GetSubstatePostAlloc()
+}
+
+func (p *JournalProxy) PrepareSubstate(substate txcontext.WorldState, block uint64) {
+	p.db.PrepareSubstate(substate, block)
+}
+
+func (p *JournalProxy) BeginTransaction(number uint32) error {
+	p.write(JournalEntry{Op: JournalBeginTransaction, Number: uint64(number)})
+	return p.db.BeginTransaction(number)
+}
+
+func (p *JournalProxy) EndTransaction() error {
+	p.write(JournalEntry{Op: JournalEndTransaction})
+	return p.db.EndTransaction()
+}
+
+// BeginBlock flushes the StateDB, drops the journal of the previous block and starts
+// journaling a new block together with the root hash of the state it is applied to.
+func (p *JournalProxy) BeginBlock(number uint64) error {
+	if !p.rootKnown {
+		root, err := p.db.GetHash()
+		if err != nil {
+			return fmt.Errorf("cannot get state hash; %w", err)
+		}
+		p.root = root
+		p.rootKnown = true
+	}
+	// the previous block may only be dropped once it is persisted in the StateDB
+	if err := p.db.Flush(); err != nil {
+		return fmt.Errorf("cannot flush state-db; %w", err)
+	}
+	if err := p.reset(); err != nil {
+		return err
+	}
+	p.write(JournalEntry{Op: JournalBeginBlock, Number: number, Value: p.root})
+	p.inBlock = true
+	return p.db.BeginBlock(number)
+}
+
+// EndBlock persists the journal of the current block before the block gets committed.
+// Once committed, the resulting root hash is recorded as well.
+func (p *JournalProxy) EndBlock() error {
+	p.write(JournalEntry{Op: JournalEndBlock})
+	if err := p.sync(); err != nil {
+		return err
+	}
+	if err := p.db.EndBlock(); err != nil {
+		return err
+	}
+	p.inBlock = false
+
+	root, err := p.db.GetHash()
+	if err != nil {
+		return fmt.Errorf("cannot get state hash; %w", err)
+	}
+	p.root = root
+	p.rootKnown = true
+	p.write(JournalEntry{Op: JournalBlockCommitted, Value: root})
+	if err = p.writer.Flush(); err != nil {
+		return fmt.Errorf("cannot flush journal; %w", err)
+	}
+	return nil
+}
+
+func (p *JournalProxy) BeginSyncPeriod(number uint64) {
+	p.db.BeginSyncPeriod(number)
+}
+
+func (p *JournalProxy) EndSyncPeriod() {
+	p.db.EndSyncPeriod()
+}
+
+func (p *JournalProxy) GetHash() (common.Hash, error) {
+	return p.db.GetHash()
+}
+
+func (p *JournalProxy) GetArchiveState(block uint64) (state.NonCommittableStateDB, error) {
+	return p.db.GetArchiveState(block)
+}
+
+func (p *JournalProxy) GetArchiveBlockHeight() (uint64, bool, error) {
+	return p.db.GetArchiveBlockHeight()
+}
+
+// Close closes the StateDB. The journal is removed once the StateDB is closed unless
+// a block remained incomplete, in which case it is kept for the recovery.
+func (p *JournalProxy) Close() error {
+	if p.inBlock {
+		journalErr := errors.Join(p.sync(), p.file.Close())
+		return errors.Join(p.db.Close(), journalErr)
+	}
+	if err := p.db.Close(); err != nil {
+		return errors.Join(err, p.file.Close())
+	}
+	return errors.Join(p.file.Close(), os.Remove(p.file.Name()))
+}
+
+// Flush persists the content of the StateDB. Completed blocks are already synced
+// to the journal, so the journal itself needs no flushing.
+func (p *JournalProxy) Flush() error {
+	return p.db.Flush()
+}
+
+// StartBulkLoad bypasses the journal, bulk loads are not crash-consistent.
+func (p *JournalProxy) StartBulkLoad(block uint64) (state.BulkLoad, error) {
+	p.rootKnown = false
+	return p.db.StartBulkLoad(block)
+}
+
+func (p *JournalProxy) GetMemoryUsage() *state.MemoryUsage {
+	return p.db.GetMemoryUsage()
+}
+
+func (p *JournalProxy) GetShadowDB() state.StateDB {
+	return p.db.GetShadowDB()
+}
+
+func (p *JournalProxy) NewAccountIterator() (state.AccountIterator, error) {
+	return state.NewAccountIterator(p.db)
+}
+
+func (p *JournalProxy) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return state.NewStorageIterator(p.db, addr)
+}
+
+// JournalOutcome describes how a journal was resolved by RecoverJournal.
+type JournalOutcome int
+
+const (
+	// JournalClean means the StateDB already contains all journaled operations.
+	JournalClean JournalOutcome = iota
+	// JournalReplayed means a completed block was missing in the StateDB and it was replayed.
+	JournalReplayed
+	// JournalDiscarded means an incomplete block was dropped, the StateDB is at the preceding block.
+	JournalDiscarded
+	// JournalStale means the StateDB matches neither the state before nor after the journaled block.
+	JournalStale
+)
+
+func (o JournalOutcome) String() string {
+	switch o {
+	case JournalClean:
+		return "clean"
+	case JournalReplayed:
+		return "replayed"
+	case JournalDiscarded:
+		return "discarded"
+	case JournalStale:
+		return "stale"
+	default:
+		return fmt.Sprintf("unknown(%d)", int(o))
+	}
+}
+
+// JournalRecovery is the result of RecoverJournal.
+type JournalRecovery struct {
+	Outcome  JournalOutcome
+	Block    uint64 // journaled block
+	HasBlock bool   // false if there was no journaled block
+}
+
+// RecoverJournal brings the StateDB to a clean block boundary using the journal stored
+// in given file. A completed but not committed block is replayed, an incomplete block is
+// discarded. The journal file is removed afterwards unless the StateDB is stale, in which
+// case it is kept so that the StateDB is not used by later runs either.
+func RecoverJournal(db state.StateDB, filename string) (JournalRecovery, error) {
+	journal, err := ReadJournal(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return JournalRecovery{Outcome: JournalClean}, nil
+	}
+	if err != nil {
+		return JournalRecovery{}, err
+	}
+
+	block, preRoot, found := journal.Block()
+	if !found {
+		return JournalRecovery{Outcome: JournalClean}, os.Remove(filename)
+	}
+	recovery := JournalRecovery{Block: block, HasBlock: true}
+
+	root, err := db.GetHash()
+	if err != nil {
+		return recovery, fmt.Errorf("cannot get state hash; %w", err)
+	}
+
+	committedRoot, committed := journal.CommittedRoot()
+	switch {
+	case committed && root == committedRoot:
+		recovery.Outcome = JournalClean
+	case root == preRoot && journal.IsComplete():
+		if err = replayJournal(db, journal); err != nil {
+			return recovery, fmt.Errorf("cannot replay block %v; %w", block, err)
+		}
+		recovery.Outcome = JournalReplayed
+	case root == preRoot:
+		recovery.Outcome = JournalDiscarded
+	default:
+		recovery.Outcome = JournalStale
+		return recovery, nil
+	}
+
+	return recovery, os.Remove(filename)
+}
+
+// replayJournal applies all operations of a completed block to the StateDB.
+func replayJournal(db state.StateDB, journal *Journal) error {
+	// snapshot ids of the replay may differ from the recorded ones
+	snapshots := make(map[uint64]int)
+	for _, entry := range journal.Entries {
+		var err error
+		switch entry.Op {
+		case JournalBeginBlock:
+			err = db.BeginBlock(entry.Number)
+		case JournalEndBlock:
+			if err = db.EndBlock(); err != nil {
+				return err
+			}
+			if root, committed := journal.CommittedRoot(); committed {
+				got, err := db.GetHash()
+				if err != nil {
+					return fmt.Errorf("cannot get state hash; %w", err)
+				}
+				if got != root {
+					return fmt.Errorf("unexpected state hash after replay; got: %v, want: %v", got, root)
+				}
+			}
+			return nil
+		case JournalBeginTransaction:
+			err = db.BeginTransaction(uint32(entry.Number))
+		case JournalEndTransaction:
+			err = db.EndTransaction()
+		case JournalCreateAccount:
+			db.CreateAccount(entry.Address)
+		case JournalAddBalance:
+			db.AddBalance(entry.Address, entry.Amount)
+		case JournalSubBalance:
+			db.SubBalance(entry.Address, entry.Amount)
+		case JournalSetNonce:
+			db.SetNonce(entry.Address, entry.Number)
+		case JournalSetCode:
+			db.SetCode(entry.Address, entry.Code)
+		case JournalSetState:
+			db.SetState(entry.Address, entry.Key, entry.Value)
+		case JournalSuicide:
+			db.Suicide(entry.Address)
+		case JournalSnapshot:
+			snapshots[entry.Number] = db.Snapshot()
+		case JournalRevertToSnapshot:
+			id, found := snapshots[entry.Number]
+			if !found {
+				return fmt.Errorf("unknown snapshot %d", entry.Number)
+			}
+			db.RevertToSnapshot(id)
+		case JournalFinalise:
+			db.Finalise(entry.Flag)
+		}
+		if err != nil {
+			return fmt.Errorf("cannot replay %v; %w", entry, err)
+		}
+	}
+	return errors.New("journal does not contain end of the block")
+}
@@ -0,0 +1,311 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// JournalFileName is the name of the journal file stored inside a state-db directory.
+const JournalFileName = "journal.wal"
+
+// journalMagic identifies journal files and the version of their format.
+var journalMagic = []byte("AIDAWAL1")
+
+// JournalOp identifies a StateDB operation recorded in a journal.
+type JournalOp byte
+
+// Journal operations. Any change in the order invalidates persisted journals.
+const (
+	JournalBeginBlock JournalOp = iota
+	JournalEndBlock
+	JournalBlockCommitted
+	JournalBeginTransaction
+	JournalEndTransaction
+	JournalCreateAccount
+	JournalAddBalance
+	JournalSubBalance
+	JournalSetNonce
+	JournalSetCode
+	JournalSetState
+	JournalSuicide
+	JournalSnapshot
+	JournalRevertToSnapshot
+	JournalFinalise
+
+	// numJournalOps is number of distinct journal operations (must be last)
+	numJournalOps
+)
+
+var journalOpLabels = [numJournalOps]string{
+	JournalBeginBlock:       "BeginBlock",
+	JournalEndBlock:         "EndBlock",
+	JournalBlockCommitted:   "BlockCommitted",
+	JournalBeginTransaction: "BeginTransaction",
+	JournalEndTransaction:   "EndTransaction",
+	JournalCreateAccount:    "CreateAccount",
+	JournalAddBalance:       "AddBalance",
+	JournalSubBalance:       "SubBalance",
+	JournalSetNonce:         "SetNonce",
+	JournalSetCode:          "SetCode",
+	JournalSetState:         "SetState",
+	JournalSuicide:          "Suicide",
+	JournalSnapshot:         "Snapshot",
+	JournalRevertToSnapshot: "RevertToSnapshot",
+	JournalFinalise:         "Finalise",
+}
+
+func (op JournalOp) String() string {
+	if op >= numJournalOps {
+		return fmt.Sprintf("Unknown(%d)", byte(op))
+	}
+	return journalOpLabels[op]
+}
+
+// JournalEntry is a single operation recorded in a journal. Only fields
+// relevant to the operation are set.
+type JournalEntry struct {
+	Op      JournalOp
+	Number  uint64         // block, transaction, nonce or snapshot id
+	Address common.Address // account affected by the operation
+	Key     common.Hash    // storage key
+	Value   common.Hash    // storage value or state root hash
+	Amount  *big.Int       // balance difference
+	Code    []byte         // contract code
+	Flag    bool           // argument of Finalise
+}
+
+func (e JournalEntry) String() string {
+	switch e.Op {
+	case JournalBeginBlock:
+		return fmt.Sprintf("%v: %d, root: %v", e.Op, e.Number, e.Value)
+	case JournalBlockCommitted:
+		return fmt.Sprintf("%v: root: %v", e.Op, e.Value)
+	case JournalBeginTransaction, JournalSnapshot, JournalRevertToSnapshot:
+		return fmt.Sprintf("%v: %d", e.Op, e.Number)
+	case JournalCreateAccount, JournalSuicide:
+		return fmt.Sprintf("%v: %v", e.Op, e.Address)
+	case JournalAddBalance, JournalSubBalance:
+		return fmt.Sprintf("%v: %v, %v", e.Op, e.Address, e.Amount)
+	case JournalSetNonce:
+		return fmt.Sprintf("%v: %v, %d", e.Op, e.Address, e.Number)
+	case JournalSetCode:
+		return fmt.Sprintf("%v: %v, %d bytes", e.Op, e.Address, len(e.Code))
+	case JournalSetState:
+		return fmt.Sprintf("%v: %v, %v, %v", e.Op, e.Address, e.Key, e.Value)
+	case JournalFinalise:
+		return fmt.Sprintf("%v: %v", e.Op, e.Flag)
+	default:
+		return e.Op.String()
+	}
+}
+
+// encode serializes the entry into a record payload.
+func (e JournalEntry) encode() []byte {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(e.Op))
+	switch e.Op {
+	case JournalBeginBlock:
+		_ = binary.Write(&buf, binary.BigEndian, e.Number)
+		buf.Write(e.Value[:])
+	case JournalBlockCommitted:
+		buf.Write(e.Value[:])
+	case JournalBeginTransaction, JournalSnapshot, JournalRevertToSnapshot:
+		_ = binary.Write(&buf, binary.BigEndian, e.Number)
+	case JournalCreateAccount, JournalSuicide:
+		buf.Write(e.Address[:])
+	case JournalAddBalance, JournalSubBalance:
+		buf.Write(e.Address[:])
+		buf.Write(e.Amount.Bytes())
+	case JournalSetNonce:
+		buf.Write(e.Address[:])
+		_ = binary.Write(&buf, binary.BigEndian, e.Number)
+	case JournalSetCode:
+		buf.Write(e.Address[:])
+		buf.Write(e.Code)
+	case JournalSetState:
+		buf.Write(e.Address[:])
+		buf.Write(e.Key[:])
+		buf.Write(e.Value[:])
+	case JournalFinalise:
+		if e.Flag {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+	}
+	return buf.Bytes()
+}
+
+// decodeJournalEntry deserializes a record payload.
+func decodeJournalEntry(payload []byte) (JournalEntry, error) {
+	if len(payload) == 0 {
+		return JournalEntry{}, errors.New("empty record")
+	}
+	e := JournalEntry{Op: JournalOp(payload[0])}
+	data := payload[1:]
+
+	expect := func(size int) error {
+		if len(data) < size {
+			return fmt.Errorf("record %v is too short; got %d bytes, want %d", e.Op, len(data), size)
+		}
+		return nil
+	}
+
+	var err error
+	switch e.Op {
+	case JournalBeginBlock:
+		if err = expect(8 + common.HashLength); err == nil {
+			e.Number = binary.BigEndian.Uint64(data)
+			e.Value = common.BytesToHash(data[8 : 8+common.HashLength])
+		}
+	case JournalBlockCommitted:
+		if err = expect(common.HashLength); err == nil {
+			e.Value = common.BytesToHash(data[:common.HashLength])
+		}
+	case JournalBeginTransaction, JournalSnapshot, JournalRevertToSnapshot:
+		if err = expect(8); err == nil {
+			e.Number = binary.BigEndian.Uint64(data)
+		}
+	case JournalCreateAccount, JournalSuicide:
+		if err = expect(common.AddressLength); err == nil {
+			e.Address = common.BytesToAddress(data[:common.AddressLength])
+		}
+	case JournalAddBalance, JournalSubBalance:
+		if err = expect(common.AddressLength); err == nil {
+			e.Address = common.BytesToAddress(data[:common.AddressLength])
+			e.Amount = new(big.Int).SetBytes(data[common.AddressLength:])
+		}
+	case JournalSetNonce:
+		if err = expect(common.AddressLength + 8); err == nil {
+			e.Address = common.BytesToAddress(data[:common.AddressLength])
+			e.Number = binary.BigEndian.Uint64(data[common.AddressLength:])
+		}
+	case JournalSetCode:
+		if err = expect(common.AddressLength); err == nil {
+			e.Address = common.BytesToAddress(data[:common.AddressLength])
+			e.Code = common.CopyBytes(data[common.AddressLength:])
+		}
+	case JournalSetState:
+		if err = expect(common.AddressLength + 2*common.HashLength); err == nil {
+			e.Address = common.BytesToAddress(data[:common.AddressLength])
+			e.Key = common.BytesToHash(data[common.AddressLength : common.AddressLength+common.HashLength])
+			e.Value = common.BytesToHash(data[common.AddressLength+common.HashLength:])
+		}
+	case JournalFinalise:
+		if err = expect(1); err == nil {
+			e.Flag = data[0] != 0
+		}
+	case JournalEndBlock, JournalEndTransaction:
+	default:
+		err = fmt.Errorf("unknown operation %v", e.Op)
+	}
+	return e, err
+}
+
+// writeJournalRecord writes a record consisting of the payload length, its checksum and the payload.
+func writeJournalRecord(w io.Writer, entry JournalEntry) error {
+	payload := entry.encode()
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:], crc32.ChecksumIEEE(payload))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// Journal is the content of a journal file.
+type Journal struct {
+	Entries []JournalEntry
+	// Torn is set if the journal ends with an incomplete or corrupted
+	// record, which happens if a crash interrupted writing.
+	Torn bool
+}
+
+// ReadJournal reads all intact records of the journal stored in given file.
+func ReadJournal(filename string) (*Journal, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open journal %v; %w", filename, err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	magic := make([]byte, len(journalMagic))
+	if _, err = io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, journalMagic) {
+		return nil, fmt.Errorf("%v is not a journal file", filename)
+	}
+
+	journal := new(Journal)
+	var header [8]byte
+	for {
+		if _, err = io.ReadFull(reader, header[:]); err != nil {
+			journal.Torn = err != io.EOF
+			return journal, nil
+		}
+		payload := make([]byte, binary.BigEndian.Uint32(header[:4]))
+		if _, err = io.ReadFull(reader, payload); err != nil || crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+			journal.Torn = true
+			return journal, nil
+		}
+		entry, err := decodeJournalEntry(payload)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode journal record %d; %w", len(journal.Entries), err)
+		}
+		journal.Entries = append(journal.Entries, entry)
+	}
+}
+
+// Block returns the number and the pre-block root hash of the journaled block.
+// False is returned if the journal does not contain any block.
+func (j *Journal) Block() (uint64, common.Hash, bool) {
+	if len(j.Entries) == 0 || j.Entries[0].Op != JournalBeginBlock {
+		return 0, common.Hash{}, false
+	}
+	return j.Entries[0].Number, j.Entries[0].Value, true
+}
+
+// IsComplete returns true if all operations of the journaled block were recorded.
+func (j *Journal) IsComplete() bool {
+	for _, entry := range j.Entries {
+		if entry.Op == JournalEndBlock {
+			return true
+		}
+	}
+	return false
+}
+
+// CommittedRoot returns the root hash of the state after the journaled block
+// has been committed. False is returned if the commit was not recorded.
+func (j *Journal) CommittedRoot() (common.Hash, bool) {
+	if n := len(j.Entries); n > 0 && j.Entries[n-1].Op == JournalBlockCommitted {
+		return j.Entries[n-1].Value, true
+	}
+	return common.Hash{}, false
+}
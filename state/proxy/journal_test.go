@@ -0,0 +1,281 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package proxy
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func makeTestJournalDb(t *testing.T) state.StateDB {
+	db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	return db
+}
+
+// runJournaledBlock executes a block modifying some state through the journal proxy.
+// If complete is false, the block is not ended simulating a crash.
+func runJournaledBlock(t *testing.T, db *JournalProxy, complete bool) {
+	if err := db.BeginBlock(1); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	if err := db.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	db.CreateAccount(common.Address{1})
+	db.AddBalance(common.Address{1}, big.NewInt(1000))
+	db.SetNonce(common.Address{1}, 1)
+	snapshot := db.Snapshot()
+	db.SetState(common.Address{1}, common.Hash{1}, common.Hash{2})
+	db.RevertToSnapshot(snapshot)
+	db.SetState(common.Address{1}, common.Hash{2}, common.Hash{3})
+	db.SetCode(common.Address{1}, []byte{0x60, 0x00})
+	if err := db.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if !complete {
+		return
+	}
+	if err := db.EndBlock(); err != nil {
+		t.Fatalf("cannot end block; %v", err)
+	}
+}
+
+func TestJournalProxy_RecordsOperationsOfCurrentBlock(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), JournalFileName)
+	db, err := NewJournalProxy(makeTestJournalDb(t), filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	runJournaledBlock(t, db, true)
+
+	journal, err := ReadJournal(filename)
+	if err != nil {
+		t.Fatalf("cannot read journal; %v", err)
+	}
+	if journal.Torn {
+		t.Errorf("journal must not be torn")
+	}
+	if block, _, found := journal.Block(); !found || block != 1 {
+		t.Errorf("unexpected journaled block; got: %v, found: %v", block, found)
+	}
+	if !journal.IsComplete() {
+		t.Errorf("journal must be complete")
+	}
+	root, err := db.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get hash; %v", err)
+	}
+	if got, committed := journal.CommittedRoot(); !committed || got != root {
+		t.Errorf("unexpected committed root; got: %v, want: %v", got, root)
+	}
+
+	want := []JournalOp{
+		JournalBeginBlock, JournalBeginTransaction, JournalCreateAccount, JournalAddBalance, JournalSetNonce,
+		JournalSnapshot, JournalSetState, JournalRevertToSnapshot, JournalSetState, JournalSetCode,
+		JournalEndTransaction, JournalEndBlock, JournalBlockCommitted,
+	}
+	if len(journal.Entries) != len(want) {
+		t.Fatalf("unexpected number of entries; got: %v, want: %v", len(journal.Entries), len(want))
+	}
+	for i, entry := range journal.Entries {
+		if entry.Op != want[i] {
+			t.Errorf("unexpected operation %d; got: %v, want: %v", i, entry.Op, want[i])
+		}
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("cannot close db; %v", err)
+	}
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("journal must be removed after a clean close")
+	}
+}
+
+func TestJournalProxy_CompletedBlockIsReplayed(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), JournalFileName)
+	db, err := NewJournalProxy(makeTestJournalDb(t), filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	runJournaledBlock(t, db, true)
+	want, err := db.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get hash; %v", err)
+	}
+
+	// a db which lost the block
+	recovered := makeTestJournalDb(t)
+	recovery, err := RecoverJournal(recovered, filename)
+	if err != nil {
+		t.Fatalf("cannot recover journal; %v", err)
+	}
+	if recovery.Outcome != JournalReplayed || recovery.Block != 1 {
+		t.Errorf("unexpected recovery; got: %v at %v", recovery.Outcome, recovery.Block)
+	}
+	if got, err := recovered.GetHash(); err != nil || got != want {
+		t.Errorf("unexpected hash after replay; got: %v, want: %v, err: %v", got, want, err)
+	}
+	if _, err = os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("journal must be removed after recovery")
+	}
+}
+
+func TestJournalProxy_IncompleteBlockIsDiscarded(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), JournalFileName)
+	db, err := NewJournalProxy(makeTestJournalDb(t), filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	runJournaledBlock(t, db, false)
+	if err = db.sync(); err != nil {
+		t.Fatalf("cannot sync journal; %v", err)
+	}
+
+	recovered := makeTestJournalDb(t)
+	want, err := recovered.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get hash; %v", err)
+	}
+	recovery, err := RecoverJournal(recovered, filename)
+	if err != nil {
+		t.Fatalf("cannot recover journal; %v", err)
+	}
+	if recovery.Outcome != JournalDiscarded || recovery.Block != 1 {
+		t.Errorf("unexpected recovery; got: %v at %v", recovery.Outcome, recovery.Block)
+	}
+	if got, err := recovered.GetHash(); err != nil || got != want {
+		t.Errorf("db must not be modified; got: %v, want: %v, err: %v", got, want, err)
+	}
+}
+
+// copyTestDir copies files of the src directory into the dst directory.
+func copyTestDir(t *testing.T, src, dst string) {
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		t.Fatalf("cannot read directory; %v", err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(src, entry.Name()))
+		if err != nil {
+			t.Fatalf("cannot read file; %v", err)
+		}
+		if err = os.WriteFile(filepath.Join(dst, entry.Name()), data, 0600); err != nil {
+			t.Fatalf("cannot write file; %v", err)
+		}
+	}
+}
+
+func TestJournalProxy_CrashWithoutCloseIsRecovered(t *testing.T) {
+	dir := t.TempDir()
+	stateDb, err := state.MakeGethStateDB(dir, "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	db, err := NewJournalProxy(stateDb, filepath.Join(dir, JournalFileName))
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	runJournaledBlock(t, db, true)
+	want, err := db.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get hash; %v", err)
+	}
+
+	// the next block is interrupted by a crash, neither the block nor the state-db is closed
+	if err = db.BeginBlock(2); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	if err = db.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	db.AddBalance(common.Address{1}, big.NewInt(1))
+	if err = db.sync(); err != nil {
+		t.Fatalf("cannot sync journal; %v", err)
+	}
+
+	// the content left on the disk by the crashed process
+	crashed := t.TempDir()
+	copyTestDir(t, dir, crashed)
+
+	recovered, err := state.MakeGethStateDB(crashed, "", want, false, nil)
+	if err != nil {
+		t.Fatalf("state-db of the last completed block must be persisted; %v", err)
+	}
+	defer recovered.Close()
+	recovery, err := RecoverJournal(recovered, filepath.Join(crashed, JournalFileName))
+	if err != nil {
+		t.Fatalf("cannot recover journal; %v", err)
+	}
+	if recovery.Outcome != JournalDiscarded || recovery.Block != 2 {
+		t.Errorf("unexpected recovery; got: %v at %v", recovery.Outcome, recovery.Block)
+	}
+	if got, err := recovered.GetHash(); err != nil || got != want {
+		t.Errorf("unexpected hash after recovery; got: %v, want: %v, err: %v", got, want, err)
+	}
+}
+
+func TestJournalProxy_TornRecordIsIgnored(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), JournalFileName)
+	db, err := NewJournalProxy(makeTestJournalDb(t), filename)
+	if err != nil {
+		t.Fatalf("cannot create journal proxy; %v", err)
+	}
+	runJournaledBlock(t, db, true)
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("cannot stat journal; %v", err)
+	}
+	if err = os.Truncate(filename, info.Size()-3); err != nil {
+		t.Fatalf("cannot truncate journal; %v", err)
+	}
+
+	journal, err := ReadJournal(filename)
+	if err != nil {
+		t.Fatalf("cannot read journal; %v", err)
+	}
+	if !journal.Torn {
+		t.Errorf("journal must be torn")
+	}
+	if _, committed := journal.CommittedRoot(); committed {
+		t.Errorf("torn commit record must be ignored")
+	}
+	if !journal.IsComplete() {
+		t.Errorf("journal must remain complete")
+	}
+}
+
+func TestJournalProxy_MissingJournalIsClean(t *testing.T) {
+	recovery, err := RecoverJournal(makeTestJournalDb(t), filepath.Join(t.TempDir(), JournalFileName))
+	if err != nil {
+		t.Fatalf("cannot recover journal; %v", err)
+	}
+	if recovery.Outcome != JournalClean || recovery.HasBlock {
+		t.Errorf("unexpected recovery; got: %v", recovery)
+	}
+}
@@ -225,6 +225,12 @@ func (s *LoggingStateDb) Close() error {
 	return res
 }
 
+func (s *LoggingStateDb) Flush() error {
+	res := s.state.Flush()
+	s.writeLog("Flush, %v", res)
+	return res
+}
+
 func (s *loggingVmStateDb) AddRefund(amount uint64) {
 	s.db.AddRefund(amount)
 	s.writeLog("AddRefund, %v, %v", amount, s.db.GetRefund())
@@ -369,6 +375,100 @@ func (s *loggingNonCommittableStateDb) Release() error {
 	return nil
 }
 
+func (s *LoggingStateDb) NewAccountIterator() (state.AccountIterator, error) {
+	return s.newAccountIterator(s.state)
+}
+
+func (s *LoggingStateDb) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return s.newStorageIterator(s.state, addr)
+}
+
+func (s *loggingNonCommittableStateDb) NewAccountIterator() (state.AccountIterator, error) {
+	return s.newAccountIterator(s.nonCommittableStateDB)
+}
+
+func (s *loggingNonCommittableStateDb) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return s.newStorageIterator(s.nonCommittableStateDB, addr)
+}
+
+func (s *loggingVmStateDb) newAccountIterator(db state.VmStateDB) (state.AccountIterator, error) {
+	it, err := state.NewAccountIterator(db)
+	s.writeLog("NewAccountIterator, %v", err)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingAccountIterator{nested: it, writeLog: s.writeLog}, nil
+}
+
+func (s *loggingVmStateDb) newStorageIterator(db state.VmStateDB, addr common.Address) (state.StorageIterator, error) {
+	it, err := state.NewStorageIterator(db, addr)
+	s.writeLog("NewStorageIterator, %v, %v", addr, err)
+	if err != nil {
+		return nil, err
+	}
+	return &loggingStorageIterator{nested: it, writeLog: s.writeLog}, nil
+}
+
+type loggingAccountIterator struct {
+	nested   state.AccountIterator
+	writeLog func(format string, a ...any)
+}
+
+func (i *loggingAccountIterator) Next() bool {
+	res := i.nested.Next()
+	if res {
+		i.writeLog("AccountIterator, Next, %v", i.nested.Address())
+	} else {
+		i.writeLog("AccountIterator, Done, %v", i.nested.Error())
+	}
+	return res
+}
+
+func (i *loggingAccountIterator) Address() common.Address {
+	return i.nested.Address()
+}
+
+func (i *loggingAccountIterator) Error() error {
+	return i.nested.Error()
+}
+
+func (i *loggingAccountIterator) Release() {
+	i.nested.Release()
+	i.writeLog("AccountIterator, Release")
+}
+
+type loggingStorageIterator struct {
+	nested   state.StorageIterator
+	writeLog func(format string, a ...any)
+}
+
+func (i *loggingStorageIterator) Next() bool {
+	res := i.nested.Next()
+	if res {
+		i.writeLog("StorageIterator, Next, %v, %v", i.nested.Key(), i.nested.Value())
+	} else {
+		i.writeLog("StorageIterator, Done, %v", i.nested.Error())
+	}
+	return res
+}
+
+func (i *loggingStorageIterator) Key() common.Hash {
+	return i.nested.Key()
+}
+
+func (i *loggingStorageIterator) Value() common.Hash {
+	return i.nested.Value()
+}
+
+func (i *loggingStorageIterator) Error() error {
+	return i.nested.Error()
+}
+
+func (i *loggingStorageIterator) Release() {
+	i.nested.Release()
+	i.writeLog("StorageIterator, Release")
+}
+
 type loggingBulkLoad struct {
 	nested   state.BulkLoad
 	writeLog func(format string, a ...any)
@@ -421,6 +421,10 @@ func (p *ProfilerProxy) Close() error {
 	return err
 }
 
+func (p *ProfilerProxy) Flush() error {
+	return p.db.Flush()
+}
+
 func (p *ProfilerProxy) StartBulkLoad(block uint64) (state.BulkLoad, error) {
 	p.log.Fatal("StartBulkLoad not supported by ProfilerProxy")
 	return nil, nil
@@ -441,3 +445,59 @@ func (p *ProfilerProxy) GetMemoryUsage() *state.MemoryUsage {
 func (p *ProfilerProxy) GetShadowDB() state.StateDB {
 	return p.db.GetShadowDB()
 }
+
+// NewAccountIterator creates an iterator over all accounts, profiling each step of the iteration.
+func (p *ProfilerProxy) NewAccountIterator() (state.AccountIterator, error) {
+	var (
+		it  state.AccountIterator
+		err error
+	)
+	p.do(operation.NewAccountIteratorID, func() {
+		it, err = state.NewAccountIterator(p.db)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profilingAccountIterator{AccountIterator: it, proxy: p}, nil
+}
+
+// NewStorageIterator creates an iterator over the storage of an account, profiling each step of the iteration.
+func (p *ProfilerProxy) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	var (
+		it  state.StorageIterator
+		err error
+	)
+	p.do(operation.NewStorageIteratorID, func() {
+		it, err = state.NewStorageIterator(p.db, addr)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &profilingStorageIterator{StorageIterator: it, proxy: p}, nil
+}
+
+type profilingAccountIterator struct {
+	state.AccountIterator
+	proxy *ProfilerProxy
+}
+
+func (i *profilingAccountIterator) Next() bool {
+	var res bool
+	i.proxy.do(operation.AccountIteratorNextID, func() {
+		res = i.AccountIterator.Next()
+	})
+	return res
+}
+
+type profilingStorageIterator struct {
+	state.StorageIterator
+	proxy *ProfilerProxy
+}
+
+func (i *profilingStorageIterator) Next() bool {
+	var res bool
+	i.proxy.do(operation.StorageIteratorNextID, func() {
+		res = i.StorageIterator.Next()
+	})
+	return res
+}
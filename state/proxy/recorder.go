@@ -131,16 +131,19 @@ func (r *RecorderProxy) GetCodeSize(addr common.Address) int {
 
 // AddRefund adds gas to the refund counter.
 func (r *RecorderProxy) AddRefund(gas uint64) {
+	r.write(operation.NewAddRefund(gas))
 	r.db.AddRefund(gas)
 }
 
 // SubRefund subtracts gas to the refund counter.
 func (r *RecorderProxy) SubRefund(gas uint64) {
+	r.write(operation.NewSubRefund(gas))
 	r.db.SubRefund(gas)
 }
 
 // GetRefund returns the current value of the refund counter.
 func (r *RecorderProxy) GetRefund() uint64 {
+	r.write(operation.NewGetRefund())
 	gas := r.db.GetRefund()
 	return gas
 }
@@ -206,6 +209,8 @@ func (r *RecorderProxy) Suicide(addr common.Address) bool {
 
 // HasSuicided checks whether a contract has been suicided.
 func (r *RecorderProxy) HasSuicided(addr common.Address) bool {
+	contract := r.ctx.EncodeContract(addr)
+	r.write(operation.NewHasSuicided(contract))
 	hasSuicided := r.db.HasSuicided(addr)
 	return hasSuicided
 }
@@ -221,6 +226,8 @@ func (r *RecorderProxy) Exist(addr common.Address) bool {
 // Empty checks whether the contract is either non-existent
 // or empty according to the EIP161 specification (balance = nonce = code = 0).
 func (r *RecorderProxy) Empty(addr common.Address) bool {
+	contract := r.ctx.EncodeContract(addr)
+	r.write(operation.NewEmpty(contract))
 	empty := r.db.Empty(addr)
 	return empty
 }
@@ -235,28 +242,33 @@ func (r *RecorderProxy) Empty(addr common.Address) bool {
 //
 // This method should only be called if Berlin/2929+2930 is applicable at the current number.
 func (r *RecorderProxy) PrepareAccessList(render common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) {
+	r.write(operation.NewPrepareAccessList(render, dest, precompiles, txAccesses))
 	r.db.PrepareAccessList(render, dest, precompiles, txAccesses)
 }
 
 // AddAddressToAccessList adds an address to the access list.
 func (r *RecorderProxy) AddAddressToAccessList(addr common.Address) {
+	r.write(operation.NewAddAddressToAccessList(addr))
 	r.db.AddAddressToAccessList(addr)
 }
 
 // AddressInAccessList checks whether an address is in the access list.
 func (r *RecorderProxy) AddressInAccessList(addr common.Address) bool {
+	r.write(operation.NewAddressInAccessList(addr))
 	ok := r.db.AddressInAccessList(addr)
 	return ok
 }
 
 // SlotInAccessList checks whether the (address, slot)-tuple is in the access list.
 func (r *RecorderProxy) SlotInAccessList(addr common.Address, slot common.Hash) (bool, bool) {
+	r.write(operation.NewSlotInAccessList(addr, slot))
 	addressOk, slotOk := r.db.SlotInAccessList(addr, slot)
 	return addressOk, slotOk
 }
 
 // AddSlotToAccessList adds the given (address, slot)-tuple to the access list
 func (r *RecorderProxy) AddSlotToAccessList(addr common.Address, slot common.Hash) {
+	r.write(operation.NewAddSlotToAccessList(addr, slot))
 	r.db.AddSlotToAccessList(addr, slot)
 }
 
@@ -276,6 +288,7 @@ func (r *RecorderProxy) Snapshot() int {
 
 // AddLog adds a log entry.
 func (r *RecorderProxy) AddLog(log *types.Log) {
+	r.write(operation.NewAddLog(log))
 	r.db.AddLog(log)
 }
 
@@ -297,6 +310,7 @@ func (r *RecorderProxy) ForEachStorage(addr common.Address, fn func(common.Hash,
 
 // Prepare sets the current transaction hash and index.
 func (r *RecorderProxy) Prepare(thash common.Hash, ti int) {
+	r.write(operation.NewPrepare(thash, ti))
 	r.db.Prepare(thash, ti)
 }
 
@@ -381,6 +395,10 @@ func (r *RecorderProxy) Close() error {
 	return r.db.Close()
 }
 
+func (r *RecorderProxy) Flush() error {
+	return r.db.Flush()
+}
+
 func (r *RecorderProxy) StartBulkLoad(uint64) (state.BulkLoad, error) {
 	panic("StartBulkLoad not supported by RecorderProxy")
 }
@@ -392,3 +410,12 @@ func (r *RecorderProxy) GetMemoryUsage() *state.MemoryUsage {
 func (r *RecorderProxy) GetShadowDB() state.StateDB {
 	return r.db.GetShadowDB()
 }
+
+func (r *RecorderProxy) NewAccountIterator() (state.AccountIterator, error) {
+	// iteration is not recorded since it cannot be replayed from a trace
+	return state.NewAccountIterator(r.db)
+}
+
+func (r *RecorderProxy) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return state.NewStorageIterator(r.db, addr)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/Fantom-foundation/Aida/txcontext"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 )
 
 // NewShadowProxy creates a StateDB instance bundling two other instances and running each
@@ -230,6 +231,10 @@ func (s *shadowStateDb) Close() error {
 	return s.getError("Close", func(s state.StateDB) error { return s.Close() })
 }
 
+func (s *shadowStateDb) Flush() error {
+	return s.getError("Flush", func(s state.StateDB) error { return s.Flush() })
+}
+
 func (s *shadowNonCommittableStateDb) Release() error {
 	s.run("Release", func(s state.NonCommittableStateDB) { s.Release() })
 	return nil
@@ -467,6 +472,126 @@ func (s *shadowStateDb) GetShadowDB() state.StateDB {
 	return s.shadow
 }
 
+func (s *shadowStateDb) NewAccountIterator() (state.AccountIterator, error) {
+	return s.newAccountIterator(s.prime, s.shadow)
+}
+
+func (s *shadowStateDb) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return s.newStorageIterator(s.prime, s.shadow, addr)
+}
+
+func (s *shadowNonCommittableStateDb) NewAccountIterator() (state.AccountIterator, error) {
+	return s.newAccountIterator(s.prime, s.shadow)
+}
+
+func (s *shadowNonCommittableStateDb) NewStorageIterator(addr common.Address) (state.StorageIterator, error) {
+	return s.newStorageIterator(s.prime, s.shadow, addr)
+}
+
+// newAccountIterator iterates accounts of the prime DB. Since DB implementations are free
+// to choose their iteration order, the shadow DB is cross checked once the iteration of the
+// prime DB has completed by comparing order-independent digests of both iterations.
+func (s *shadowVmStateDb) newAccountIterator(prime, shadow state.VmStateDB) (state.AccountIterator, error) {
+	shadowIt, err := state.NewAccountIterator(shadow)
+	if err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+	defer shadowIt.Release()
+	var expected iterationDigest
+	for shadowIt.Next() {
+		addr := shadowIt.Address()
+		expected.add(addr[:])
+	}
+	if err = shadowIt.Error(); err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+
+	primeIt, err := state.NewAccountIterator(prime)
+	if err != nil {
+		return nil, fmt.Errorf("prime: %w", err)
+	}
+	return &shadowAccountIterator{AccountIterator: primeIt, db: s, expected: expected}, nil
+}
+
+// newStorageIterator iterates the storage of the prime DB cross checked with the shadow DB
+// the same way as accounts are.
+func (s *shadowVmStateDb) newStorageIterator(prime, shadow state.VmStateDB, addr common.Address) (state.StorageIterator, error) {
+	shadowIt, err := state.NewStorageIterator(shadow, addr)
+	if err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+	defer shadowIt.Release()
+	var expected iterationDigest
+	for shadowIt.Next() {
+		key, value := shadowIt.Key(), shadowIt.Value()
+		expected.add(key[:], value[:])
+	}
+	if err = shadowIt.Error(); err != nil {
+		return nil, fmt.Errorf("shadow: %w", err)
+	}
+
+	primeIt, err := state.NewStorageIterator(prime, addr)
+	if err != nil {
+		return nil, fmt.Errorf("prime: %w", err)
+	}
+	return &shadowStorageIterator{StorageIterator: primeIt, db: s, addr: addr, expected: expected}, nil
+}
+
+// iterationDigest summarizes iterated elements independently of their order.
+type iterationDigest struct {
+	count int
+	sum   common.Hash
+}
+
+func (d *iterationDigest) add(data ...[]byte) {
+	d.count++
+	hash := crypto.Keccak256Hash(data...)
+	for i := range d.sum {
+		d.sum[i] ^= hash[i]
+	}
+}
+
+type shadowAccountIterator struct {
+	state.AccountIterator
+	db       *shadowVmStateDb
+	expected iterationDigest
+	got      iterationDigest
+}
+
+func (i *shadowAccountIterator) Next() bool {
+	if i.AccountIterator.Next() {
+		addr := i.Address()
+		i.got.add(addr[:])
+		return true
+	}
+	if i.AccountIterator.Error() == nil && i.got != i.expected {
+		i.db.logIssue("AccountIterator", i.got.count, i.expected.count)
+		i.db.err = fmt.Errorf("%v diverged from shadow DB.", getOpcodeString("AccountIterator"))
+	}
+	return false
+}
+
+type shadowStorageIterator struct {
+	state.StorageIterator
+	db       *shadowVmStateDb
+	addr     common.Address
+	expected iterationDigest
+	got      iterationDigest
+}
+
+func (i *shadowStorageIterator) Next() bool {
+	if i.StorageIterator.Next() {
+		key, value := i.Key(), i.Value()
+		i.got.add(key[:], value[:])
+		return true
+	}
+	if i.StorageIterator.Error() == nil && i.got != i.expected {
+		i.db.logIssue("StorageIterator", i.got.count, i.expected.count, i.addr)
+		i.db.err = fmt.Errorf("%v diverged from shadow DB.", getOpcodeString("StorageIterator", i.addr))
+	}
+	return false
+}
+
 type shadowBulkLoad struct {
 	prime  state.BulkLoad
 	shadow state.BulkLoad
@@ -838,3 +838,77 @@ func TestShadowState_GetHash_FailWithValidate(t *testing.T) {
 		t.Fatal("Expect a mistach of state hashes")
 	}
 }
+
+func fillShadowIterationTestDb(t *testing.T, db state.StateDB, accounts int) {
+	if err := db.BeginBlock(1); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	if err := db.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	for i := 1; i <= accounts; i++ {
+		addr := common.Address{byte(i)}
+		db.CreateAccount(addr)
+		db.SetNonce(addr, 1)
+		db.SetState(addr, common.Hash{1}, common.Hash{byte(i)})
+	}
+	if err := db.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if err := db.EndBlock(); err != nil {
+		t.Fatalf("cannot end block; %v", err)
+	}
+}
+
+func TestShadowState_IterationIsCrossChecked(t *testing.T) {
+	tests := map[string]struct {
+		shadowAccounts int
+		diverged       bool
+	}{
+		"equal":    {3, false},
+		"diverged": {4, true},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			prime, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+			if err != nil {
+				t.Fatalf("failed to create geth state DB: %v", err)
+			}
+			shadow, err := state.MakeEmptyGethInMemoryStateDB("")
+			if err != nil {
+				t.Fatalf("failed to create in-memory state DB: %v", err)
+			}
+			fillShadowIterationTestDb(t, prime, 3)
+			fillShadowIterationTestDb(t, shadow, test.shadowAccounts)
+
+			db := NewShadowProxy(prime, shadow, false)
+			defer db.Close()
+
+			it, err := state.NewAccountIterator(db)
+			if err != nil {
+				t.Fatalf("cannot create account iterator; %v", err)
+			}
+			count := 0
+			for it.Next() {
+				count++
+			}
+			it.Release()
+			if count != 3 {
+				t.Errorf("unexpected number of iterated accounts; got: %v, want: 3", count)
+			}
+
+			storage, err := state.NewStorageIterator(db, common.Address{1})
+			if err != nil {
+				t.Fatalf("cannot create storage iterator; %v", err)
+			}
+			for storage.Next() {
+			}
+			storage.Release()
+
+			if got := db.Error() != nil; got != test.diverged {
+				t.Errorf("unexpected divergence; got: %v, want: %v", got, test.diverged)
+			}
+		})
+	}
+}
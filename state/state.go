@@ -148,6 +148,10 @@ type StateDB interface {
 	// After this call no more operations will be allowed on the state.
 	Close() error
 
+	// Flush requests the StateDB to persist all committed content to secondary storage.
+	// Unlike Close, the state remains usable. It must not be called while a block is open.
+	Flush() error
+
 	// StartBulkLoad creates a interface supporting the efficient loading of large amount
 	// of data as it is, for instance, needed during priming. Only one bulk load operation
 	// may be active at any time and no other concurrent operations on the StateDB are
@@ -190,6 +194,42 @@ type StateDB interface {
 	GetShadowDB() StateDB
 }
 
+// IterableState is implemented by states able to enumerate their content. Iterators
+// cover the committed state, modifications of an open block or transaction are not
+// guaranteed to be visible. The order of iterated elements is implementation specific.
+// Carmen head states iterate a key index kept next to the state-db since the Carmen API
+// offers no means for enumerating accounts and storage slots. Carmen state-dbs created
+// before key indexing and Carmen archive states do not support iteration. Use
+// NewAccountIterator and NewStorageIterator to iterate states which may not support it.
+type IterableState interface {
+	// NewAccountIterator creates an iterator over addresses of all accounts in the state.
+	NewAccountIterator() (AccountIterator, error)
+
+	// NewStorageIterator creates an iterator over all non-empty storage slots of
+	// the given account. Iterating an account not present in the state yields no slots.
+	NewStorageIterator(common.Address) (StorageIterator, error)
+}
+
+// AccountIterator enumerates accounts of a state. Next must be called before the first
+// account is accessed. Once Next returns false, Error reports whether the iteration
+// ended prematurely. Release must be called on every iterator once it is not needed.
+type AccountIterator interface {
+	Next() bool
+	Address() common.Address
+	Error() error
+	Release()
+}
+
+// StorageIterator enumerates storage slots of a single account. It follows the same
+// protocol as the AccountIterator.
+type StorageIterator interface {
+	Next() bool
+	Key() common.Hash
+	Value() common.Hash
+	Error() error
+	Release()
+}
+
 // BulkWrite is a faster interface to StateDB instances for writing data without
 // the overhead of snapshots or transactions. It is mainly intended for priming DB
 // instances before running evaluations.
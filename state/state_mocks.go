@@ -21,6 +21,7 @@
 //
 //	mockgen -source state.go -destination state_mocks.go -package state
 //
+
 // Package state is a generated GoMock package.
 package state
 
@@ -1315,6 +1316,20 @@ func (mr *MockStateDBMockRecorder) Finalise(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finalise", reflect.TypeOf((*MockStateDB)(nil).Finalise), arg0)
 }
 
+// Flush mocks base method.
+func (m *MockStateDB) Flush() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Flush")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Flush indicates an expected call of Flush.
+func (mr *MockStateDBMockRecorder) Flush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MockStateDB)(nil).Flush))
+}
+
 // ForEachStorage mocks base method.
 func (m *MockStateDB) ForEachStorage(arg0 common.Address, arg1 func(common.Hash, common.Hash) bool) error {
 	m.ctrl.T.Helper()
@@ -1737,6 +1752,227 @@ func (mr *MockStateDBMockRecorder) Suicide(arg0 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Suicide", reflect.TypeOf((*MockStateDB)(nil).Suicide), arg0)
 }
 
+// MockIterableState is a mock of IterableState interface.
+type MockIterableState struct {
+	ctrl     *gomock.Controller
+	recorder *MockIterableStateMockRecorder
+}
+
+// MockIterableStateMockRecorder is the mock recorder for MockIterableState.
+type MockIterableStateMockRecorder struct {
+	mock *MockIterableState
+}
+
+// NewMockIterableState creates a new mock instance.
+func NewMockIterableState(ctrl *gomock.Controller) *MockIterableState {
+	mock := &MockIterableState{ctrl: ctrl}
+	mock.recorder = &MockIterableStateMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIterableState) EXPECT() *MockIterableStateMockRecorder {
+	return m.recorder
+}
+
+// NewAccountIterator mocks base method.
+func (m *MockIterableState) NewAccountIterator() (AccountIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewAccountIterator")
+	ret0, _ := ret[0].(AccountIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewAccountIterator indicates an expected call of NewAccountIterator.
+func (mr *MockIterableStateMockRecorder) NewAccountIterator() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewAccountIterator", reflect.TypeOf((*MockIterableState)(nil).NewAccountIterator))
+}
+
+// NewStorageIterator mocks base method.
+func (m *MockIterableState) NewStorageIterator(arg0 common.Address) (StorageIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NewStorageIterator", arg0)
+	ret0, _ := ret[0].(StorageIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewStorageIterator indicates an expected call of NewStorageIterator.
+func (mr *MockIterableStateMockRecorder) NewStorageIterator(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewStorageIterator", reflect.TypeOf((*MockIterableState)(nil).NewStorageIterator), arg0)
+}
+
+// MockAccountIterator is a mock of AccountIterator interface.
+type MockAccountIterator struct {
+	ctrl     *gomock.Controller
+	recorder *MockAccountIteratorMockRecorder
+}
+
+// MockAccountIteratorMockRecorder is the mock recorder for MockAccountIterator.
+type MockAccountIteratorMockRecorder struct {
+	mock *MockAccountIterator
+}
+
+// NewMockAccountIterator creates a new mock instance.
+func NewMockAccountIterator(ctrl *gomock.Controller) *MockAccountIterator {
+	mock := &MockAccountIterator{ctrl: ctrl}
+	mock.recorder = &MockAccountIteratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAccountIterator) EXPECT() *MockAccountIteratorMockRecorder {
+	return m.recorder
+}
+
+// Address mocks base method.
+func (m *MockAccountIterator) Address() common.Address {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Address")
+	ret0, _ := ret[0].(common.Address)
+	return ret0
+}
+
+// Address indicates an expected call of Address.
+func (mr *MockAccountIteratorMockRecorder) Address() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockAccountIterator)(nil).Address))
+}
+
+// Error mocks base method.
+func (m *MockAccountIterator) Error() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Error")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Error indicates an expected call of Error.
+func (mr *MockAccountIteratorMockRecorder) Error() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockAccountIterator)(nil).Error))
+}
+
+// Next mocks base method.
+func (m *MockAccountIterator) Next() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockAccountIteratorMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockAccountIterator)(nil).Next))
+}
+
+// Release mocks base method.
+func (m *MockAccountIterator) Release() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release")
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockAccountIteratorMockRecorder) Release() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockAccountIterator)(nil).Release))
+}
+
+// MockStorageIterator is a mock of StorageIterator interface.
+type MockStorageIterator struct {
+	ctrl     *gomock.Controller
+	recorder *MockStorageIteratorMockRecorder
+}
+
+// MockStorageIteratorMockRecorder is the mock recorder for MockStorageIterator.
+type MockStorageIteratorMockRecorder struct {
+	mock *MockStorageIterator
+}
+
+// NewMockStorageIterator creates a new mock instance.
+func NewMockStorageIterator(ctrl *gomock.Controller) *MockStorageIterator {
+	mock := &MockStorageIterator{ctrl: ctrl}
+	mock.recorder = &MockStorageIteratorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStorageIterator) EXPECT() *MockStorageIteratorMockRecorder {
+	return m.recorder
+}
+
+// Error mocks base method.
+func (m *MockStorageIterator) Error() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Error")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Error indicates an expected call of Error.
+func (mr *MockStorageIteratorMockRecorder) Error() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Error", reflect.TypeOf((*MockStorageIterator)(nil).Error))
+}
+
+// Key mocks base method.
+func (m *MockStorageIterator) Key() common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Key")
+	ret0, _ := ret[0].(common.Hash)
+	return ret0
+}
+
+// Key indicates an expected call of Key.
+func (mr *MockStorageIteratorMockRecorder) Key() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Key", reflect.TypeOf((*MockStorageIterator)(nil).Key))
+}
+
+// Next mocks base method.
+func (m *MockStorageIterator) Next() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Next indicates an expected call of Next.
+func (mr *MockStorageIteratorMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockStorageIterator)(nil).Next))
+}
+
+// Release mocks base method.
+func (m *MockStorageIterator) Release() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release")
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockStorageIteratorMockRecorder) Release() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockStorageIterator)(nil).Release))
+}
+
+// Value mocks base method.
+func (m *MockStorageIterator) Value() common.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Value")
+	ret0, _ := ret[0].(common.Hash)
+	return ret0
+}
+
+// Value indicates an expected call of Value.
+func (mr *MockStorageIteratorMockRecorder) Value() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Value", reflect.TypeOf((*MockStorageIterator)(nil).Value))
+}
+
 // MockBulkLoad is a mock of BulkLoad interface.
 type MockBulkLoad struct {
 	ctrl     *gomock.Controller
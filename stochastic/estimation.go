@@ -38,6 +38,15 @@ type EstimationModelJSON struct {
 	Values    EstimationStatsJSON `json:"valueStats"`
 
 	SnapshotLambda float64 `json:"snapshotLambda"`
+
+	// optional second-order stochastic matrix; first-order models do not have one
+	SecondOrder *SecondOrderJSON `json:"secondOrder,omitempty"`
+
+	// optional magnitudes of code sizes, balance changes and nonce increments;
+	// uniform ranges are used for models without them
+	CodeSizes       *statistics.MagnitudeJSON `json:"codeSizes,omitempty"`
+	Balances        *statistics.MagnitudeJSON `json:"balances,omitempty"`
+	NonceIncrements *statistics.MagnitudeJSON `json:"nonceIncrements,omitempty"`
 }
 
 // NewEstimationModelJSON creates a new estimation model.
@@ -68,7 +77,21 @@ func NewEstimationModelJSON(d *EventRegistryJSON) EstimationModelJSON {
 		Keys:             NewEstimationStats(&d.Keys),
 		Values:           NewEstimationStats(&d.Values),
 		SnapshotLambda:   snapshotLambda,
+		CodeSizes:        d.CodeSizes.Copy(),
+		Balances:         d.Balances.Copy(),
+		NonceIncrements:  d.NonceIncrements.Copy(),
+	}
+}
+
+// NewSecondOrderEstimationModelJSON creates a new estimation model whose operations are
+// sampled from the second-order Markov process of the events.
+func NewSecondOrderEstimationModelJSON(d *EventRegistryJSON) (EstimationModelJSON, error) {
+	if d.SecondOrder == nil {
+		return EstimationModelJSON{}, fmt.Errorf("events file has no second-order transitions; record the events again")
 	}
+	m := NewEstimationModelJSON(d)
+	m.SecondOrder = d.SecondOrder.Copy()
+	return m, nil
 }
 
 // ReadSimulation reads the simulation file in JSON format (generated by the estimator).
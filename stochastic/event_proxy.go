@@ -56,6 +56,7 @@ func (p *EventProxy) CreateAccount(address common.Address) {
 func (p *EventProxy) SubBalance(address common.Address, amount *big.Int) {
 	// register event
 	p.registry.RegisterAddressOp(SubBalanceID, &address)
+	p.registry.RegisterBalance(amount)
 
 	// call real StateDB
 	p.db.SubBalance(address, amount)
@@ -65,6 +66,7 @@ func (p *EventProxy) SubBalance(address common.Address, amount *big.Int) {
 func (p *EventProxy) AddBalance(address common.Address, amount *big.Int) {
 	// register event
 	p.registry.RegisterAddressOp(AddBalanceID, &address)
+	p.registry.RegisterBalance(amount)
 
 	// call real StateDB
 	p.db.AddBalance(address, amount)
@@ -85,13 +87,16 @@ func (p *EventProxy) GetNonce(address common.Address) uint64 {
 	p.registry.RegisterAddressOp(GetNonceID, &address)
 
 	// call real StateDB
-	return p.db.GetNonce(address)
+	nonce := p.db.GetNonce(address)
+	p.registry.ObserveNonce(address, nonce)
+	return nonce
 }
 
 // SetNonce sets the nonce of a contract address.
 func (p *EventProxy) SetNonce(address common.Address, nonce uint64) {
 	// register event
 	p.registry.RegisterAddressOp(SetNonceID, &address)
+	p.registry.RegisterNonce(address, nonce)
 
 	// call real StateDB
 	p.db.SetNonce(address, nonce)
@@ -119,6 +124,7 @@ func (p *EventProxy) GetCode(address common.Address) []byte {
 func (p *EventProxy) SetCode(address common.Address, code []byte) {
 	// register event
 	p.registry.RegisterAddressOp(SetCodeID, &address)
+	p.registry.RegisterCodeSize(len(code))
 
 	// call real StateDB
 	p.db.SetCode(address, code)
@@ -410,6 +416,10 @@ func (p *EventProxy) Close() error {
 	return p.db.Close()
 }
 
+func (p *EventProxy) Flush() error {
+	return p.db.Flush()
+}
+
 func (p *EventProxy) StartBulkLoad(uint64) (state.BulkLoad, error) {
 	panic("StartBulkLoad not supported by EventProxy")
 }
@@ -429,3 +439,12 @@ func (p *EventProxy) GetArchiveBlockHeight() (uint64, bool, error) {
 func (p *EventProxy) GetShadowDB() state.StateDB {
 	return p.db.GetShadowDB()
 }
+
+func (p *EventProxy) NewAccountIterator() (state.AccountIterator, error) {
+	// iteration is not part of the stochastic model, no event is registered
+	return state.NewAccountIterator(p.db)
+}
+
+func (p *EventProxy) NewStorageIterator(address common.Address) (state.StorageIterator, error) {
+	return state.NewStorageIterator(p.db, address)
+}
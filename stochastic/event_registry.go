@@ -21,7 +21,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math/big"
+	"math/bits"
 	"os"
+	"sort"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/paulmach/orb"
@@ -47,21 +50,39 @@ type EventRegistry struct {
 	// Storage-value access statistics
 	values statistics.Access[common.Hash]
 
+	// Transition frequencies of the second-order Markov process, i.e., from
+	// two subsequent argument-encoded operations to the next one
+	secondOrderFreq map[[2]int]map[int]uint64
+
 	// Previous argument-encoded operation
 	prevArgOp int
 
+	// Argument-encoded operation before the previous one
+	prevPrevArgOp int
+
 	// Snapshot deltas
 	snapshotFreq map[int]uint64
+
+	// Magnitudes of code sizes, balance changes and nonce increments
+	codeSizes       statistics.Magnitude
+	balances        statistics.Magnitude
+	nonceIncrements statistics.Magnitude
+
+	// Last observed nonce of accounts for computing nonce increments
+	lastNonces map[common.Address]uint64
 }
 
 // NewEventRegistry creates a new event registry.
 func NewEventRegistry() EventRegistry {
 	return EventRegistry{
-		prevArgOp:    numArgOps,
-		contracts:    statistics.NewAccess[common.Address](),
-		keys:         statistics.NewAccess[common.Hash](),
-		values:       statistics.NewAccess[common.Hash](),
-		snapshotFreq: map[int]uint64{},
+		prevArgOp:       numArgOps,
+		prevPrevArgOp:   numArgOps,
+		secondOrderFreq: map[[2]int]map[int]uint64{},
+		contracts:       statistics.NewAccess[common.Address](),
+		keys:            statistics.NewAccess[common.Hash](),
+		values:          statistics.NewAccess[common.Hash](),
+		snapshotFreq:    map[int]uint64{},
+		lastNonces:      map[common.Address]uint64{},
 	}
 }
 
@@ -156,6 +177,16 @@ func (r *EventRegistry) updateFreq(op int, addr int, key int, value int) {
 	if r.prevArgOp < numArgOps {
 		r.transitFreq[r.prevArgOp][argOp] = r.transitFreq[r.prevArgOp][argOp] + 1
 	}
+
+	// skip counting of second-order transitions for the first two operations
+	if r.prevPrevArgOp < numArgOps {
+		context := [2]int{r.prevPrevArgOp, r.prevArgOp}
+		if r.secondOrderFreq[context] == nil {
+			r.secondOrderFreq[context] = map[int]uint64{}
+		}
+		r.secondOrderFreq[context][argOp]++
+	}
+	r.prevPrevArgOp = r.prevArgOp
 	r.prevArgOp = argOp
 }
 
@@ -168,6 +199,31 @@ func (r *EventRegistry) RegisterSnapshotDelta(delta int) {
 	r.snapshotFreq[delta]++
 }
 
+// RegisterCodeSize counts the size of a contract's code set by a SetCode operation.
+func (r *EventRegistry) RegisterCodeSize(size int) {
+	r.codeSizes.Place(bits.Len(uint(size)))
+}
+
+// RegisterBalance counts the amount of a balance change.
+func (r *EventRegistry) RegisterBalance(amount *big.Int) {
+	r.balances.Place(amount.BitLen())
+}
+
+// ObserveNonce keeps the nonce of an account read by a GetNonce operation.
+func (r *EventRegistry) ObserveNonce(address common.Address, nonce uint64) {
+	r.lastNonces[address] = nonce
+}
+
+// RegisterNonce counts the increment of a nonce set by a SetNonce operation with respect
+// to the last observed nonce of the account. Nonces of accounts without an observed nonce
+// are kept, but not counted.
+func (r *EventRegistry) RegisterNonce(address common.Address, nonce uint64) {
+	if last, found := r.lastNonces[address]; found && nonce >= last {
+		r.nonceIncrements.Place(bits.Len64(nonce - last))
+	}
+	r.lastNonces[address] = nonce
+}
+
 // WriteJSON writes an event registry in JSON format.
 func (r *EventRegistry) WriteJSON(filename string) error {
 	f, fErr := os.Create(filename)
@@ -199,6 +255,34 @@ type EventRegistryJSON struct {
 
 	// snapshot delta frequencies
 	SnapshotEcdf [][2]float64 `json:"snapshotEcdf"`
+
+	// observed second-order stochastic matrix (absent in event files of older versions)
+	SecondOrder *SecondOrderJSON `json:"secondOrder,omitempty"`
+
+	// magnitudes of code sizes, balance changes and nonce increments
+	// (absent in event files of older versions)
+	CodeSizes       *statistics.MagnitudeJSON `json:"codeSizes,omitempty"`
+	Balances        *statistics.MagnitudeJSON `json:"balances,omitempty"`
+	NonceIncrements *statistics.MagnitudeJSON `json:"nonceIncrements,omitempty"`
+}
+
+// SecondOrderJSON is the JSON struct for the stochastic matrix of a second-order Markov process.
+// The next operation depends on the previous and the current operation, i.e., the context.
+type SecondOrderJSON struct {
+	Contexts         [][2]int    `json:"contexts"`         // indexes of the previous and current operation
+	StochasticMatrix [][]float64 `json:"stochasticMatrix"` // transition probabilities of the contexts
+}
+
+// Copy creates a deep copy of a second-order stochastic matrix.
+func (s *SecondOrderJSON) Copy() *SecondOrderJSON {
+	contexts := make([][2]int, len(s.Contexts))
+	copy(contexts, s.Contexts)
+	A := make([][]float64, len(s.StochasticMatrix))
+	for i := range s.StochasticMatrix {
+		A[i] = make([]float64, len(s.StochasticMatrix[i]))
+		copy(A[i], s.StochasticMatrix[i])
+	}
+	return &SecondOrderJSON{Contexts: contexts, StochasticMatrix: A}
 }
 
 // NewEventRegistry produces the JSON output for an event registry.
@@ -300,7 +384,54 @@ func (r *EventRegistry) NewEventRegistryJSON() EventRegistryJSON {
 		Keys:             r.keys.NewAccessJSON(),
 		Values:           r.values.NewAccessJSON(),
 		SnapshotEcdf:     eCdf,
+		SecondOrder:      r.newSecondOrderJSON(),
+		CodeSizes:        r.codeSizes.NewMagnitudeJSON(),
+		Balances:         r.balances.NewMagnitudeJSON(),
+		NonceIncrements:  r.nonceIncrements.NewMagnitudeJSON(),
+	}
+}
+
+// newSecondOrderJSON computes the second-order stochastic matrix for observable operations.
+func (r *EventRegistry) newSecondOrderJSON() *SecondOrderJSON {
+	if len(r.secondOrderFreq) == 0 {
+		return nil
+	}
+
+	// index of argument-encoded operations in the labels of observable operations
+	index := map[int]int{}
+	for argop := 0; argop < numArgOps; argop++ {
+		if r.argOpFreq[argop] > 0 {
+			index[argop] = len(index)
+		}
+	}
+
+	// sort contexts for a deterministic output
+	contexts := make([][2]int, 0, len(r.secondOrderFreq))
+	for context := range r.secondOrderFreq {
+		contexts = append(contexts, context)
+	}
+	sort.Slice(contexts, func(i, j int) bool {
+		if contexts[i][0] != contexts[j][0] {
+			return contexts[i][0] < contexts[j][0]
+		}
+		return contexts[i][1] < contexts[j][1]
+	})
+
+	s := &SecondOrderJSON{}
+	for _, context := range contexts {
+		freq := r.secondOrderFreq[context]
+		total := uint64(0)
+		for _, f := range freq {
+			total += f
+		}
+		row := make([]float64, len(index))
+		for argop, f := range freq {
+			row[index[argop]] = float64(f) / float64(total)
+		}
+		s.Contexts = append(s.Contexts, [2]int{index[context[0]], index[context[1]]})
+		s.StochasticMatrix = append(s.StochasticMatrix, row)
 	}
+	return s
 }
 
 // ReadEventsJSON reads event file in JSON format.
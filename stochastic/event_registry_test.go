@@ -17,6 +17,8 @@
 package stochastic
 
 import (
+	"math/big"
+	"reflect"
 	"testing"
 
 	"github.com/Fantom-foundation/Aida/stochastic/statistics"
@@ -208,3 +210,89 @@ func TestEventRegistryZeroOperation(t *testing.T) {
 		t.Fatalf("operation/transit frequency diverges")
 	}
 }
+
+// TestEventRegistrySecondOrder checks the second-order stochastic matrix of the JSON output.
+func TestEventRegistrySecondOrder(t *testing.T) {
+	r := NewEventRegistry()
+	for _, op := range []int{BeginBlockID, BeginTransactionID, EndTransactionID, BeginBlockID, BeginTransactionID, EndBlockID} {
+		r.RegisterOp(op)
+	}
+	events := r.NewEventRegistryJSON()
+
+	// observed operations are BB, BT, EB, ET
+	wantLabels := []string{OpMnemo(BeginBlockID), OpMnemo(BeginTransactionID), OpMnemo(EndBlockID), OpMnemo(EndTransactionID)}
+	if !reflect.DeepEqual(events.Operations, wantLabels) {
+		t.Fatalf("unexpected operations; got %v, want %v", events.Operations, wantLabels)
+	}
+	if events.SecondOrder == nil {
+		t.Fatalf("second-order stochastic matrix is missing")
+	}
+	want := &SecondOrderJSON{
+		Contexts: [][2]int{{0, 1}, {1, 3}, {3, 0}},
+		StochasticMatrix: [][]float64{
+			{0.0, 0.0, 0.5, 0.5},
+			{1.0, 0.0, 0.0, 0.0},
+			{0.0, 1.0, 0.0, 0.0},
+		},
+	}
+	if !reflect.DeepEqual(events.SecondOrder, want) {
+		t.Errorf("unexpected second-order stochastic matrix; got %v, want %v", events.SecondOrder, want)
+	}
+}
+
+// TestEventRegistryNoSecondOrder checks that the second-order stochastic matrix is omitted without observations.
+func TestEventRegistryNoSecondOrder(t *testing.T) {
+	r := NewEventRegistry()
+	r.RegisterOp(BeginBlockID)
+	r.RegisterOp(EndBlockID)
+	if events := r.NewEventRegistryJSON(); events.SecondOrder != nil {
+		t.Errorf("unexpected second-order stochastic matrix; got %v", events.SecondOrder)
+	}
+}
+
+// TestEventRegistryMagnitudes checks the code size, balance and nonce increment distributions of the JSON output.
+func TestEventRegistryMagnitudes(t *testing.T) {
+	r := NewEventRegistry()
+	if events := r.NewEventRegistryJSON(); events.CodeSizes != nil || events.Balances != nil || events.NonceIncrements != nil {
+		t.Fatalf("unexpected distributions without observations")
+	}
+
+	r.RegisterCodeSize(100)
+	r.RegisterCodeSize(120)
+	r.RegisterBalance(big.NewInt(0))
+	r.RegisterBalance(new(big.Int).Lsh(big.NewInt(1), 70))
+	r.ObserveNonce(common.Address{1}, 5)
+	r.RegisterNonce(common.Address{1}, 6)
+	events := r.NewEventRegistryJSON()
+
+	if got := events.CodeSizes.Distribution; len(got) != 8 || got[7] != 1.0 {
+		t.Errorf("unexpected code size distribution; got %v", got)
+	}
+	if got := events.Balances.Distribution; len(got) != 72 || got[0] != 0.5 || got[71] != 0.5 {
+		t.Errorf("unexpected balance distribution; got %v", got)
+	}
+	if got := events.NonceIncrements.Distribution; !reflect.DeepEqual(got, []float64{0.0, 1.0}) {
+		t.Errorf("unexpected nonce increment distribution; got %v", got)
+	}
+}
+
+// TestEventRegistryNonceIncrements checks that nonce increments are computed from the last observed nonce.
+func TestEventRegistryNonceIncrements(t *testing.T) {
+	r := NewEventRegistry()
+	addr := common.Address{1}
+
+	// the first nonce of an account without an observed nonce is not counted
+	r.RegisterNonce(addr, 100)
+	if events := r.NewEventRegistryJSON(); events.NonceIncrements != nil {
+		t.Fatalf("unexpected nonce increments without observed nonce; got %v", events.NonceIncrements)
+	}
+
+	// increments are relative to the last set or read nonce
+	r.RegisterNonce(addr, 101)
+	r.ObserveNonce(addr, 200)
+	r.RegisterNonce(addr, 203)
+	events := r.NewEventRegistryJSON()
+	if got := events.NonceIncrements.Distribution; !reflect.DeepEqual(got, []float64{0.0, 0.5, 0.5}) {
+		t.Errorf("unexpected nonce increment distribution; got %v", got)
+	}
+}
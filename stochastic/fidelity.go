@@ -0,0 +1,306 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/Fantom-foundation/Aida/stochastic/stationary"
+	"github.com/Fantom-foundation/Aida/stochastic/statistics"
+)
+
+// FidelityScorecard quantifies how close a generated workload is to the
+// recorded workload. All distances are in the range [0,1] where zero
+// means that the workloads are indistinguishable for the metric.
+type FidelityScorecard struct {
+	// stationary-weighted total variation distance between the rows of the stochastic matrices
+	TransitionDistance float64 `json:"transitionDistance"`
+
+	// Jensen-Shannon divergence between the operation frequencies
+	OperationDivergence float64 `json:"operationDivergence"`
+
+	// access statistics for contracts, keys, and values
+	Contracts AccessFidelity `json:"contractStats"`
+	Keys      AccessFidelity `json:"keyStats"`
+	Values    AccessFidelity `json:"valueStats"`
+
+	// Kolmogorov-Smirnov distance between the snapshot delta ECDFs
+	SnapshotKSDistance float64 `json:"snapshotKSDistance"`
+}
+
+// AccessFidelity quantifies the difference of access statistics.
+type AccessFidelity struct {
+	// total variation distance between the access class distributions
+	ClassDistance float64 `json:"classDistance"`
+
+	// Kolmogorov-Smirnov distance between the counting ECDFs
+	CountingKSDistance float64 `json:"countingKSDistance"`
+
+	// total variation distance between the queuing distributions
+	QueuingDistance float64 `json:"queuingDistance"`
+}
+
+// CompareEvents compares the events of a replayed workload with the events
+// of the recorded workload and produces a fidelity scorecard.
+func CompareEvents(recorded *EventRegistryJSON, replayed *EventRegistryJSON) (*FidelityScorecard, error) {
+	// align the operations of both registries
+	labels := unionLabels(recorded.Operations, replayed.Operations)
+	recA := alignMatrix(labels, recorded.Operations, recorded.StochasticMatrix)
+	repA := alignMatrix(labels, replayed.Operations, replayed.StochasticMatrix)
+
+	// compute the frequencies of the operations
+	recFreq, err := operationFrequencies(labels, recA)
+	if err != nil {
+		return nil, fmt.Errorf("failed computing operation frequencies of recorded events; %v", err)
+	}
+	repFreq, err := operationFrequencies(labels, repA)
+	if err != nil {
+		return nil, fmt.Errorf("failed computing operation frequencies of replayed events; %v", err)
+	}
+
+	// transition distance is weighted by the frequencies of the recorded operations
+	transitionDistance := 0.0
+	for i := range labels {
+		transitionDistance += recFreq[i] * rowDistance(recA[i], repA[i])
+	}
+
+	// aggregate frequencies by operations without argument classes
+	recOps := make([]float64, NumOps)
+	repOps := make([]float64, NumOps)
+	for i, label := range labels {
+		op, _, _, _ := DecodeOpcode(label)
+		recOps[op] += recFreq[i]
+		repOps[op] += repFreq[i]
+	}
+
+	return &FidelityScorecard{
+		TransitionDistance:  transitionDistance,
+		OperationDivergence: jsDivergence(recOps, repOps),
+		Contracts: compareAccess(&recorded.Contracts, &replayed.Contracts,
+			classDistribution(labels, recFreq, addrClassOf), classDistribution(labels, repFreq, addrClassOf)),
+		Keys: compareAccess(&recorded.Keys, &replayed.Keys,
+			classDistribution(labels, recFreq, keyClassOf), classDistribution(labels, repFreq, keyClassOf)),
+		Values: compareAccess(&recorded.Values, &replayed.Values,
+			classDistribution(labels, recFreq, valueClassOf), classDistribution(labels, repFreq, valueClassOf)),
+		SnapshotKSDistance: ksDistance(recorded.SnapshotEcdf, replayed.SnapshotEcdf),
+	}, nil
+}
+
+// unionLabels returns the sorted union of the operation labels.
+func unionLabels(a []string, b []string) []string {
+	set := map[string]struct{}{}
+	for _, label := range a {
+		set[label] = struct{}{}
+	}
+	for _, label := range b {
+		set[label] = struct{}{}
+	}
+	labels := make([]string, 0, len(set))
+	for label := range set {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// alignMatrix expands a stochastic matrix to the given labels. Operations which
+// are not observed have zero transitions; undefined transitions (i.e. the row
+// of the last observed operation) are replaced by zero transitions.
+func alignMatrix(labels []string, operations []string, A [][]float64) [][]float64 {
+	M := make([][]float64, len(labels))
+	for i := range M {
+		M[i] = make([]float64, len(labels))
+	}
+	for i, from := range operations {
+		k := find(labels, from)
+		for j, to := range operations {
+			if p := A[i][j]; !math.IsNaN(p) {
+				M[k][find(labels, to)] = p
+			}
+		}
+	}
+	return M
+}
+
+// operationFrequencies computes the long-run frequencies of the operations as the
+// stationary distribution of the stochastic matrix. Operations without transitions
+// restart the process with a new sync-period.
+func operationFrequencies(labels []string, A [][]float64) ([]float64, error) {
+	n := len(labels)
+	restart := find(labels, OpMnemo(BeginSyncPeriodID))
+	M := make([][]float64, n)
+	for i := range A {
+		M[i] = make([]float64, n)
+		copy(M[i], A[i])
+		if rowSum(M[i]) > 0.0 {
+			continue
+		}
+		if restart != -1 {
+			M[i][restart] = 1.0
+		} else {
+			M[i][i] = 1.0
+		}
+	}
+	if n == 0 {
+		return []float64{}, nil
+	}
+	return stationary.ComputeDistribution(M)
+}
+
+// classDistribution computes the distribution of the argument classes of the
+// operations weighted by their frequencies. Operations without an argument of
+// the given kind are ignored.
+func classDistribution(labels []string, freq []float64, classOf func(string) int) []float64 {
+	dist := make([]float64, statistics.NumClasses)
+	for i, label := range labels {
+		if class := classOf(label); class != statistics.NoArgID {
+			dist[class] += freq[i]
+		}
+	}
+	if total := rowSum(dist); total > 0.0 {
+		for i := range dist {
+			dist[i] /= total
+		}
+	}
+	return dist
+}
+
+func addrClassOf(label string) int {
+	_, addr, _, _ := DecodeOpcode(label)
+	return addr
+}
+
+func keyClassOf(label string) int {
+	_, _, key, _ := DecodeOpcode(label)
+	return key
+}
+
+func valueClassOf(label string) int {
+	_, _, _, value := DecodeOpcode(label)
+	return value
+}
+
+// compareAccess compares access statistics and their class distributions.
+func compareAccess(recorded *statistics.AccessJSON, replayed *statistics.AccessJSON, recClasses []float64, repClasses []float64) AccessFidelity {
+	return AccessFidelity{
+		ClassDistance:      totalVariation(recClasses, repClasses),
+		CountingKSDistance: ksDistance(recorded.Counting.ECdf, replayed.Counting.ECdf),
+		QueuingDistance:    totalVariation(recorded.Queuing.Distribution, replayed.Queuing.Distribution),
+	}
+}
+
+// rowDistance computes the total variation distance between two rows of stochastic
+// matrices. A row without transitions has the maximal distance to any other row.
+func rowDistance(p []float64, q []float64) float64 {
+	pEmpty, qEmpty := rowSum(p) == 0.0, rowSum(q) == 0.0
+	if pEmpty && qEmpty {
+		return 0.0
+	}
+	if pEmpty || qEmpty {
+		return 1.0
+	}
+	return totalVariation(p, q)
+}
+
+// totalVariation computes the total variation distance between two discrete
+// distributions. The shorter distribution is padded with zero probabilities.
+func totalVariation(p []float64, q []float64) float64 {
+	n := max(len(p), len(q))
+	d := 0.0
+	for i := 0; i < n; i++ {
+		d += math.Abs(at(p, i) - at(q, i))
+	}
+	return math.Min(d/2.0, 1.0)
+}
+
+// jsDivergence computes the Jensen-Shannon divergence (base 2) between two
+// discrete distributions of the same length.
+func jsDivergence(p []float64, q []float64) float64 {
+	pTotal, qTotal := rowSum(p), rowSum(q)
+	if pTotal == 0.0 || qTotal == 0.0 {
+		if pTotal == qTotal {
+			return 0.0
+		}
+		return 1.0
+	}
+	d := 0.0
+	for i := range p {
+		pi, qi := p[i]/pTotal, q[i]/qTotal
+		mi := (pi + qi) / 2.0
+		if pi > 0.0 {
+			d += pi * math.Log2(pi/mi) / 2.0
+		}
+		if qi > 0.0 {
+			d += qi * math.Log2(qi/mi) / 2.0
+		}
+	}
+	return d
+}
+
+// ksDistance computes the Kolmogorov-Smirnov distance between two piecewise
+// linear ECDFs given by their points.
+func ksDistance(a [][2]float64, b [][2]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		if len(a) == len(b) {
+			return 0.0
+		}
+		return 1.0
+	}
+	d := 0.0
+	for _, p := range a {
+		d = math.Max(d, math.Abs(p[1]-interpolate(b, p[0])))
+	}
+	for _, p := range b {
+		d = math.Max(d, math.Abs(p[1]-interpolate(a, p[0])))
+	}
+	return d
+}
+
+// interpolate evaluates a piecewise linear ECDF at x.
+func interpolate(ecdf [][2]float64, x float64) float64 {
+	if x <= ecdf[0][0] {
+		return ecdf[0][1]
+	}
+	for i := 1; i < len(ecdf); i++ {
+		x0, y0 := ecdf[i-1][0], ecdf[i-1][1]
+		x1, y1 := ecdf[i][0], ecdf[i][1]
+		if x <= x1 {
+			if x1 == x0 {
+				return y1
+			}
+			return y0 + (y1-y0)*(x-x0)/(x1-x0)
+		}
+	}
+	return ecdf[len(ecdf)-1][1]
+}
+
+func rowSum(row []float64) float64 {
+	sum := 0.0
+	for _, p := range row {
+		sum += p
+	}
+	return sum
+}
+
+func at(p []float64, i int) float64 {
+	if i < len(p) {
+		return p[i]
+	}
+	return 0.0
+}
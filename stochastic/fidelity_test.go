@@ -0,0 +1,110 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const fidelityEps = 1e-6
+
+// registerBlocks registers blocks of a single sync-period where every
+// transaction accesses the storage of a contract numStores times.
+func registerBlocks(numBlocks int, numStores int) *EventRegistryJSON {
+	r := NewEventRegistry()
+	r.RegisterOp(BeginSyncPeriodID)
+	for b := 0; b < numBlocks; b++ {
+		r.RegisterOp(BeginBlockID)
+		for tx := 0; tx < 2; tx++ {
+			r.RegisterOp(BeginTransactionID)
+			addr := common.BigToAddress(common.Big1)
+			for s := 0; s < numStores; s++ {
+				key := common.BigToHash(common.Big2)
+				value := common.BigToHash(common.Big3)
+				r.RegisterValueOp(SetStateID, &addr, &key, &value)
+			}
+			r.RegisterOp(EndTransactionID)
+		}
+		r.RegisterOp(EndBlockID)
+	}
+	r.RegisterOp(EndSyncPeriodID)
+	events := r.NewEventRegistryJSON()
+	return &events
+}
+
+// TestFidelity_IdenticalEventsAreIndistinguishable checks that comparing events with themselves yields zero distances.
+func TestFidelity_IdenticalEventsAreIndistinguishable(t *testing.T) {
+	events := registerBlocks(10, 3)
+	s, err := CompareEvents(events, events)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	distances := []float64{
+		s.TransitionDistance,
+		s.OperationDivergence,
+		s.Contracts.ClassDistance, s.Contracts.CountingKSDistance, s.Contracts.QueuingDistance,
+		s.Keys.ClassDistance, s.Keys.CountingKSDistance, s.Keys.QueuingDistance,
+		s.Values.ClassDistance, s.Values.CountingKSDistance, s.Values.QueuingDistance,
+		s.SnapshotKSDistance,
+	}
+	for i, d := range distances {
+		if math.Abs(d) > fidelityEps {
+			t.Errorf("distance %d of identical events must be zero; got %v", i, d)
+		}
+	}
+}
+
+// TestFidelity_DifferentEventsAreDistinguishable checks that different workloads have positive distances.
+func TestFidelity_DifferentEventsAreDistinguishable(t *testing.T) {
+	s, err := CompareEvents(registerBlocks(10, 1), registerBlocks(10, 5))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.TransitionDistance <= fidelityEps {
+		t.Errorf("transition distance must be positive; got %v", s.TransitionDistance)
+	}
+	if s.OperationDivergence <= fidelityEps {
+		t.Errorf("operation divergence must be positive; got %v", s.OperationDivergence)
+	}
+	if s.TransitionDistance > 1.0 || s.OperationDivergence > 1.0 {
+		t.Errorf("distances must not exceed one; got %v and %v", s.TransitionDistance, s.OperationDivergence)
+	}
+}
+
+// TestFidelity_Distances checks the distance measures on simple distributions.
+func TestFidelity_Distances(t *testing.T) {
+	if d := totalVariation([]float64{1.0, 0.0}, []float64{0.0, 1.0}); math.Abs(d-1.0) > fidelityEps {
+		t.Errorf("unexpected total variation distance; got %v, want 1", d)
+	}
+	if d := totalVariation([]float64{0.5, 0.5}, []float64{0.5}); math.Abs(d-0.25) > fidelityEps {
+		t.Errorf("unexpected total variation distance; got %v, want 0.25", d)
+	}
+	if d := jsDivergence([]float64{1.0, 0.0}, []float64{0.0, 1.0}); math.Abs(d-1.0) > fidelityEps {
+		t.Errorf("unexpected Jensen-Shannon divergence; got %v, want 1", d)
+	}
+	a := [][2]float64{{0.0, 0.0}, {1.0, 1.0}}
+	b := [][2]float64{{0.0, 0.0}, {0.5, 1.0}, {1.0, 1.0}}
+	if d := ksDistance(a, b); math.Abs(d-0.5) > fidelityEps {
+		t.Errorf("unexpected Kolmogorov-Smirnov distance; got %v, want 0.5", d)
+	}
+	if d := ksDistance(a, nil); d != 1.0 {
+		t.Errorf("unexpected Kolmogorov-Smirnov distance to an empty ECDF; got %v, want 1", d)
+	}
+}
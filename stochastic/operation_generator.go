@@ -0,0 +1,93 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Operation is a StateDB operation with the argument classes of its
+// contract address, storage key and storage value.
+type Operation struct {
+	ID         int // operation identifier
+	AddrClass  int // argument class of contract address
+	KeyClass   int // argument class of storage key
+	ValueClass int // argument class of storage value
+}
+
+// OperationGenerator samples a sequence of StateDB operations from the
+// Markovian process of a simulation model. The sequence starts with
+// a BeginSyncPeriod operation. If the simulation model has a second-order
+// stochastic matrix, the next operation depends on the previous and the
+// current operation; contexts not observed by the model fall back to the
+// first-order stochastic matrix.
+type OperationGenerator struct {
+	operations  []string             // operations of the stochastic matrix
+	A           [][]float64          // stochastic matrix
+	secondOrder map[[2]int][]float64 // second-order transitions by context; nil for first-order models
+	prev        int                  // previous state in the Markovian process; -1 if none
+	state       int                  // current state in the Markovian process
+	rg          *rand.Rand           // random generator for state transitions
+}
+
+// NewOperationGenerator creates an operation generator for the simulation model.
+func NewOperationGenerator(e *EstimationModelJSON, rg *rand.Rand) *OperationGenerator {
+	operations, A, state := getStochasticMatrix(e)
+	var secondOrder map[[2]int][]float64
+	if e.SecondOrder != nil {
+		secondOrder = make(map[[2]int][]float64, len(e.SecondOrder.Contexts))
+		for i, context := range e.SecondOrder.Contexts {
+			secondOrder[context] = e.SecondOrder.StochasticMatrix[i]
+		}
+	}
+	return &OperationGenerator{
+		operations:  operations,
+		A:           A,
+		secondOrder: secondOrder,
+		prev:        -1,
+		state:       state,
+		rg:          rg,
+	}
+}
+
+// Next returns the operation of the current state and transits
+// to the next state in the Markovian process.
+func (g *OperationGenerator) Next() (Operation, error) {
+	if g.state < 0 {
+		return Operation{}, fmt.Errorf("stochastic matrix has no transition from the previous state")
+	}
+	id, addrCl, keyCl, valueCl := DecodeOpcode(g.operations[g.state])
+	g.prev, g.state = g.state, g.nextState()
+	return Operation{
+		ID:         id,
+		AddrClass:  addrCl,
+		KeyClass:   keyCl,
+		ValueClass: valueCl,
+	}, nil
+}
+
+// nextState samples the next state from the second-order stochastic matrix
+// if the context has been observed, and from the first-order matrix otherwise.
+func (g *OperationGenerator) nextState() int {
+	if row, ok := g.secondOrder[[2]int{g.prev, g.state}]; ok {
+		if next := sampleState(g.rg, row); next != -1 {
+			return next
+		}
+	}
+	return nextState(g.rg, g.A, g.state)
+}
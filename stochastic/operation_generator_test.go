@@ -0,0 +1,155 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package stochastic
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/stochastic/statistics"
+)
+
+// newCyclicModel creates a simulation model whose Markovian process
+// deterministically cycles through the given operations.
+func newCyclicModel(operations ...string) *EstimationModelJSON {
+	n := len(operations)
+	A := make([][]float64, n)
+	for i := range A {
+		A[i] = make([]float64, n)
+		A[i][(i+1)%n] = 1.0
+	}
+	return &EstimationModelJSON{
+		Operations:       operations,
+		StochasticMatrix: A,
+	}
+}
+
+func TestOperationGenerator_StartsWithBeginSyncPeriod(t *testing.T) {
+	e := newCyclicModel(OpMnemo(EndSyncPeriodID), OpMnemo(BeginSyncPeriodID), OpMnemo(BeginBlockID), OpMnemo(EndBlockID))
+	g := NewOperationGenerator(e, rand.New(rand.NewSource(999)))
+
+	want := []int{BeginSyncPeriodID, BeginBlockID, EndBlockID, EndSyncPeriodID, BeginSyncPeriodID}
+	for i, id := range want {
+		op, err := g.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.ID != id {
+			t.Errorf("unexpected operation %d; got %v, want %v", i, opText[op.ID], opText[id])
+		}
+	}
+}
+
+func TestOperationGenerator_DecodesArgumentClasses(t *testing.T) {
+	setState := EncodeOpcode(SetStateID, statistics.NewValueID, statistics.RecentValueID, statistics.ZeroValueID)
+	e := newCyclicModel(OpMnemo(BeginSyncPeriodID), setState)
+	g := NewOperationGenerator(e, rand.New(rand.NewSource(999)))
+
+	if _, err := g.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	op, err := g.Next()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := Operation{
+		ID:         SetStateID,
+		AddrClass:  statistics.NewValueID,
+		KeyClass:   statistics.RecentValueID,
+		ValueClass: statistics.ZeroValueID,
+	}
+	if op != want {
+		t.Errorf("unexpected operation; got %+v, want %+v", op, want)
+	}
+}
+
+func TestOperationGenerator_FailsOnStateWithoutTransition(t *testing.T) {
+	e := &EstimationModelJSON{
+		Operations:       []string{OpMnemo(BeginSyncPeriodID), OpMnemo(BeginBlockID)},
+		StochasticMatrix: [][]float64{{0.0, 1.0}, {0.0, 0.0}},
+	}
+	g := NewOperationGenerator(e, rand.New(rand.NewSource(999)))
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.Next(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := g.Next(); err == nil {
+		t.Errorf("generator must fail on a state without outgoing transitions")
+	}
+}
+
+func TestOperationGenerator_SamplesFromSecondOrderMatrix(t *testing.T) {
+	// first-order: after BT the process continues with BB or EB by chance;
+	// second-order: BB and EB alternate since (BB,BT) leads to EB and (EB,BT) leads to BB.
+	e := &EstimationModelJSON{
+		Operations: []string{OpMnemo(BeginSyncPeriodID), OpMnemo(BeginBlockID), OpMnemo(EndBlockID), OpMnemo(BeginTransactionID)},
+		StochasticMatrix: [][]float64{
+			{0.0, 0.0, 0.0, 1.0},
+			{0.0, 0.0, 0.0, 1.0},
+			{0.0, 0.0, 0.0, 1.0},
+			{0.0, 0.5, 0.5, 0.0},
+		},
+		SecondOrder: &SecondOrderJSON{
+			Contexts: [][2]int{{1, 3}, {2, 3}},
+			StochasticMatrix: [][]float64{
+				{0.0, 0.0, 1.0, 0.0},
+				{0.0, 1.0, 0.0, 0.0},
+			},
+		},
+	}
+	g := NewOperationGenerator(e, rand.New(rand.NewSource(999)))
+
+	var blockOps []int
+	for i := 0; i < 100; i++ {
+		op, err := g.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.ID == BeginBlockID || op.ID == EndBlockID {
+			blockOps = append(blockOps, op.ID)
+		}
+	}
+	for i := 1; i < len(blockOps); i++ {
+		if blockOps[i] == blockOps[i-1] {
+			t.Fatalf("operations %v and %v must alternate; got %v", opText[BeginBlockID], opText[EndBlockID], blockOps)
+		}
+	}
+}
+
+func TestOperationGenerator_FallsBackToFirstOrderMatrix(t *testing.T) {
+	e := newCyclicModel(OpMnemo(BeginSyncPeriodID), OpMnemo(BeginBlockID), OpMnemo(EndBlockID))
+	// the only context is never reached, and its row has no transitions
+	e.SecondOrder = &SecondOrderJSON{
+		Contexts:         [][2]int{{2, 1}},
+		StochasticMatrix: [][]float64{{0.0, 0.0, 0.0}},
+	}
+	g := NewOperationGenerator(e, rand.New(rand.NewSource(999)))
+
+	want := []int{BeginSyncPeriodID, BeginBlockID, EndBlockID, BeginSyncPeriodID, BeginBlockID}
+	for i, id := range want {
+		op, err := g.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if op.ID != id {
+			t.Errorf("unexpected operation %d; got %v, want %v", i, opText[op.ID], opText[id])
+		}
+	}
+}
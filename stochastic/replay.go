@@ -19,9 +19,9 @@ package stochastic
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 	"math/big"
 	"math/rand"
-	"time"
 
 	"github.com/Fantom-foundation/Aida/logger"
 	"github.com/Fantom-foundation/Aida/state"
@@ -51,6 +51,10 @@ type stochasticState struct {
 	keys           *generator.RandomAccess   // index access generator for keys
 	values         *generator.RandomAccess   // index access generator for values
 	snapshotLambda float64                   // lambda parameter for snapshot delta distribution
+	codeSizes      *statistics.MagnitudeJSON // code size distribution (optional)
+	balances       *statistics.MagnitudeJSON // balance change distribution (optional)
+	nonces         *statistics.MagnitudeJSON // nonce increment distribution (optional)
+	lastNonces     map[common.Address]uint64 // last nonce set per account
 	totalTx        uint64                    // total number of transactions
 	txNum          uint32                    // current transaction number
 	blockNum       uint64                    // current block number
@@ -74,6 +78,17 @@ func find[T comparable](a []T, x T) int {
 
 // createState creates a stochastic state and primes the StateDB
 func createState(cfg *utils.Config, e *EstimationModelJSON, db state.StateDB, rg *rand.Rand, log logger.Logger) *stochasticState {
+	ss := newState(e, rg, log)
+	ss.db = db
+
+	// create accounts in StateDB
+	ss.prime()
+
+	return ss
+}
+
+// newState creates a stochastic state for a simulation model without a StateDB.
+func newState(e *EstimationModelJSON, rg *rand.Rand, log logger.Logger) *stochasticState {
 	// produce random access generators for contract addresses,
 	// storage-keys, and storage addresses.
 	// (NB: Contracts need an indirect access wrapper because
@@ -98,11 +113,10 @@ func createState(cfg *utils.Config, e *EstimationModelJSON, db state.StateDB, rg
 	)
 
 	// setup state
-	ss := NewStochasticState(rg, db, contracts, keys, values, e.SnapshotLambda, log)
-
-	// create accounts in StateDB
-	ss.prime()
-
+	ss := NewStochasticState(rg, nil, contracts, keys, values, e.SnapshotLambda, log)
+	ss.codeSizes = e.CodeSizes
+	ss.balances = e.Balances
+	ss.nonces = e.NonceIncrements
 	return &ss
 }
 
@@ -118,121 +132,79 @@ func getStochasticMatrix(e *EstimationModelJSON) ([]string, [][]float64, int) {
 	return operations, A, state
 }
 
-// retrieve operations and stochastic matrix from simulation object
-
-// RunStochasticReplay runs the stochastic simulation for StateDB operations.
-// It requires the simulation model and simulation length. The trace-debug flag
-// enables/disables the printing of StateDB operations and their arguments on
-// the screen.
-func RunStochasticReplay(db state.StateDB, e *EstimationModelJSON, nBlocks int, cfg *utils.Config, log logger.Logger) error {
-	var (
-		opFrequency [NumOps]uint64 // operation frequency
-		numOps      uint64         // total number of operations
-	)
+// Replayer applies operations sampled from a simulation model to a StateDB.
+// It keeps the stochastic state (i.e. the contract, key and value access
+// generators) across operations so that the arguments of the operations
+// follow the distributions of the simulation model.
+type Replayer struct {
+	cfg         *utils.Config
+	ss          *stochasticState
+	opFrequency [NumOps]uint64 // operation frequency
+	numOps      uint64         // total number of operations
+	log         logger.Logger
+}
 
-	if db.GetShadowDB() == nil {
-		log.Notice("No validation with a shadow DB.")
-	}
+// NewReplayer creates a replayer for the simulation model. The random generator
+// is used for sampling the arguments of the operations.
+func NewReplayer(cfg *utils.Config, e *EstimationModelJSON, rg *rand.Rand, log logger.Logger) *Replayer {
 	log.Noticef("balance range %d", cfg.BalanceRange)
 	BalanceRange = cfg.BalanceRange
 
 	log.Noticef("nonce range %d", cfg.NonceRange)
 	NonceRange = cfg.NonceRange
 
-	// random generator
-	rg := rand.New(rand.NewSource(cfg.RandomSeed))
-	log.Noticef("using random seed %d", cfg.RandomSeed)
-
-	// create a stochastic state
-	ss := createState(cfg, e, db, rg, log)
+	return &Replayer{
+		cfg: cfg,
+		ss:  newState(e, rg, log),
+		log: log,
+	}
+}
 
-	// get stochastic matrix
-	operations, A, state := getStochasticMatrix(e)
+// Prime creates the accounts of the simulation model in the StateDB.
+func (r *Replayer) Prime(db state.StateDB) {
+	if db.GetShadowDB() == nil {
+		r.log.Notice("No validation with a shadow DB.")
+	}
+	r.ss.db = db
+	r.ss.prime()
+}
 
-	// progress message setup
-	var (
-		start    time.Time
-		sec      float64
-		lastSec  float64
-		runErr   error
-		errCount int
-	)
+// Execute applies the operation to the StateDB and returns the error of the StateDB if any.
+func (r *Replayer) Execute(db state.StateDB, op Operation) error {
+	ss := r.ss
+	ss.db = db
 
-	start = time.Now()
-	sec = time.Since(start).Seconds()
-	lastSec = time.Since(start).Seconds()
-	// if block after priming is greater or equal to debug block, enable debug.
-	if cfg.Debug && ss.blockNum >= cfg.DebugFrom {
+	// if current block is greater or equal to debug block, enable debug.
+	if r.cfg.Debug && !ss.traceDebug && ss.blockNum >= r.cfg.DebugFrom {
 		ss.enableDebug()
 	}
 
-	block := 0
-	// inclusive range
-	log.Noticef("Simulation block range: first %v, last %v", ss.blockNum, ss.blockNum+uint64(nBlocks-1))
-	for {
-
-		// decode opcode
-		op, addrCl, keyCl, valueCl := DecodeOpcode(operations[state])
+	// keep track of stats
+	r.numOps++
+	r.opFrequency[op.ID]++
 
-		// keep track of stats
-		numOps++
-		opFrequency[op]++
+	// the block and transaction numbers change when ending them
+	block, tx := ss.blockNum, ss.txNum
 
-		// execute operation with its argument classes
-		ss.execute(op, addrCl, keyCl, valueCl)
+	// execute operation with its argument classes
+	ss.execute(op.ID, op.AddrClass, op.KeyClass, op.ValueClass)
 
-		// check for end of simulation
-		if op == EndBlockID {
-			block++
-			if block >= nBlocks {
-				break
-			}
-			// if current block is greater or equal to debug block, enable debug.
-			if cfg.Debug && !ss.traceDebug && ss.blockNum >= cfg.DebugFrom {
-				ss.enableDebug()
-			}
-		}
-
-		// report progress
-		sec = time.Since(start).Seconds()
-		if sec-lastSec >= 15 {
-			log.Debugf("Elapsed time: %.0f s, at block %v", sec, block)
-			lastSec = sec
-		}
-
-		// check for errors
-		if err := ss.db.Error(); err != nil {
-			errCount++
-			if runErr == nil {
-				runErr = fmt.Errorf("error: stochastic replay failed.")
-			}
-
-			runErr = fmt.Errorf("%v\n\tBlock %v Tx %v: %v", runErr, ss.blockNum, ss.txNum, err)
-			if !cfg.ContinueOnFailure {
-				break
-			}
-		}
-
-		// transit to next state in Markovian process
-		state = nextState(rg, A, state)
-	}
-
-	// print progress summary
-	log.Noticef("Total elapsed time: %.3f s, processed %v blocks", sec, block)
-	if errCount > 0 {
-		log.Warningf("%v errors were found", errCount)
+	if err := db.Error(); err != nil {
+		return fmt.Errorf("block %v tx %v: %v failed; %w", block, tx, opText[op.ID], err)
 	}
+	return nil
+}
 
-	// print statistics
-	log.Noticef("SyncPeriods: %v", ss.syncPeriodNum)
-	log.Noticef("Blocks: %v", ss.blockNum)
-	log.Noticef("Transactions: %v", ss.totalTx)
-	log.Noticef("Operations: %v", numOps)
-	log.Noticef("Operation Frequencies:")
+// Report prints the statistics of the replayed operations.
+func (r *Replayer) Report() {
+	r.log.Noticef("SyncPeriods: %v", r.ss.syncPeriodNum)
+	r.log.Noticef("Blocks: %v", r.ss.blockNum)
+	r.log.Noticef("Transactions: %v", r.ss.totalTx)
+	r.log.Noticef("Operations: %v", r.numOps)
+	r.log.Noticef("Operation Frequencies:")
 	for op := 0; op < NumOps; op++ {
-		log.Noticef("\t%v: %v", opText[op], opFrequency[op])
+		r.log.Noticef("\t%v: %v", opText[op], r.opFrequency[op])
 	}
-	return runErr
 }
 
 // NewStochasticState creates a new state for execution StateDB operations
@@ -247,6 +219,7 @@ func NewStochasticState(rg *rand.Rand, db state.StateDB, contracts *generator.In
 		snapshotLambda: snapshotLambda,
 		traceDebug:     false,
 		suicided:       []int64{},
+		lastNonces:     map[common.Address]uint64{},
 		blockNum:       1,
 		syncPeriodNum:  1,
 		rg:             rg,
@@ -269,7 +242,7 @@ func (ss *stochasticState) prime() {
 	for i := int64(0); i <= numInitialAccounts; i++ {
 		addr := toAddress(i)
 		db.CreateAccount(addr)
-		db.AddBalance(addr, big.NewInt(ss.rg.Int63n(BalanceRange)))
+		db.AddBalance(addr, ss.sampleBalance())
 		pt.PrintProgress()
 	}
 	ss.log.Notice("Finalizing...")
@@ -329,11 +302,11 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 
 	switch op {
 	case AddBalanceID:
-		value := rg.Int63n(BalanceRange)
+		value := ss.sampleBalance()
 		if ss.traceDebug {
 			ss.log.Infof("value: %v", value)
 		}
-		db.AddBalance(addr, big.NewInt(value))
+		db.AddBalance(addr, value)
 
 	case BeginBlockID:
 		if ss.traceDebug {
@@ -359,6 +332,7 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 
 	case CreateAccountID:
 		db.CreateAccount(addr)
+		delete(ss.lastNonces, addr)
 
 	case EmptyID:
 		db.Empty(addr)
@@ -421,7 +395,7 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 		}
 
 	case SetCodeID:
-		sz := rg.Intn(MaxCodeSize-1) + 1
+		sz := ss.sampleCodeSize()
 		if ss.traceDebug {
 			ss.log.Infof(" code-size: %v", sz)
 		}
@@ -433,8 +407,12 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 		db.SetCode(addr, code)
 
 	case SetNonceID:
-		value := uint64(rg.Intn(NonceRange))
+		value := ss.sampleNonce(addr)
+		if ss.traceDebug {
+			ss.log.Infof(" nonce: %v", value)
+		}
 		db.SetNonce(addr, value)
+		ss.lastNonces[addr] = value
 
 	case SetStateID:
 		db.SetState(addr, key, value)
@@ -448,20 +426,28 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 
 	case SubBalanceID:
 		shadowDB := db.GetShadowDB()
-		var balance int64
+		var balance *big.Int
 		if shadowDB == nil {
-			balance = db.GetBalance(addr).Int64()
+			balance = db.GetBalance(addr)
 		} else {
-			balance = shadowDB.GetBalance(addr).Int64()
+			balance = shadowDB.GetBalance(addr)
 		}
-		if balance > 0 {
+		if balance.Sign() > 0 {
 			// get a delta that does not exceed current balance
 			// in the current snapshot
-			value := rg.Int63n(balance)
+			var value *big.Int
+			if ss.balances == nil {
+				value = big.NewInt(rg.Int63n(balance.Int64()))
+			} else {
+				value = ss.balances.Sample(rg)
+				if value.Cmp(balance) > 0 {
+					value = new(big.Int).Set(balance)
+				}
+			}
 			if ss.traceDebug {
 				ss.log.Infof(" value: %v", value)
 			}
-			db.SubBalance(addr, big.NewInt(value))
+			db.SubBalance(addr, value)
 		}
 
 	case SuicideID:
@@ -477,6 +463,11 @@ func (ss *stochasticState) execute(op int, addrCl int, keyCl int, valueCl int) {
 
 // nextState produces the next state in the Markovian process.
 func nextState(rg *rand.Rand, A [][]float64, i int) int {
+	return sampleState(rg, A[i])
+}
+
+// sampleState samples a state from the transition probabilities of a row of a stochastic matrix.
+func sampleState(rg *rand.Rand, p []float64) int {
 	// Retrieve a random number in [0,1.0).
 	r := rg.Float64()
 
@@ -486,8 +477,8 @@ func nextState(rg *rand.Rand, A [][]float64, i int) int {
 	sum := float64(0.0)
 	c := float64(0.0)
 	k := -1
-	for j := 0; j < len(A); j++ {
-		y := A[i][j] - c
+	for j := 0; j < len(p); j++ {
+		y := p[j] - c
 		t := sum + y
 		c = (t - sum) - y
 		sum = t
@@ -500,7 +491,7 @@ func nextState(rg *rand.Rand, A [][]float64, i int) int {
 		// non-zero entry as a solution. It also detects
 		// stochastic matrices with a row whose row
 		// sum is not zero (return value is -1 for such a case).
-		if A[i][j] > 0.0 {
+		if p[j] > 0.0 {
 			k = j
 		}
 	}
@@ -541,6 +532,45 @@ func (ss *stochasticState) deleteAccounts() {
 		if err := ss.contracts.DeleteIndex(addrIdx); err != nil {
 			ss.log.Fatal("failed deleting index")
 		}
+		delete(ss.lastNonces, toAddress(addrIdx))
 	}
 	ss.suicided = []int64{}
 }
+
+// sampleBalance returns a random amount for a balance change. Without a balance
+// distribution in the simulation model, the amount is uniform in the balance range.
+func (ss *stochasticState) sampleBalance() *big.Int {
+	if ss.balances == nil {
+		return big.NewInt(ss.rg.Int63n(BalanceRange))
+	}
+	return ss.balances.Sample(ss.rg)
+}
+
+// sampleCodeSize returns a random code size between 1 and MaxCodeSize. Without a code
+// size distribution in the simulation model, the code size is uniform.
+func (ss *stochasticState) sampleCodeSize() int {
+	if ss.codeSizes == nil {
+		return ss.rg.Intn(MaxCodeSize-1) + 1
+	}
+	sz := ss.codeSizes.Sample(ss.rg)
+	if !sz.IsInt64() || sz.Int64() > MaxCodeSize {
+		return MaxCodeSize
+	}
+	return max(int(sz.Int64()), 1)
+}
+
+// sampleNonce returns a random nonce for a contract. The last nonce set for the contract
+// is incremented by a sampled increment; the StateDB is not read since such a read is not
+// part of the simulation. Without a nonce increment distribution in the simulation model,
+// the nonce is uniform in the nonce range.
+func (ss *stochasticState) sampleNonce(addr common.Address) uint64 {
+	if ss.nonces == nil {
+		return uint64(ss.rg.Intn(NonceRange))
+	}
+	nonce := ss.lastNonces[addr]
+	increment := ss.nonces.Sample(ss.rg)
+	if !increment.IsUint64() || increment.Uint64() > math.MaxUint64-nonce {
+		return math.MaxUint64
+	}
+	return nonce + increment.Uint64()
+}
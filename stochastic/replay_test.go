@@ -22,6 +22,9 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/Fantom-foundation/Aida/stochastic/statistics"
+	"github.com/ethereum/go-ethereum/common"
+
 	"gonum.org/v1/gonum/stat/distuv"
 )
 
@@ -204,3 +207,67 @@ func TestInitialState(t *testing.T) {
 		t.Fatalf("Should not find first state")
 	}
 }
+
+// TestSampleCodeSize checks that sampled code sizes follow the code size distribution.
+func TestSampleCodeSize(t *testing.T) {
+	ss := stochasticState{rg: rand.New(rand.NewSource(999))}
+	for i := 0; i < 100; i++ {
+		if sz := ss.sampleCodeSize(); sz < 1 || sz > MaxCodeSize {
+			t.Fatalf("code size out of range; got %v", sz)
+		}
+	}
+
+	// code sizes are sampled in [64, 128) and clamped to the maximum code size
+	ss.codeSizes = &statistics.MagnitudeJSON{Distribution: []float64{0, 0, 0, 0, 0, 0, 0, 1}}
+	for i := 0; i < 100; i++ {
+		if sz := ss.sampleCodeSize(); sz < 64 || sz >= 128 {
+			t.Fatalf("code size does not follow distribution; got %v", sz)
+		}
+	}
+	ss.codeSizes = &statistics.MagnitudeJSON{Distribution: make([]float64, 100)}
+	ss.codeSizes.Distribution[99] = 1.0
+	if sz := ss.sampleCodeSize(); sz != MaxCodeSize {
+		t.Fatalf("code size exceeds maximum; got %v", sz)
+	}
+}
+
+// TestSampleBalance checks that sampled balances follow the balance distribution.
+func TestSampleBalance(t *testing.T) {
+	ss := stochasticState{rg: rand.New(rand.NewSource(999))}
+	for i := 0; i < 100; i++ {
+		if b := ss.sampleBalance(); b.Sign() < 0 || b.Int64() >= BalanceRange {
+			t.Fatalf("balance out of range; got %v", b)
+		}
+	}
+
+	distribution := make([]float64, 81)
+	distribution[80] = 1.0
+	ss.balances = &statistics.MagnitudeJSON{Distribution: distribution}
+	for i := 0; i < 100; i++ {
+		if b := ss.sampleBalance(); b.BitLen() != 80 {
+			t.Fatalf("balance does not follow distribution; got %v", b)
+		}
+	}
+}
+
+// TestSampleNonce checks that sampled nonces increment the last nonce of an account.
+func TestSampleNonce(t *testing.T) {
+	ss := stochasticState{rg: rand.New(rand.NewSource(999))}
+	for i := 0; i < 100; i++ {
+		if n := ss.sampleNonce(common.Address{1}); n >= uint64(NonceRange) {
+			t.Fatalf("nonce out of range; got %v", n)
+		}
+	}
+
+	// increments are sampled in [2, 4) and added to the last nonce of the account
+	ss.nonces = &statistics.MagnitudeJSON{Distribution: []float64{0, 0, 1}}
+	ss.lastNonces = map[common.Address]uint64{{1}: 10}
+	for i := 0; i < 100; i++ {
+		if n := ss.sampleNonce(common.Address{1}); n < 12 || n >= 14 {
+			t.Fatalf("nonce does not follow distribution; got %v", n)
+		}
+		if n := ss.sampleNonce(common.Address{2}); n < 2 || n >= 4 {
+			t.Fatalf("nonce of unknown account does not start at zero; got %v", n)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statistics
+
+import (
+	"math/big"
+	"math/rand"
+)
+
+// MaxMagnitude is the largest bit length of values in magnitude statistics.
+const MaxMagnitude = 256
+
+// Magnitude counts the magnitudes (i.e. bit lengths) of observed values.
+type Magnitude struct {
+	freq [MaxMagnitude + 1]uint64 // frequency of bit lengths
+}
+
+// MagnitudeJSON is the JSON output for magnitude statistics.
+type MagnitudeJSON struct {
+	// probability of a bit length up to the largest observed bit length
+	Distribution []float64 `json:"distribution"`
+}
+
+// Place counts a value of the given bit length. Bit lengths exceeding
+// MaxMagnitude are counted as MaxMagnitude.
+func (m *Magnitude) Place(bitLen int) {
+	m.freq[min(bitLen, MaxMagnitude)]++
+}
+
+// NewMagnitudeJSON produces JSON output for magnitude statistics. It
+// returns nil if no value has been observed.
+func (m *Magnitude) NewMagnitudeJSON() *MagnitudeJSON {
+	// compute total frequency and largest observed bit length
+	total := uint64(0)
+	maxBitLen := -1
+	for i, f := range m.freq {
+		total += f
+		if f > 0 {
+			maxBitLen = i
+		}
+	}
+	if total == 0 {
+		return nil
+	}
+
+	// compute bit length probabilities
+	dist := make([]float64, maxBitLen+1)
+	for i := range dist {
+		dist[i] = float64(m.freq[i]) / float64(total)
+	}
+	return &MagnitudeJSON{
+		Distribution: dist,
+	}
+}
+
+// Copy creates a deep copy of magnitude statistics. The copy of nil is nil.
+func (m *MagnitudeJSON) Copy() *MagnitudeJSON {
+	if m == nil {
+		return nil
+	}
+	dist := make([]float64, len(m.Distribution))
+	copy(dist, m.Distribution)
+	return &MagnitudeJSON{
+		Distribution: dist,
+	}
+}
+
+// Sample produces a random value whose bit length follows the distribution.
+// Values of the same bit length are uniformly distributed.
+func (m *MagnitudeJSON) Sample(rg *rand.Rand) *big.Int {
+	// sample bit length; if the distribution is numerically
+	// unstable, take the last non-zero entry.
+	r := rg.Float64()
+	sum := 0.0
+	bitLen := 0
+	for i, p := range m.Distribution {
+		if p > 0.0 {
+			bitLen = i
+		}
+		sum += p
+		if r <= sum {
+			break
+		}
+	}
+	if bitLen == 0 {
+		return new(big.Int)
+	}
+
+	// sample value in [2^(bitLen-1), 2^bitLen)
+	lower := new(big.Int).Lsh(big.NewInt(1), uint(bitLen-1))
+	value := new(big.Int).Rand(rg, lower)
+	return value.Add(value, lower)
+}
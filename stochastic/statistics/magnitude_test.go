@@ -0,0 +1,85 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package statistics
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// TestMagnitudeEmpty checks that no JSON output is produced without observations.
+func TestMagnitudeEmpty(t *testing.T) {
+	var m Magnitude
+	if json := m.NewMagnitudeJSON(); json != nil {
+		t.Fatalf("unexpected distribution %v", json.Distribution)
+	}
+}
+
+// TestMagnitudeDistribution checks the bit length probabilities.
+func TestMagnitudeDistribution(t *testing.T) {
+	var m Magnitude
+	m.Place(0)
+	m.Place(3)
+	m.Place(3)
+	m.Place(1000)
+
+	json := m.NewMagnitudeJSON()
+	want := make([]float64, MaxMagnitude+1)
+	want[0], want[3], want[MaxMagnitude] = 0.25, 0.5, 0.25
+	if !reflect.DeepEqual(json.Distribution, want) {
+		t.Fatalf("unexpected distribution %v", json.Distribution)
+	}
+}
+
+// TestMagnitudeSample checks that sampled values have the bit lengths of the distribution.
+func TestMagnitudeSample(t *testing.T) {
+	var m Magnitude
+	m.Place(0)
+	m.Place(12)
+	json := m.NewMagnitudeJSON()
+
+	rg := rand.New(rand.NewSource(999))
+	count := [2]int{}
+	for i := 0; i < 1000; i++ {
+		switch value := json.Sample(rg); value.BitLen() {
+		case 0:
+			count[0]++
+		case 12:
+			count[1]++
+		default:
+			t.Fatalf("unexpected value %v", value)
+		}
+	}
+	if count[0] < 400 || count[1] < 400 {
+		t.Errorf("bit lengths are not sampled with their probabilities; got %v", count)
+	}
+}
+
+// TestMagnitudeCopy checks that copies do not share their distribution.
+func TestMagnitudeCopy(t *testing.T) {
+	var nilJSON *MagnitudeJSON
+	if nilJSON.Copy() != nil {
+		t.Fatalf("copy of nil must be nil")
+	}
+	json := &MagnitudeJSON{Distribution: []float64{0.5, 0.5}}
+	c := json.Copy()
+	c.Distribution[0] = 1.0
+	if json.Distribution[0] != 0.5 {
+		t.Errorf("copy shares distribution with original")
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/klauspost/compress/zstd"
+)
+
+// A chunked trace file consists of
+//   - a header: the trace magic, the format version and the first block,
+//   - a sequence of chunks, each a zstd frame of serialized operations
+//     starting with the begin-block operation of the chunk's first block,
+//   - an index holding the first block and the file offset of each chunk,
+//   - a footer: the offset of the index and the number of chunks.
+//
+// Operations are stored in their absolute form (see operation.Absolute), hence
+// replaying a chunked trace does not depend on operations of previous chunks.
+
+// DefaultChunkSize is the default size of uncompressed chunks in bytes.
+const DefaultChunkSize = 16 * 1024 * 1024
+
+const (
+	chunkedHeaderSize = len(context.TraceMagic) + 1 + 8
+	chunkedFooterSize = 16
+)
+
+// ChunkIndexEntry locates a chunk in a chunked trace file.
+type ChunkIndexEntry struct {
+	FirstBlock uint64 // first block of the chunk
+	Offset     uint64 // offset of the chunk in the trace file
+}
+
+// ChunkedTraceWriter writes operations into a chunked trace file.
+type ChunkedTraceWriter struct {
+	file       *os.File
+	encoder    *zstd.Encoder
+	chunk      bytes.Buffer      // uncompressed operations of current chunk
+	chunkFirst uint64            // first block of current chunk
+	chunkSize  int               // uncompressed size at which a chunk is closed
+	offset     uint64            // current offset in trace file
+	index      []ChunkIndexEntry // index of written chunks
+}
+
+// NewChunkedTraceWriter creates a chunked trace file starting with the given block.
+func NewChunkedTraceWriter(filename string, first uint64, chunkSize int) (*ChunkedTraceWriter, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open trace file; %w", err)
+	}
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot create zstd encoder; %w", err), file.Close())
+	}
+
+	header := make([]byte, 0, chunkedHeaderSize)
+	header = append(header, context.TraceMagic...)
+	header = append(header, context.ChunkedTraceVersion)
+	header = binary.LittleEndian.AppendUint64(header, first)
+	if _, err = file.Write(header); err != nil {
+		return nil, errors.Join(fmt.Errorf("cannot write file header; %w", err), file.Close())
+	}
+
+	return &ChunkedTraceWriter{
+		file:       file,
+		encoder:    encoder,
+		chunkFirst: first,
+		chunkSize:  chunkSize,
+		offset:     uint64(chunkedHeaderSize),
+	}, nil
+}
+
+// Write appends an operation to the trace. Chunks are closed before begin-block
+// operations once they exceed the chunk size.
+func (w *ChunkedTraceWriter) Write(op operation.Operation) error {
+	if bb, ok := op.(*operation.BeginBlock); ok {
+		if w.chunk.Len() >= w.chunkSize {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+		if w.chunk.Len() == 0 {
+			w.chunkFirst = bb.BlockNumber
+		}
+	}
+	id := op.GetId()
+	if err := w.chunk.WriteByte(id); err != nil {
+		return err
+	}
+	if err := op.Write(&w.chunk); err != nil {
+		return fmt.Errorf("cannot write operation %v; %w", operation.GetLabel(id), err)
+	}
+	return nil
+}
+
+// flush compresses the current chunk and writes it to the trace file.
+func (w *ChunkedTraceWriter) flush() error {
+	if w.chunk.Len() == 0 {
+		return nil
+	}
+	data := w.encoder.EncodeAll(w.chunk.Bytes(), nil)
+	if _, err := w.file.Write(data); err != nil {
+		return fmt.Errorf("cannot write chunk; %w", err)
+	}
+	w.index = append(w.index, ChunkIndexEntry{FirstBlock: w.chunkFirst, Offset: w.offset})
+	w.offset += uint64(len(data))
+	w.chunk.Reset()
+	return nil
+}
+
+// Close writes the last chunk and the index and closes the trace file.
+func (w *ChunkedTraceWriter) Close() error {
+	err := w.flush()
+	if err == nil {
+		err = binary.Write(w.file, binary.LittleEndian, w.index)
+	}
+	if err == nil {
+		err = binary.Write(w.file, binary.LittleEndian, [2]uint64{w.offset, uint64(len(w.index))})
+	}
+	if err != nil {
+		err = fmt.Errorf("cannot write chunk index; %w", err)
+	}
+	return errors.Join(err, w.encoder.Close(), w.file.Close())
+}
+
+// readChunkIndex reads the index of a chunked trace file and returns it
+// together with the offset of the index.
+func readChunkIndex(file *os.File) ([]ChunkIndexEntry, uint64, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	if info.Size() < int64(chunkedHeaderSize+chunkedFooterSize) {
+		return nil, 0, fmt.Errorf("file is too short")
+	}
+	var footer [2]uint64
+	if err = binary.Read(io.NewSectionReader(file, info.Size()-chunkedFooterSize, chunkedFooterSize), binary.LittleEndian, &footer); err != nil {
+		return nil, 0, err
+	}
+	indexOffset, numChunks := footer[0], footer[1]
+	if indexOffset < uint64(chunkedHeaderSize) || indexOffset+numChunks*16 != uint64(info.Size()-chunkedFooterSize) {
+		return nil, 0, fmt.Errorf("corrupted footer")
+	}
+	index := make([]ChunkIndexEntry, numChunks)
+	if err = binary.Read(io.NewSectionReader(file, int64(indexOffset), int64(numChunks*16)), binary.LittleEndian, index); err != nil {
+		return nil, 0, err
+	}
+	return index, indexOffset, nil
+}
+
+// findChunk returns the position of the last chunk starting at or before the given block.
+func findChunk(index []ChunkIndexEntry, block uint64) int {
+	i := sort.Search(len(index), func(i int) bool { return index[i].FirstBlock > block })
+	if i > 0 {
+		i--
+	}
+	return i
+}
+
+// ConvertTrace converts a trace file of any version into a chunked trace file.
+func ConvertTrace(input, output string, chunkSize int) error {
+	tf, err := NewTraceFile(input)
+	if err != nil {
+		return err
+	}
+	defer tf.Release()
+
+	w, err := NewChunkedTraceWriter(output, tf.firstBlock, chunkSize)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.NewReplay()
+	for {
+		op, err := operation.Read(tf.reader)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Join(err, w.Close())
+		}
+		if err = w.Write(operation.Absolute(op, ctx)); err != nil {
+			return errors.Join(err, w.Close())
+		}
+	}
+	return w.Close()
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"io"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// makeTestOperations returns operations of the given blocks using cached encodings.
+func makeTestOperations(blocks ...uint64) []operation.Operation {
+	contract := common.Address{1}
+	var ops []operation.Operation
+	for _, block := range blocks {
+		ops = append(ops,
+			operation.NewBeginBlock(block),
+			operation.NewBeginTransaction(0),
+			operation.NewGetState(contract, common.Hash{byte(block)}),
+			operation.NewGetStateLcls(),
+			operation.NewSetStateLcls(common.Hash{2}),
+			operation.NewEndTransaction(),
+			operation.NewEndBlock(),
+		)
+	}
+	return ops
+}
+
+// writeTestTrace writes the operations into a bzip2 trace file.
+func writeTestTrace(t *testing.T, filename string, first uint64, ops []operation.Operation) {
+	rCtx, err := context.NewRecord(filename, first)
+	if err != nil {
+		t.Fatalf("cannot create trace file; %v", err)
+	}
+	for _, op := range ops {
+		operation.WriteOp(rCtx, op)
+	}
+	rCtx.Close()
+}
+
+// readTestTrace reads all operations from a trace file starting at the given block.
+func readTestTrace(t *testing.T, filename string, block uint64) []operation.Operation {
+	tf, err := NewTraceFile(filename)
+	if err != nil {
+		t.Fatalf("cannot open trace file; %v", err)
+	}
+	defer tf.Release()
+	if err = tf.Seek(block); err != nil {
+		t.Fatalf("cannot seek block %v; %v", block, err)
+	}
+	var ops []operation.Operation
+	for {
+		op, err := operation.Read(tf.reader)
+		if err == io.EOF {
+			return ops
+		}
+		if err != nil {
+			t.Fatalf("cannot read operation; %v", err)
+		}
+		ops = append(ops, op)
+	}
+}
+
+func TestChunkedTrace_ConvertedTraceContainsAbsoluteOperations(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "trace.zst")
+	ops := makeTestOperations(1, 2, 3, 4)
+	writeTestTrace(t, input, 1, ops)
+
+	// a chunk size of one byte creates a chunk per block
+	if err := ConvertTrace(input, output, 1); err != nil {
+		t.Fatalf("cannot convert trace; %v", err)
+	}
+
+	tf, err := NewTraceFile(output)
+	if err != nil {
+		t.Fatalf("cannot open trace file; %v", err)
+	}
+	if got, want := tf.Version(), context.ChunkedTraceVersion; got != want {
+		t.Errorf("unexpected version; got %v, want %v", got, want)
+	}
+	if got, want := tf.firstBlock, uint64(1); got != want {
+		t.Errorf("unexpected first block; got %v, want %v", got, want)
+	}
+	if got, want := len(tf.index), 4; got != want {
+		t.Errorf("unexpected number of chunks; got %v, want %v", got, want)
+	}
+	if err = tf.Release(); err != nil {
+		t.Fatalf("cannot release trace file; %v", err)
+	}
+
+	ctx := context.NewReplay()
+	var want []operation.Operation
+	for _, op := range ops {
+		want = append(want, operation.Absolute(op, ctx))
+	}
+	if got := readTestTrace(t, output, 0); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected operations; got %v, want %v", got, want)
+	}
+}
+
+func TestChunkedTrace_SeekSkipsPrecedingChunks(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "trace.zst")
+	writeTestTrace(t, input, 1, makeTestOperations(1, 2, 3, 4, 5))
+	if err := ConvertTrace(input, output, 1); err != nil {
+		t.Fatalf("cannot convert trace; %v", err)
+	}
+
+	ops := readTestTrace(t, output, 4)
+	if got, want := len(ops), 14; got != want {
+		t.Fatalf("unexpected number of operations; got %v, want %v", got, want)
+	}
+	if bb, ok := ops[0].(*operation.BeginBlock); !ok || bb.BlockNumber != 4 {
+		t.Errorf("trace does not start with block 4; got %v", ops[0])
+	}
+	want := operation.NewGetState(common.Address{1}, common.Hash{4})
+	if !reflect.DeepEqual(ops[3], want) {
+		t.Errorf("unexpected operation; got %v, want %v", ops[3], want)
+	}
+
+	// seeking a block before the first chunk reads the whole trace
+	if got, want := len(readTestTrace(t, output, 0)), 35; got != want {
+		t.Errorf("unexpected number of operations; got %v, want %v", got, want)
+	}
+}
+
+func TestChunkedTrace_IteratorStartsAtFirstBlock(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "trace.zst")
+	writeTestTrace(t, input, 1, makeTestOperations(1, 2, 3))
+	if err := ConvertTrace(input, output, 1); err != nil {
+		t.Fatalf("cannot convert trace; %v", err)
+	}
+
+	iter := NewTraceIterator([]string{output}, 2)
+	defer iter.Release()
+	count := 0
+	for iter.Next() {
+		if count == 0 {
+			if bb, ok := iter.Value().(*operation.BeginBlock); !ok || bb.BlockNumber != 2 {
+				t.Errorf("iteration does not start with block 2; got %v", iter.Value())
+			}
+		}
+		count++
+	}
+	if got, want := count, 14; got != want {
+		t.Errorf("unexpected number of operations; got %v, want %v", got, want)
+	}
+}
+
+func TestChunkedTrace_CorruptedIndexIsReported(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "trace.zst")
+	w, err := NewChunkedTraceWriter(filename, 1, 0)
+	if err != nil {
+		t.Fatalf("cannot create trace file; %v", err)
+	}
+	// close the file without writing the index
+	if err = w.file.Close(); err != nil {
+		t.Fatalf("cannot close trace file; %v", err)
+	}
+	if _, err = NewTraceFile(filename); err == nil {
+		t.Errorf("opening a trace file without index must fail")
+	}
+}
@@ -30,6 +30,16 @@ import (
 
 const (
 	WriteBufferSize = 1048576 // Size of write buffer for writing trace file.
+
+	// TraceMagic identifies versioned trace files. Trace files without it are
+	// legacy traces of version 1 starting directly with the first block.
+	TraceMagic = "AIDATRC"
+	// TraceVersion is the version of trace files written by the recorder.
+	// Version 2 adds access-list, log and refund operations.
+	TraceVersion uint8 = 2
+	// ChunkedTraceVersion is the version of seekable trace files consisting of
+	// independently compressed chunks and a block index.
+	ChunkedTraceVersion uint8 = 3
 )
 
 // Context is an environment/facade for recording and replaying trace files
@@ -82,6 +92,12 @@ func NewRecord(filename string, first uint64) (*Record, error) {
 		return nil, fmt.Errorf("cannot open bzip2 stream; %v", err)
 	}
 	// write header
+	if _, err := ZFile.Write([]byte(TraceMagic)); err != nil {
+		return nil, fmt.Errorf("fail to write file header")
+	}
+	if err := binary.Write(ZFile, binary.LittleEndian, TraceVersion); err != nil {
+		return nil, fmt.Errorf("fail to write file header")
+	}
 	if err := binary.Write(ZFile, binary.LittleEndian, first); err != nil {
 		return nil, fmt.Errorf("fail to write file header")
 	}
@@ -146,6 +162,12 @@ func (ctx *Context) DecodeKey(key common.Hash) common.Hash {
 	return key
 }
 
+// KeyPosition returns the position of a storage key in the key cache without
+// updating the cache, or -1 if the key is not cached.
+func (ctx *Context) KeyPosition(key common.Hash) int {
+	return ctx.keyCache.Find(key)
+}
+
 // DecodeKeyCache reads from cache with updating index cache.
 func (ctx *Context) DecodeKeyCache(sPos int) common.Hash {
 	key, err := ctx.keyCache.Get(sPos)
@@ -74,6 +74,21 @@ func (q *KeyCache) Place(item common.Hash) int {
 	return -1
 }
 
+// Find returns the position of a key in the key cache without updating
+// the cache. If the key is not cached, -1 is returned.
+func (q *KeyCache) Find(item common.Hash) int {
+	for i := 0; i < KeyCacheLength; i++ {
+		if q.data[i] == item {
+			j := (q.top - i) % KeyCacheLength
+			if j < 0 {
+				j += KeyCacheLength
+			}
+			return j
+		}
+	}
+	return -1
+}
+
 // Get a key for a cache position.
 func (q *KeyCache) Get(pos int) (common.Hash, error) {
 	if pos < 0 || pos >= KeyCacheLength {
@@ -125,3 +125,26 @@ func TestKeyCacheOverflow(t *testing.T) {
 		t.Fatalf("First key must have been evicted.")
 	}
 }
+
+// TestKeyCacheFind checks that Find reports the positions returned by Place
+// without modifying the key cache.
+func TestKeyCacheFind(t *testing.T) {
+	cache := NewKeyCache()
+	keys := []common.Hash{{1}, {2}, {3}}
+	for _, key := range keys {
+		cache.Place(key)
+	}
+
+	if pos := cache.Find(common.Hash{4}); pos != -1 {
+		t.Errorf("unknown key must not be found; got position %v", pos)
+	}
+	for _, key := range keys {
+		pos := cache.Find(key)
+		if again := cache.Find(key); again != pos {
+			t.Fatalf("Find must not modify the cache; got %v and %v", pos, again)
+		}
+		if placed := cache.Place(key); placed != pos {
+			t.Errorf("unexpected position of key %v; got %v, Place returned %v", key, pos, placed)
+		}
+	}
+}
@@ -52,6 +52,9 @@ func (ti *TraceIterator) OpenCurrentTraceFile() {
 	if ti.tf, err = NewTraceFile(ti.fileList[ti.currentFileIdx]); err != nil {
 		log.Fatalf("cannot open trace file; %v", err)
 	}
+	if err := ti.tf.Seek(ti.firstBlock); err != nil {
+		log.Fatalf("cannot seek trace file; %v", err)
+	}
 }
 
 // Next loads the next operation from the trace file.
@@ -0,0 +1,160 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+)
+
+// unit is an inclusive range of operations removed as a whole while minimizing.
+type unit struct {
+	first, last int
+}
+
+// MinimizeOperations shrinks a sequence of absolute operations (see operation.Absolute) to a
+// smaller sequence for which fails still holds using delta debugging. Whole blocks are removed
+// first, then whole transactions and finally single operations. Structural operations are only
+// removed with their block or transaction so that the minimized sequence can still be replayed.
+// The sequence is expected to fail initially.
+func MinimizeOperations(ops []operation.Operation, fails func([]operation.Operation) bool) []operation.Operation {
+	ops = minimize(ops, blockUnits(ops), fails)
+	ops = minimize(ops, transactionUnits(ops), fails)
+	ops = minimize(ops, operationUnits(ops), fails)
+	return ops
+}
+
+// blockUnits returns the blocks of a sequence.
+func blockUnits(ops []operation.Operation) []unit {
+	return enclosedUnits(ops, operation.BeginBlockID, operation.EndBlockID)
+}
+
+// transactionUnits returns the transactions of a sequence.
+func transactionUnits(ops []operation.Operation) []unit {
+	return enclosedUnits(ops, operation.BeginTransactionID, operation.EndTransactionID)
+}
+
+// enclosedUnits returns the ranges of operations from a begin to the matching end operation.
+// A range which is not closed at the end of the sequence is no unit.
+func enclosedUnits(ops []operation.Operation, begin byte, end byte) []unit {
+	var units []unit
+	first := -1
+	for i, op := range ops {
+		switch op.GetId() {
+		case begin:
+			first = i
+		case end:
+			if first != -1 {
+				units = append(units, unit{first, i})
+				first = -1
+			}
+		}
+	}
+	return units
+}
+
+// operationUnits returns the non-structural operations of a sequence.
+func operationUnits(ops []operation.Operation) []unit {
+	var units []unit
+	for i, op := range ops {
+		if !isStructural(op) {
+			units = append(units, unit{i, i})
+		}
+	}
+	return units
+}
+
+// minimize implements the ddmin algorithm removing units from the sequence. The sequence
+// is split into n chunks of units and a chunk is removed if the remaining sequence still
+// fails. If no chunk can be removed, the granularity is doubled until single units are
+// tried.
+func minimize(ops []operation.Operation, units []unit, fails func([]operation.Operation) bool) []operation.Operation {
+	removed := make([]bool, len(units))
+	active := make([]int, len(units))
+	for i := range active {
+		active[i] = i
+	}
+
+	n := 2
+	for len(active) > 0 {
+		size := (len(active) + n - 1) / n
+		reduced := false
+		for start := 0; start < len(active); start += size {
+			end := min(start+size, len(active))
+			for _, u := range active[start:end] {
+				removed[u] = true
+			}
+			if fails(without(ops, units, removed)) {
+				active = append(active[:start:start], active[end:]...)
+				n = max(n-1, 2)
+				reduced = true
+				break
+			}
+			for _, u := range active[start:end] {
+				removed[u] = false
+			}
+		}
+		if !reduced {
+			if size == 1 {
+				break
+			}
+			n = min(2*n, len(active))
+		}
+	}
+	return without(ops, units, removed)
+}
+
+// without returns the sequence without the operations of the removed units.
+func without(ops []operation.Operation, units []unit, removed []bool) []operation.Operation {
+	skip := make([]bool, len(ops))
+	for i, u := range units {
+		if removed[i] {
+			for j := u.first; j <= u.last; j++ {
+				skip[j] = true
+			}
+		}
+	}
+	res := make([]operation.Operation, 0, len(ops))
+	for i, op := range ops {
+		if !skip[i] {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// WriteOperations writes a sequence of absolute operations into a new trace file. The
+// first block of the trace file is the block of the first BeginBlock operation.
+func WriteOperations(ops []operation.Operation, output string) error {
+	first := uint64(0)
+	for _, op := range ops {
+		if t, ok := op.(*operation.BeginBlock); ok {
+			first = t.BlockNumber
+			break
+		}
+	}
+	record, err := context.NewRecord(output, first)
+	if err != nil {
+		return err
+	}
+	ctx := context.NewReplay()
+	for _, op := range ops {
+		operation.WriteOp(record, operation.Relative(op, ctx))
+	}
+	record.Close()
+	return nil
+}
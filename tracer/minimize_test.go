@@ -0,0 +1,117 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// contains checks whether a sequence contains an operation.
+func contains(ops []operation.Operation, op operation.Operation) bool {
+	for _, o := range ops {
+		if reflect.DeepEqual(o, op) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMinimizeOperations_KeepsFailingOperationsAndStructure(t *testing.T) {
+	ops := []operation.Operation{operation.NewBeginSyncPeriod(0)}
+	ops = append(ops, absoluteOperations(makeTestOperations(1, 2, 3, 4, 5, 6))...)
+	ops = append(ops, operation.NewEndSyncPeriod())
+
+	// the failure requires a read in block 2 and a write after a read in block 5
+	read := operation.NewGetState(common.Address{1}, common.Hash{2})
+	write := operation.NewSetState(common.Address{1}, common.Hash{5}, common.Hash{2})
+	calls := 0
+	fails := func(ops []operation.Operation) bool {
+		calls++
+		return contains(ops, read) && contains(ops, write)
+	}
+	if !fails(ops) {
+		t.Fatalf("test sequence must fail")
+	}
+
+	got := MinimizeOperations(ops, fails)
+	want := []operation.Operation{
+		operation.NewBeginSyncPeriod(0),
+		operation.NewBeginBlock(2),
+		operation.NewBeginTransaction(0),
+		read,
+		operation.NewEndTransaction(),
+		operation.NewEndBlock(),
+		operation.NewBeginBlock(5),
+		operation.NewBeginTransaction(0),
+		write,
+		operation.NewEndTransaction(),
+		operation.NewEndBlock(),
+		operation.NewEndSyncPeriod(),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected minimized operations; got %v, want %v", got, want)
+	}
+	if calls > 100 {
+		t.Errorf("minimization took too many steps; %v", calls)
+	}
+}
+
+func TestMinimizeOperations_KeepsUnclosedTransaction(t *testing.T) {
+	failing := operation.NewSetState(common.Address{1}, common.Hash{1}, common.Hash{1})
+	ops := []operation.Operation{
+		operation.NewBeginSyncPeriod(0),
+		operation.NewBeginBlock(1),
+		operation.NewBeginTransaction(0),
+		operation.NewGetBalance(common.Address{2}),
+		failing,
+	}
+	got := MinimizeOperations(ops, func(ops []operation.Operation) bool {
+		return contains(ops, failing)
+	})
+	want := []operation.Operation{ops[0], ops[1], ops[2], failing}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected minimized operations; got %v, want %v", got, want)
+	}
+}
+
+func TestWriteOperations_WritesReplayableTrace(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "minimized.dat")
+	ops := []operation.Operation{operation.NewBeginSyncPeriod(0)}
+	ops = append(ops, absoluteOperations(makeTestOperations(7, 8))...)
+	ops = append(ops, operation.NewEndSyncPeriod())
+
+	if err := WriteOperations(ops, output); err != nil {
+		t.Fatalf("cannot write operations; %v", err)
+	}
+	if got := readAbsoluteOperations(t, output); !reflect.DeepEqual(got, ops) {
+		t.Errorf("unexpected operations; got %v, want %v", got, ops)
+	}
+
+	tf, err := NewTraceFile(output)
+	if err != nil {
+		t.Fatalf("cannot open trace; %v", err)
+	}
+	defer tf.Release()
+	if got, want := tf.firstBlock, uint64(7); got != want {
+		t.Errorf("unexpected first block; got %v, want %v", got, want)
+	}
+}
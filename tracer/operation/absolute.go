@@ -0,0 +1,175 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"math/big"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// Absolute returns an operation equivalent to op which does not refer to the contract
+// and key caches of the replay context. Operations referring to the last contract or to
+// cached storage keys are replaced by their full counterparts, all other operations are
+// returned as they are. The context is updated as if op was executed.
+func Absolute(op Operation, ctx *context.Replay) Operation {
+	var abs Operation
+	switch t := op.(type) {
+	case *GetCodeHashLc:
+		abs = NewGetCodeHash(ctx.PrevContract())
+	case *GetCommittedStateLcls:
+		abs = NewGetCommittedState(ctx.PrevContract(), ctx.ReadKeyCache(0))
+	case *GetStateLc:
+		abs = NewGetState(ctx.PrevContract(), t.Key)
+	case *GetStateLccs:
+		abs = NewGetState(ctx.PrevContract(), ctx.ReadKeyCache(int(t.StoragePosition)))
+	case *GetStateLcls:
+		abs = NewGetState(ctx.PrevContract(), ctx.ReadKeyCache(0))
+	case *SetStateLcls:
+		abs = NewSetState(ctx.PrevContract(), ctx.ReadKeyCache(0), t.Value)
+	default:
+		abs = op
+	}
+	// full operations update the context in the same way as their cached counterparts
+	abs.Execute(nopStateDB{}, ctx)
+	return abs
+}
+
+// nopStateDB is a StateDB ignoring all operations. It is used to track the replay
+// context of a trace without executing it. Methods not issued by operations are
+// not implemented.
+type nopStateDB struct {
+	state.StateDB
+}
+
+func (nopStateDB) CreateAccount(common.Address)        {}
+func (nopStateDB) Exist(common.Address) bool           { return false }
+func (nopStateDB) Empty(common.Address) bool           { return false }
+func (nopStateDB) Suicide(common.Address) bool         { return false }
+func (nopStateDB) HasSuicided(common.Address) bool     { return false }
+func (nopStateDB) GetBalance(common.Address) *big.Int  { return new(big.Int) }
+func (nopStateDB) AddBalance(common.Address, *big.Int) {}
+func (nopStateDB) SubBalance(common.Address, *big.Int) {}
+func (nopStateDB) GetNonce(common.Address) uint64      { return 0 }
+func (nopStateDB) SetNonce(common.Address, uint64)     {}
+func (nopStateDB) GetCommittedState(common.Address, common.Hash) common.Hash {
+	return common.Hash{}
+}
+func (nopStateDB) GetState(common.Address, common.Hash) common.Hash  { return common.Hash{} }
+func (nopStateDB) SetState(common.Address, common.Hash, common.Hash) {}
+func (nopStateDB) GetCodeHash(common.Address) common.Hash            { return common.Hash{} }
+func (nopStateDB) GetCode(common.Address) []byte                     { return nil }
+func (nopStateDB) SetCode(common.Address, []byte)                    {}
+func (nopStateDB) GetCodeSize(common.Address) int                    { return 0 }
+func (nopStateDB) AddRefund(uint64)                                  {}
+func (nopStateDB) SubRefund(uint64)                                  {}
+func (nopStateDB) GetRefund() uint64                                 { return 0 }
+func (nopStateDB) PrepareAccessList(common.Address, *common.Address, []common.Address, types.AccessList) {
+}
+func (nopStateDB) AddressInAccessList(common.Address) bool                   { return false }
+func (nopStateDB) SlotInAccessList(common.Address, common.Hash) (bool, bool) { return false, false }
+func (nopStateDB) AddAddressToAccessList(common.Address)                     {}
+func (nopStateDB) AddSlotToAccessList(common.Address, common.Hash)           {}
+func (nopStateDB) AddLog(*types.Log)                                         {}
+func (nopStateDB) Snapshot() int                                             { return 0 }
+func (nopStateDB) RevertToSnapshot(int)                                      {}
+func (nopStateDB) BeginTransaction(uint32) error                             { return nil }
+func (nopStateDB) EndTransaction() error                                     { return nil }
+func (nopStateDB) BeginBlock(uint64) error                                   { return nil }
+func (nopStateDB) EndBlock() error                                           { return nil }
+func (nopStateDB) BeginSyncPeriod(uint64)                                    {}
+func (nopStateDB) EndSyncPeriod()                                            {}
+func (nopStateDB) Prepare(common.Hash, int)                                  {}
+func (nopStateDB) Finalise(bool)                                             {}
+
+// Relative returns an operation equivalent to the absolute operation op which refers to
+// the contract and key caches of the replay context wherever possible, using the same
+// encoding as the recorder. The context is updated as if the returned operation was
+// executed.
+func Relative(op Operation, ctx *context.Replay) Operation {
+	rel := op
+	switch t := op.(type) {
+	case *GetCodeHash:
+		if t.Contract == ctx.PrevContract() {
+			rel = NewGetCodeHashLc()
+		}
+	case *GetCommittedState:
+		if t.Contract == ctx.PrevContract() && ctx.KeyPosition(t.Key) == 0 {
+			rel = NewGetCommittedStateLcls()
+		}
+	case *GetState:
+		if t.Contract == ctx.PrevContract() {
+			if pos := ctx.KeyPosition(t.Key); pos == 0 {
+				rel = NewGetStateLcls()
+			} else if pos != -1 {
+				rel = NewGetStateLccs(pos)
+			} else {
+				rel = NewGetStateLc(t.Key)
+			}
+		}
+	case *SetState:
+		if t.Contract == ctx.PrevContract() && ctx.KeyPosition(t.Key) == 0 {
+			rel = NewSetStateLcls(t.Value)
+		}
+	}
+	rel.Execute(nopStateDB{}, ctx)
+	return rel
+}
+
+// Contract returns the contract accessed by an absolute operation, if any.
+func Contract(op Operation) (common.Address, bool) {
+	switch t := op.(type) {
+	case *AddBalance:
+		return t.Contract, true
+	case *CreateAccount:
+		return t.Contract, true
+	case *Empty:
+		return t.Contract, true
+	case *Exist:
+		return t.Contract, true
+	case *GetBalance:
+		return t.Contract, true
+	case *GetCode:
+		return t.Contract, true
+	case *GetCodeHash:
+		return t.Contract, true
+	case *GetCodeSize:
+		return t.Contract, true
+	case *GetCommittedState:
+		return t.Contract, true
+	case *GetNonce:
+		return t.Contract, true
+	case *GetState:
+		return t.Contract, true
+	case *HasSuicided:
+		return t.Contract, true
+	case *SetCode:
+		return t.Contract, true
+	case *SetNonce:
+		return t.Contract, true
+	case *SetState:
+		return t.Contract, true
+	case *SubBalance:
+		return t.Contract, true
+	case *Suicide:
+		return t.Contract, true
+	}
+	return common.Address{}, false
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestAbsolute_CachedOperationsAreReplacedByFullOperations checks that operations
+// referring to the replay context are resolved into their full counterparts.
+func TestAbsolute_CachedOperationsAreReplacedByFullOperations(t *testing.T) {
+	contract := getRandomAddress(t)
+	other := getRandomAddress(t)
+	key1 := common.Hash{1}
+	key2 := common.Hash{2}
+	value := common.Hash{3}
+
+	tests := []struct {
+		op   Operation
+		want Operation
+	}{
+		{NewGetState(contract, key1), NewGetState(contract, key1)},
+		{NewGetStateLcls(), NewGetState(contract, key1)},
+		{NewGetStateLc(key2), NewGetState(contract, key2)},
+		{NewGetStateLccs(1), NewGetState(contract, key1)},
+		{NewSetStateLcls(value), NewSetState(contract, key1, value)},
+		{NewGetCommittedStateLcls(), NewGetCommittedState(contract, key1)},
+		{NewGetCodeHashLc(), NewGetCodeHash(contract)},
+		{NewGetBalance(other), NewGetBalance(other)},
+		{NewGetCodeHashLc(), NewGetCodeHash(other)},
+	}
+
+	ctx := context.NewReplay()
+	for i, test := range tests {
+		if got := Absolute(test.op, ctx); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("unexpected operation %d; got %v, want %v", i, got, test.want)
+		}
+	}
+}
+
+// TestRelative_EncodingIsReversedByAbsolute checks that relative operations are decoded
+// into the original absolute operations.
+func TestRelative_EncodingIsReversedByAbsolute(t *testing.T) {
+	contract := getRandomAddress(t)
+	other := getRandomAddress(t)
+	key1 := common.Hash{1}
+	key2 := common.Hash{2}
+
+	tests := []struct {
+		op   Operation
+		want Operation // expected relative operation
+	}{
+		{NewGetState(contract, key1), NewGetState(contract, key1)},
+		{NewGetState(contract, key1), NewGetStateLcls()},
+		{NewGetState(contract, key2), NewGetStateLc(key2)},
+		{NewGetState(contract, key1), NewGetStateLccs(1)},
+		{NewSetState(contract, key1, key2), NewSetStateLcls(key2)},
+		{NewGetCommittedState(contract, key1), NewGetCommittedStateLcls()},
+		{NewGetCodeHash(contract), NewGetCodeHashLc()},
+		{NewGetBalance(other), NewGetBalance(other)},
+		{NewGetState(contract, key1), NewGetState(contract, key1)},
+		{NewSetState(other, key1, key2), NewSetState(other, key1, key2)},
+	}
+
+	encoder := context.NewReplay()
+	decoder := context.NewReplay()
+	for i, test := range tests {
+		rel := Relative(test.op, encoder)
+		if !reflect.DeepEqual(rel, test.want) {
+			t.Errorf("unexpected relative operation %d; got %v, want %v", i, rel, test.want)
+		}
+		if abs := Absolute(rel, decoder); !reflect.DeepEqual(abs, test.op) {
+			t.Errorf("unexpected absolute operation %d; got %v, want %v", i, abs, test.op)
+		}
+	}
+}
+
+// TestContract_ContractOfOperationsIsReturned checks the contracts of absolute operations.
+func TestContract_ContractOfOperationsIsReturned(t *testing.T) {
+	contract := getRandomAddress(t)
+	if got, ok := Contract(NewGetState(contract, common.Hash{})); !ok || got != contract {
+		t.Errorf("unexpected contract; got %v, %v", got, ok)
+	}
+	if _, ok := Contract(NewBeginBlock(1)); ok {
+		t.Errorf("begin-block operation must not access a contract")
+	}
+}
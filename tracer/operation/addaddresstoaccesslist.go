@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// AddAddressToAccessList data structure. The address is stored as is and does not
+// affect the contract context of the trace.
+type AddAddressToAccessList struct {
+	Address common.Address
+}
+
+// GetId returns the add-address-to-access-list operation identifier.
+func (op *AddAddressToAccessList) GetId() byte {
+	return AddAddressToAccessListID
+}
+
+// NewAddAddressToAccessList creates a new add-address-to-access-list operation.
+func NewAddAddressToAccessList(addr common.Address) *AddAddressToAccessList {
+	return &AddAddressToAccessList{Address: addr}
+}
+
+// ReadAddAddressToAccessList reads an add-address-to-access-list operation from a file.
+func ReadAddAddressToAccessList(f io.Reader) (Operation, error) {
+	data := new(AddAddressToAccessList)
+	err := binary.Read(f, binary.LittleEndian, data)
+	return data, err
+}
+
+// Write the add-address-to-access-list operation to a file.
+func (op *AddAddressToAccessList) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the add-address-to-access-list operation.
+func (op *AddAddressToAccessList) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.AddAddressToAccessList(op.Address)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the add-address-to-access-list operation.
+func (op *AddAddressToAccessList) Debug(ctx *context.Context) {
+	fmt.Print(op.Address)
+}
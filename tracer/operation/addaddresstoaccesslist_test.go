@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func initAddAddressToAccessList(t *testing.T) (*context.Replay, *AddAddressToAccessList, common.Address) {
+	addr := getRandomAddress(t)
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewAddAddressToAccessList(addr)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != AddAddressToAccessListID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, addr
+}
+
+// TestAddAddressToAccessListReadWrite writes a new AddAddressToAccessList object into a buffer, reads from it,
+// and checks equality.
+func TestAddAddressToAccessListReadWrite(t *testing.T) {
+	_, op1, _ := initAddAddressToAccessList(t)
+	testOperationReadWrite(t, op1, ReadAddAddressToAccessList)
+}
+
+// TestAddAddressToAccessListDebug creates a new AddAddressToAccessList object and checks its Debug message.
+func TestAddAddressToAccessListDebug(t *testing.T) {
+	ctx, op, addr := initAddAddressToAccessList(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(addr))
+}
+
+// TestAddAddressToAccessListExecute
+func TestAddAddressToAccessListExecute(t *testing.T) {
+	ctx, op, addr := initAddAddressToAccessList(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{AddAddressToAccessListID, []any{addr}}}
+	mock.compareRecordings(expected, t)
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// AddLog data structure. Only the consensus fields of a log are recorded, all
+// other fields are derived by the StateDB.
+type AddLog struct {
+	Address common.Address // address of the contract emitting the log
+	Topics  []common.Hash  // log topics
+	Data    []byte         // log payload
+}
+
+// GetId returns the add-log operation identifier.
+func (op *AddLog) GetId() byte {
+	return AddLogID
+}
+
+// NewAddLog creates a new add-log operation.
+func NewAddLog(log *types.Log) *AddLog {
+	return &AddLog{Address: log.Address, Topics: log.Topics, Data: log.Data}
+}
+
+// ReadAddLog reads an add-log operation from a file.
+func ReadAddLog(f io.Reader) (Operation, error) {
+	data := new(AddLog)
+	if err := binary.Read(f, binary.LittleEndian, &data.Address); err != nil {
+		return nil, fmt.Errorf("cannot read log address; %w", err)
+	}
+	var numTopics uint32
+	if err := binary.Read(f, binary.LittleEndian, &numTopics); err != nil {
+		return nil, fmt.Errorf("cannot read number of topics; %w", err)
+	}
+	if numTopics > 0 {
+		data.Topics = make([]common.Hash, numTopics)
+		if err := binary.Read(f, binary.LittleEndian, data.Topics); err != nil {
+			return nil, fmt.Errorf("cannot read topics; %w", err)
+		}
+	}
+	var length uint32
+	if err := binary.Read(f, binary.LittleEndian, &length); err != nil {
+		return nil, fmt.Errorf("cannot read log data length; %w", err)
+	}
+	if length > 0 {
+		data.Data = make([]byte, length)
+		if err := binary.Read(f, binary.LittleEndian, data.Data); err != nil {
+			return nil, fmt.Errorf("cannot read log data; %w", err)
+		}
+	}
+	return data, nil
+}
+
+// Write the add-log operation to a file.
+func (op *AddLog) Write(f io.Writer) error {
+	if err := binary.Write(f, binary.LittleEndian, op.Address); err != nil {
+		return fmt.Errorf("cannot write log address; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, uint32(len(op.Topics))); err != nil {
+		return fmt.Errorf("cannot write number of topics; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, op.Topics); err != nil {
+		return fmt.Errorf("cannot write topics; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, uint32(len(op.Data))); err != nil {
+		return fmt.Errorf("cannot write log data length; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, op.Data); err != nil {
+		return fmt.Errorf("cannot write log data; %w", err)
+	}
+	return nil
+}
+
+// Execute the add-log operation.
+func (op *AddLog) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	log := &types.Log{Address: op.Address, Topics: op.Topics, Data: op.Data}
+	start := time.Now()
+	db.AddLog(log)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the add-log operation.
+func (op *AddLog) Debug(ctx *context.Context) {
+	fmt.Print(op.Address, op.Topics, op.Data)
+}
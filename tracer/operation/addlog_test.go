@@ -0,0 +1,87 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func initAddLog(t *testing.T) (*context.Replay, *AddLog, *types.Log) {
+	data := make([]byte, 64)
+	rand.Read(data)
+	log := &types.Log{
+		Address: getRandomAddress(t),
+		Topics: []common.Hash{
+			common.BytesToHash(getRandomAddress(t).Bytes()),
+			common.BytesToHash(getRandomAddress(t).Bytes()),
+		},
+		Data: data,
+	}
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewAddLog(log)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != AddLogID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, log
+}
+
+// TestAddLogReadWrite writes a new AddLog object into a buffer, reads from it,
+// and checks equality.
+func TestAddLogReadWrite(t *testing.T) {
+	_, op1, _ := initAddLog(t)
+	testOperationReadWrite(t, op1, ReadAddLog)
+}
+
+// TestAddLogReadWriteEmpty checks that a log without topics and data survives serialization.
+func TestAddLogReadWriteEmpty(t *testing.T) {
+	op := NewAddLog(&types.Log{Address: getRandomAddress(t)})
+	testOperationReadWrite(t, op, ReadAddLog)
+}
+
+// TestAddLogDebug creates a new AddLog object and checks its Debug message.
+func TestAddLogDebug(t *testing.T) {
+	ctx, op, log := initAddLog(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(log.Address, log.Topics, log.Data))
+}
+
+// TestAddLogExecute
+func TestAddLogExecute(t *testing.T) {
+	ctx, op, log := initAddLog(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{AddLogID, []any{log}}}
+	mock.compareRecordings(expected, t)
+}
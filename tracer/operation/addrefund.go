@@ -0,0 +1,68 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// AddRefund data structure
+type AddRefund struct {
+	Gas uint64 // gas added to refund counter
+}
+
+// GetId returns the add-refund operation identifier.
+func (op *AddRefund) GetId() byte {
+	return AddRefundID
+}
+
+// NewAddRefund creates a new add-refund operation.
+func NewAddRefund(gas uint64) *AddRefund {
+	return &AddRefund{Gas: gas}
+}
+
+// ReadAddRefund reads an add-refund operation from a file.
+func ReadAddRefund(f io.Reader) (Operation, error) {
+	data := new(AddRefund)
+	err := binary.Read(f, binary.LittleEndian, data)
+	return data, err
+}
+
+// Write the add-refund operation to a file.
+func (op *AddRefund) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the add-refund operation.
+func (op *AddRefund) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.AddRefund(op.Gas)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the add-refund operation.
+func (op *AddRefund) Debug(ctx *context.Context) {
+	fmt.Print(op.Gas)
+}
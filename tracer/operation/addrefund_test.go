@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+func initAddRefund(t *testing.T) (*context.Replay, *AddRefund, uint64) {
+	gas := rand.Uint64()
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewAddRefund(gas)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != AddRefundID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, gas
+}
+
+// TestAddRefundReadWrite writes a new AddRefund object into a buffer, reads from it,
+// and checks equality.
+func TestAddRefundReadWrite(t *testing.T) {
+	_, op1, _ := initAddRefund(t)
+	testOperationReadWrite(t, op1, ReadAddRefund)
+}
+
+// TestAddRefundDebug creates a new AddRefund object and checks its Debug message.
+func TestAddRefundDebug(t *testing.T) {
+	ctx, op, gas := initAddRefund(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(gas))
+}
+
+// TestAddRefundExecute
+func TestAddRefundExecute(t *testing.T) {
+	ctx, op, gas := initAddRefund(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{AddRefundID, []any{gas}}}
+	mock.compareRecordings(expected, t)
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// AddressInAccessList data structure. The address is stored as is and does not
+// affect the contract context of the trace.
+type AddressInAccessList struct {
+	Address common.Address
+}
+
+// GetId returns the address-in-access-list operation identifier.
+func (op *AddressInAccessList) GetId() byte {
+	return AddressInAccessListID
+}
+
+// NewAddressInAccessList creates a new address-in-access-list operation.
+func NewAddressInAccessList(addr common.Address) *AddressInAccessList {
+	return &AddressInAccessList{Address: addr}
+}
+
+// ReadAddressInAccessList reads an address-in-access-list operation from a file.
+func ReadAddressInAccessList(f io.Reader) (Operation, error) {
+	data := new(AddressInAccessList)
+	err := binary.Read(f, binary.LittleEndian, data)
+	return data, err
+}
+
+// Write the address-in-access-list operation to a file.
+func (op *AddressInAccessList) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the address-in-access-list operation.
+func (op *AddressInAccessList) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.AddressInAccessList(op.Address)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the address-in-access-list operation.
+func (op *AddressInAccessList) Debug(ctx *context.Context) {
+	fmt.Print(op.Address)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// GetRefund data structure
+type GetRefund struct {
+}
+
+// GetId returns the get-refund operation identifier.
+func (op *GetRefund) GetId() byte {
+	return GetRefundID
+}
+
+// NewGetRefund creates a new get-refund operation.
+func NewGetRefund() *GetRefund {
+	return &GetRefund{}
+}
+
+// ReadGetRefund reads a get-refund operation from a file.
+func ReadGetRefund(io.Reader) (Operation, error) {
+	return new(GetRefund), nil
+}
+
+// Write the get-refund operation to a file.
+func (op *GetRefund) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the get-refund operation.
+func (op *GetRefund) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.GetRefund()
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the get-refund operation.
+func (op *GetRefund) Debug(*context.Context) {
+}
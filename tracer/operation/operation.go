@@ -80,6 +80,11 @@ const (
 	PrepareID
 	SubRefundID
 
+	NewAccountIteratorID
+	NewStorageIteratorID
+	AccountIteratorNextID
+	StorageIteratorNextID
+
 	// WARNING: New IDs should be added here. Any change in the order of the
 	// IDs above invalidates persisted data -- in particular storage traces.
 
@@ -129,22 +134,28 @@ var opDict = map[byte]OperationDictionary{
 	SubBalanceID:            {label: "SubBalance", readfunc: ReadSubBalance},
 	SuicideID:               {label: "Suicide", readfunc: ReadSuicide},
 
+	// access lists, logs and refunds (since trace format version 2)
+	AddAddressToAccessListID: {label: "AddAddressToAccessList", readfunc: ReadAddAddressToAccessList},
+	AddLogID:                 {label: "AddLog", readfunc: ReadAddLog},
+	AddRefundID:              {label: "AddRefund", readfunc: ReadAddRefund},
+	AddressInAccessListID:    {label: "AddressInAccessList", readfunc: ReadAddressInAccessList},
+	AddSlotToAccessListID:    {label: "AddSlotToAccessList", readfunc: ReadAddSlotToAccessList},
+	GetRefundID:              {label: "GetRefund", readfunc: ReadGetRefund},
+	PrepareAccessListID:      {label: "PrepareAccessList", readfunc: ReadPrepareAccessList},
+	PrepareID:                {label: "Prepare", readfunc: ReadPrepare},
+	SlotInAccessListID:       {label: "SlotInAccessList", readfunc: ReadSlotInAccessList},
+	SubRefundID:              {label: "SubRefund", readfunc: ReadSubRefund},
+
 	// for testing
-	AddAddressToAccessListID: {label: "AddAddressToAccessList", readfunc: ReadPanic},
-	AddLogID:                 {label: "AddLog", readfunc: ReadPanic},
-	AddPreimageID:            {label: "AddPreimage", readfunc: ReadPanic},
-	AddRefundID:              {label: "AddRefund", readfunc: ReadPanic},
-	AddressInAccessListID:    {label: "AddressInAccessList", readfunc: ReadPanic},
-	AddSlotToAccessListID:    {label: "AddSlotToAccessList", readfunc: ReadPanic},
-	CloseID:                  {label: "Close", readfunc: ReadPanic},
-	ForEachStorageID:         {label: "ForEachStorage", readfunc: ReadPanic},
-	GetLogsID:                {label: "GetLogs", readfunc: ReadPanic},
-	GetRefundID:              {label: "GetRefund", readfunc: ReadPanic},
-	IntermediateRootID:       {label: "IntermediateRoot", readfunc: ReadPanic},
-	PrepareAccessListID:      {label: "PrepareAccessList", readfunc: ReadPanic},
-	PrepareID:                {label: "Prepare", readfunc: ReadPanic},
-	SlotInAccessListID:       {label: "SlotInAccessList", readfunc: ReadPanic},
-	SubRefundID:              {label: "SubRefund", readfunc: ReadPanic},
+	AddPreimageID:         {label: "AddPreimage", readfunc: ReadPanic},
+	CloseID:               {label: "Close", readfunc: ReadPanic},
+	ForEachStorageID:      {label: "ForEachStorage", readfunc: ReadPanic},
+	GetLogsID:             {label: "GetLogs", readfunc: ReadPanic},
+	IntermediateRootID:    {label: "IntermediateRoot", readfunc: ReadPanic},
+	NewAccountIteratorID:  {label: "NewAccountIterator", readfunc: ReadPanic},
+	NewStorageIteratorID:  {label: "NewStorageIterator", readfunc: ReadPanic},
+	AccountIteratorNextID: {label: "AccountIteratorNext", readfunc: ReadPanic},
+	StorageIteratorNextID: {label: "StorageIteratorNext", readfunc: ReadPanic},
 }
 
 // GetLabel retrieves a label of a state operation.
@@ -278,6 +278,10 @@ func (s *MockStateDB) Close() error {
 	return nil
 }
 
+func (s *MockStateDB) Flush() error {
+	return nil
+}
+
 func (s *MockStateDB) Error() error {
 	return nil
 }
@@ -318,6 +322,8 @@ func areEqual(v1 any, v2 any) bool {
 	case *big.Int:
 		c2 := v2.(*big.Int)
 		return c2.Cmp(c1) == 0
+	case *common.Address, []common.Address, types.AccessList, *types.Log:
+		return reflect.DeepEqual(v1, v2)
 	default:
 		return v1 == v2
 	}
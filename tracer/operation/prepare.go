@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// Prepare data structure
+type Prepare struct {
+	TxHash  common.Hash // transaction hash
+	TxIndex int64       // transaction index
+}
+
+// GetId returns the prepare operation identifier.
+func (op *Prepare) GetId() byte {
+	return PrepareID
+}
+
+// NewPrepare creates a new prepare operation.
+func NewPrepare(thash common.Hash, ti int) *Prepare {
+	return &Prepare{TxHash: thash, TxIndex: int64(ti)}
+}
+
+// ReadPrepare reads a prepare operation from a file.
+func ReadPrepare(f io.Reader) (Operation, error) {
+	data := new(Prepare)
+	err := binary.Read(f, binary.LittleEndian, data)
+	return data, err
+}
+
+// Write the prepare operation to a file.
+func (op *Prepare) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the prepare operation.
+func (op *Prepare) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.Prepare(op.TxHash, int(op.TxIndex))
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the prepare operation.
+func (op *Prepare) Debug(ctx *context.Context) {
+	fmt.Print(op.TxHash, op.TxIndex)
+}
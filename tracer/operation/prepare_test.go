@@ -0,0 +1,72 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func initPrepare(t *testing.T) (*context.Replay, *Prepare, common.Hash, int) {
+	thash := common.BytesToHash(getRandomAddress(t).Bytes())
+	ti := rand.Intn(1000)
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewPrepare(thash, ti)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != PrepareID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, thash, ti
+}
+
+// TestPrepareReadWrite writes a new Prepare object into a buffer, reads from it,
+// and checks equality.
+func TestPrepareReadWrite(t *testing.T) {
+	_, op1, _, _ := initPrepare(t)
+	testOperationReadWrite(t, op1, ReadPrepare)
+}
+
+// TestPrepareDebug creates a new Prepare object and checks its Debug message.
+func TestPrepareDebug(t *testing.T) {
+	ctx, op, thash, ti := initPrepare(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(thash, int64(ti)))
+}
+
+// TestPrepareExecute
+func TestPrepareExecute(t *testing.T) {
+	ctx, op, thash, ti := initPrepare(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{PrepareID, []any{thash, ti}}}
+	mock.compareRecordings(expected, t)
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// PrepareAccessList data structure
+type PrepareAccessList struct {
+	Sender      common.Address   // transaction sender
+	Dest        *common.Address  // transaction recipient; nil for contract creations
+	Precompiles []common.Address // addresses of precompiled contracts
+	AccessList  types.AccessList // access list of the transaction (EIP-2930)
+}
+
+// GetId returns the prepare-access-list operation identifier.
+func (op *PrepareAccessList) GetId() byte {
+	return PrepareAccessListID
+}
+
+// NewPrepareAccessList creates a new prepare-access-list operation.
+func NewPrepareAccessList(sender common.Address, dest *common.Address, precompiles []common.Address, txAccesses types.AccessList) *PrepareAccessList {
+	return &PrepareAccessList{Sender: sender, Dest: dest, Precompiles: precompiles, AccessList: txAccesses}
+}
+
+// ReadPrepareAccessList reads a prepare-access-list operation from a file.
+func ReadPrepareAccessList(f io.Reader) (Operation, error) {
+	data := new(PrepareAccessList)
+	if err := binary.Read(f, binary.LittleEndian, &data.Sender); err != nil {
+		return nil, fmt.Errorf("cannot read sender; %w", err)
+	}
+	var hasDest bool
+	if err := binary.Read(f, binary.LittleEndian, &hasDest); err != nil {
+		return nil, fmt.Errorf("cannot read destination flag; %w", err)
+	}
+	if hasDest {
+		data.Dest = new(common.Address)
+		if err := binary.Read(f, binary.LittleEndian, data.Dest); err != nil {
+			return nil, fmt.Errorf("cannot read destination; %w", err)
+		}
+	}
+	var err error
+	if data.Precompiles, err = readAddresses(f); err != nil {
+		return nil, fmt.Errorf("cannot read precompiles; %w", err)
+	}
+	var numTuples uint32
+	if err = binary.Read(f, binary.LittleEndian, &numTuples); err != nil {
+		return nil, fmt.Errorf("cannot read access list length; %w", err)
+	}
+	if numTuples > 0 {
+		data.AccessList = make(types.AccessList, numTuples)
+	}
+	for i := range data.AccessList {
+		if err = binary.Read(f, binary.LittleEndian, &data.AccessList[i].Address); err != nil {
+			return nil, fmt.Errorf("cannot read access list address; %w", err)
+		}
+		var numKeys uint32
+		if err = binary.Read(f, binary.LittleEndian, &numKeys); err != nil {
+			return nil, fmt.Errorf("cannot read number of storage keys; %w", err)
+		}
+		if numKeys > 0 {
+			data.AccessList[i].StorageKeys = make([]common.Hash, numKeys)
+			if err = binary.Read(f, binary.LittleEndian, data.AccessList[i].StorageKeys); err != nil {
+				return nil, fmt.Errorf("cannot read storage keys; %w", err)
+			}
+		}
+	}
+	return data, nil
+}
+
+// Write the prepare-access-list operation to a file.
+func (op *PrepareAccessList) Write(f io.Writer) error {
+	if err := binary.Write(f, binary.LittleEndian, op.Sender); err != nil {
+		return fmt.Errorf("cannot write sender; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, op.Dest != nil); err != nil {
+		return fmt.Errorf("cannot write destination flag; %w", err)
+	}
+	if op.Dest != nil {
+		if err := binary.Write(f, binary.LittleEndian, *op.Dest); err != nil {
+			return fmt.Errorf("cannot write destination; %w", err)
+		}
+	}
+	if err := writeAddresses(f, op.Precompiles); err != nil {
+		return fmt.Errorf("cannot write precompiles; %w", err)
+	}
+	if err := binary.Write(f, binary.LittleEndian, uint32(len(op.AccessList))); err != nil {
+		return fmt.Errorf("cannot write access list length; %w", err)
+	}
+	for _, tuple := range op.AccessList {
+		if err := binary.Write(f, binary.LittleEndian, tuple.Address); err != nil {
+			return fmt.Errorf("cannot write access list address; %w", err)
+		}
+		if err := binary.Write(f, binary.LittleEndian, uint32(len(tuple.StorageKeys))); err != nil {
+			return fmt.Errorf("cannot write number of storage keys; %w", err)
+		}
+		if err := binary.Write(f, binary.LittleEndian, tuple.StorageKeys); err != nil {
+			return fmt.Errorf("cannot write storage keys; %w", err)
+		}
+	}
+	return nil
+}
+
+// Execute the prepare-access-list operation.
+func (op *PrepareAccessList) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.PrepareAccessList(op.Sender, op.Dest, op.Precompiles, op.AccessList)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the prepare-access-list operation.
+func (op *PrepareAccessList) Debug(ctx *context.Context) {
+	fmt.Print(op.Sender, op.Dest, op.Precompiles, op.AccessList)
+}
+
+// readAddresses reads a length-prefixed list of addresses.
+func readAddresses(f io.Reader) ([]common.Address, error) {
+	var length uint32
+	if err := binary.Read(f, binary.LittleEndian, &length); err != nil {
+		return nil, err
+	}
+	if length == 0 {
+		return nil, nil
+	}
+	addresses := make([]common.Address, length)
+	if err := binary.Read(f, binary.LittleEndian, addresses); err != nil {
+		return nil, err
+	}
+	return addresses, nil
+}
+
+// writeAddresses writes a length-prefixed list of addresses.
+func writeAddresses(f io.Writer, addresses []common.Address) error {
+	if err := binary.Write(f, binary.LittleEndian, uint32(len(addresses))); err != nil {
+		return err
+	}
+	return binary.Write(f, binary.LittleEndian, addresses)
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func initPrepareAccessList(t *testing.T) (*context.Replay, *PrepareAccessList, common.Address, *common.Address, []common.Address, types.AccessList) {
+	sender := getRandomAddress(t)
+	dest := getRandomAddress(t)
+	precompiles := []common.Address{common.BytesToAddress([]byte{1}), common.BytesToAddress([]byte{2})}
+	accessList := types.AccessList{
+		{Address: getRandomAddress(t), StorageKeys: []common.Hash{{1}, {2}}},
+		{Address: getRandomAddress(t)},
+	}
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewPrepareAccessList(sender, &dest, precompiles, accessList)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != PrepareAccessListID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, sender, &dest, precompiles, accessList
+}
+
+// TestPrepareAccessListReadWrite writes a new PrepareAccessList object into a buffer, reads from it,
+// and checks equality.
+func TestPrepareAccessListReadWrite(t *testing.T) {
+	_, op1, _, _, _, _ := initPrepareAccessList(t)
+	testOperationReadWrite(t, op1, ReadPrepareAccessList)
+}
+
+// TestPrepareAccessListReadWriteContractCreation checks that an operation without destination
+// and access list survives serialization.
+func TestPrepareAccessListReadWriteContractCreation(t *testing.T) {
+	op := NewPrepareAccessList(getRandomAddress(t), nil, nil, nil)
+	testOperationReadWrite(t, op, ReadPrepareAccessList)
+}
+
+// TestPrepareAccessListDebug creates a new PrepareAccessList object and checks its Debug message.
+func TestPrepareAccessListDebug(t *testing.T) {
+	ctx, op, sender, dest, precompiles, accessList := initPrepareAccessList(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(sender, dest, precompiles, accessList))
+}
+
+// TestPrepareAccessListExecute
+func TestPrepareAccessListExecute(t *testing.T) {
+	ctx, op, sender, dest, precompiles, accessList := initPrepareAccessList(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{PrepareAccessListID, []any{sender, dest, precompiles, accessList}}}
+	mock.compareRecordings(expected, t)
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+// SlotInAccessList data structure. The address and the slot are stored as is and
+// do not affect the contract and key context of the trace.
+type SlotInAccessList struct {
+	Address common.Address
+	Slot    common.Hash
+}
+
+// GetId returns the slot-in-access-list operation identifier.
+func (op *SlotInAccessList) GetId() byte {
+	return SlotInAccessListID
+}
+
+// NewSlotInAccessList creates a new slot-in-access-list operation.
+func NewSlotInAccessList(addr common.Address, slot common.Hash) *SlotInAccessList {
+	return &SlotInAccessList{Address: addr, Slot: slot}
+}
+
+// ReadSlotInAccessList reads a slot-in-access-list operation from a file.
+func ReadSlotInAccessList(f io.Reader) (Operation, error) {
+	data := new(SlotInAccessList)
+	err := binary.Read(f, binary.LittleEndian, data)
+	return data, err
+}
+
+// Write the slot-in-access-list operation to a file.
+func (op *SlotInAccessList) Write(f io.Writer) error {
+	err := binary.Write(f, binary.LittleEndian, *op)
+	return err
+}
+
+// Execute the slot-in-access-list operation.
+func (op *SlotInAccessList) Execute(db state.StateDB, ctx *context.Replay) time.Duration {
+	start := time.Now()
+	db.SlotInAccessList(op.Address, op.Slot)
+	return time.Since(start)
+}
+
+// Debug prints a debug message for the slot-in-access-list operation.
+func (op *SlotInAccessList) Debug(ctx *context.Context) {
+	fmt.Print(op.Address, op.Slot)
+}
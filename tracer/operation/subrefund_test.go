@@ -0,0 +1,70 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package operation
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+)
+
+func initSubRefund(t *testing.T) (*context.Replay, *SubRefund, uint64) {
+	gas := rand.Uint64()
+
+	// create context context
+	ctx := context.NewReplay()
+
+	// create new operation
+	op := NewSubRefund(gas)
+	if op == nil {
+		t.Fatalf("failed to create operation")
+	}
+	// check id
+	if op.GetId() != SubRefundID {
+		t.Fatalf("wrong ID returned")
+	}
+
+	return ctx, op, gas
+}
+
+// TestSubRefundReadWrite writes a new SubRefund object into a buffer, reads from it,
+// and checks equality.
+func TestSubRefundReadWrite(t *testing.T) {
+	_, op1, _ := initSubRefund(t)
+	testOperationReadWrite(t, op1, ReadSubRefund)
+}
+
+// TestSubRefundDebug creates a new SubRefund object and checks its Debug message.
+func TestSubRefundDebug(t *testing.T) {
+	ctx, op, gas := initSubRefund(t)
+	testOperationDebug(t, ctx, op, fmt.Sprint(gas))
+}
+
+// TestSubRefundExecute
+func TestSubRefundExecute(t *testing.T) {
+	ctx, op, gas := initSubRefund(t)
+
+	// check execution
+	mock := NewMockStateDB()
+	op.Execute(mock, ctx)
+
+	// check whether methods were correctly called
+	expected := []Record{{SubRefundID, []any{gas}}}
+	mock.compareRecordings(expected, t)
+}
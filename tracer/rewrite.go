@@ -0,0 +1,233 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TraceFilter selects the content of a rewritten trace.
+type TraceFilter struct {
+	First      uint64                  // first block
+	Last       uint64                  // last block (inclusive)
+	Contracts  map[common.Address]bool // if not empty, only transactions accessing one of the contracts are kept
+	Operations map[byte]bool           // if not empty, only operations of these types and structural operations are kept
+}
+
+// NewTraceFilter creates a filter keeping all content of the given block range.
+func NewTraceFilter(first, last uint64) TraceFilter {
+	return TraceFilter{First: first, Last: last}
+}
+
+// AllBlocks is a filter keeping all content of a trace.
+var AllBlocks = NewTraceFilter(0, math.MaxUint64)
+
+// isStructural returns true for operations which are kept by all filters
+// since a trace cannot be replayed without them.
+func isStructural(op operation.Operation) bool {
+	switch op.GetId() {
+	case operation.BeginBlockID, operation.EndBlockID,
+		operation.BeginSyncPeriodID, operation.EndSyncPeriodID,
+		operation.BeginTransactionID, operation.EndTransactionID,
+		operation.SnapshotID, operation.RevertToSnapshotID:
+		return true
+	}
+	return false
+}
+
+// keepTransaction checks whether a transaction passes the contract filter.
+func (f TraceFilter) keepTransaction(ops []operation.Operation) bool {
+	if len(f.Contracts) == 0 {
+		return true
+	}
+	for _, op := range ops {
+		if contract, ok := operation.Contract(op); ok && f.Contracts[contract] {
+			return true
+		}
+	}
+	return false
+}
+
+// keepOperation checks whether an operation passes the operation filter.
+func (f TraceFilter) keepOperation(op operation.Operation) bool {
+	return len(f.Operations) == 0 || f.Operations[op.GetId()] || isStructural(op)
+}
+
+// errStopReading terminates reading of trace files.
+var errStopReading = errors.New("stop reading")
+
+// ReadOperations reads all operations of the given trace files in their absolute
+// form (see operation.Absolute). Each file is decoded with its own context since
+// the recorder starts every trace file with an empty context.
+func ReadOperations(files []string, visit func(op operation.Operation) error) error {
+	for _, file := range files {
+		tf, err := NewTraceFile(file)
+		if err != nil {
+			return err
+		}
+		ctx := context.NewReplay()
+		for {
+			op, err := operation.Read(tf.reader)
+			if err == io.EOF {
+				break
+			}
+			if err == nil {
+				err = visit(operation.Absolute(op, ctx))
+			}
+			if err != nil {
+				return errors.Join(err, tf.Release())
+			}
+		}
+		if err = tf.Release(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SortTraceFiles sorts trace files by their first block.
+func SortTraceFiles(files []string) ([]string, error) {
+	first := make(map[string]uint64, len(files))
+	for _, file := range files {
+		tf, err := NewTraceFile(file)
+		if err != nil {
+			return nil, err
+		}
+		first[file] = tf.firstBlock
+		if err = tf.Release(); err != nil {
+			return nil, err
+		}
+	}
+	sorted := append([]string(nil), files...)
+	sort.SliceStable(sorted, func(i, j int) bool { return first[sorted[i]] < first[sorted[j]] })
+	return sorted, nil
+}
+
+// traceRewriter writes the operations passing a filter into a new trace file.
+type traceRewriter struct {
+	filter     TraceFilter
+	output     string
+	record     *context.Record // output trace; opened with the first block in range
+	ctx        *context.Replay // context of a replay of the output trace
+	block      uint64          // current block
+	hasBlock   bool            // true after the first block
+	inRange    bool            // true if the current block is in range
+	syncPeriod operation.Operation
+	syncOpen   bool                  // true if a sync-period is open in the output trace
+	tx         []operation.Operation // operations of the current transaction
+	inTx       bool                  // true while reading a transaction
+}
+
+// RewriteTrace writes the operations of the trace files passing the filter into a new trace
+// file. Trace files must be given in the order of their blocks. Contract and key caches are
+// re-encoded for the output trace, and sync-periods cut by the block range are opened and
+// closed, so that the output trace can be replayed on its own.
+func RewriteTrace(files []string, output string, filter TraceFilter) error {
+	w := &traceRewriter{filter: filter, output: output, ctx: context.NewReplay()}
+	err := ReadOperations(files, w.add)
+	if errors.Is(err, errStopReading) {
+		err = nil
+	}
+	if err != nil {
+		if w.record != nil {
+			w.record.Close()
+		}
+		return err
+	}
+	if w.record == nil {
+		return fmt.Errorf("no blocks in range %v - %v", filter.First, filter.Last)
+	}
+	if w.syncOpen {
+		w.write(operation.NewEndSyncPeriod())
+	}
+	w.record.Close()
+	return nil
+}
+
+// add processes the next operation of the input traces.
+func (w *traceRewriter) add(op operation.Operation) error {
+	switch t := op.(type) {
+	case *operation.BeginSyncPeriod:
+		w.syncPeriod = op
+		return nil
+	case *operation.EndSyncPeriod:
+		w.syncPeriod = nil
+		if !w.syncOpen {
+			return nil
+		}
+		w.syncOpen = false
+	case *operation.BeginBlock:
+		if w.hasBlock && t.BlockNumber <= w.block {
+			return fmt.Errorf("block %v follows block %v; trace files overlap or are out of order", t.BlockNumber, w.block)
+		}
+		w.block, w.hasBlock = t.BlockNumber, true
+		if w.block > w.filter.Last {
+			return errStopReading
+		}
+		w.inRange = w.block >= w.filter.First
+		if !w.inRange {
+			return nil
+		}
+		if w.record == nil {
+			var err error
+			if w.record, err = context.NewRecord(w.output, w.block); err != nil {
+				return err
+			}
+		}
+		// open a sync-period whose beginning was cut off
+		if w.syncPeriod != nil && !w.syncOpen {
+			w.write(w.syncPeriod)
+			w.syncOpen = true
+		}
+	case *operation.BeginTransaction:
+		w.inTx = true
+	}
+
+	if !w.inRange {
+		return nil
+	}
+	if w.inTx {
+		w.tx = append(w.tx, op)
+		if op.GetId() != operation.EndTransactionID {
+			return nil
+		}
+		if w.filter.keepTransaction(w.tx) {
+			for _, op := range w.tx {
+				w.write(op)
+			}
+		}
+		w.tx, w.inTx = w.tx[:0], false
+		return nil
+	}
+	w.write(op)
+	return nil
+}
+
+// write encodes an operation passing the operation filter into the output trace.
+func (w *traceRewriter) write(op operation.Operation) {
+	if w.filter.keepOperation(op) {
+		operation.WriteOp(w.record, operation.Relative(op, w.ctx))
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/context"
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// readAbsoluteOperations reads all operations of a trace file in their absolute form.
+func readAbsoluteOperations(t *testing.T, filename string) []operation.Operation {
+	var ops []operation.Operation
+	err := ReadOperations([]string{filename}, func(op operation.Operation) error {
+		ops = append(ops, op)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("cannot read trace %v; %v", filename, err)
+	}
+	return ops
+}
+
+// absoluteOperations returns the absolute form of operations of a trace.
+func absoluteOperations(ops []operation.Operation) []operation.Operation {
+	ctx := context.NewReplay()
+	var res []operation.Operation
+	for _, op := range ops {
+		res = append(res, operation.Absolute(op, ctx))
+	}
+	return res
+}
+
+func TestRewriteTrace_CutKeepsBlockRangeAndSyncPeriod(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "cut.dat")
+	ops := append([]operation.Operation{operation.NewBeginSyncPeriod(0)}, makeTestOperations(1, 2, 3, 4)...)
+	ops = append(ops, operation.NewEndSyncPeriod())
+	writeTestTrace(t, input, 1, ops)
+
+	if err := RewriteTrace([]string{input}, output, NewTraceFilter(2, 3)); err != nil {
+		t.Fatalf("cannot cut trace; %v", err)
+	}
+
+	want := []operation.Operation{operation.NewBeginSyncPeriod(0)}
+	want = append(want, absoluteOperations(makeTestOperations(1, 2, 3))[7:]...)
+	want = append(want, operation.NewEndSyncPeriod())
+	if got := readAbsoluteOperations(t, output); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected operations; got %v, want %v", got, want)
+	}
+
+	// the output is encoded relative to its own context
+	raw := readTestTrace(t, output, 0)
+	if _, ok := raw[4].(*operation.GetStateLcls); !ok {
+		t.Errorf("storage access is not encoded by key cache; got %v", raw[4])
+	}
+
+	tf, err := NewTraceFile(output)
+	if err != nil {
+		t.Fatalf("cannot open trace; %v", err)
+	}
+	defer tf.Release()
+	if got, want := tf.firstBlock, uint64(2); got != want {
+		t.Errorf("unexpected first block; got %v, want %v", got, want)
+	}
+}
+
+func TestRewriteTrace_MergeConcatenatesTraces(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.dat")
+	second := filepath.Join(dir, "second.dat")
+	output := filepath.Join(dir, "merged.dat")
+	writeTestTrace(t, first, 1, makeTestOperations(1, 2))
+	writeTestTrace(t, second, 3, makeTestOperations(3, 4))
+
+	files, err := SortTraceFiles([]string{second, first})
+	if err != nil {
+		t.Fatalf("cannot sort trace files; %v", err)
+	}
+	if err = RewriteTrace(files, output, AllBlocks); err != nil {
+		t.Fatalf("cannot merge traces; %v", err)
+	}
+
+	want := append(absoluteOperations(makeTestOperations(1, 2)), absoluteOperations(makeTestOperations(3, 4))...)
+	if got := readAbsoluteOperations(t, output); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected operations; got %v, want %v", got, want)
+	}
+}
+
+func TestRewriteTrace_OverlappingTracesAreRejected(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.dat")
+	second := filepath.Join(dir, "second.dat")
+	writeTestTrace(t, first, 1, makeTestOperations(1, 2))
+	writeTestTrace(t, second, 2, makeTestOperations(2, 3))
+
+	if err := RewriteTrace([]string{first, second}, filepath.Join(dir, "merged.dat"), AllBlocks); err == nil {
+		t.Errorf("merging overlapping traces must fail")
+	}
+}
+
+func TestRewriteTrace_EmptyRangeIsRejected(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	writeTestTrace(t, input, 1, makeTestOperations(1, 2))
+
+	if err := RewriteTrace([]string{input}, filepath.Join(dir, "cut.dat"), NewTraceFilter(5, 6)); err == nil {
+		t.Errorf("cutting an empty block range must fail")
+	}
+}
+
+func TestRewriteTrace_FilterByContractKeepsTransactions(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "filtered.dat")
+	a, b := common.Address{0xa}, common.Address{0xb}
+	txA := []operation.Operation{
+		operation.NewBeginTransaction(0),
+		operation.NewGetBalance(a),
+		operation.NewEndTransaction(),
+	}
+	txB := []operation.Operation{
+		operation.NewBeginTransaction(1),
+		operation.NewGetBalance(b),
+		operation.NewEndTransaction(),
+	}
+	ops := []operation.Operation{operation.NewBeginBlock(1)}
+	ops = append(ops, txA...)
+	ops = append(ops, txB...)
+	ops = append(ops, operation.NewEndBlock())
+	writeTestTrace(t, input, 1, ops)
+
+	filter := AllBlocks
+	filter.Contracts = map[common.Address]bool{b: true}
+	if err := RewriteTrace([]string{input}, output, filter); err != nil {
+		t.Fatalf("cannot filter trace; %v", err)
+	}
+
+	want := []operation.Operation{operation.NewBeginBlock(1)}
+	want = append(want, txB...)
+	want = append(want, operation.NewEndBlock())
+	if got := readAbsoluteOperations(t, output); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected operations; got %v, want %v", got, want)
+	}
+}
+
+func TestRewriteTrace_FilterByOperationKeepsStructure(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "trace.dat")
+	output := filepath.Join(dir, "filtered.dat")
+	contract := common.Address{1}
+	ops := []operation.Operation{
+		operation.NewBeginBlock(1),
+		operation.NewBeginTransaction(0),
+		operation.NewSnapshot(0),
+		operation.NewGetState(contract, common.Hash{1}),
+		operation.NewGetBalance(contract),
+		operation.NewGetStateLcls(),
+		operation.NewRevertToSnapshot(0),
+		operation.NewEndTransaction(),
+		operation.NewEndBlock(),
+	}
+	writeTestTrace(t, input, 1, ops)
+
+	filter := AllBlocks
+	filter.Operations = map[byte]bool{operation.GetStateID: true}
+	if err := RewriteTrace([]string{input}, output, filter); err != nil {
+		t.Fatalf("cannot filter trace; %v", err)
+	}
+
+	want := []operation.Operation{
+		operation.NewBeginBlock(1),
+		operation.NewBeginTransaction(0),
+		operation.NewSnapshot(0),
+		operation.NewGetState(contract, common.Hash{1}),
+		operation.NewGetState(contract, common.Hash{1}),
+		operation.NewRevertToSnapshot(0),
+		operation.NewEndTransaction(),
+		operation.NewEndBlock(),
+	}
+	if got := readAbsoluteOperations(t, output); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected operations; got %v, want %v", got, want)
+	}
+}
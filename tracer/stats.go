@@ -0,0 +1,155 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+)
+
+// OperationStats summarizes all occurrences of an operation type.
+type OperationStats struct {
+	Count uint64 `json:"count"` // number of operations
+	Bytes uint64 `json:"bytes"` // serialized size of operations including their IDs
+}
+
+// TraceStats summarizes the content of a storage trace.
+type TraceStats struct {
+	Blocks         uint64                     `json:"blocks"`         // number of blocks
+	Transactions   uint64                     `json:"transactions"`   // number of transactions
+	Operations     uint64                     `json:"operations"`     // number of operations
+	Bytes          uint64                     `json:"bytes"`          // serialized size of all operations
+	OperationStats map[string]*OperationStats `json:"operationStats"` // statistics per operation type
+	SnapshotDepths map[int]uint64             `json:"snapshotDepths"` // number of transactions per maximum snapshot depth
+}
+
+// NewTraceStats creates empty trace statistics.
+func NewTraceStats() *TraceStats {
+	return &TraceStats{
+		OperationStats: make(map[string]*OperationStats),
+		SnapshotDepths: make(map[int]uint64),
+	}
+}
+
+// countingWriter counts the number of written bytes.
+type countingWriter struct {
+	n uint64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += uint64(len(p))
+	return len(p), nil
+}
+
+// Add includes the operations of a transaction into the statistics.
+func (s *TraceStats) Add(ops []operation.Operation) error {
+	var (
+		snapshots []int32 // currently valid snapshot IDs
+		maxDepth  int
+		isTx      bool
+	)
+	for _, op := range ops {
+		switch t := op.(type) {
+		case *operation.BeginBlock:
+			s.Blocks++
+		case *operation.BeginTransaction:
+			s.Transactions++
+			isTx = true
+		case *operation.Snapshot:
+			snapshots = append(snapshots, t.SnapshotID)
+			maxDepth = max(maxDepth, len(snapshots))
+		case *operation.RevertToSnapshot:
+			// reverting invalidates the snapshot and all snapshots taken after it
+			for i := len(snapshots) - 1; i >= 0; i-- {
+				if snapshots[i] == t.SnapshotID {
+					snapshots = snapshots[:i]
+					break
+				}
+			}
+		}
+
+		w := new(countingWriter)
+		if err := op.Write(w); err != nil {
+			return fmt.Errorf("cannot measure operation %v; %w", operation.GetLabel(op.GetId()), err)
+		}
+		label := operation.GetLabel(op.GetId())
+		stats, found := s.OperationStats[label]
+		if !found {
+			stats = new(OperationStats)
+			s.OperationStats[label] = stats
+		}
+		stats.Count++
+		stats.Bytes += w.n + 1
+		s.Operations++
+		s.Bytes += w.n + 1
+	}
+	if isTx {
+		s.SnapshotDepths[maxDepth]++
+	}
+	return nil
+}
+
+// WriteJson writes the statistics into given file.
+func (s *TraceStats) WriteJson(filename string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode trace statistics; %w", err)
+	}
+	if err = os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("cannot write trace statistics to %v; %w", filename, err)
+	}
+	return nil
+}
+
+// Print writes a human-readable version of the statistics. Operations are
+// listed by decreasing frequency.
+func (s *TraceStats) Print(w io.Writer) {
+	fmt.Fprintf(w, "Blocks: %v\nTransactions: %v\nOperations: %v\nBytes: %v\n", s.Blocks, s.Transactions, s.Operations, s.Bytes)
+
+	labels := make([]string, 0, len(s.OperationStats))
+	for label := range s.OperationStats {
+		labels = append(labels, label)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := s.OperationStats[labels[i]], s.OperationStats[labels[j]]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return labels[i] < labels[j]
+	})
+	fmt.Fprintf(w, "\n%-24s %12s %8s %14s %10s\n", "Operation", "Count", "Share", "Bytes", "Bytes/Op")
+	for _, label := range labels {
+		stats := s.OperationStats[label]
+		fmt.Fprintf(w, "%-24s %12d %7.2f%% %14d %10.2f\n",
+			label, stats.Count, 100*float64(stats.Count)/float64(s.Operations), stats.Bytes, float64(stats.Bytes)/float64(stats.Count))
+	}
+
+	depths := make([]int, 0, len(s.SnapshotDepths))
+	for depth := range s.SnapshotDepths {
+		depths = append(depths, depth)
+	}
+	sort.Ints(depths)
+	fmt.Fprintf(w, "\n%-24s %12s\n", "Max snapshot depth", "Transactions")
+	for _, depth := range depths {
+		fmt.Fprintf(w, "%-24d %12d\n", depth, s.SnapshotDepths[depth])
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/tracer/operation"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestTraceStats_OperationsAreCounted(t *testing.T) {
+	stats := NewTraceStats()
+	ops := []operation.Operation{
+		operation.NewBeginBlock(1),
+		operation.NewBeginTransaction(0),
+		operation.NewGetBalance(common.Address{1}),
+		operation.NewGetBalance(common.Address{2}),
+		operation.NewEndTransaction(),
+	}
+	if err := stats.Add(ops); err != nil {
+		t.Fatalf("cannot add operations; %v", err)
+	}
+
+	if stats.Blocks != 1 || stats.Transactions != 1 || stats.Operations != 5 {
+		t.Errorf("unexpected counts; got %v blocks, %v transactions, %v operations", stats.Blocks, stats.Transactions, stats.Operations)
+	}
+	balance := stats.OperationStats["GetBalance"]
+	if balance == nil || balance.Count != 2 {
+		t.Fatalf("unexpected GetBalance statistics; got %v", balance)
+	}
+	// operation ID and address
+	if got, want := balance.Bytes, uint64(2*(1+20)); got != want {
+		t.Errorf("unexpected size of GetBalance operations; got %v, want %v", got, want)
+	}
+	// operation ID and block number
+	if got, want := stats.OperationStats["BeginBlock"].Bytes, uint64(1+8); got != want {
+		t.Errorf("unexpected size of BeginBlock operation; got %v, want %v", got, want)
+	}
+}
+
+func TestTraceStats_MaximumSnapshotDepthIsRecordedPerTransaction(t *testing.T) {
+	stats := NewTraceStats()
+	transactions := [][]operation.Operation{
+		{
+			operation.NewBeginTransaction(0),
+			operation.NewEndTransaction(),
+		},
+		{
+			operation.NewBeginTransaction(1),
+			operation.NewSnapshot(0),
+			operation.NewSnapshot(1),
+			operation.NewSnapshot(2),
+			operation.NewRevertToSnapshot(1),
+			operation.NewSnapshot(3),
+			operation.NewEndTransaction(),
+		},
+		{
+			operation.NewBeginTransaction(2),
+			operation.NewSnapshot(0),
+			operation.NewRevertToSnapshot(0),
+			operation.NewSnapshot(1),
+			operation.NewEndTransaction(),
+		},
+	}
+	for _, ops := range transactions {
+		if err := stats.Add(ops); err != nil {
+			t.Fatalf("cannot add operations; %v", err)
+		}
+	}
+
+	want := map[int]uint64{0: 1, 1: 1, 3: 1}
+	if !reflect.DeepEqual(stats.SnapshotDepths, want) {
+		t.Errorf("unexpected snapshot depths; got %v, want %v", stats.SnapshotDepths, want)
+	}
+}
+
+func TestTraceStats_PrintAndWriteJson(t *testing.T) {
+	stats := NewTraceStats()
+	ops := []operation.Operation{
+		operation.NewBeginTransaction(0),
+		operation.NewGetBalance(common.Address{1}),
+		operation.NewEndTransaction(),
+	}
+	if err := stats.Add(ops); err != nil {
+		t.Fatalf("cannot add operations; %v", err)
+	}
+
+	var buf bytes.Buffer
+	stats.Print(&buf)
+	if !strings.Contains(buf.String(), "GetBalance") {
+		t.Errorf("printed statistics do not contain GetBalance; got %v", buf.String())
+	}
+
+	filename := filepath.Join(t.TempDir(), "stats.json")
+	if err := stats.WriteJson(filename); err != nil {
+		t.Fatalf("cannot write statistics; %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("cannot read statistics; %v", err)
+	}
+	got := NewTraceStats()
+	if err = json.Unmarshal(data, got); err != nil {
+		t.Fatalf("cannot decode statistics; %v", err)
+	}
+	if !reflect.DeepEqual(got, stats) {
+		t.Errorf("unexpected statistics; got %v, want %v", got, stats)
+	}
+}
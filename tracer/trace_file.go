@@ -25,49 +25,132 @@ import (
 	"path/filepath"
 	"sort"
 
+	"github.com/Fantom-foundation/Aida/tracer/context"
 	"github.com/Fantom-foundation/Aida/utils"
 	"github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
 )
 
 const ReaderBufferSize = 65536 * 256 // 16MiB
 
 // TraceFile data structure for reading a trace file.
 type TraceFile struct {
-	firstBlock uint64        // first block in trace file
-	file       *os.File      // trace file
-	reader     *bufio.Reader // read buffer
-	zreader    *bzip2.Reader // compressed stream
+	firstBlock  uint64            // first block in trace file
+	version     uint8             // version of trace file format
+	file        *os.File          // trace file
+	reader      *bufio.Reader     // read buffer
+	zreader     *bzip2.Reader     // compressed stream of bzip2 trace files
+	decoder     *zstd.Decoder     // compressed stream of chunked trace files
+	index       []ChunkIndexEntry // chunk index of chunked trace files
+	indexOffset uint64            // offset of chunk index in chunked trace files
 }
 
 // NewTraceFile opens a file, read header and create a TraceFile object.
 func NewTraceFile(fname string) (*TraceFile, error) {
 	tf := new(TraceFile)
 
-	// open a bzip file
 	var err error
 	tf.file, err = os.Open(fname)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open trace file; %v", err)
 	}
+
+	// chunked trace files start with an uncompressed header
+	var header [chunkedHeaderSize]byte
+	if _, err := io.ReadFull(tf.file, header[:]); err == nil && string(header[:len(context.TraceMagic)]) == context.TraceMagic {
+		if err := tf.openChunked(header[:]); err != nil {
+			return nil, err
+		}
+		return tf, nil
+	}
+	if _, err := tf.file.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("cannot rewind trace file; %v", err)
+	}
+
+	// open a bzip file
 	tf.zreader, err = bzip2.NewReader(tf.file, &bzip2.ReaderConfig{})
 	if err != nil {
 		return nil, fmt.Errorf("cannot open bzip stream; %v", err)
 	}
 	tf.reader = bufio.NewReaderSize(tf.zreader, ReaderBufferSize)
 
+	// read version; legacy trace files have no magic and start with the first block
+	tf.version = 1
+	if magic, err := tf.reader.Peek(len(context.TraceMagic)); err == nil && string(magic) == context.TraceMagic {
+		var header [len(context.TraceMagic) + 1]byte
+		if _, err := io.ReadFull(tf.reader, header[:]); err != nil {
+			return nil, fmt.Errorf("fail to read file version; %v", err)
+		}
+		tf.version = header[len(context.TraceMagic)]
+		if tf.version < 2 || tf.version > context.TraceVersion {
+			return nil, fmt.Errorf("unsupported trace file version %v", tf.version)
+		}
+	}
+
 	//read first block
-	var header [8]byte
-	if _, err := io.ReadFull(tf.reader, header[:]); err != nil {
+	var first [8]byte
+	if _, err := io.ReadFull(tf.reader, first[:]); err != nil {
 		return nil, fmt.Errorf("fail to read file header; %v", err)
 	}
-	tf.firstBlock = binary.LittleEndian.Uint64(header[:])
+	tf.firstBlock = binary.LittleEndian.Uint64(first[:])
 	return tf, nil
 }
 
+// openChunked reads the index of a chunked trace file and positions the reader
+// at the first chunk.
+func (tf *TraceFile) openChunked(header []byte) error {
+	tf.version = header[len(context.TraceMagic)]
+	if tf.version != context.ChunkedTraceVersion {
+		return fmt.Errorf("unsupported trace file version %v", tf.version)
+	}
+	tf.firstBlock = binary.LittleEndian.Uint64(header[len(context.TraceMagic)+1:])
+
+	var err error
+	if tf.index, tf.indexOffset, err = readChunkIndex(tf.file); err != nil {
+		return fmt.Errorf("cannot read chunk index; %v", err)
+	}
+	tf.decoder, err = zstd.NewReader(tf.chunks(uint64(chunkedHeaderSize)))
+	if err != nil {
+		return fmt.Errorf("cannot open zstd stream; %v", err)
+	}
+	tf.reader = bufio.NewReaderSize(tf.decoder, ReaderBufferSize)
+	return nil
+}
+
+// chunks returns a reader of all chunks starting at the given offset.
+func (tf *TraceFile) chunks(offset uint64) io.Reader {
+	return io.NewSectionReader(tf.file, int64(offset), int64(tf.indexOffset-offset))
+}
+
+// Seek positions the trace file at the chunk containing the given block so that
+// reading skips all preceding chunks. Trace files without chunk index are read
+// from their beginning.
+func (tf *TraceFile) Seek(block uint64) error {
+	if len(tf.index) == 0 {
+		return nil
+	}
+	chunk := tf.index[findChunk(tf.index, block)]
+	if err := tf.decoder.Reset(tf.chunks(chunk.Offset)); err != nil {
+		return fmt.Errorf("cannot seek block %v; %v", block, err)
+	}
+	tf.reader.Reset(tf.decoder)
+	return nil
+}
+
+// Version returns the format version of the trace file.
+func (tf *TraceFile) Version() uint8 {
+	return tf.version
+}
+
 // Release closes all file channels.
 func (tf *TraceFile) Release() error {
-	if err := tf.zreader.Close(); err != nil {
-		return fmt.Errorf("cannot close compressed stream. %v", err)
+	if tf.zreader != nil {
+		if err := tf.zreader.Close(); err != nil {
+			return fmt.Errorf("cannot close compressed stream. %v", err)
+		}
+	}
+	if tf.decoder != nil {
+		tf.decoder.Close()
 	}
 	if err := tf.file.Close(); err != nil {
 		return fmt.Errorf("cannot close trace file. %v", err)
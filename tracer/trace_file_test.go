@@ -18,6 +18,7 @@ package tracer
 
 import (
 	"bufio"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -74,6 +75,11 @@ func prepareTraceDirectory(fdir string, numFiles int) error {
 // prepareTraceFileWithoutHeader create a special trace file without header.
 // This file is used to test error handling.
 func prepareTraceFileWithoutHeader(filename string) error {
+	return prepareTraceFileWithRawHeader(filename, nil)
+}
+
+// prepareTraceFileWithRawHeader creates a trace file containing only the given header.
+func prepareTraceFileWithRawHeader(filename string, header []byte) error {
 	if _, err := os.Stat(filename); err == nil {
 		return fmt.Errorf("file %v already exists", filename)
 	}
@@ -88,6 +94,10 @@ func prepareTraceFileWithoutHeader(filename string) error {
 		return fmt.Errorf("cannot open bzip2 stream; %v", err)
 	}
 
+	if _, err := zFile.Write(header); err != nil {
+		return fmt.Errorf("cannot write header; %v", err)
+	}
+
 	// close file
 	if err := zFile.Close(); err != nil {
 		return fmt.Errorf("cannot close bzip2 writer; %v", err)
@@ -150,6 +160,49 @@ func TestTraceFile_NewAndRelease(t *testing.T) {
 	}
 }
 
+// Test that the format version and the first block are read from current and legacy trace files.
+func TestTraceFile_Version(t *testing.T) {
+	fname := "versioned_trace.dat"
+	if err := prepareTraceFile(fname); err != nil {
+		t.Fatalf("Fail to create a trace file %v; %v", fname, err)
+	}
+	defer os.Remove(fname)
+
+	legacy := "legacy_trace.dat"
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint64(header, firstBlockInTrace)
+	if err := prepareTraceFileWithRawHeader(legacy, header); err != nil {
+		t.Fatalf("Fail to create a trace file %v; %v", legacy, err)
+	}
+	defer os.Remove(legacy)
+
+	for name, version := range map[string]uint8{fname: context.TraceVersion, legacy: 1} {
+		tf, err := NewTraceFile(name)
+		if err != nil {
+			t.Fatalf("Fail to read a trace file %v; %v", name, err)
+		}
+		if got := tf.Version(); got != version {
+			t.Errorf("wrong version of %v; got %v, want %v", name, got, version)
+		}
+		if tf.firstBlock != firstBlockInTrace {
+			t.Errorf("wrong first block of %v; got %v, want %v", name, tf.firstBlock, firstBlockInTrace)
+		}
+		if err := tf.Release(); err != nil {
+			t.Fatalf("Fail to release a trace file; %v", err)
+		}
+	}
+
+	unsupported := "unsupported_trace.dat"
+	header = append([]byte(context.TraceMagic), context.TraceVersion+1, 0, 0, 0, 0, 0, 0, 0, 0)
+	if err := prepareTraceFileWithRawHeader(unsupported, header); err != nil {
+		t.Fatalf("Fail to create a trace file %v; %v", unsupported, err)
+	}
+	defer os.Remove(unsupported)
+	if _, err := NewTraceFile(unsupported); err == nil {
+		t.Fatalf("Expect an error reading a trace file of unsupported version")
+	}
+}
+
 // Test function keepRelevantTraceFiles. The test ensures that any files not in
 // the target range are removed from the return list.
 func TestTraceFile_keepRelevantTraceFiles(t *testing.T) {
@@ -0,0 +1,333 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utildb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Fantom-foundation/Aida/logger"
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/Fantom-foundation/Aida/txcontext"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StateDiffCategory classifies a difference between two StateDBs.
+type StateDiffCategory string
+
+const (
+	ExistenceDiff StateDiffCategory = "existence"
+	BalanceDiff   StateDiffCategory = "balance"
+	NonceDiff     StateDiffCategory = "nonce"
+	CodeDiff      StateDiffCategory = "code"
+	StorageDiff   StateDiffCategory = "storage"
+)
+
+// StateDiff is a single difference between two StateDBs.
+type StateDiff struct {
+	Category StateDiffCategory `json:"category"`
+	Address  common.Address    `json:"address"`
+	Key      *common.Hash      `json:"key,omitempty"` // storage key of storage differences
+	A        string            `json:"a"`             // value in the first StateDB
+	B        string            `json:"b"`             // value in the second StateDB
+}
+
+func (d StateDiff) String() string {
+	if d.Key != nil {
+		return fmt.Sprintf("%v %v %v: %v != %v", d.Category, d.Address, d.Key, d.A, d.B)
+	}
+	return fmt.Sprintf("%v %v: %v != %v", d.Category, d.Address, d.A, d.B)
+}
+
+// StateDiffReport summarizes all differences found between two StateDBs.
+type StateDiffReport struct {
+	Block        uint64                    `json:"block"`        // block at which the StateDBs were compared
+	DbA          string                    `json:"dbA"`          // path to the first StateDB
+	DbB          string                    `json:"dbB"`          // path to the second StateDB
+	Accounts     int                       `json:"accounts"`     // number of compared accounts
+	StorageSlots int                       `json:"storageSlots"` // number of compared storage slots
+	Counts       map[StateDiffCategory]int `json:"counts"`       // number of differences per category
+	Diffs        []StateDiff               `json:"diffs"`        // recorded differences
+	Truncated    bool                      `json:"truncated"`    // true if not all differences were recorded
+	Partial      bool                      `json:"partial"`      // true if only accounts of a world-state were compared
+	maxDiffs     int
+}
+
+// NewStateDiffReport creates an empty report recording at most maxDiffs differences;
+// zero means all differences are recorded.
+func NewStateDiffReport(maxDiffs int) *StateDiffReport {
+	return &StateDiffReport{
+		Counts:   make(map[StateDiffCategory]int),
+		maxDiffs: maxDiffs,
+	}
+}
+
+func (r *StateDiffReport) add(diff StateDiff) {
+	r.Counts[diff.Category]++
+	if r.maxDiffs > 0 && len(r.Diffs) >= r.maxDiffs {
+		r.Truncated = true
+		return
+	}
+	r.Diffs = append(r.Diffs, diff)
+}
+
+// NumDiffs returns the total number of differences.
+func (r *StateDiffReport) NumDiffs() int {
+	total := 0
+	for _, count := range r.Counts {
+		total += count
+	}
+	return total
+}
+
+// WriteJson writes the report into given file.
+func (r *StateDiffReport) WriteJson(filename string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode state diff report; %w", err)
+	}
+	if err = os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("cannot write state diff report to %v; %w", filename, err)
+	}
+	return nil
+}
+
+// Print logs a human-readable version of the report.
+func (r *StateDiffReport) Print(log logger.Logger) {
+	log.Noticef("Compared %v accounts and %v storage slots at block %v", r.Accounts, r.StorageSlots, r.Block)
+	for _, category := range []StateDiffCategory{ExistenceDiff, BalanceDiff, NonceDiff, CodeDiff, StorageDiff} {
+		log.Noticef("\t%v differences: %v", category, r.Counts[category])
+	}
+	for _, diff := range r.Diffs {
+		log.Info(diff.String())
+	}
+	if r.Truncated {
+		log.Warningf("Only first %v of %v differences were listed", len(r.Diffs), r.NumDiffs())
+	}
+	if r.Partial {
+		log.Warningf("State-db content could not be enumerated; only accounts and storage slots of the world-state were compared")
+	}
+}
+
+// CompareStateDbs compares all accounts and storage slots of both StateDBs. The StateDBs are
+// enumerated by their iterators, so accounts and storage slots present in only one of them are
+// reported as well. Accounts are compared one at a time, hence the memory usage is independent
+// of the size of the StateDBs. StateDBs not supporting iteration (see state.IsIterable) can only
+// be compared on the accounts and storage slots of the given world-state, whose values are
+// ignored; the report is marked as partial in this case. The world-state may be nil if both
+// StateDBs support iteration.
+func CompareStateDbs(a, b state.VmStateDB, ws txcontext.WorldState, report *StateDiffReport) error {
+	iterableA, iterableB := state.IsIterable(a), state.IsIterable(b)
+	if ws == nil && !(iterableA && iterableB) {
+		return fmt.Errorf("cannot compare state-dbs without world-state; %w", state.ErrIterationNotSupported)
+	}
+	report.Partial = !(iterableA && iterableB)
+
+	var sources []stateDiffSource
+	if iterableA {
+		sources = append(sources, iterableSource{a})
+	}
+	if iterableB {
+		sources = append(sources, iterableSource{b})
+	}
+	if ws != nil {
+		sources = append(sources, worldStateSource{ws})
+	}
+
+	for i, source := range sources {
+		// entries listed by preceding sources have already been compared
+		compared := sources[:i]
+		err := source.forEachAccount(func(addr common.Address) error {
+			if !containsAccount(compared, addr) {
+				compareAccount(a, b, addr, report)
+			}
+			if existsA, existsB := a.Exist(addr), b.Exist(addr); !existsA || !existsB {
+				return nil
+			}
+			return source.forEachSlot(addr, func(key common.Hash) {
+				if !containsSlot(compared, addr, key) {
+					compareSlot(a, b, addr, key, report)
+				}
+			})
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stateDiffSource lists accounts and storage slots to be compared.
+type stateDiffSource interface {
+	forEachAccount(func(common.Address) error) error
+	forEachSlot(common.Address, func(common.Hash)) error
+	hasAccount(common.Address) bool
+	hasSlot(common.Address, common.Hash) bool
+}
+
+func containsAccount(sources []stateDiffSource, addr common.Address) bool {
+	for _, source := range sources {
+		if source.hasAccount(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsSlot(sources []stateDiffSource, addr common.Address, key common.Hash) bool {
+	for _, source := range sources {
+		if source.hasSlot(addr, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// iterableSource lists the content of a StateDB enumerated by its iterators.
+type iterableSource struct {
+	db state.VmStateDB
+}
+
+func (s iterableSource) forEachAccount(fn func(common.Address) error) error {
+	it, err := state.NewAccountIterator(s.db)
+	if err != nil {
+		return fmt.Errorf("cannot iterate accounts; %w", err)
+	}
+	defer it.Release()
+
+	for it.Next() {
+		if err = fn(it.Address()); err != nil {
+			return err
+		}
+	}
+	if err = it.Error(); err != nil {
+		return fmt.Errorf("cannot iterate accounts; %w", err)
+	}
+	return nil
+}
+
+func (s iterableSource) forEachSlot(addr common.Address, fn func(common.Hash)) error {
+	it, err := state.NewStorageIterator(s.db, addr)
+	if err != nil {
+		return fmt.Errorf("cannot iterate storage of %v; %w", addr, err)
+	}
+	defer it.Release()
+
+	for it.Next() {
+		fn(it.Key())
+	}
+	if err = it.Error(); err != nil {
+		return fmt.Errorf("cannot iterate storage of %v; %w", addr, err)
+	}
+	return nil
+}
+
+func (s iterableSource) hasAccount(addr common.Address) bool {
+	return s.db.Exist(addr)
+}
+
+func (s iterableSource) hasSlot(addr common.Address, key common.Hash) bool {
+	return s.db.GetState(addr, key) != (common.Hash{})
+}
+
+// worldStateSource lists accounts and storage slots of a world-state.
+type worldStateSource struct {
+	ws txcontext.WorldState
+}
+
+func (s worldStateSource) forEachAccount(fn func(common.Address) error) error {
+	addresses := make([]common.Address, 0, s.ws.Len())
+	s.ws.ForEachAccount(func(addr common.Address, _ txcontext.Account) {
+		addresses = append(addresses, addr)
+	})
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+	for _, addr := range addresses {
+		if err := fn(addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s worldStateSource) forEachSlot(addr common.Address, fn func(common.Hash)) error {
+	acc := s.ws.Get(addr)
+	if acc == nil {
+		return nil
+	}
+	keys := make([]common.Hash, 0, acc.GetStorageSize())
+	acc.ForEachStorage(func(key common.Hash, _ common.Hash) {
+		keys = append(keys, key)
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+	for _, key := range keys {
+		fn(key)
+	}
+	return nil
+}
+
+func (s worldStateSource) hasAccount(addr common.Address) bool {
+	return s.ws.Has(addr)
+}
+
+func (s worldStateSource) hasSlot(addr common.Address, key common.Hash) bool {
+	acc := s.ws.Get(addr)
+	return acc != nil && acc.HasStorageAt(key)
+}
+
+// compareAccount compares existence, balance, nonce and code of a single account.
+func compareAccount(a, b state.VmStateDB, addr common.Address, report *StateDiffReport) {
+	report.Accounts++
+
+	existsA, existsB := a.Exist(addr), b.Exist(addr)
+	if existsA != existsB {
+		report.add(StateDiff{Category: ExistenceDiff, Address: addr, A: fmt.Sprint(existsA), B: fmt.Sprint(existsB)})
+		return
+	}
+	if !existsA {
+		return
+	}
+
+	if balanceA, balanceB := a.GetBalance(addr), b.GetBalance(addr); balanceA.Cmp(balanceB) != 0 {
+		report.add(StateDiff{Category: BalanceDiff, Address: addr, A: balanceA.String(), B: balanceB.String()})
+	}
+	if nonceA, nonceB := a.GetNonce(addr), b.GetNonce(addr); nonceA != nonceB {
+		report.add(StateDiff{Category: NonceDiff, Address: addr, A: fmt.Sprint(nonceA), B: fmt.Sprint(nonceB)})
+	}
+	if hashA, hashB := a.GetCodeHash(addr), b.GetCodeHash(addr); hashA != hashB {
+		report.add(StateDiff{
+			Category: CodeDiff,
+			Address:  addr,
+			A:        fmt.Sprintf("%v (%d bytes)", hashA, a.GetCodeSize(addr)),
+			B:        fmt.Sprintf("%v (%d bytes)", hashB, b.GetCodeSize(addr)),
+		})
+	}
+}
+
+// compareSlot compares a single storage slot of an account present in both StateDBs.
+func compareSlot(a, b state.VmStateDB, addr common.Address, key common.Hash, report *StateDiffReport) {
+	report.StorageSlots++
+	if valueA, valueB := a.GetState(addr, key), b.GetState(addr, key); valueA != valueB {
+		report.add(StateDiff{Category: StorageDiff, Address: addr, Key: &key, A: valueA.Hex(), B: valueB.Hex()})
+	}
+}
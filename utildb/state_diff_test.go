@@ -0,0 +1,208 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utildb
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	substatecontext "github.com/Fantom-foundation/Aida/txcontext/substate"
+	substate "github.com/Fantom-foundation/Substate"
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/mock/gomock"
+)
+
+func makeStateDiffTestDb(t *testing.T) state.StateDB {
+	db, err := state.MakeEmptyGethInMemoryStateDB("")
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	for i := byte(1); i <= 3; i++ {
+		addr := common.Address{i}
+		db.CreateAccount(addr)
+		db.AddBalance(addr, big.NewInt(int64(i)))
+		db.SetNonce(addr, uint64(i))
+		db.SetState(addr, common.Hash{i}, common.Hash{i})
+	}
+	return db
+}
+
+func makeStateDiffTestWorldState() substate.SubstateAlloc {
+	ws := make(substate.SubstateAlloc)
+	for i := byte(1); i <= 4; i++ {
+		ws[common.Address{i}] = substate.NewSubstateAccount(0, big.NewInt(0), nil)
+		ws[common.Address{i}].Storage[common.Hash{i}] = common.Hash{}
+	}
+	return ws
+}
+
+func TestStateDiff_EqualStateDbsHaveNoDiffs(t *testing.T) {
+	report := NewStateDiffReport(0)
+	if err := CompareStateDbs(makeStateDiffTestDb(t), makeStateDiffTestDb(t), substatecontext.NewWorldState(makeStateDiffTestWorldState()), report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+
+	if got := report.NumDiffs(); got != 0 {
+		t.Errorf("unexpected differences: %v", report.Diffs)
+	}
+	if got, want := report.Accounts, 4; got != want {
+		t.Errorf("unexpected number of accounts; got: %v, want: %v", got, want)
+	}
+	if got, want := report.StorageSlots, 3; got != want {
+		t.Errorf("unexpected number of storage slots; got: %v, want: %v", got, want)
+	}
+}
+
+func TestStateDiff_AllCategoriesAreDetected(t *testing.T) {
+	a, b := makeStateDiffTestDb(t), makeStateDiffTestDb(t)
+	b.AddBalance(common.Address{1}, big.NewInt(1))
+	b.SetNonce(common.Address{2}, 10)
+	b.SetCode(common.Address{3}, []byte{0x60})
+	b.SetState(common.Address{3}, common.Hash{3}, common.Hash{0xff})
+	b.CreateAccount(common.Address{4})
+	b.AddBalance(common.Address{4}, big.NewInt(1))
+
+	report := NewStateDiffReport(0)
+	if err := CompareStateDbs(a, b, substatecontext.NewWorldState(makeStateDiffTestWorldState()), report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+
+	for _, category := range []StateDiffCategory{ExistenceDiff, BalanceDiff, NonceDiff, CodeDiff, StorageDiff} {
+		if got := report.Counts[category]; got != 1 {
+			t.Errorf("unexpected number of %v differences; got: %v, want: 1", category, got)
+		}
+	}
+	if got, want := len(report.Diffs), 5; got != want {
+		t.Errorf("unexpected number of listed differences; got: %v, want: %v", got, want)
+	}
+}
+
+func TestStateDiff_ListedDiffsAreLimited(t *testing.T) {
+	a, b := makeStateDiffTestDb(t), makeStateDiffTestDb(t)
+	for i := byte(1); i <= 3; i++ {
+		b.AddBalance(common.Address{i}, big.NewInt(1))
+	}
+
+	report := NewStateDiffReport(2)
+	if err := CompareStateDbs(a, b, substatecontext.NewWorldState(makeStateDiffTestWorldState()), report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+
+	if got, want := report.NumDiffs(), 3; got != want {
+		t.Errorf("unexpected number of differences; got: %v, want: %v", got, want)
+	}
+	if got, want := len(report.Diffs), 2; got != want {
+		t.Errorf("unexpected number of listed differences; got: %v, want: %v", got, want)
+	}
+	if !report.Truncated {
+		t.Errorf("report must be truncated")
+	}
+}
+
+func TestStateDiff_ReportIsWrittenAsJson(t *testing.T) {
+	a, b := makeStateDiffTestDb(t), makeStateDiffTestDb(t)
+	b.SetState(common.Address{1}, common.Hash{1}, common.Hash{0xff})
+
+	report := NewStateDiffReport(0)
+	report.Block = 10
+	if err := CompareStateDbs(a, b, substatecontext.NewWorldState(makeStateDiffTestWorldState()), report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "report.json")
+	if err := report.WriteJson(filename); err != nil {
+		t.Fatalf("cannot write report; %v", err)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatalf("cannot read report; %v", err)
+	}
+	var got StateDiffReport
+	if err = json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("cannot decode report; %v", err)
+	}
+	if got.Block != 10 || got.Counts[StorageDiff] != 1 || len(got.Diffs) != 1 || got.Diffs[0].Key == nil || *got.Diffs[0].Key != (common.Hash{1}) {
+		t.Errorf("unexpected report content: %+v", got)
+	}
+}
+
+func TestStateDiff_EntriesOfOnlyOneStateDbAreDetected(t *testing.T) {
+	a, b := makeStateDiffTestDb(t), makeStateDiffTestDb(t)
+	b.CreateAccount(common.Address{5})
+	b.AddBalance(common.Address{5}, big.NewInt(1))
+	a.SetState(common.Address{1}, common.Hash{0xaa}, common.Hash{1})
+
+	// without a world-state, the content of the state-dbs is enumerated
+	report := NewStateDiffReport(0)
+	if err := CompareStateDbs(a, b, nil, report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+	if got := report.Counts[ExistenceDiff]; got != 1 {
+		t.Errorf("unexpected number of existence differences; got: %v, want: 1", got)
+	}
+	if got := report.Counts[StorageDiff]; got != 1 {
+		t.Errorf("unexpected number of storage differences; got: %v, want: 1", got)
+	}
+	if got, want := report.Accounts, 4; got != want {
+		t.Errorf("unexpected number of accounts; got: %v, want: %v", got, want)
+	}
+	if report.Partial {
+		t.Errorf("report of enumerated state-dbs must not be partial")
+	}
+}
+
+// nonIterableStateDb hides the iteration support of a StateDB.
+type nonIterableStateDb struct {
+	state.VmStateDB
+}
+
+func TestStateDiff_StateDbWithoutIterationIsComparedOnWorldState(t *testing.T) {
+	a, b := nonIterableStateDb{makeStateDiffTestDb(t)}, makeStateDiffTestDb(t)
+	b.SetState(common.Address{1}, common.Hash{0xbb}, common.Hash{1})
+
+	report := NewStateDiffReport(0)
+	if err := CompareStateDbs(a, b, substatecontext.NewWorldState(makeStateDiffTestWorldState()), report); err != nil {
+		t.Fatalf("cannot compare state-dbs; %v", err)
+	}
+	if !report.Partial {
+		t.Errorf("report must be partial")
+	}
+	if got := report.Counts[StorageDiff]; got != 1 {
+		t.Errorf("unexpected number of storage differences; got: %v, want: 1", got)
+	}
+	if got, want := report.Accounts, 4; got != want {
+		t.Errorf("unexpected number of accounts; got: %v, want: %v", got, want)
+	}
+	if got, want := report.StorageSlots, 4; got != want {
+		t.Errorf("unexpected number of storage slots; got: %v, want: %v", got, want)
+	}
+}
+
+func TestStateDiff_StateDbsWithoutIterationRequireWorldState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	a, b := state.NewMockStateDB(ctrl), makeStateDiffTestDb(t)
+
+	err := CompareStateDbs(a, b, nil, NewStateDiffReport(0))
+	if !errors.Is(err, state.ErrIterationNotSupported) {
+		t.Errorf("unexpected error; got: %v, want: %v", err, state.ErrIterationNotSupported)
+	}
+}
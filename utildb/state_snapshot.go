@@ -0,0 +1,486 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utildb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math/big"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/klauspost/compress/zstd"
+)
+
+// A state snapshot is a portable dump of a StateDB at a block. It starts with a header
+// followed by a sequence of chunks. Each chunk is framed by its kind, the length and
+// a CRC32 checksum of its zstd compressed payload. Code is stored once per code hash
+// and written before the first account referring to it; storage of an account is
+// written after the account itself. The final chunk holds the number of entries
+// written so that truncated snapshots are detected.
+const (
+	StateSnapshotMagic   = "AIDASNAP"
+	StateSnapshotVersion = uint16(1)
+
+	stateSnapshotChunkSize = 4 * 1024 * 1024 // uncompressed size at which chunks are flushed
+	stateSnapshotMaxChunk  = 1 << 30         // upper bound of a compressed chunk accepted by readers
+)
+
+// emptyCodeHash is the code hash of accounts without code.
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+type stateSnapshotChunkKind byte
+
+const (
+	codeChunk stateSnapshotChunkKind = iota + 1
+	accountChunk
+	storageChunk
+	endChunk
+)
+
+// StateSnapshotHeader describes the state captured by a snapshot.
+type StateSnapshotHeader struct {
+	Version uint16
+	Block   uint64      // block after which the state was captured
+	Root    common.Hash // state hash of the source StateDB
+}
+
+// StateSnapshotStats counts entries of a snapshot.
+type StateSnapshotStats struct {
+	Accounts     uint64
+	StorageSlots uint64
+	Codes        uint64 // number of distinct codes
+}
+
+// ExportStateSnapshot writes all accounts, code and storage of the given state into w.
+// The state has to support iteration (see state.IterableState).
+func ExportStateSnapshot(src state.VmStateDB, header StateSnapshotHeader, w io.Writer) (StateSnapshotStats, error) {
+	sw, err := newStateSnapshotWriter(w, header)
+	if err != nil {
+		return StateSnapshotStats{}, err
+	}
+
+	accounts, err := state.NewAccountIterator(src)
+	if err != nil {
+		return StateSnapshotStats{}, fmt.Errorf("cannot iterate accounts; %w", err)
+	}
+	defer accounts.Release()
+
+	for accounts.Next() {
+		addr := accounts.Address()
+		if err = sw.addAccount(addr, src.GetNonce(addr), src.GetBalance(addr), src.GetCode(addr)); err != nil {
+			return StateSnapshotStats{}, err
+		}
+
+		storage, err := state.NewStorageIterator(src, addr)
+		if err != nil {
+			return StateSnapshotStats{}, fmt.Errorf("cannot iterate storage of %v; %w", addr, err)
+		}
+		for storage.Next() {
+			if err = sw.addStorage(addr, storage.Key(), storage.Value()); err != nil {
+				storage.Release()
+				return StateSnapshotStats{}, err
+			}
+		}
+		err = storage.Error()
+		storage.Release()
+		if err != nil {
+			return StateSnapshotStats{}, fmt.Errorf("cannot iterate storage of %v; %w", addr, err)
+		}
+	}
+	if err = accounts.Error(); err != nil {
+		return StateSnapshotStats{}, fmt.Errorf("cannot iterate accounts; %w", err)
+	}
+
+	if err = sw.close(); err != nil {
+		return StateSnapshotStats{}, err
+	}
+	return sw.stats, nil
+}
+
+// stateSnapshotWriter buffers entries of each chunk kind and flushes them in an order
+// guaranteeing that code precedes accounts and accounts precede their storage.
+type stateSnapshotWriter struct {
+	out     *bufio.Writer
+	encoder *zstd.Encoder
+	codes   map[common.Hash]struct{}
+	buffers map[stateSnapshotChunkKind]*bytes.Buffer
+	stats   StateSnapshotStats
+}
+
+func newStateSnapshotWriter(w io.Writer, header StateSnapshotHeader) (*stateSnapshotWriter, error) {
+	encoder, err := zstd.NewWriter(nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create encoder; %w", err)
+	}
+	sw := &stateSnapshotWriter{
+		out:     bufio.NewWriter(w),
+		encoder: encoder,
+		codes:   make(map[common.Hash]struct{}),
+		buffers: map[stateSnapshotChunkKind]*bytes.Buffer{
+			codeChunk:    new(bytes.Buffer),
+			accountChunk: new(bytes.Buffer),
+			storageChunk: new(bytes.Buffer),
+		},
+	}
+
+	var buf [2 + 8 + common.HashLength]byte
+	binary.BigEndian.PutUint16(buf[0:], StateSnapshotVersion)
+	binary.BigEndian.PutUint64(buf[2:], header.Block)
+	copy(buf[10:], header.Root[:])
+	if _, err = sw.out.WriteString(StateSnapshotMagic); err != nil {
+		return nil, fmt.Errorf("cannot write header; %w", err)
+	}
+	if _, err = sw.out.Write(buf[:]); err != nil {
+		return nil, fmt.Errorf("cannot write header; %w", err)
+	}
+	return sw, nil
+}
+
+func (sw *stateSnapshotWriter) addAccount(addr common.Address, nonce uint64, balance *big.Int, code []byte) error {
+	codeHash := emptyCodeHash
+	if len(code) > 0 {
+		codeHash = crypto.Keccak256Hash(code)
+		if _, found := sw.codes[codeHash]; !found {
+			sw.codes[codeHash] = struct{}{}
+			buf := sw.buffers[codeChunk]
+			writeUvarint(buf, uint64(len(code)))
+			buf.Write(code)
+			sw.stats.Codes++
+		}
+	}
+
+	buf := sw.buffers[accountChunk]
+	buf.Write(addr[:])
+	writeUvarint(buf, nonce)
+	writeBytes(buf, balance.Bytes())
+	buf.Write(codeHash[:])
+	sw.stats.Accounts++
+	return sw.mayFlush()
+}
+
+func (sw *stateSnapshotWriter) addStorage(addr common.Address, key, value common.Hash) error {
+	buf := sw.buffers[storageChunk]
+	buf.Write(addr[:])
+	buf.Write(key[:])
+	writeBytes(buf, bytes.TrimLeft(value[:], "\x00"))
+	sw.stats.StorageSlots++
+	return sw.mayFlush()
+}
+
+func (sw *stateSnapshotWriter) mayFlush() error {
+	size := 0
+	for _, buf := range sw.buffers {
+		size += buf.Len()
+	}
+	if size < stateSnapshotChunkSize {
+		return nil
+	}
+	return sw.flush()
+}
+
+func (sw *stateSnapshotWriter) flush() error {
+	for _, kind := range []stateSnapshotChunkKind{codeChunk, accountChunk, storageChunk} {
+		buf := sw.buffers[kind]
+		if buf.Len() == 0 {
+			continue
+		}
+		if err := sw.writeChunk(kind, buf.Bytes()); err != nil {
+			return err
+		}
+		buf.Reset()
+	}
+	return nil
+}
+
+func (sw *stateSnapshotWriter) writeChunk(kind stateSnapshotChunkKind, payload []byte) error {
+	compressed := sw.encoder.EncodeAll(payload, nil)
+	var frame [1 + 4 + 4]byte
+	frame[0] = byte(kind)
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(compressed)))
+	binary.BigEndian.PutUint32(frame[5:], crc32.ChecksumIEEE(compressed))
+	if _, err := sw.out.Write(frame[:]); err != nil {
+		return fmt.Errorf("cannot write chunk; %w", err)
+	}
+	if _, err := sw.out.Write(compressed); err != nil {
+		return fmt.Errorf("cannot write chunk; %w", err)
+	}
+	return nil
+}
+
+func (sw *stateSnapshotWriter) close() error {
+	if err := sw.flush(); err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	writeUvarint(buf, sw.stats.Accounts)
+	writeUvarint(buf, sw.stats.StorageSlots)
+	writeUvarint(buf, sw.stats.Codes)
+	if err := sw.writeChunk(endChunk, buf.Bytes()); err != nil {
+		return err
+	}
+	return errors.Join(sw.out.Flush(), sw.encoder.Close())
+}
+
+// ReadStateSnapshotHeader reads and checks the header of a snapshot.
+func ReadStateSnapshotHeader(r io.Reader) (StateSnapshotHeader, error) {
+	var buf [len(StateSnapshotMagic) + 2 + 8 + common.HashLength]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return StateSnapshotHeader{}, fmt.Errorf("cannot read header; %w", err)
+	}
+	if string(buf[:len(StateSnapshotMagic)]) != StateSnapshotMagic {
+		return StateSnapshotHeader{}, errors.New("not a state snapshot")
+	}
+	rest := buf[len(StateSnapshotMagic):]
+	header := StateSnapshotHeader{
+		Version: binary.BigEndian.Uint16(rest[0:]),
+		Block:   binary.BigEndian.Uint64(rest[2:]),
+		Root:    common.BytesToHash(rest[10:]),
+	}
+	if header.Version != StateSnapshotVersion {
+		return StateSnapshotHeader{}, fmt.Errorf("unsupported snapshot version %d, supported version is %d", header.Version, StateSnapshotVersion)
+	}
+	return header, nil
+}
+
+// ImportStateSnapshot reads a snapshot from r, whose header has already been consumed
+// by ReadStateSnapshotHeader, and inserts its content into db through bulk-loads. To
+// keep memory usage bounded, a new bulk-load is started every opsPerBulkLoad operations;
+// consecutive bulk-loads use consecutive block numbers starting at firstBlock. The number
+// of the last used block is returned together with statistics of the imported content.
+func ImportStateSnapshot(r io.Reader, db state.StateDB, firstBlock uint64, opsPerBulkLoad int) (StateSnapshotStats, uint64, error) {
+	decoder, err := zstd.NewReader(nil)
+	if err != nil {
+		return StateSnapshotStats{}, 0, fmt.Errorf("cannot create decoder; %w", err)
+	}
+	defer decoder.Close()
+
+	loader := &stateSnapshotLoader{db: db, block: firstBlock, opsPerBulkLoad: opsPerBulkLoad, codes: make(map[common.Hash][]byte)}
+	if loader.load, err = db.StartBulkLoad(firstBlock); err != nil {
+		return StateSnapshotStats{}, 0, fmt.Errorf("cannot start bulk-load; %w", err)
+	}
+
+	in := bufio.NewReader(r)
+	for {
+		kind, payload, err := readStateSnapshotChunk(in, decoder)
+		if err != nil {
+			return loader.stats, 0, errors.Join(err, loader.load.Close())
+		}
+		if kind == endChunk {
+			if err = loader.checkEnd(payload); err != nil {
+				return loader.stats, 0, errors.Join(err, loader.load.Close())
+			}
+			break
+		}
+		if err = loader.apply(kind, payload); err != nil {
+			return loader.stats, 0, errors.Join(err, loader.load.Close())
+		}
+	}
+
+	if err = loader.load.Close(); err != nil {
+		return loader.stats, 0, fmt.Errorf("cannot close bulk-load; %w", err)
+	}
+	return loader.stats, loader.block, nil
+}
+
+func readStateSnapshotChunk(in io.Reader, decoder *zstd.Decoder) (stateSnapshotChunkKind, []byte, error) {
+	var frame [1 + 4 + 4]byte
+	if _, err := io.ReadFull(in, frame[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, errors.New("snapshot is truncated")
+		}
+		return 0, nil, fmt.Errorf("cannot read chunk; %w", err)
+	}
+	kind := stateSnapshotChunkKind(frame[0])
+	if kind < codeChunk || kind > endChunk {
+		return 0, nil, fmt.Errorf("unknown chunk kind %d", kind)
+	}
+	size := binary.BigEndian.Uint32(frame[1:])
+	if size > stateSnapshotMaxChunk {
+		return 0, nil, fmt.Errorf("chunk of %d bytes exceeds maximum size", size)
+	}
+	compressed := make([]byte, size)
+	if _, err := io.ReadFull(in, compressed); err != nil {
+		return 0, nil, fmt.Errorf("cannot read chunk; %w", err)
+	}
+	if crc32.ChecksumIEEE(compressed) != binary.BigEndian.Uint32(frame[5:]) {
+		return 0, nil, errors.New("chunk checksum mismatch")
+	}
+	payload, err := decoder.DecodeAll(compressed, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot decompress chunk; %w", err)
+	}
+	return kind, payload, nil
+}
+
+// stateSnapshotLoader inserts decoded chunks into a StateDB.
+type stateSnapshotLoader struct {
+	db             state.StateDB
+	load           state.BulkLoad
+	block          uint64
+	operations     int
+	opsPerBulkLoad int
+	codes          map[common.Hash][]byte
+	stats          StateSnapshotStats
+}
+
+func (l *stateSnapshotLoader) apply(kind stateSnapshotChunkKind, payload []byte) error {
+	r := bytes.NewReader(payload)
+	for r.Len() > 0 {
+		var err error
+		switch kind {
+		case codeChunk:
+			err = l.applyCode(r)
+		case accountChunk:
+			err = l.applyAccount(r)
+		case storageChunk:
+			err = l.applyStorage(r)
+		}
+		if err != nil {
+			return err
+		}
+		if err = l.mayRestartBulkLoad(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *stateSnapshotLoader) applyCode(r *bytes.Reader) error {
+	code, err := readBytes(r)
+	if err != nil {
+		return fmt.Errorf("cannot decode code; %w", err)
+	}
+	l.codes[crypto.Keccak256Hash(code)] = code
+	l.stats.Codes++
+	return nil
+}
+
+func (l *stateSnapshotLoader) applyAccount(r *bytes.Reader) error {
+	var addr common.Address
+	var codeHash common.Hash
+	if _, err := io.ReadFull(r, addr[:]); err != nil {
+		return fmt.Errorf("cannot decode account; %w", err)
+	}
+	nonce, err := binary.ReadUvarint(r)
+	if err != nil {
+		return fmt.Errorf("cannot decode nonce of %v; %w", addr, err)
+	}
+	balance, err := readBytes(r)
+	if err != nil {
+		return fmt.Errorf("cannot decode balance of %v; %w", addr, err)
+	}
+	if _, err = io.ReadFull(r, codeHash[:]); err != nil {
+		return fmt.Errorf("cannot decode code hash of %v; %w", addr, err)
+	}
+
+	l.load.CreateAccount(addr)
+	l.load.SetBalance(addr, new(big.Int).SetBytes(balance))
+	l.load.SetNonce(addr, nonce)
+	l.operations += 3
+	if codeHash != emptyCodeHash {
+		code, found := l.codes[codeHash]
+		if !found {
+			return fmt.Errorf("missing code %v of %v", codeHash, addr)
+		}
+		l.load.SetCode(addr, code)
+		l.operations++
+	}
+	l.stats.Accounts++
+	return nil
+}
+
+func (l *stateSnapshotLoader) applyStorage(r *bytes.Reader) error {
+	var addr common.Address
+	var key common.Hash
+	if _, err := io.ReadFull(r, addr[:]); err != nil {
+		return fmt.Errorf("cannot decode storage; %w", err)
+	}
+	if _, err := io.ReadFull(r, key[:]); err != nil {
+		return fmt.Errorf("cannot decode storage key of %v; %w", addr, err)
+	}
+	value, err := readBytes(r)
+	if err != nil || len(value) > common.HashLength {
+		return fmt.Errorf("cannot decode storage value of %v %v; %v", addr, key, err)
+	}
+	l.load.SetState(addr, key, common.BytesToHash(value))
+	l.operations++
+	l.stats.StorageSlots++
+	return nil
+}
+
+// mayRestartBulkLoad applies the current bulk-load once it exceeds the operation limit.
+func (l *stateSnapshotLoader) mayRestartBulkLoad() error {
+	if l.opsPerBulkLoad <= 0 || l.operations < l.opsPerBulkLoad {
+		return nil
+	}
+	l.operations = 0
+	if err := l.load.Close(); err != nil {
+		return fmt.Errorf("cannot close bulk-load; %w", err)
+	}
+	l.block++
+	var err error
+	if l.load, err = l.db.StartBulkLoad(l.block); err != nil {
+		return fmt.Errorf("cannot start bulk-load; %w", err)
+	}
+	return nil
+}
+
+func (l *stateSnapshotLoader) checkEnd(payload []byte) error {
+	r := bytes.NewReader(payload)
+	var want StateSnapshotStats
+	for _, field := range []*uint64{&want.Accounts, &want.StorageSlots, &want.Codes} {
+		value, err := binary.ReadUvarint(r)
+		if err != nil {
+			return fmt.Errorf("cannot decode end of snapshot; %w", err)
+		}
+		*field = value
+	}
+	if want != l.stats {
+		return fmt.Errorf("snapshot content does not match its summary; got: %+v, want: %+v", l.stats, want)
+	}
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, value uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	buf.Write(tmp[:binary.PutUvarint(tmp[:], value)])
+}
+
+func writeBytes(buf *bytes.Buffer, data []byte) {
+	writeUvarint(buf, uint64(len(data)))
+	buf.Write(data)
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	size, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	return data, err
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utildb
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/Fantom-foundation/Aida/state"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func makeStateSnapshotTestDb(t *testing.T) state.StateDB {
+	db, err := state.MakeGethStateDB(t.TempDir(), "", common.Hash{}, false, nil)
+	if err != nil {
+		t.Fatalf("cannot create state-db; %v", err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func fillStateSnapshotTestDb(t *testing.T, db state.StateDB) {
+	if err := db.BeginBlock(1); err != nil {
+		t.Fatalf("cannot begin block; %v", err)
+	}
+	if err := db.BeginTransaction(0); err != nil {
+		t.Fatalf("cannot begin transaction; %v", err)
+	}
+	for i := byte(1); i <= 10; i++ {
+		addr := common.Address{i}
+		db.CreateAccount(addr)
+		db.AddBalance(addr, new(big.Int).Mul(big.NewInt(int64(i)), big.NewInt(1e18)))
+		db.SetNonce(addr, uint64(i))
+		// accounts share two distinct codes
+		db.SetCode(addr, []byte{0x60, i % 2})
+		for j := byte(1); j <= i; j++ {
+			db.SetState(addr, common.Hash{j}, common.Hash{31: i})
+		}
+	}
+	if err := db.EndTransaction(); err != nil {
+		t.Fatalf("cannot end transaction; %v", err)
+	}
+	if err := db.EndBlock(); err != nil {
+		t.Fatalf("cannot end block; %v", err)
+	}
+}
+
+func exportStateSnapshotTestDb(t *testing.T) ([]byte, common.Hash) {
+	src := makeStateSnapshotTestDb(t)
+	fillStateSnapshotTestDb(t, src)
+	root, err := src.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get state hash; %v", err)
+	}
+
+	var buf bytes.Buffer
+	stats, err := ExportStateSnapshot(src, StateSnapshotHeader{Block: 5, Root: root}, &buf)
+	if err != nil {
+		t.Fatalf("cannot export state; %v", err)
+	}
+	if want := (StateSnapshotStats{Accounts: 10, StorageSlots: 55, Codes: 2}); stats != want {
+		t.Errorf("unexpected export statistics; got: %+v, want: %+v", stats, want)
+	}
+	return buf.Bytes(), root
+}
+
+func TestStateSnapshot_ImportReproducesExportedState(t *testing.T) {
+	snapshot, root := exportStateSnapshotTestDb(t)
+
+	r := bytes.NewReader(snapshot)
+	header, err := ReadStateSnapshotHeader(r)
+	if err != nil {
+		t.Fatalf("cannot read header; %v", err)
+	}
+	if header.Block != 5 || header.Root != root || header.Version != StateSnapshotVersion {
+		t.Errorf("unexpected header %+v", header)
+	}
+
+	dst := makeStateSnapshotTestDb(t)
+	stats, _, err := ImportStateSnapshot(r, dst, 0, 0)
+	if err != nil {
+		t.Fatalf("cannot import state; %v", err)
+	}
+	if want := (StateSnapshotStats{Accounts: 10, StorageSlots: 55, Codes: 2}); stats != want {
+		t.Errorf("unexpected import statistics; got: %+v, want: %+v", stats, want)
+	}
+	got, err := dst.GetHash()
+	if err != nil {
+		t.Fatalf("cannot get state hash; %v", err)
+	}
+	if got != root {
+		t.Errorf("unexpected state hash of imported state; got: %v, want: %v", got, root)
+	}
+}
+
+func TestStateSnapshot_ImportSplitsBulkLoads(t *testing.T) {
+	snapshot, root := exportStateSnapshotTestDb(t)
+
+	r := bytes.NewReader(snapshot)
+	if _, err := ReadStateSnapshotHeader(r); err != nil {
+		t.Fatalf("cannot read header; %v", err)
+	}
+	dst := makeStateSnapshotTestDb(t)
+	_, last, err := ImportStateSnapshot(r, dst, 0, 10)
+	if err != nil {
+		t.Fatalf("cannot import state; %v", err)
+	}
+	if last == 0 {
+		t.Errorf("import was not split into multiple bulk-loads")
+	}
+	if got, _ := dst.GetHash(); got != root {
+		t.Errorf("unexpected state hash of imported state; got: %v, want: %v", got, root)
+	}
+}
+
+func TestStateSnapshot_CorruptedSnapshotsAreRejected(t *testing.T) {
+	snapshot, _ := exportStateSnapshotTestDb(t)
+	headerSize := len(StateSnapshotMagic) + 2 + 8 + common.HashLength
+
+	flipped := bytes.Clone(snapshot)
+	flipped[len(flipped)-1] ^= 0xff
+	version := bytes.Clone(snapshot)
+	version[len(StateSnapshotMagic)+1]++
+
+	tests := map[string]struct {
+		data []byte
+		err  string
+	}{
+		"truncated":   {snapshot[:len(snapshot)-20], ""},
+		"checksum":    {flipped, "checksum"},
+		"no snapshot": {bytes.Repeat([]byte{1}, headerSize), "not a state snapshot"},
+		"version":     {version, "unsupported snapshot version"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := bytes.NewReader(test.data)
+			_, err := ReadStateSnapshotHeader(r)
+			if err == nil {
+				_, _, err = ImportStateSnapshot(r, makeStateSnapshotTestDb(t), 0, 0)
+			}
+			if err == nil {
+				t.Fatal("corrupted snapshot must be rejected")
+			}
+			if !strings.Contains(err.Error(), test.err) {
+				t.Errorf("unexpected error; %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const PathToCheckpoint = "checkpoint.json"
+
+// Checkpoint describes a StateDb which was flushed at a block boundary
+// and from which an interrupted run can be resumed.
+type Checkpoint struct {
+	Block          uint64      `json:"block"`          // last completed block
+	Impl           string      `json:"dbImpl"`         // type of db engine
+	Variant        string      `json:"dbVariant"`      // type of db variant
+	Schema         int         `json:"schema"`         // DB schema version used
+	ArchiveMode    bool        `json:"archiveMode"`    // archive mode
+	ArchiveVariant string      `json:"archiveVariant"` // archive variant
+	RootHash       common.Hash `json:"rootHash"`       // root hash after the last completed block
+	ConfigHash     string      `json:"configHash"`     // hash of the run configuration affecting the state
+	GitCommit      string      `json:"gitCommit"`      // Aida git version when creating the checkpoint
+	CreateTime     string      `json:"createTimeUTC"`  // time of creation in utc timezone
+}
+
+// checkpointConfig lists configuration values which must not change
+// between a checkpointed run and its resumption.
+type checkpointConfig struct {
+	ChainID        ChainID
+	DbImpl         string
+	DbVariant      string
+	CarmenSchema   int
+	ArchiveMode    bool
+	ArchiveVariant string
+	VmImpl         string
+}
+
+// GetCheckpointConfigHash returns a hash of all configuration values which
+// influence the content of a StateDb produced by a run.
+func GetCheckpointConfigHash(cfg *Config) (string, error) {
+	data, err := json.Marshal(checkpointConfig{
+		ChainID:        cfg.ChainID,
+		DbImpl:         cfg.DbImpl,
+		DbVariant:      cfg.DbVariant,
+		CarmenSchema:   cfg.CarmenSchema,
+		ArchiveMode:    cfg.ArchiveMode,
+		ArchiveVariant: cfg.ArchiveVariant,
+		VmImpl:         cfg.VmImpl,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cannot encode config; %w", err)
+	}
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// WriteCheckpoint writes a checkpoint descriptor for given block next to
+// the StateDb stored in directory. The StateDb info file is rewritten as well
+// so that the StateDb can be reopened at the checkpoint.
+func WriteCheckpoint(directory string, cfg *Config, configHash string, block uint64, root common.Hash) error {
+	if err := WriteStateDbInfo(directory, cfg, block, root); err != nil {
+		return err
+	}
+
+	checkpoint := &Checkpoint{
+		Block:          block,
+		Impl:           cfg.DbImpl,
+		Variant:        cfg.DbVariant,
+		Schema:         cfg.CarmenSchema,
+		ArchiveMode:    cfg.ArchiveMode,
+		ArchiveVariant: cfg.ArchiveVariant,
+		RootHash:       root,
+		ConfigHash:     configHash,
+		GitCommit:      GitCommit,
+		CreateTime:     time.Now().UTC().Format(time.UnixDate),
+	}
+	jsonByte, err := json.MarshalIndent(checkpoint, "", "  ")
+	if err != nil {
+		return fmt.Errorf("cannot encode checkpoint; %w", err)
+	}
+
+	// write into a temporary file first, so a crash never leaves a partial descriptor behind
+	filename := filepath.Join(directory, PathToCheckpoint)
+	tmpFilename := filename + ".tmp"
+	if err = os.WriteFile(tmpFilename, jsonByte, 0666); err != nil {
+		return fmt.Errorf("cannot write checkpoint to file %v; %w", tmpFilename, err)
+	}
+	if err = os.Rename(tmpFilename, filename); err != nil {
+		return fmt.Errorf("cannot rename checkpoint file %v; %w", tmpFilename, err)
+	}
+	return nil
+}
+
+// ReadCheckpoint reads the checkpoint descriptor stored in directory.
+func ReadCheckpoint(directory string) (Checkpoint, error) {
+	var checkpoint Checkpoint
+	filename := filepath.Join(directory, PathToCheckpoint)
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return checkpoint, fmt.Errorf("failed to read %v; %v", filename, err)
+	}
+	err = json.Unmarshal(file, &checkpoint)
+	return checkpoint, err
+}
+
+// Validate checks that the checkpoint is consistent with the StateDb info
+// stored in directory and that the current configuration matches the
+// configuration of the checkpointed run.
+func (c Checkpoint) Validate(directory string, cfg *Config) error {
+	info, err := ReadStateDbInfo(filepath.Join(directory, PathToDbInfo))
+	if err != nil {
+		return err
+	}
+
+	if info.Block != c.Block {
+		return fmt.Errorf("checkpoint block %v does not match state-db block %v", c.Block, info.Block)
+	}
+	if info.RootHash != c.RootHash {
+		return fmt.Errorf("checkpoint root hash %v does not match state-db root hash %v", c.RootHash, info.RootHash)
+	}
+	if info.Impl != c.Impl || info.Variant != c.Variant || info.Schema != c.Schema {
+		return fmt.Errorf("checkpoint was created by %v (variant: %v, schema: %v) but state-db is %v (variant: %v, schema: %v)",
+			c.Impl, c.Variant, c.Schema, info.Impl, info.Variant, info.Schema)
+	}
+	if info.ArchiveMode != c.ArchiveMode || info.ArchiveVariant != c.ArchiveVariant {
+		return fmt.Errorf("checkpoint archive (mode: %v, variant: %v) does not match state-db archive (mode: %v, variant: %v)",
+			c.ArchiveMode, c.ArchiveVariant, info.ArchiveMode, info.ArchiveVariant)
+	}
+
+	// the state-db properties are taken from the state-db info when it is opened,
+	// hence they override the command line configuration
+	effective := *cfg
+	effective.DbImpl = info.Impl
+	effective.DbVariant = info.Variant
+	effective.CarmenSchema = info.Schema
+	effective.ArchiveMode = info.ArchiveMode
+	effective.ArchiveVariant = info.ArchiveVariant
+	configHash, err := GetCheckpointConfigHash(&effective)
+	if err != nil {
+		return err
+	}
+	if configHash != c.ConfigHash {
+		return fmt.Errorf("configuration of this run differs from the checkpointed run")
+	}
+	return nil
+}
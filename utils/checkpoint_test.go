@@ -0,0 +1,129 @@
+// Copyright 2024 Fantom Foundation
+// This file is part of Aida Testing Infrastructure for Sonic
+//
+// Aida is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// Aida is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with Aida. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TestCheckpoint_WriteReadCheckpoint tests writing and reading of a checkpoint descriptor.
+func TestCheckpoint_WriteReadCheckpoint(t *testing.T) {
+	cfg := &Config{DbImpl: "carmen", DbVariant: "go-file", CarmenSchema: 5, ArchiveMode: true, ArchiveVariant: "s5"}
+	dir := t.TempDir()
+	hash := common.Hash{0x1}
+
+	configHash, err := GetCheckpointConfigHash(cfg)
+	if err != nil {
+		t.Fatalf("cannot get config hash: %v", err)
+	}
+
+	if err = WriteCheckpoint(dir, cfg, configHash, 42, hash); err != nil {
+		t.Fatalf("cannot write checkpoint: %v", err)
+	}
+
+	checkpoint, err := ReadCheckpoint(dir)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+
+	if checkpoint.Block != 42 {
+		t.Errorf("unexpected block; got: %v, want: %v", checkpoint.Block, 42)
+	}
+	if checkpoint.RootHash != hash {
+		t.Errorf("unexpected root hash; got: %v, want: %v", checkpoint.RootHash, hash)
+	}
+	if checkpoint.Impl != cfg.DbImpl || checkpoint.Variant != cfg.DbVariant || checkpoint.Schema != cfg.CarmenSchema {
+		t.Errorf("unexpected db description; got: %v %v %v", checkpoint.Impl, checkpoint.Variant, checkpoint.Schema)
+	}
+	if checkpoint.ConfigHash != configHash {
+		t.Errorf("unexpected config hash; got: %v, want: %v", checkpoint.ConfigHash, configHash)
+	}
+
+	// state db info has to be updated as well so that the db can be reopened
+	info, err := ReadStateDbInfo(filepath.Join(dir, PathToDbInfo))
+	if err != nil {
+		t.Fatalf("cannot read state-db info: %v", err)
+	}
+	if info.Block != 42 || info.RootHash != hash {
+		t.Errorf("state-db info was not updated; got block %v, root %v", info.Block, info.RootHash)
+	}
+
+	if err = checkpoint.Validate(dir, cfg); err != nil {
+		t.Errorf("checkpoint must be valid: %v", err)
+	}
+}
+
+// TestCheckpoint_ValidateFailsOnDifferentConfig tests that a checkpoint cannot be resumed using different configuration.
+func TestCheckpoint_ValidateFailsOnDifferentConfig(t *testing.T) {
+	cfg := &Config{DbImpl: "geth", ChainID: MainnetChainID, VmImpl: "geth"}
+	dir := t.TempDir()
+
+	configHash, err := GetCheckpointConfigHash(cfg)
+	if err != nil {
+		t.Fatalf("cannot get config hash: %v", err)
+	}
+	if err = WriteCheckpoint(dir, cfg, configHash, 1, common.Hash{}); err != nil {
+		t.Fatalf("cannot write checkpoint: %v", err)
+	}
+
+	checkpoint, err := ReadCheckpoint(dir)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+
+	other := *cfg
+	other.VmImpl = "lfvm"
+	if err = checkpoint.Validate(dir, &other); err == nil {
+		t.Errorf("validation must fail with different vm implementation")
+	}
+
+	// state db info not matching the checkpoint
+	if err = WriteStateDbInfo(dir, cfg, 2, common.Hash{}); err != nil {
+		t.Fatalf("cannot write state-db info: %v", err)
+	}
+	if err = checkpoint.Validate(dir, cfg); err == nil {
+		t.Errorf("validation must fail when state-db block differs")
+	}
+}
+
+// TestCheckpoint_ValidateUsesStateDbProperties tests that the state-db properties of the command line
+// are ignored since they are overridden by the state-db info when the state-db is opened.
+func TestCheckpoint_ValidateUsesStateDbProperties(t *testing.T) {
+	cfg := &Config{DbImpl: "carmen", DbVariant: "go-file", CarmenSchema: 5, ChainID: MainnetChainID}
+	dir := t.TempDir()
+
+	configHash, err := GetCheckpointConfigHash(cfg)
+	if err != nil {
+		t.Fatalf("cannot get config hash: %v", err)
+	}
+	if err = WriteCheckpoint(dir, cfg, configHash, 1, common.Hash{}); err != nil {
+		t.Fatalf("cannot write checkpoint: %v", err)
+	}
+	checkpoint, err := ReadCheckpoint(dir)
+	if err != nil {
+		t.Fatalf("cannot read checkpoint: %v", err)
+	}
+
+	// defaults of the command line differ from the checkpointed state-db
+	cli := &Config{DbImpl: "geth", ChainID: MainnetChainID}
+	if err = checkpoint.Validate(dir, cli); err != nil {
+		t.Errorf("checkpoint must be valid: %v", err)
+	}
+}
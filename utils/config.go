@@ -149,6 +149,7 @@ type Config struct {
 	CarmenNodeCacheSize    int            // the size of the in-memory cache to be used by a Carmen LiveDB in byte (0 for default value)
 	ChainID                ChainID        // Blockchain ID (mainnet: 250/testnet: 4002)
 	ChannelBufferSize      int            // set a buffer size for profiling channel
+	CheckpointInterval     uint64         // number of blocks between two state-db checkpoints (0 for disabled)
 	CompactDb              bool           // compact database after merging
 	ContinueOnFailure      bool           // continue validation when an error detected
 	ContractNumber         int64          // number of contracts to create
@@ -167,6 +168,7 @@ type Config struct {
 	Genesis                string         // genesis file
 	IncludeStorage         bool           // represents a flag for contract storage inclusion in an operation
 	IsExistingStateDb      bool           // this is true if we are using an existing StateDb
+	Journal                bool           // record state-db operations of the current block into a write-ahead journal
 	KeepDb                 bool           // set to true if db is kept after run
 	KeysNumber             int64          // number of keys to generate
 	LogLevel               string         // level of the logging of the app action
@@ -174,12 +176,14 @@ type Config struct {
 	MaxNumTransactions     int            // the maximum number of processed transactions
 	MemoryBreakdown        bool           // enable printing of memory breakdown
 	MemoryProfile          string         // capture the memory heap profile into the file
+	MetricsServer          int64          // if not zero, the port used for hosting a HTTP server exposing metrics and status of the run
 	MicroProfiling         bool           // enable micro-profiling of EVM
 	NoHeartbeatLogging     bool           // disables heartbeat logging
 	NonceRange             int            // nonce range for stochastic simulation/replay
 	OnlySuccessful         bool           // only runs transactions that have been successful
 	OperaBinary            string         // path to opera binary
 	OperaDb                string         // path to opera database
+	OptimisticExecution    bool           // executes transactions of a block optimistically in parallel
 	Output                 string         // output directory for aida-db patches or path to events.json file in stochastic generation
 	OverwriteRunId         string         // when registering runs, use provided id instead of the autogenerated run id
 	PathToStateDb          string         // Path to a working state-db directory
@@ -187,6 +191,9 @@ type Config struct {
 	PrimeThreshold         int            // set account threshold before commit
 	Profile                bool           // enable micro profiling
 	ProfileBlocks          bool           // enables block profiler extension
+	ProfileBlocksGraph     string         // directory for exported transaction dependency graphs of blocks
+	ProfileBlocksGraphFmt  string         // comma separated formats of exported dependency graphs (json, dot)
+	ProfileBlocksSlots     bool           // enables derivation of transaction conflicts from storage-slot and balance accesses in block profiler
 	ProfileDB              string         // profile db for parallel transaction execution
 	ProfileDepth           int            // 0 = Interval, 1 = Interval+Block, 2 = Interval+Block+Tx
 	ProfileEVMCall         bool           // enable profiling for EVM call
@@ -196,7 +203,12 @@ type Config struct {
 	ProfilingDbName        string         // set a database name for storing micro-profiling results
 	RandomSeed             int64          // set random seed for stochastic testing
 	RegisterRun            string         // register run to the provided connection string
+	Resume                 bool           // resume an interrupted run from the state-db checkpoint
+	RpcAddress             string         // address the JSON-RPC server listens on
+	RpcDivergenceReport    string         // path to JSONL file with mismatches found by RPC validation
 	RpcRecordingPath       string         // path to source file (or dir with files) with recorded RPC requests
+	RpcSpeedUp             float64        // speed-up factor of timed replay of recorded RPC requests, disabled if 0
+	RpcUpstream            string         // URL of the JSON-RPC endpoint receiving recorded requests
 	ShadowDb               bool           // defines we want to open an existing db as shadow
 	ShadowImpl             string         // implementation of the shadow DB to use, empty if disabled
 	ShadowVariant          string         // database variant of the shadow DB to be used
@@ -212,8 +224,11 @@ type Config struct {
 	TargetDb               string         // represents the path of a target DB
 	TargetEpoch            uint64         // represents the ID of target epoch to be reached by autogen patch generator
 	Trace                  bool           // trace flag
+	TraceChunkSize         int            // uncompressed size of trace chunks in MiB
+	TraceContracts         []string       // contracts whose transactions are kept in filtered traces
 	TraceDirectory         string         // name of trace directory
 	TraceFile              string         // name of trace file
+	TraceOperations        []string       // operations kept in filtered traces
 	TrackProgress          bool           // enables track progress logging
 	TransactionLength      uint64         // determines indirectly the length of a transaction
 	UpdateBufferSize       uint64         // cache size in Bytes
@@ -43,6 +43,7 @@ func createConfigFromFlags(ctx *cli.Context) *Config {
 		CarmenSchema:           getFlagValue(ctx, CarmenSchemaFlag).(int),
 		ChainID:                ChainID(getFlagValue(ctx, ChainIDFlag).(int)),
 		ChannelBufferSize:      getFlagValue(ctx, ChannelBufferSizeFlag).(int),
+		CheckpointInterval:     getFlagValue(ctx, CheckpointIntervalFlag).(uint64),
 		CompactDb:              getFlagValue(ctx, CompactDbFlag).(bool),
 		ContinueOnFailure:      getFlagValue(ctx, ContinueOnFailureFlag).(bool),
 		ContractNumber:         getFlagValue(ctx, ContractNumberFlag).(int64),
@@ -60,6 +61,7 @@ func createConfigFromFlags(ctx *cli.Context) *Config {
 		ErrorLogging:           getFlagValue(ctx, ErrorLoggingFlag).(string),
 		Genesis:                getFlagValue(ctx, GenesisFlag).(string),
 		IncludeStorage:         getFlagValue(ctx, IncludeStorageFlag).(bool),
+		Journal:                getFlagValue(ctx, JournalFlag).(bool),
 		KeepDb:                 getFlagValue(ctx, KeepDbFlag).(bool),
 		KeysNumber:             getFlagValue(ctx, KeysNumberFlag).(int64),
 		LogLevel:               getFlagValue(ctx, logger.LogLevelFlag).(string),
@@ -67,18 +69,23 @@ func createConfigFromFlags(ctx *cli.Context) *Config {
 		MaxNumTransactions:     getFlagValue(ctx, MaxNumTransactionsFlag).(int),
 		MemoryBreakdown:        getFlagValue(ctx, MemoryBreakdownFlag).(bool),
 		MemoryProfile:          getFlagValue(ctx, MemoryProfileFlag).(string),
+		MetricsServer:          getFlagValue(ctx, MetricsServerFlag).(int64),
 		MicroProfiling:         getFlagValue(ctx, MicroProfilingFlag).(bool),
 		NoHeartbeatLogging:     getFlagValue(ctx, NoHeartbeatLoggingFlag).(bool),
 		NonceRange:             getFlagValue(ctx, NonceRangeFlag).(int),
 		OnlySuccessful:         getFlagValue(ctx, OnlySuccessfulFlag).(bool),
 		OperaBinary:            getFlagValue(ctx, OperaBinaryFlag).(string),
 		OperaDb:                getFlagValue(ctx, OperaDbFlag).(string),
+		OptimisticExecution:    getFlagValue(ctx, OptimisticExecutionFlag).(bool),
 		Output:                 getFlagValue(ctx, OutputFlag).(string),
 		OverwriteRunId:         getFlagValue(ctx, OverwriteRunIdFlag).(string),
 		PrimeRandom:            getFlagValue(ctx, RandomizePrimingFlag).(bool),
 		PrimeThreshold:         getFlagValue(ctx, PrimeThresholdFlag).(int),
 		Profile:                getFlagValue(ctx, ProfileFlag).(bool),
 		ProfileBlocks:          getFlagValue(ctx, ProfileBlocksFlag).(bool),
+		ProfileBlocksGraph:     getFlagValue(ctx, ProfileBlocksGraphFlag).(string),
+		ProfileBlocksGraphFmt:  getFlagValue(ctx, ProfileBlocksGraphFmtFlag).(string),
+		ProfileBlocksSlots:     getFlagValue(ctx, ProfileBlocksSlotsFlag).(bool),
 		ProfileDB:              getFlagValue(ctx, ProfileDBFlag).(string),
 		ProfileDepth:           getFlagValue(ctx, ProfileDepthFlag).(int),
 		ProfileEVMCall:         getFlagValue(ctx, ProfileEVMCallFlag).(bool),
@@ -88,7 +95,12 @@ func createConfigFromFlags(ctx *cli.Context) *Config {
 		ProfilingDbName:        getFlagValue(ctx, ProfilingDbNameFlag).(string),
 		RandomSeed:             getFlagValue(ctx, RandomSeedFlag).(int64),
 		RegisterRun:            getFlagValue(ctx, RegisterRunFlag).(string),
+		Resume:                 getFlagValue(ctx, ResumeFlag).(bool),
+		RpcAddress:             getFlagValue(ctx, RpcAddressFlag).(string),
+		RpcDivergenceReport:    getFlagValue(ctx, RpcDivergenceReportFlag).(string),
 		RpcRecordingPath:       getFlagValue(ctx, RpcRecordingFileFlag).(string),
+		RpcSpeedUp:             getFlagValue(ctx, RpcSpeedUpFlag).(float64),
+		RpcUpstream:            getFlagValue(ctx, RpcUpstreamFlag).(string),
 		ShadowDb:               getFlagValue(ctx, ShadowDb).(bool),
 		ShadowImpl:             getFlagValue(ctx, ShadowDbImplementationFlag).(string),
 		ShadowVariant:          getFlagValue(ctx, ShadowDbVariantFlag).(string),
@@ -104,8 +116,11 @@ func createConfigFromFlags(ctx *cli.Context) *Config {
 		TargetDb:               getFlagValue(ctx, TargetDbFlag).(string),
 		TargetEpoch:            getFlagValue(ctx, TargetEpochFlag).(uint64),
 		Trace:                  getFlagValue(ctx, TraceFlag).(bool),
+		TraceChunkSize:         getFlagValue(ctx, TraceChunkSizeFlag).(int),
+		TraceContracts:         getFlagValue(ctx, TraceContractsFlag).([]string),
 		TraceDirectory:         getFlagValue(ctx, TraceDirectoryFlag).(string),
 		TraceFile:              getFlagValue(ctx, TraceFileFlag).(string),
+		TraceOperations:        getFlagValue(ctx, TraceOperationsFlag).([]string),
 		TrackProgress:          getFlagValue(ctx, TrackProgressFlag).(bool),
 		TransactionLength:      getFlagValue(ctx, TransactionLengthFlag).(uint64),
 		UpdateBufferSize:       getFlagValue(ctx, UpdateBufferSizeFlag).(uint64),
@@ -146,6 +161,11 @@ func getFlagValue(ctx *cli.Context, flag interface{}) interface{} {
 				return ctx.Int64(f.Name)
 			}
 
+		case cli.Float64Flag:
+			if cmdFlag.Names()[0] == f.Name {
+				return ctx.Float64(f.Name)
+			}
+
 		case cli.StringFlag:
 			if cmdFlag.Names()[0] == f.Name {
 				return ctx.String(f.Name)
@@ -175,6 +195,8 @@ func getFlagValue(ctx *cli.Context, flag interface{}) interface{} {
 		return f.Value
 	case cli.Int64Flag:
 		return f.Value
+	case cli.Float64Flag:
+		return f.Value
 	case cli.StringFlag:
 		return f.Value
 	case cli.PathFlag:
@@ -22,11 +22,29 @@ import (
 
 // Command line options for common flags in record and replay.
 var (
+	RpcAddressFlag = cli.StringFlag{
+		Name:  "rpc-addr",
+		Usage: "address the JSON-RPC server listens on",
+		Value: "localhost:8545",
+	}
+	RpcDivergenceReportFlag = cli.PathFlag{
+		Name:  "rpc-divergence-report",
+		Usage: "path to a JSONL file receiving every mismatch found by RPC validation (requires --validate)",
+	}
 	RpcRecordingFileFlag = cli.PathFlag{
 		Name:    "rpc-recording",
 		Usage:   "Path to source file with recorded API data",
 		Aliases: []string{"r"},
 	}
+	RpcSpeedUpFlag = cli.Float64Flag{
+		Name:  "rpc-speed-up",
+		Usage: "replays requests in their recorded timing accelerated by given factor using at most --workers concurrent requests, disabled if 0",
+	}
+	RpcUpstreamFlag = cli.StringFlag{
+		Name:  "rpc-upstream",
+		Usage: "URL of the JSON-RPC endpoint receiving recorded requests",
+		Value: "http://localhost:18545",
+	}
 	ArchiveModeFlag = cli.BoolFlag{
 		Name:  "archive",
 		Usage: "set node type to archival mode. If set, the node keep all the EVM state history; otherwise the state history will be pruned.",
@@ -94,10 +112,32 @@ var (
 		Usage: "enable hosting of a realtime diagnostic server by providing a port",
 		Value: 0,
 	}
+	MetricsServerFlag = cli.Int64Flag{
+		Name:  "metrics-port",
+		Usage: "enable hosting of a HTTP server with Prometheus /metrics and JSON /status endpoints by providing a port",
+		Value: 0,
+	}
 	KeepDbFlag = cli.BoolFlag{
 		Name:  "keep-db",
 		Usage: "if set, state-db is not deleted after run",
 	}
+	CheckpointIntervalFlag = cli.Uint64Flag{
+		Name:  "checkpoint-interval",
+		Usage: "flushes state-db and writes a resumable checkpoint every N blocks; 0 disables checkpoints",
+		Value: 0,
+	}
+	ResumeFlag = cli.BoolFlag{
+		Name:  "resume",
+		Usage: "resumes an interrupted run from the checkpoint of state-db given by --db-src",
+	}
+	JournalFlag = cli.BoolFlag{
+		Name:  "journal",
+		Usage: "records state-db operations of the current block into a write-ahead journal, so the state-db can be recovered after a crash; the state-db is flushed after each block",
+	}
+	OptimisticExecutionFlag = cli.BoolFlag{
+		Name:  "optimistic-execution",
+		Usage: "executes transactions of each block optimistically in parallel using --workers threads",
+	}
 	CustomDbNameFlag = cli.StringFlag{
 		Name:  "custom-db-name",
 		Usage: "sets the name of state-db direcotry when --keep-db is enabled",
@@ -175,7 +215,7 @@ var (
 	}
 	StateDbVariantFlag = cli.StringFlag{
 		Name:  "db-variant",
-		Usage: "select a state DB variant",
+		Usage: "select a state DB variant (e.g. go-file for carmen, leveldb or pebble for geth)",
 		Value: "",
 	}
 	StateDbSrcFlag = cli.PathFlag{
@@ -209,6 +249,15 @@ var (
 		Name:  "trace",
 		Usage: "enable tracing",
 	}
+	TraceChunkSizeFlag = cli.IntFlag{
+		Name:  "trace-chunk-size",
+		Usage: "uncompressed size of chunks of converted trace files in MiB",
+		Value: 16,
+	}
+	TraceContractsFlag = cli.StringSliceFlag{
+		Name:  "trace-contracts",
+		Usage: "list of contract addresses whose transactions are kept in filtered traces",
+	}
 	TraceDebugFlag = cli.BoolFlag{
 		Name:  "trace-debug",
 		Usage: "enable debug output for tracing",
@@ -222,6 +271,10 @@ var (
 		Name:  "trace-dir",
 		Usage: "set storage trace directory",
 	}
+	TraceOperationsFlag = cli.StringSliceFlag{
+		Name:  "trace-operations",
+		Usage: "list of operation labels kept in filtered traces",
+	}
 	UpdateDbFlag = cli.PathFlag{
 		Name:  "update-db",
 		Usage: "set update-set database directory",
@@ -303,6 +356,11 @@ var (
 		Usage: "Depth of snapshot history",
 		Value: 100,
 	}
+	MarkovOrderFlag = cli.IntFlag{
+		Name:  "markov-order",
+		Usage: "order of the Markov process estimated for the stochastic simulation (1 or 2)",
+		Value: 1,
+	}
 	OperaDbFlag = cli.PathFlag{
 		Name:    "db",
 		Aliases: []string{"datadir"},
@@ -388,6 +446,19 @@ var (
 		Name:  "profile-blocks",
 		Usage: "enables block profiling",
 	}
+	ProfileBlocksGraphFlag = cli.PathFlag{
+		Name:  "profile-blocks-graph",
+		Usage: "defines directory to which transaction dependency graphs of profiled blocks are exported",
+	}
+	ProfileBlocksGraphFmtFlag = cli.StringFlag{
+		Name:  "profile-blocks-graph-format",
+		Usage: "comma separated formats of exported dependency graphs (json, dot)",
+		Value: "json",
+	}
+	ProfileBlocksSlotsFlag = cli.BoolFlag{
+		Name:  "profile-blocks-slots",
+		Usage: "derives transaction conflicts from accessed storage slots and balances in addition to addresses when profiling blocks",
+	}
 	ProfileDBFlag = cli.PathFlag{
 		Name:  "profile-db",
 		Usage: "defines path to profile-db",
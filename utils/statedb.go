@@ -69,8 +69,8 @@ func useExistingStateDB(cfg *Config) (state.StateDB, string, error) {
 		log            = logger.NewLogger(cfg.LogLevel, "StateDB-Creation")
 	)
 
-	// make a copy of source statedb
-	if !cfg.SrcDbReadonly {
+	// make a copy of source statedb unless it is readonly or a resumed run continues in it
+	if !cfg.SrcDbReadonly && !cfg.Resume {
 		// does path to state db exist?
 		if _, err = os.Stat(cfg.StateDbSrc); os.IsNotExist(err) {
 			return nil, "", fmt.Errorf("%v does not exist", cfg.StateDbSrc)
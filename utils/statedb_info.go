@@ -148,7 +148,7 @@ func RenameTempStateDbDirectory(cfg *Config, oldDirectory string, block uint64)
 	// if custom db name is given, use it. Otherwise, generate readable name from db info.
 	if cfg.CustomDbName != "" {
 		newDirectory = cfg.CustomDbName
-	} else if cfg.DbImpl != "geth" {
+	} else if cfg.DbImpl != "geth" || cfg.DbVariant != "" {
 		newDirectory = fmt.Sprintf("state_db_%v_%v_%v", cfg.DbImpl, cfg.DbVariant, block)
 	} else {
 		newDirectory = fmt.Sprintf("state_db_%v_%v", cfg.DbImpl, block)